@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"maps"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/blakerouse/ssh-mcp/ssh"
 	"github.com/blakerouse/ssh-mcp/utils"
@@ -22,8 +26,27 @@ const (
 	CommandStatusCompleted CommandStatus = "completed"
 	CommandStatusFailed    CommandStatus = "failed"
 	CommandStatusCancelled CommandStatus = "cancelled"
+	// CommandStatusConnectFailed is used when no host could be connected to, so the
+	// command never ran anywhere
+	CommandStatusConnectFailed CommandStatus = "connect_failed"
+	// CommandStatusInterrupted is used for a command the server stopped before it finished, found
+	// unfinished in the history when the server started again
+	CommandStatusInterrupted CommandStatus = "interrupted"
 )
 
+// IsTerminal returns true when the status is final and will no longer change.
+func (s CommandStatus) IsTerminal() bool {
+	switch s {
+	case CommandStatusCompleted, CommandStatusFailed, CommandStatusCancelled, CommandStatusConnectFailed, CommandStatusInterrupted:
+		return true
+	}
+	return false
+}
+
+// maxOutputBytes is a hard ceiling on the output captured per host. A command producing more is
+// killed and the host marked failed, so a single runaway command cannot take down the server.
+const maxOutputBytes = 50 << 20
+
 // Command represents a background command
 type Command struct {
 	id        string
@@ -37,6 +60,50 @@ type Command struct {
 	err       error
 	cancel    context.CancelFunc
 	mu        sync.RWMutex
+
+	// pty is the pseudo-terminal to request, nil when not running in a PTY
+	pty *PTYOptions
+	// retry is the connection retry policy, nil uses the ssh default
+	retry *ssh.RetryPolicy
+	// reachability records connection outcomes, nil when not tracked
+	reachability *Reachability
+	// skipUnreachable skips hosts the reachability cache reports as recently unreachable
+	skipUnreachable bool
+	// idleTimeout stops a host's execution when no output arrives within it, 0 disables it
+	idleTimeout time.Duration
+	// hardTimeout stops a host's execution when it runs longer than it, 0 disables it
+	hardTimeout time.Duration
+	// parseJSON parses each host's output as JSON once it completes
+	parseJSON bool
+	// expectExitCode fails hosts whose command exits with a different code, nil disables the check
+	expectExitCode *int
+	// onComplete are called once with the final state after the command reaches a terminal status
+	onComplete []func(*CommandState)
+	// onStatus are called with the state when the command is created by the runner and when it starts
+	onStatus []func(*CommandState)
+	// lastActivityAt is when any host last produced output, nil until the first output
+	lastActivityAt *time.Time
+	// stallAfter marks a running command as stalled when no host produces output within it, 0 disables it
+	stallAfter time.Duration
+	// autoSudo runs the command again through sudo on hosts where it failed with permission denied
+	autoSudo bool
+	// hostCommand resolves the command for each host, nil runs command on every host
+	hostCommand func(host ssh.ClientInfo) (string, error)
+	// task runs instead of the command on each host when set, see WithTask
+	task func(ctx context.Context, report func(CommandResult))
+	// binary base64 encodes each host's output once it finishes instead of returning it as text
+	binary bool
+	// initiatedBy identifies the caller that created the command, empty when unknown
+	initiatedBy string
+}
+
+// CommandHost is a host targeted by a command with the address it resolved to when the command
+// was created, so the history stays accurate after the host's address is changed in storage.
+type CommandHost struct {
+	Group   string `json:"group"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Port    string `json:"port"`
 }
 
 // CommandState represents the serializable state of a Command
@@ -44,12 +111,71 @@ type CommandState struct {
 	ID        string                   `json:"id"`
 	Status    CommandStatus            `json:"status"`
 	Command   string                   `json:"command"`
-	Hosts     []utils.HostIdentifier   `json:"hosts"`
+	Hosts     []CommandHost            `json:"hosts"`
+	HostCount int                      `json:"host_count"`
 	Results   map[string]CommandResult `json:"results"`
 	CreatedAt time.Time                `json:"created_at"`
 	StartedAt *time.Time               `json:"started_at,omitempty"`
 	EndedAt   *time.Time               `json:"ended_at,omitempty"`
 	Error     string                   `json:"error,omitempty"`
+	// InitiatedBy identifies the user or agent that created the command
+	InitiatedBy string `json:"initiated_by,omitempty"`
+
+	// LastActivityAt is when any host last produced output
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty"`
+	// Stalled is true while the command is running but no host has produced output within the
+	// stall threshold. It is only an indicator, the command keeps running.
+	Stalled bool `json:"stalled,omitempty"`
+
+	// OutputCursor is the per-host byte offset of the output returned so far,
+	// only set when the results have been trimmed with ApplyOutputCursor.
+	OutputCursor map[string]int `json:"output_cursor,omitempty"`
+	// OutputPage describes the slice of a single host's output returned, only set when the
+	// results have been scoped with ApplyOutputPage.
+	OutputPage *OutputPage `json:"output_page,omitempty"`
+}
+
+// OutputPage describes a byte range of a host's output.
+type OutputPage struct {
+	Host string `json:"host"`
+	// Offset is the byte offset of the first byte returned
+	Offset int `json:"offset"`
+	// Length is the number of bytes returned
+	Length int `json:"length"`
+	// TotalLength is the number of bytes of output the host has produced so far
+	TotalLength int `json:"total_length"`
+	// NextOffset is the offset to request the following page with
+	NextOffset int `json:"next_offset"`
+	// HasMore is true when output remains after the returned page
+	HasMore bool `json:"has_more"`
+	// PastEnd is true when the offset is at or beyond the end of the output, so nothing was returned
+	PastEnd bool `json:"past_end,omitempty"`
+}
+
+// CommandSummary is the aggregate state of a command without any per-host results, cheap to
+// poll for commands targeting many hosts.
+type CommandSummary struct {
+	ID        string        `json:"id"`
+	Status    CommandStatus `json:"status"`
+	Command   string        `json:"command"`
+	HostCount int           `json:"host_count"`
+	// Succeeded is the number of hosts that completed without an error
+	Succeeded int `json:"succeeded"`
+	// Failed is the number of hosts that errored, including those that were never reached
+	Failed int `json:"failed"`
+	// Running is the number of hosts still executing or waiting to start
+	Running   int        `json:"running"`
+	CreatedAt time.Time  `json:"created_at"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	// DurationMillis is the time from start to end, or to now while the command is running
+	DurationMillis int64  `json:"duration_millis,omitempty"`
+	Error          string `json:"error,omitempty"`
+	InitiatedBy    string `json:"initiated_by,omitempty"`
+
+	// LastActivityAt is when any host last produced output
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty"`
+	Stalled        bool       `json:"stalled,omitempty"`
 }
 
 // CommandListItem represents a summary of a command for listing (without results)
@@ -61,6 +187,9 @@ type CommandListItem struct {
 	CreatedAt time.Time              `json:"created_at"`
 	StartedAt *time.Time             `json:"started_at,omitempty"`
 	EndedAt   *time.Time             `json:"ended_at,omitempty"`
+	Stalled   bool                   `json:"stalled,omitempty"`
+	// InitiatedBy identifies the user or agent that created the command
+	InitiatedBy string `json:"initiated_by,omitempty"`
 }
 
 // Start starts executing the command in the background
@@ -78,6 +207,17 @@ func (c *Command) Start() error {
 	c.startedAt = &now
 	c.mu.Unlock()
 
+	slog.Info("command started", "command_id", c.id, "hosts", len(c.hosts))
+	c.notifyStatus()
+
+	if c.task != nil {
+		go func() {
+			c.task(ctx, c.reportResult)
+			c.finish(ctx)
+		}()
+		return nil
+	}
+
 	// Run the command on all hosts in parallel
 	go func() {
 		var wg sync.WaitGroup
@@ -100,14 +240,65 @@ func (c *Command) Start() error {
 				default:
 				}
 
+				// Resolve the command of this host, failing only this host when it cannot be rendered
+				command := c.command
+				if c.hostCommand != nil {
+					rendered, err := c.hostCommand(host)
+					if err != nil {
+						c.mu.Lock()
+						c.results[host.Name] = CommandResult{
+							Host: host.Name,
+							Err:  fmt.Errorf("failed to render command: %w", err),
+						}
+						c.mu.Unlock()
+						return
+					}
+					command = rendered
+					// keep what this host was sent with its result, whatever the outcome
+					defer func() {
+						c.mu.Lock()
+						result := c.results[host.Name]
+						result.Command = rendered
+						c.results[host.Name] = result
+						c.mu.Unlock()
+					}()
+				}
+
+				// Skip hosts that recently failed to connect when requested
+				if c.skipUnreachable && c.reachability != nil {
+					if since, ok := c.reachability.UnreachableSince(host); ok {
+						c.mu.Lock()
+						c.results[host.Name] = CommandResult{
+							Host:          host.Name,
+							Err:           fmt.Errorf("skipped: host recently unreachable (last failed %s)", since.UTC().Format(time.RFC3339)),
+							ConnectFailed: true,
+							Skipped:       true,
+						}
+						c.mu.Unlock()
+						return
+					}
+				}
+
 				// Connect to the host
 				sshClient := ssh.NewClient(&host)
-				err := sshClient.Connect()
+				if c.retry != nil {
+					sshClient.SetRetryPolicy(*c.retry)
+				}
+				connectStart := time.Now()
+				err := sshClient.ConnectContext(ctx)
+				connectMillis := time.Since(connectStart).Milliseconds()
+				if c.reachability != nil {
+					c.reachability.Record(host, err)
+				}
 				if err != nil {
+					slog.Warn("failed to connect to host", "command_id", c.id, "host", host.Name, "error", err)
 					c.mu.Lock()
 					c.results[host.Name] = CommandResult{
-						Host: host.Name,
-						Err:  fmt.Errorf("failed to connect: %w", err),
+						Host:          host.Name,
+						Err:           fmt.Errorf("failed to connect: %w", err),
+						ConnectFailed: true,
+						AuthFailed:    ssh.ClassifyError(err) == ssh.ErrorClassAuth,
+						ConnectMillis: connectMillis,
 					}
 					c.mu.Unlock()
 					return
@@ -115,44 +306,105 @@ func (c *Command) Start() error {
 				defer sshClient.Close()
 
 				// Execute command with streaming output
-				c.executeWithStreaming(ctx, sshClient, host.Name)
+				execStart := time.Now()
+				c.executeWithStreaming(ctx, sshClient, host.Name, command, "")
+				if c.autoSudo && !utils.IsWindows(host) {
+					c.escalateIfDenied(ctx, sshClient, host.Name, command)
+				}
+				c.setDurations(host.Name, connectMillis, time.Since(execStart).Milliseconds())
+				if c.parseJSON {
+					c.setParsedJSON(host.Name)
+				}
+				if c.expectExitCode != nil {
+					c.checkExitCode(host.Name, *c.expectExitCode)
+				}
+				c.mu.Lock()
+				result := c.results[host.Name].withEmpty()
+				if c.binary {
+					result = result.withBase64()
+				}
+				c.results[host.Name] = result
+				c.mu.Unlock()
 			}(host)
 		}
 
 		wg.Wait()
+		c.finish(ctx)
+	}()
 
-		// Update final status
-		c.mu.Lock()
-		defer c.mu.Unlock()
+	return nil
+}
 
-		now := time.Now()
-		c.endedAt = &now
+// finish sets the final status of the command once every host has finished and calls the
+// completion hooks.
+func (c *Command) finish(ctx context.Context) {
+	c.mu.Lock()
+	now := time.Now()
+	c.endedAt = &now
 
-		// Check if command was cancelled
-		select {
-		case <-ctx.Done():
-			c.status = CommandStatusCancelled
-			return
-		default:
-		}
+	// Check if command was cancelled
+	select {
+	case <-ctx.Done():
+		c.status = CommandStatusCancelled
+	default:
+		c.status = resolveStatus(c.results, len(c.hosts))
+	}
+	slog.Info("command finished", "command_id", c.id, "status", c.status, "duration", c.endedAt.Sub(*c.startedAt))
+	c.mu.Unlock()
 
-		// Check if any results have errors
-		hasErrors := false
-		for _, result := range c.results {
-			if result.Err != nil {
-				hasErrors = true
-				break
-			}
+	// every host has finished, so the hooks see the complete result set exactly once
+	if len(c.onComplete) > 0 {
+		state := c.ToState()
+		for _, onComplete := range c.onComplete {
+			onComplete(state)
 		}
+	}
+}
+
+// reportResult records the result of a host of a command running a task.
+func (c *Command) reportResult(result CommandResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.lastActivityAt = &now
+	c.results[result.Host] = result.withEmpty()
+}
 
-		if hasErrors {
-			c.status = CommandStatusFailed
-		} else {
-			c.status = CommandStatusCompleted
+// notifyStatus calls the status hooks with the current state of the command.
+func (c *Command) notifyStatus() {
+	if len(c.onStatus) == 0 {
+		return
+	}
+	state := c.ToState()
+	for _, onStatus := range c.onStatus {
+		onStatus(state)
+	}
+}
+
+// resolveStatus determines the final status of a command that was not cancelled from its results.
+// The precedence is:
+//   - connect_failed when every host failed to connect, so the command never ran
+//   - failed when any host failed, including a mix of connection and execution failures
+//   - completed when every host succeeded
+func resolveStatus(results map[string]CommandResult, hostCount int) CommandStatus {
+	failed := 0
+	connectFailed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			if result.ConnectFailed {
+				connectFailed++
+			}
 		}
-	}()
+	}
 
-	return nil
+	switch {
+	case hostCount > 0 && connectFailed == hostCount:
+		return CommandStatusConnectFailed
+	case failed > 0:
+		return CommandStatusFailed
+	}
+	return CommandStatusCompleted
 }
 
 // Cancel cancels the running command
@@ -167,10 +419,38 @@ func (c *Command) Cancel() error {
 	if c.cancel != nil {
 		c.cancel()
 	}
+	slog.Info("command cancelled", "command_id", c.id)
 
 	return nil
 }
 
+// TargetsHost returns true when the command runs on the host. An empty name matches any host in the group.
+func (c *Command) TargetsHost(group, name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, host := range c.hosts {
+		if host.Group == group && (name == "" || host.Name == name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Groups returns the groups of the hosts the command runs on, each once.
+func (c *Command) Groups() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var groups []string
+	for _, host := range c.hosts {
+		if !slices.Contains(groups, host.Group) {
+			groups = append(groups, host.Group)
+		}
+	}
+	return groups
+}
+
 // ID returns the command's unique identifier
 func (c *Command) ID() string {
 	c.mu.RLock()
@@ -214,7 +494,74 @@ func (c *Command) ToListItem() *CommandListItem {
 		CreatedAt: c.createdAt,
 		StartedAt: c.startedAt,
 		EndedAt:   c.endedAt,
+		Stalled:   c.stalledLocked(time.Now()),
+
+		InitiatedBy: c.initiatedBy,
+	}
+}
+
+// ToSummary returns the aggregate state of the command with the hosts counted by outcome. The
+// results are only inspected, never copied.
+func (c *Command) ToSummary() *CommandSummary {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	summary := &CommandSummary{
+		ID:        c.id,
+		Status:    c.status,
+		Command:   c.command,
+		HostCount: len(c.hosts),
+		CreatedAt: c.createdAt,
+		StartedAt: c.startedAt,
+		EndedAt:   c.endedAt,
+
+		InitiatedBy:    c.initiatedBy,
+		LastActivityAt: c.lastActivityAt,
+		Stalled:        c.stalledLocked(now),
+	}
+	if c.err != nil {
+		summary.Error = c.err.Error()
+	}
+	if c.startedAt != nil {
+		end := now
+		if c.endedAt != nil {
+			end = *c.endedAt
+		}
+		summary.DurationMillis = end.Sub(*c.startedAt).Milliseconds()
+	}
+
+	terminal := c.status.IsTerminal()
+	for _, h := range c.hosts {
+		result, ok := c.results[h.Name]
+		switch {
+		case ok && result.Err != nil:
+			summary.Failed++
+		case !terminal:
+			// output without an error is only final once the command finished
+			summary.Running++
+		case ok:
+			summary.Succeeded++
+		default:
+			// the command ended before the host produced a result, e.g. it was cancelled
+			summary.Failed++
+		}
 	}
+	return summary
+}
+
+// stalledLocked returns true when the command is running and no host has produced output within
+// the stall threshold, counting from the start when there was no output yet. Must be called with
+// the mutex held.
+func (c *Command) stalledLocked(now time.Time) bool {
+	if c.stallAfter <= 0 || c.status != CommandStatusRunning || c.startedAt == nil {
+		return false
+	}
+	last := *c.startedAt
+	if c.lastActivityAt != nil {
+		last = *c.lastActivityAt
+	}
+	return now.Sub(last) > c.stallAfter
 }
 
 // ToState returns a safe copy of the command state for serialization
@@ -222,12 +569,14 @@ func (c *Command) ToState() *CommandState {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	// Convert hosts to simplified identifiers
-	hosts := make([]utils.HostIdentifier, len(c.hosts))
+	// Convert hosts to identifiers with their resolved addresses
+	hosts := make([]CommandHost, len(c.hosts))
 	for i, h := range c.hosts {
-		hosts[i] = utils.HostIdentifier{
-			Group: h.Group,
-			Name:  h.Name,
+		hosts[i] = CommandHost{
+			Group:   h.Group,
+			Name:    h.Name,
+			Address: h.Host,
+			Port:    h.Port,
 		}
 	}
 
@@ -246,36 +595,215 @@ func (c *Command) ToState() *CommandState {
 		Status:    c.status,
 		Command:   c.command,
 		Hosts:     hosts,
+		HostCount: len(hosts),
 		Results:   results,
 		CreatedAt: c.createdAt,
 		StartedAt: c.startedAt,
 		EndedAt:   c.endedAt,
 		Error:     errStr,
+
+		InitiatedBy:    c.initiatedBy,
+		LastActivityAt: c.lastActivityAt,
+		Stalled:        c.stalledLocked(time.Now()),
+	}
+}
+
+// ApplyOutputCursor trims the output of each result to only the bytes appended
+// after the per-host offset in cursor and records the updated offsets in
+// OutputCursor. Hosts missing from the cursor return their full output.
+// Offsets are kept on UTF-8 rune boundaries, so a multi-byte character is
+// never split between two polls.
+func (s *CommandState) ApplyOutputCursor(cursor map[string]int) {
+	next := make(map[string]int, len(s.Results))
+	for host, result := range s.Results {
+		offset := max(cursor[host], 0)
+		if offset > len(result.Result) {
+			// output only ever grows, so an offset past the end means there
+			// is nothing new to return
+			offset = len(result.Result)
+		}
+		offset = runeStart(result.Result, offset)
+		end := len(result.Result)
+		if result.running {
+			// the rest of a character cut off by the last read is still to come
+			end = completeRunesEnd(result.Result)
+		}
+		result.Result = result.Result[offset:max(end, offset)]
+		s.Results[host] = result
+		next[host] = offset + len(result.Result)
+	}
+	s.OutputCursor = next
+}
+
+// runeStart moves the byte offset i in s back to the start of the UTF-8 rune it falls in.
+func runeStart(s string, i int) int {
+	for back := 0; back < utf8.UTFMax-1 && i > 0 && i < len(s) && !utf8.RuneStart(s[i]); back++ {
+		i--
+	}
+	return i
+}
+
+// completeRunesEnd returns the length of s without a trailing incomplete UTF-8 rune.
+func completeRunesEnd(s string) int {
+	start := runeStart(s, len(s)-1)
+	if start >= 0 && !utf8.FullRuneInString(s[start:]) {
+		return start
+	}
+	return len(s)
+}
+
+// ApplyOutputPage scopes the results to the host and trims its output to at most limit bytes
+// starting at offset, recording the range in OutputPage. A limit of 0 returns the rest of the
+// output. An offset past the end returns no output with PastEnd set.
+func (s *CommandState) ApplyOutputPage(host string, offset int, limit int) error {
+	if offset < 0 {
+		return fmt.Errorf("output offset cannot be negative")
+	}
+	if limit < 0 {
+		return fmt.Errorf("output limit cannot be negative")
+	}
+	result, ok := s.Results[host]
+	if !ok {
+		return fmt.Errorf("no output for host %s in command %s", host, s.ID)
+	}
+
+	total := len(result.Result)
+	start := min(offset, total)
+	end := total
+	if limit > 0 {
+		end = min(start+limit, total)
+	}
+	result.Result = result.Result[start:end]
+	s.Results = map[string]CommandResult{host: result}
+	s.OutputPage = &OutputPage{
+		Host:        host,
+		Offset:      offset,
+		Length:      end - start,
+		TotalLength: total,
+		NextOffset:  max(end, offset),
+		HasMore:     end < total,
+		PastEnd:     offset > 0 && offset >= total,
+	}
+	return nil
+}
+
+// setDurations records the connect and execution durations on the host's result
+func (c *Command) setDurations(hostName string, connectMillis int64, execMillis int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := c.results[hostName]
+	result.Host = hostName
+	result.ConnectMillis = connectMillis
+	result.ExecMillis = execMillis
+	c.results[hostName] = result
+}
+
+// setParsedJSON parses the host's output as JSON and records it on the host's result
+func (c *Command) setParsedJSON(hostName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := c.results[hostName]
+	output := result.Result
+	if result.Escalated {
+		// only the output of the escalated run is the command's output
+		_, output, _ = strings.Cut(output, autoSudoMarker)
+	}
+	result.JSON, result.JSONNote = parseJSONOutput(output)
+	c.results[hostName] = result
+}
+
+// escalateIfDenied runs the host's command again through non-interactive sudo when it failed on
+// the host with a permission denied error. The escalated output is appended to the output of the
+// first attempt after autoSudoMarker, so the output only ever grows.
+func (c *Command) escalateIfDenied(ctx context.Context, sshClient *ssh.Client, hostName string, command string) {
+	c.mu.Lock()
+	result := c.results[hostName]
+	if ctx.Err() != nil || !isPermissionDenied(result) || isSudoCommand(command) {
+		c.mu.Unlock()
+		return
+	}
+	prior := result.Result + autoSudoMarker
+	result.Result = prior
+	c.results[hostName] = result
+	c.mu.Unlock()
+
+	slog.Info("permission denied, retrying with sudo", "command_id", c.id, "host", hostName)
+	c.executeWithStreaming(ctx, sshClient, hostName, sudoCommand(command), prior)
+
+	c.mu.Lock()
+	result = c.results[hostName]
+	result.Escalated = true
+	c.results[hostName] = result
+	c.mu.Unlock()
+}
+
+// checkExitCode fails the host's result when the command exited with a different code than expected,
+// and clears the error of a non-zero exit code that was expected. Results of commands that did not
+// run to completion are left as is.
+func (c *Command) checkExitCode(hostName string, expected int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := c.results[hostName]
+	code, ok := result.ExitCode()
+	if !ok || result.TimedOut {
+		return
+	}
+	if code == expected {
+		result.Err = nil
+	} else {
+		result.Err = &UnexpectedExitCodeError{Code: code, Expected: expected, Err: result.Err}
 	}
+	result.ExpectedExitCode = &expected
+	c.results[hostName] = result
 }
 
-// executeWithStreaming executes a command with streaming stdout/stderr capture
-func (c *Command) executeWithStreaming(ctx context.Context, sshClient *ssh.Client, hostName string) {
+// executeWithStreaming executes a command with streaming stdout/stderr capture. The captured output
+// starts with prior, the output of an earlier attempt on the host.
+func (c *Command) executeWithStreaming(ctx context.Context, sshClient *ssh.Client, hostName string, command string, prior string) {
 	// Create SSH session
 	session, err := sshClient.NewSession()
 	if err != nil {
 		c.mu.Lock()
 		c.results[hostName] = CommandResult{
-			Host: hostName,
-			Err:  fmt.Errorf("failed to create session: %w", err),
+			Host:   hostName,
+			Result: prior,
+			Err:    fmt.Errorf("failed to create session: %w", err),
 		}
 		c.mu.Unlock()
 		return
 	}
 	defer session.Close()
 
+	// Request a pseudo-terminal when enabled (stderr is merged into stdout by the remote)
+	if c.pty != nil {
+		modes := gossh.TerminalModes{
+			gossh.ECHO:          0,
+			gossh.TTY_OP_ISPEED: 14400,
+			gossh.TTY_OP_OSPEED: 14400,
+		}
+		if err := session.RequestPty(c.pty.Term, c.pty.Rows, c.pty.Cols, modes); err != nil {
+			c.mu.Lock()
+			c.results[hostName] = CommandResult{
+				Host:   hostName,
+				Result: prior,
+				Err:    fmt.Errorf("failed to request pty: %w", err),
+			}
+			c.mu.Unlock()
+			return
+		}
+	}
+
 	// Create a pipe for stdout and stderr
 	stdout, err := session.StdoutPipe()
 	if err != nil {
 		c.mu.Lock()
 		c.results[hostName] = CommandResult{
-			Host: hostName,
-			Err:  fmt.Errorf("failed to create stdout pipe: %w", err),
+			Host:   hostName,
+			Result: prior,
+			Err:    fmt.Errorf("failed to create stdout pipe: %w", err),
 		}
 		c.mu.Unlock()
 		return
@@ -285,19 +813,21 @@ func (c *Command) executeWithStreaming(ctx context.Context, sshClient *ssh.Clien
 	if err != nil {
 		c.mu.Lock()
 		c.results[hostName] = CommandResult{
-			Host: hostName,
-			Err:  fmt.Errorf("failed to create stderr pipe: %w", err),
+			Host:   hostName,
+			Result: prior,
+			Err:    fmt.Errorf("failed to create stderr pipe: %w", err),
 		}
 		c.mu.Unlock()
 		return
 	}
 
 	// Start the command
-	if err := session.Start(c.command); err != nil {
+	if err := session.Start(command); err != nil {
 		c.mu.Lock()
 		c.results[hostName] = CommandResult{
-			Host: hostName,
-			Err:  fmt.Errorf("failed to start command: %w", err),
+			Host:   hostName,
+			Result: prior,
+			Err:    fmt.Errorf("failed to start command: %w", err),
 		}
 		c.mu.Unlock()
 		return
@@ -305,11 +835,26 @@ func (c *Command) executeWithStreaming(ctx context.Context, sshClient *ssh.Clien
 
 	// Read output in real-time and update results
 	var output []byte
+	// stderrOutput is the standard error of binary output, which is captured apart from stdout
+	var stderrOutput []byte
 	done := make(chan error, 1)
+	// activity is signalled whenever output arrives, used to detect idle commands
+	activity := make(chan struct{}, 1)
+	// overflow is closed once the output crosses maxOutputBytes
+	overflow := make(chan struct{})
+	var overflowOnce sync.Once
 
 	go func() {
-		// Read from stdout and stderr concurrently
-		var stdoutBuf, stderrBuf []byte
+		// Read from stdout and stderr concurrently into a single buffer in the
+		// order the bytes arrive, so the captured output only ever grows by
+		// appending (required for cursor based polling of partial output)
+		outputBuf := []byte(prior)
+		// binary output keeps stderr apart, so it cannot corrupt the bytes of stdout
+		var stderrBuf []byte
+		stderrTarget := &outputBuf
+		if c.binary {
+			stderrTarget = &stderrBuf
+		}
 		var bufMu sync.Mutex
 		var wg sync.WaitGroup
 		wg.Add(2)
@@ -321,20 +866,37 @@ func (c *Command) executeWithStreaming(ctx context.Context, sshClient *ssh.Clien
 			for {
 				n, err := pipe.Read(readBuf)
 				if n > 0 {
+					select {
+					case activity <- struct{}{}:
+					default:
+					}
+
 					bufMu.Lock()
+					if len(outputBuf)+len(stderrBuf)+n > maxOutputBytes {
+						bufMu.Unlock()
+						overflowOnce.Do(func() { close(overflow) })
+						break
+					}
 					*buf = append(*buf, readBuf[:n]...)
 					// Update the result with partial output
-					combined := string(append(stdoutBuf, stderrBuf...))
+					combined := string(outputBuf)
 					bufMu.Unlock()
 
 					c.mu.Lock()
-					if result, exists := c.results[hostName]; exists {
-						result.Result = combined
-						c.results[hostName] = result
-					} else {
-						c.results[hostName] = CommandResult{
-							Host:   hostName,
-							Result: combined,
+					now := time.Now()
+					c.lastActivityAt = &now
+					// binary output is only published once complete
+					if !c.binary {
+						if result, exists := c.results[hostName]; exists {
+							result.Result = combined
+							result.running = true
+							c.results[hostName] = result
+						} else {
+							c.results[hostName] = CommandResult{
+								Host:    hostName,
+								Result:  combined,
+								running: true,
+							}
 						}
 					}
 					c.mu.Unlock()
@@ -345,41 +907,87 @@ func (c *Command) executeWithStreaming(ctx context.Context, sshClient *ssh.Clien
 			}
 		}
 
-		go readPipe(stdout, &stdoutBuf)
-		go readPipe(stderr, &stderrBuf)
+		go readPipe(stdout, &outputBuf)
+		go readPipe(stderr, stderrTarget)
 
 		wg.Wait()
-		output = append(stdoutBuf, stderrBuf...)
+		output = outputBuf
+		stderrOutput = stderrBuf
 		done <- session.Wait()
 	}()
 
-	// Wait for command to complete or context to be cancelled
-	select {
-	case <-ctx.Done():
-		// Try to terminate the session gracefully
-		_ = session.Signal(gossh.SIGTERM)
-		session.Close()
-		c.mu.Lock()
-		c.results[hostName] = CommandResult{
-			Host:   hostName,
-			Result: string(output),
-			Err:    fmt.Errorf("command cancelled"),
-		}
-		c.mu.Unlock()
-	case err := <-done:
-		c.mu.Lock()
-		if err != nil {
+	// Stop the command when it produces no output within the idle timeout
+	var idleTimer *time.Timer
+	var idle <-chan time.Time
+	if c.idleTimeout > 0 {
+		idleTimer = time.NewTimer(c.idleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
+	// Stop the command when it runs longer than the hard timeout
+	var hard <-chan time.Time
+	if c.hardTimeout > 0 {
+		hardTimer := time.NewTimer(c.hardTimeout)
+		defer hardTimer.Stop()
+		hard = hardTimer.C
+	}
+
+	// Wait for command to complete, time out or context to be cancelled
+	for {
+		select {
+		case <-activity:
+			if idleTimer != nil {
+				idleTimer.Reset(c.idleTimeout)
+			}
+			continue
+		case <-ctx.Done():
+			// Try to terminate the session gracefully
+			_ = session.Signal(gossh.SIGTERM)
+			session.Close()
+			c.mu.Lock()
 			c.results[hostName] = CommandResult{
 				Host:   hostName,
-				Result: string(output),
-				Err:    fmt.Errorf("command failed: %w", err),
+				Result: c.results[hostName].Result,
+				Err:    fmt.Errorf("command cancelled"),
 			}
-		} else {
+			c.mu.Unlock()
+		case <-overflow:
+			// Last resort guard so a runaway command (e.g. cat /dev/urandom) cannot exhaust memory
+			_ = session.Signal(gossh.SIGKILL)
+			session.Close()
+			c.mu.Lock()
 			c.results[hostName] = CommandResult{
+				Host:   hostName,
+				Result: c.results[hostName].Result,
+				Err:    fmt.Errorf("output exceeded safety limit of %d bytes", maxOutputBytes),
+			}
+			c.mu.Unlock()
+		case <-idle:
+			_ = session.Signal(gossh.SIGTERM)
+			session.Close()
+			c.mu.Lock()
+			c.results[hostName] = timedOutResult(hostName, c.results[hostName].Result, TimeoutReasonIdle, c.idleTimeout)
+			c.mu.Unlock()
+		case <-hard:
+			_ = session.Signal(gossh.SIGTERM)
+			session.Close()
+			c.mu.Lock()
+			c.results[hostName] = timedOutResult(hostName, c.results[hostName].Result, TimeoutReasonHard, c.hardTimeout)
+			c.mu.Unlock()
+		case err := <-done:
+			result := CommandResult{
 				Host:   hostName,
 				Result: string(output),
+				Stderr: string(stderrOutput),
 			}
+			if err != nil {
+				result.Err = fmt.Errorf("command failed: %w", err)
+			}
+			c.mu.Lock()
+			c.results[hostName] = result
+			c.mu.Unlock()
 		}
-		c.mu.Unlock()
+		return
 	}
 }
@@ -0,0 +1,521 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+func TestResolveStatus(t *testing.T) {
+	connectErr := CommandResult{Err: errors.New("failed to connect"), ConnectFailed: true}
+	execErr := CommandResult{Err: errors.New("exit status 1")}
+	ok := CommandResult{Result: "ok"}
+
+	tests := []struct {
+		name     string
+		results  map[string]CommandResult
+		expected CommandStatus
+	}{
+		{"all succeeded", map[string]CommandResult{"a": ok, "b": ok}, CommandStatusCompleted},
+		{"all connect failures", map[string]CommandResult{"a": connectErr, "b": connectErr}, CommandStatusConnectFailed},
+		{"some connect failures", map[string]CommandResult{"a": connectErr, "b": ok}, CommandStatusFailed},
+		{"connect and exec failures", map[string]CommandResult{"a": connectErr, "b": execErr}, CommandStatusFailed},
+		{"exec failure", map[string]CommandResult{"a": execErr}, CommandStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveStatus(tt.results, len(tt.results)); got != tt.expected {
+				t.Errorf("expected status %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCommandStatus_IsTerminal(t *testing.T) {
+	for _, status := range []CommandStatus{CommandStatusCompleted, CommandStatusFailed, CommandStatusCancelled, CommandStatusConnectFailed} {
+		if !status.IsTerminal() {
+			t.Errorf("expected %s to be terminal", status)
+		}
+	}
+	for _, status := range []CommandStatus{CommandStatusPending, CommandStatusRunning} {
+		if status.IsTerminal() {
+			t.Errorf("expected %s to not be terminal", status)
+		}
+	}
+}
+
+func TestCommand_SkipUnreachable(t *testing.T) {
+	host := ssh.ClientInfo{Name: "db1", Group: "prod", Host: "127.0.0.1", Port: "1"}
+
+	r := NewRunner().(*runner)
+	r.reachability.MarkUnreachable(host)
+
+	cmd := r.CreateCommand("uptime", []ssh.ClientInfo{host}, WithSkipUnreachable())
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for !cmd.Status().IsTerminal() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	result := cmd.ToState().Results["db1"]
+	if !result.Skipped {
+		t.Error("expected recently unreachable host to be skipped")
+	}
+	if cmd.Status() != CommandStatusConnectFailed {
+		t.Errorf("expected status %s, got %s", CommandStatusConnectFailed, cmd.Status())
+	}
+}
+
+func TestCommand_ToStateIncludesAddresses(t *testing.T) {
+	mock := NewMockRunner()
+	cmd := mock.CreateCommand("uptime", []ssh.ClientInfo{{Group: "prod", Name: "web01", Host: "10.0.0.5", Port: "2222"}})
+
+	state := cmd.ToState()
+	expected := CommandHost{Group: "prod", Name: "web01", Address: "10.0.0.5", Port: "2222"}
+	if len(state.Hosts) != 1 || state.Hosts[0] != expected {
+		t.Errorf("expected hosts [%+v], got %+v", expected, state.Hosts)
+	}
+	if state.HostCount != 1 {
+		t.Errorf("expected host count 1, got %d", state.HostCount)
+	}
+}
+
+func TestCommand_ToSummaryCountsHostOutcomes(t *testing.T) {
+	mock := NewMockRunner()
+	cmd := mock.CreateCommand("uptime", []ssh.ClientInfo{
+		{Group: "prod", Name: "web01"},
+		{Group: "prod", Name: "web02"},
+		{Group: "prod", Name: "web03"},
+	})
+	cmd.SetStatusForTest(CommandStatusRunning)
+	cmd.SetResultForTest("web01", CommandResult{Host: "web01", Result: "up 3 days"})
+	cmd.SetResultForTest("web02", CommandResult{Host: "web02", Err: errors.New("connection refused")})
+
+	summary := cmd.ToSummary()
+	if summary.HostCount != 3 || summary.Succeeded != 0 || summary.Failed != 1 || summary.Running != 2 {
+		t.Errorf("unexpected counts while running: %+v", summary)
+	}
+
+	cmd.SetStatusForTest(CommandStatusCompleted)
+	summary = cmd.ToSummary()
+	// web03 never produced a result before the command ended
+	if summary.Succeeded != 1 || summary.Failed != 2 || summary.Running != 0 {
+		t.Errorf("unexpected counts once completed: %+v", summary)
+	}
+	if summary.Status != CommandStatusCompleted || summary.ID != cmd.ID() {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestCommand_InitiatedBy(t *testing.T) {
+	mock := NewMockRunner()
+	cmd := mock.CreateCommand("uptime", []ssh.ClientInfo{{Group: "prod", Name: "web01"}}, WithInitiatedBy("alice via claude-code 1.0"))
+
+	if got := cmd.ToState().InitiatedBy; got != "alice via claude-code 1.0" {
+		t.Errorf("expected state initiated by alice, got %q", got)
+	}
+	if got := cmd.ToListItem().InitiatedBy; got != "alice via claude-code 1.0" {
+		t.Errorf("expected list item initiated by alice, got %q", got)
+	}
+	if got := cmd.ToSummary().InitiatedBy; got != "alice via claude-code 1.0" {
+		t.Errorf("expected summary initiated by alice, got %q", got)
+	}
+}
+
+func TestCommand_OnCompleteFiresOnceWithAllResults(t *testing.T) {
+	hosts := []ssh.ClientInfo{
+		{Name: "db1", Group: "prod", Host: "127.0.0.1", Port: "1"},
+		{Name: "db2", Group: "prod", Host: "127.0.0.1", Port: "2"},
+	}
+
+	calls := make(chan *CommandState, 2)
+	r := NewRunner(WithCompletionHook(func(state *CommandState) {
+		calls <- state
+	})).(*runner)
+	for _, host := range hosts {
+		r.reachability.MarkUnreachable(host)
+	}
+
+	cmd := r.CreateCommand("uptime", hosts, WithSkipUnreachable())
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var state *CommandState
+	select {
+	case state = <-calls:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the completion hook to fire")
+	}
+	if state.Status != CommandStatusConnectFailed {
+		t.Errorf("expected status %s, got %s", CommandStatusConnectFailed, state.Status)
+	}
+	if len(state.Results) != len(hosts) {
+		t.Errorf("expected %d results, got %d", len(hosts), len(state.Results))
+	}
+	if state.EndedAt == nil {
+		t.Error("expected the final state to have an end time")
+	}
+
+	select {
+	case <-calls:
+		t.Error("expected the completion hook to fire only once")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCommand_CheckExitCode(t *testing.T) {
+	cmd := &Command{results: map[string]CommandResult{
+		"ok":        {Host: "ok", Result: "healthy"},
+		"cancelled": {Host: "cancelled", Err: errors.New("command cancelled")},
+	}}
+
+	cmd.checkExitCode("ok", 1)
+	result := cmd.results["ok"]
+	var unexpected *UnexpectedExitCodeError
+	if !errors.As(result.Err, &unexpected) {
+		t.Fatalf("expected an unexpected exit code error, got %v", result.Err)
+	}
+	if result.Err.Error() != "unexpected exit code 0, expected 1" {
+		t.Errorf("unexpected error message: %s", result.Err)
+	}
+	if code, ok := result.ExitCode(); !ok || code != 0 {
+		t.Errorf("expected exit code 0, got %d (%v)", code, ok)
+	}
+
+	cmd.checkExitCode("cancelled", 0)
+	if result := cmd.results["cancelled"]; result.ExpectedExitCode != nil || result.Err.Error() != "command cancelled" {
+		t.Errorf("expected a command that did not complete to be left as is, got %+v", result)
+	}
+
+	if resolveStatus(cmd.results, 2) != CommandStatusFailed {
+		t.Error("expected an unexpected exit code to fail the command")
+	}
+}
+
+func TestCommandResult_ExitCodeExpected(t *testing.T) {
+	expected := 1
+	result := CommandResult{ExpectedExitCode: &expected}
+	if code, ok := result.ExitCode(); !ok || code != 1 {
+		t.Errorf("expected a matched expected exit code of 1, got %d (%v)", code, ok)
+	}
+}
+
+func TestCommand_Stalled(t *testing.T) {
+	now := time.Now()
+	started := now.Add(-10 * time.Minute)
+	recent := now.Add(-10 * time.Second)
+
+	testCases := []struct {
+		name         string
+		status       CommandStatus
+		stallAfter   time.Duration
+		lastActivity *time.Time
+		expected     bool
+	}{
+		{"no output since start", CommandStatusRunning, time.Minute, nil, true},
+		{"old output", CommandStatusRunning, time.Minute, &started, true},
+		{"recent output", CommandStatusRunning, time.Minute, &recent, false},
+		{"disabled", CommandStatusRunning, 0, nil, false},
+		{"finished", CommandStatusCompleted, time.Minute, nil, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &Command{
+				status:         tc.status,
+				startedAt:      &started,
+				stallAfter:     tc.stallAfter,
+				lastActivityAt: tc.lastActivity,
+				results:        make(map[string]CommandResult),
+			}
+			if got := cmd.ToState().Stalled; got != tc.expected {
+				t.Errorf("expected stalled %v, got %v", tc.expected, got)
+			}
+			if got := cmd.ToListItem().Stalled; got != tc.expected {
+				t.Errorf("expected list item stalled %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunner_DefaultStallAfter(t *testing.T) {
+	r := NewRunner(WithDefaultStallAfter(time.Minute))
+	cmd := r.CreateCommand("sleep 3600", nil)
+	if cmd.stallAfter != time.Minute {
+		t.Errorf("expected stall threshold of 1m, got %s", cmd.stallAfter)
+	}
+
+	cmd = r.CreateCommand("sleep 3600", nil, WithStallAfter(time.Hour))
+	if cmd.stallAfter != time.Hour {
+		t.Errorf("expected command option to override the default, got %s", cmd.stallAfter)
+	}
+}
+
+func TestCommandState_ApplyOutputPage(t *testing.T) {
+	newState := func() *CommandState {
+		return &CommandState{ID: "cmd-1", Results: map[string]CommandResult{
+			"web1": {Host: "web1", Result: "0123456789"},
+			"web2": {Host: "web2", Result: "other"},
+		}}
+	}
+
+	testCases := []struct {
+		name     string
+		offset   int
+		limit    int
+		output   string
+		expected OutputPage
+	}{
+		{"first page", 0, 4, "0123", OutputPage{Host: "web1", Offset: 0, Length: 4, TotalLength: 10, NextOffset: 4, HasMore: true}},
+		{"last page", 8, 4, "89", OutputPage{Host: "web1", Offset: 8, Length: 2, TotalLength: 10, NextOffset: 10}},
+		{"no limit", 3, 0, "3456789", OutputPage{Host: "web1", Offset: 3, Length: 7, TotalLength: 10, NextOffset: 10}},
+		{"at end", 10, 4, "", OutputPage{Host: "web1", Offset: 10, TotalLength: 10, NextOffset: 10, PastEnd: true}},
+		{"past end", 50, 4, "", OutputPage{Host: "web1", Offset: 50, TotalLength: 10, NextOffset: 50, PastEnd: true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := newState()
+			if err := state.ApplyOutputPage("web1", tc.offset, tc.limit); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(state.Results) != 1 {
+				t.Errorf("expected only the paged host in the results, got %d results", len(state.Results))
+			}
+			if got := state.Results["web1"].Result; got != tc.output {
+				t.Errorf("expected output %q, got %q", tc.output, got)
+			}
+			if *state.OutputPage != tc.expected {
+				t.Errorf("expected page %+v, got %+v", tc.expected, *state.OutputPage)
+			}
+		})
+	}
+
+	if err := newState().ApplyOutputPage("db1", 0, 4); err == nil {
+		t.Error("expected an error for a host without output")
+	}
+	if err := newState().ApplyOutputPage("web1", -1, 4); err == nil {
+		t.Error("expected an error for a negative offset")
+	}
+}
+
+func TestCommand_HostCommandRenderFailureOnlyFailsHost(t *testing.T) {
+	hosts := []ssh.ClientInfo{
+		{Name: "db1", Group: "prod", Host: "127.0.0.1", Port: "1"},
+		{Name: "db2", Group: "prod", Host: "127.0.0.1", Port: "1"},
+	}
+
+	var rendered []string
+	var mu sync.Mutex
+	render := func(host ssh.ClientInfo) (string, error) {
+		if host.Name == "db2" {
+			return "", errors.New(`map has no entry for key "port"`)
+		}
+		mu.Lock()
+		rendered = append(rendered, host.Name)
+		mu.Unlock()
+		return "echo " + host.Name, nil
+	}
+
+	r := NewRunner(WithDefaultRetryPolicy(ssh.RetryPolicy{Attempts: 1})).(*runner)
+	cmd := r.CreateCommand("echo {{.Name}}", hosts, WithHostCommand(render))
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for !cmd.Status().IsTerminal() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	results := cmd.ToState().Results
+	if !strings.Contains(results["db2"].Err.Error(), "failed to render command") || results["db2"].ConnectFailed {
+		t.Errorf("expected db2 to fail rendering without connecting, got %+v", results["db2"])
+	}
+	if !results["db1"].ConnectFailed {
+		t.Errorf("expected db1 to be rendered and connected to, got %+v", results["db1"])
+	}
+	if len(rendered) != 1 || rendered[0] != "db1" {
+		t.Errorf("expected only db1 to be rendered, got %v", rendered)
+	}
+	if results["db1"].Command != "echo db1" || results["db2"].Command != "" {
+		t.Errorf("expected only db1 to keep its rendered command, got %q and %q", results["db1"].Command, results["db2"].Command)
+	}
+
+	// the rendered command survives the history
+	encoded, err := json.Marshal(results["db1"])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded CommandResult
+	if err := json.Unmarshal(encoded, &decoded); err != nil || decoded.Command != "echo db1" {
+		t.Errorf("expected the rendered command to round-trip, got %q (%v)", decoded.Command, err)
+	}
+}
+
+func TestCommand_BinaryOutputKeepsStderrApart(t *testing.T) {
+	stdout := []byte{0x1f, 0x8b, 0x00, 0xff, '\n'}
+	host := startExecTestServer(t, func(command string, channel gossh.Channel) uint32 {
+		_, _ = channel.Write(stdout[:2])
+		_, _ = channel.Stderr().Write([]byte("tar: Removing leading '/' from member names\n"))
+		_, _ = channel.Write(stdout[2:])
+		return 0
+	})
+
+	r := NewRunner(WithDefaultRetryPolicy(ssh.RetryPolicy{Attempts: 1})).(*runner)
+	cmd := r.CreateCommand("tar -czf - /etc", []ssh.ClientInfo{host}, WithBinaryOutput())
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for !cmd.Status().IsTerminal() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	result := cmd.ToState().Results[host.Name]
+	if result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if result.Encoding != EncodingBase64 || result.Result != base64.StdEncoding.EncodeToString(stdout) {
+		t.Errorf("expected only stdout to be encoded, got %q with encoding %q", result.Result, result.Encoding)
+	}
+	if result.Stderr != "tar: Removing leading '/' from member names\n" {
+		t.Errorf("expected stderr to be returned apart, got %q", result.Stderr)
+	}
+}
+
+func TestCommandState_ApplyOutputCursor_RuneBoundaries(t *testing.T) {
+	// "héllo" with the é (2 bytes) at offsets 1-2, and the first 2 bytes of € (3 bytes) at the end
+	partial := "h\xc3\xa9llo \xe2\x82"
+	state := &CommandState{Results: map[string]CommandResult{
+		"web1": {Host: "web1", Result: partial, running: true},
+		"web2": {Host: "web2", Result: "h\xc3\xa9llo"},
+	}}
+	state.ApplyOutputCursor(map[string]int{"web1": 0, "web2": 2})
+
+	// the incomplete € of a running host is held back until the rest of it arrives
+	if got := state.Results["web1"].Result; got != "h\xc3\xa9llo " {
+		t.Errorf("expected the incomplete rune to be held back, got %q", got)
+	}
+	if state.OutputCursor["web1"] != 7 {
+		t.Errorf("expected the cursor before the incomplete rune, got %d", state.OutputCursor["web1"])
+	}
+	// an offset inside the é moves back to its start
+	if got := state.Results["web2"].Result; got != "\xc3\xa9llo" {
+		t.Errorf("expected the output from the start of the rune, got %q", got)
+	}
+	if state.OutputCursor["web2"] != 6 {
+		t.Errorf("expected the cursor at the end, got %d", state.OutputCursor["web2"])
+	}
+}
+
+func TestCommand_CancelKeepsPartialOutput(t *testing.T) {
+	host := startExecTestServer(t, func(command string, channel gossh.Channel) uint32 {
+		_, _ = channel.Write([]byte("partial\n"))
+		// runs until the test ends
+		<-t.Context().Done()
+		return 0
+	})
+
+	r := NewRunner(WithDefaultRetryPolicy(ssh.RetryPolicy{Attempts: 1})).(*runner)
+	cmd := r.CreateCommand("sleep infinity", []ssh.ClientInfo{host})
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for cmd.ToState().Results[host.Name].Result == "" {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := cmd.Cancel(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for !cmd.Status().IsTerminal() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	result := cmd.ToState().Results[host.Name]
+	if result.Err == nil || result.Err.Error() != "command cancelled" {
+		t.Errorf("expected the host to be cancelled, got %v", result.Err)
+	}
+	if result.Result != "partial\n" {
+		t.Errorf("expected the partial output to be kept, got %q", result.Result)
+	}
+}
+
+func TestCommand_TaskReportsResults(t *testing.T) {
+	hosts := []ssh.ClientInfo{{Name: "web1", Group: "prod"}, {Name: "web2", Group: "prod"}}
+	calls := make(chan *CommandState, 1)
+	r := NewRunner(WithCompletionHook(func(state *CommandState) {
+		calls <- state
+	}))
+	cmd := r.CreateCommand("rolling reboot", hosts, WithTask(func(ctx context.Context, report func(CommandResult)) {
+		report(CommandResult{Host: "web1", Result: "recovered"})
+		report(CommandResult{Host: "web2", Err: errors.New("did not come back")})
+	}))
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var state *CommandState
+	select {
+	case state = <-calls:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the completion hook to fire")
+	}
+	if state.Status != CommandStatusFailed {
+		t.Errorf("expected status %s, got %s", CommandStatusFailed, state.Status)
+	}
+	if state.Results["web1"].Result != "recovered" || state.Results["web2"].Err == nil {
+		t.Errorf("unexpected results: %+v", state.Results)
+	}
+}
+
+func TestCommand_TaskCancelled(t *testing.T) {
+	hosts := []ssh.ClientInfo{{Name: "web1", Group: "prod"}}
+	cmd := NewRunner().CreateCommand("rolling reboot", hosts, WithTask(func(ctx context.Context, report func(CommandResult)) {
+		<-ctx.Done()
+		report(CommandResult{Host: "web1", Err: ctx.Err()})
+	}))
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cmd.Cancel(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for !cmd.Status().IsTerminal() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status := cmd.Status(); status != CommandStatusCancelled {
+		t.Errorf("expected status %s, got %s", CommandStatusCancelled, status)
+	}
+}
+
+func TestCommand_AuthFailureIsNotUnreachable(t *testing.T) {
+	// without a password, agent or default key no authentication method is available
+	t.Setenv("SSH_AUTH_SOCK", "")
+	t.Setenv("HOME", t.TempDir())
+	host := ssh.ClientInfo{Name: "db1", Group: "prod", Host: "127.0.0.1", Port: "1", User: "tester"}
+
+	r := NewRunner(WithDefaultRetryPolicy(ssh.RetryPolicy{Attempts: 1})).(*runner)
+	cmd := r.CreateCommand("uptime", []ssh.ClientInfo{host})
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for !cmd.Status().IsTerminal() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	result := cmd.ToState().Results["db1"]
+	if !result.ConnectFailed || !result.AuthFailed {
+		t.Errorf("expected an authentication failure, got %+v", result)
+	}
+	if _, ok := r.reachability.UnreachableSince(host); ok {
+		t.Error("expected the host to not be marked unreachable")
+	}
+}
@@ -25,7 +25,7 @@ func (c *Command) SetStatusForTest(status CommandStatus) {
 			_, cancel := context.WithCancel(context.Background())
 			c.cancel = cancel
 		}
-	case CommandStatusCompleted, CommandStatusFailed, CommandStatusCancelled:
+	case CommandStatusCompleted, CommandStatusFailed, CommandStatusCancelled, CommandStatusConnectFailed:
 		if c.startedAt == nil {
 			c.startedAt = &now
 		}
@@ -52,3 +52,12 @@ func (c *Command) SimulateCancellationForTest() {
 		}
 	}
 }
+
+// SetResultForTest is a helper method for testing to set the result for a host
+// This should only be used in tests
+func (c *Command) SetResultForTest(host string, result CommandResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.results[host] = result
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// execHandler runs the command of an exec request on the channel and returns its exit status.
+type execHandler func(command string, channel gossh.Channel) uint32
+
+// startExecTestServer starts an in-process SSH server accepting any password that runs every exec
+// request with handle, and returns the host to connect to it. The server stops when the test ends.
+func startExecTestServer(t *testing.T, handle execHandler) ssh.ClientInfo {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := gossh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &gossh.ServerConfig{
+		PasswordCallback: func(conn gossh.ConnMetadata, pass []byte) (*gossh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, chans, reqs, err := gossh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go gossh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					channel, channelReqs, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go serveExec(channel, channelReqs, handle)
+				}
+			}()
+		}
+	}()
+
+	// trust the new host key in memory only
+	if err := ssh.SetKnownHostsData("unrelated.invalid " + string(gossh.MarshalAuthorizedKey(signer.PublicKey()))); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ssh.SetKnownHostsData("") })
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ssh.ClientInfo{Group: "test", Name: "exec1", Host: host, Port: port, User: "tester", Pass: "secret"}
+}
+
+// serveExec runs the exec request of the channel with handle and sends its exit status.
+func serveExec(channel gossh.Channel, reqs <-chan *gossh.Request, handle execHandler) {
+	defer channel.Close()
+	for req := range reqs {
+		if req.Type != "exec" || len(req.Payload) < 4 {
+			_ = req.Reply(req.Type == "pty-req", nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+		go gossh.DiscardRequests(reqs)
+		status := binary.BigEndian.AppendUint32(nil, handle(string(req.Payload[4:]), channel))
+		_, _ = channel.SendRequest("exit-status", false, status)
+		return
+	}
+}
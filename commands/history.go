@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+// SaveToHistory returns a hook saving the state of every command to the store, so LoadHistory can
+// restore it after a restart. It is used both as a status hook, so unfinished commands are known
+// after a restart, and as a completion hook overwriting them with their final state. Failures are
+// logged, they never fail the command.
+func SaveToHistory(store storage.CommandStore) func(*CommandState) {
+	return func(state *CommandState) {
+		encoded, err := json.Marshal(state)
+		if err == nil {
+			err = store.SetCommand(storage.CommandRecord{ID: state.ID, CreatedAt: state.CreatedAt, State: encoded})
+		}
+		if err != nil {
+			slog.Error("failed to save command to history", "command_id", state.ID, "error", err)
+		}
+	}
+}
+
+// interruptedError is the error of a command found unfinished in the history.
+const interruptedError = "the server stopped before the command finished"
+
+// LoadHistory reads the commands saved by SaveToHistory, oldest first. Records that cannot be
+// decoded are logged and skipped, as are commands on a host outside allowedGroups unless it is
+// empty. A command that was still pending or running when the server stopped is marked interrupted
+// with an explanatory error, and saved back so it stays that way.
+func LoadHistory(store storage.CommandStore, allowedGroups []string) ([]*CommandState, error) {
+	records, err := store.ListCommands()
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*CommandState, 0, len(records))
+	for _, record := range records {
+		var state CommandState
+		if err := json.Unmarshal(record.State, &state); err != nil {
+			slog.Warn("skipping unreadable command in history", "command_id", record.ID, "error", err)
+			continue
+		}
+		if len(allowedGroups) > 0 && slices.ContainsFunc(state.Hosts, func(host CommandHost) bool {
+			return !slices.Contains(allowedGroups, host.Group)
+		}) {
+			continue
+		}
+		if !state.Status.IsTerminal() {
+			markInterrupted(&state)
+			SaveToHistory(store)(&state)
+		}
+		history = append(history, &state)
+	}
+	return history, nil
+}
+
+// markInterrupted moves an unfinished command to the interrupted status, failing each host without
+// a result with the same error.
+func markInterrupted(state *CommandState) {
+	state.Status = CommandStatusInterrupted
+	state.Error = interruptedError
+	state.Stalled = false
+	if state.Results == nil {
+		state.Results = make(map[string]CommandResult)
+	}
+	for _, host := range state.Hosts {
+		if _, ok := state.Results[host.Name]; !ok {
+			state.Results[host.Name] = CommandResult{Host: host.Name, Err: errors.New(interruptedError)}
+		}
+	}
+}
+
+// restoreCommand rebuilds a finished command from its saved state. It can be listed and inspected
+// like any other command but is never run again.
+func restoreCommand(state *CommandState) (*Command, error) {
+	if !state.Status.IsTerminal() {
+		return nil, fmt.Errorf("command %s is not finished", state.ID)
+	}
+	hosts := make([]ssh.ClientInfo, len(state.Hosts))
+	for i, h := range state.Hosts {
+		hosts[i] = ssh.ClientInfo{Group: h.Group, Name: h.Name, Host: h.Address, Port: h.Port}
+	}
+	cmd := &Command{
+		id:        state.ID,
+		status:    state.Status,
+		command:   state.Command,
+		hosts:     hosts,
+		results:   maps.Clone(state.Results),
+		createdAt: state.CreatedAt,
+		startedAt: state.StartedAt,
+		endedAt:   state.EndedAt,
+
+		lastActivityAt: state.LastActivityAt,
+		initiatedBy:    state.InitiatedBy,
+	}
+	if state.Error != "" {
+		cmd.err = errors.New(state.Error)
+	}
+	return cmd, nil
+}
@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+// memoryCommandStore is an in-memory storage.CommandStore.
+type memoryCommandStore struct {
+	records []storage.CommandRecord
+}
+
+func (s *memoryCommandStore) SetCommand(record storage.CommandRecord) error {
+	for i, existing := range s.records {
+		if existing.ID == record.ID {
+			s.records[i] = record
+			return nil
+		}
+	}
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *memoryCommandStore) ListCommands() ([]storage.CommandRecord, error) {
+	return s.records, nil
+}
+
+func (s *memoryCommandStore) DeleteCommandsBefore(cutoff time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestHistory_SaveAndRestore(t *testing.T) {
+	store := &memoryCommandStore{}
+	started := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	ended := started.Add(30 * time.Second)
+	SaveToHistory(store)(&CommandState{
+		ID:      "deploy-1",
+		Status:  CommandStatusFailed,
+		Command: "systemctl restart app",
+		Hosts: []CommandHost{
+			{Group: "prod", Name: "web1", Address: "10.0.0.1", Port: "22"},
+			{Group: "prod", Name: "web2", Address: "10.0.0.2", Port: "22"},
+		},
+		HostCount: 2,
+		Results: map[string]CommandResult{
+			"web1": {Host: "web1", Result: "ok", ExecMillis: 12},
+			"web2": {Host: "web2", Result: "denied", Err: errors.New("exit status 1")},
+		},
+		CreatedAt:   started,
+		StartedAt:   &started,
+		EndedAt:     &ended,
+		InitiatedBy: "alice",
+	})
+	// unreadable commands are not restored
+	store.records = append(store.records, storage.CommandRecord{ID: "garbage", CreatedAt: started, State: json.RawMessage(`[1]`)})
+
+	history, err := LoadHistory(store, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("expected 1 decodable command, got %d", len(history))
+	}
+
+	r := NewRunner(WithHistory(history))
+	if got := len(r.ListCommands()); got != 1 {
+		t.Fatalf("expected the finished command to be restored, got %d", got)
+	}
+	cmd, err := r.GetCommand("deploy-1")
+	if err != nil {
+		t.Fatalf("expected restored command: %v", err)
+	}
+	state := cmd.ToState()
+	if state.Status != CommandStatusFailed || state.Command != "systemctl restart app" || state.InitiatedBy != "alice" {
+		t.Errorf("unexpected restored state: %+v", state)
+	}
+	if state.EndedAt == nil || !state.EndedAt.Equal(ended) {
+		t.Errorf("expected ended_at %v, got %v", ended, state.EndedAt)
+	}
+	if state.Results["web1"].Err != nil || state.Results["web1"].Result != "ok" {
+		t.Errorf("unexpected web1 result: %+v", state.Results["web1"])
+	}
+	if err := state.Results["web2"].Err; err == nil || err.Error() != "exit status 1" {
+		t.Errorf("expected web2 error to be restored, got %v", err)
+	}
+	if !cmd.TargetsHost("prod", "web2") {
+		t.Error("expected restored command to target prod:web2")
+	}
+	if summary := cmd.ToSummary(); summary.Succeeded != 1 || summary.Failed != 1 {
+		t.Errorf("expected 1 succeeded and 1 failed, got %+v", summary)
+	}
+	if err := cmd.Cancel(); err == nil {
+		t.Error("expected a restored command to not be cancellable")
+	}
+	if _, err := r.CreateCommandWithID("deploy-1", "uptime", nil); err == nil {
+		t.Error("expected the ID of a restored command to stay in use")
+	}
+}
+
+func TestHistory_LoadAllowedGroups(t *testing.T) {
+	store := &memoryCommandStore{}
+	now := time.Now().UTC()
+	SaveToHistory(store)(&CommandState{ID: "dev-1", Status: CommandStatusCompleted, CreatedAt: now,
+		Hosts: []CommandHost{{Group: "dev", Name: "web1"}}})
+	SaveToHistory(store)(&CommandState{ID: "mixed-1", Status: CommandStatusCompleted, CreatedAt: now,
+		Hosts: []CommandHost{{Group: "dev", Name: "web1"}, {Group: "prod", Name: "web1"}}})
+	SaveToHistory(store)(&CommandState{ID: "prod-1", Status: CommandStatusCompleted, CreatedAt: now,
+		Hosts: []CommandHost{{Group: "prod", Name: "web1"}}})
+
+	history, err := LoadHistory(store, []string{"dev"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 1 || history[0].ID != "dev-1" {
+		t.Errorf("expected only the command on allowed groups, got %d commands", len(history))
+	}
+}
+
+func TestHistory_RunningCommandIsInterruptedOnReload(t *testing.T) {
+	store := &memoryCommandStore{}
+	save := SaveToHistory(store)
+	r := NewRunner(WithStatusHook(save), WithCompletionHook(save))
+	hosts := []ssh.ClientInfo{{Group: "prod", Name: "web1", Host: "10.0.0.1", Port: "22"}}
+	cmd, err := r.CreateCommandWithID("deploy-1", "systemctl restart app", hosts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.records) != 1 {
+		t.Fatalf("expected the pending command to be saved, got %d records", len(store.records))
+	}
+
+	// the server stops while the command runs, so its last saved state is running
+	started := time.Now().UTC()
+	save(&CommandState{ID: cmd.ID(), Status: CommandStatusRunning, Command: "systemctl restart app",
+		Hosts:     []CommandHost{{Group: "prod", Name: "web1", Address: "10.0.0.1", Port: "22"}},
+		CreatedAt: started, StartedAt: &started})
+
+	history, err := LoadHistory(store, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 1 || history[0].Status != CommandStatusInterrupted || history[0].Error == "" {
+		t.Fatalf("expected the running command to be interrupted with an error, got %+v", history)
+	}
+	if err := history[0].Results["web1"].Err; err == nil {
+		t.Error("expected the unfinished host to fail")
+	}
+
+	// the interrupted state is saved back and the command restored like any finished one
+	var saved CommandState
+	if err := json.Unmarshal(store.records[0].State, &saved); err != nil || saved.Status != CommandStatusInterrupted {
+		t.Errorf("expected the interrupted state to be saved, got %q (%v)", saved.Status, err)
+	}
+	restored, err := NewRunner(WithHistory(history)).GetCommand("deploy-1")
+	if err != nil {
+		t.Fatalf("expected restored command: %v", err)
+	}
+	if restored.Status() != CommandStatusInterrupted {
+		t.Errorf("expected interrupted, got %q", restored.Status())
+	}
+}
+
+func TestHistory_BackgroundedCommandIsNotLeftRunning(t *testing.T) {
+	store := &memoryCommandStore{}
+	save := SaveToHistory(store)
+	r := NewRunner(WithStatusHook(save))
+	hosts := []ssh.ClientInfo{{Group: "prod", Name: "web1"}, {Group: "prod", Name: "web2"}}
+	release := make(chan struct{})
+	cmd := r.CreateCommand("rolling restart", hosts, WithTask(func(ctx context.Context, report func(CommandResult)) {
+		<-release
+	}))
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer close(release)
+
+	// the server stops here, the last state saved by the status hook is the start of the command
+	history, err := LoadHistory(store, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restored, err := NewRunner(WithHistory(history)).GetCommand(cmd.ID())
+	if err != nil {
+		t.Fatalf("expected restored command: %v", err)
+	}
+	if !restored.Status().IsTerminal() {
+		t.Fatalf("expected a terminal status, got %q", restored.Status())
+	}
+	state := restored.ToState()
+	for _, host := range []string{"web1", "web2"} {
+		if state.Results[host].Err == nil {
+			t.Errorf("expected %s to fail as interrupted", host)
+		}
+	}
+	if err := restored.Cancel(); err == nil {
+		t.Error("expected an interrupted command not to be cancellable")
+	}
+}
@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// CommandOption configures optional behaviour of a Command.
+type CommandOption func(*Command)
+
+// PTYOptions describes the pseudo-terminal requested for a command.
+type PTYOptions struct {
+	Term string
+	Cols int
+	Rows int
+}
+
+// WithPTY runs the command inside a pseudo-terminal of the given type and size.
+func WithPTY(pty PTYOptions) CommandOption {
+	return func(c *Command) {
+		c.pty = &pty
+	}
+}
+
+// WithRetryPolicy overrides the retry policy used when the command connects to its hosts.
+func WithRetryPolicy(policy ssh.RetryPolicy) CommandOption {
+	return func(c *Command) {
+		c.retry = &policy
+	}
+}
+
+// WithSkipUnreachable skips hosts that recently failed to connect instead of dialing them again.
+func WithSkipUnreachable() CommandOption {
+	return func(c *Command) {
+		c.skipUnreachable = true
+	}
+}
+
+// WithIdleTimeout stops a host's execution when it produces no output on stdout or stderr for
+// longer than the timeout. The result is marked as timed out with the idle reason.
+func WithIdleTimeout(timeout time.Duration) CommandOption {
+	return func(c *Command) {
+		c.idleTimeout = timeout
+	}
+}
+
+// WithHardTimeout stops a host's execution when it is still running after the timeout, whether or
+// not it produces output. The result is marked as timed out with the hard reason.
+func WithHardTimeout(timeout time.Duration) CommandOption {
+	return func(c *Command) {
+		c.hardTimeout = timeout
+	}
+}
+
+// WithParseJSON parses each host's output as JSON once it completes, attaching the parsed value
+// to the result alongside the raw output.
+func WithParseJSON() CommandOption {
+	return func(c *Command) {
+		c.parseJSON = true
+	}
+}
+
+// WithOnComplete adds a hook called once with the final state of the command after it reaches a
+// terminal status and every host has finished. Hooks run in the command's goroutine.
+func WithOnComplete(onComplete func(*CommandState)) CommandOption {
+	return func(c *Command) {
+		c.onComplete = append(c.onComplete, onComplete)
+	}
+}
+
+// WithExpectExitCode fails each host whose command exits with a different code than expected,
+// even when the command ran, and treats a matching non-zero exit code as success.
+func WithExpectExitCode(code int) CommandOption {
+	return func(c *Command) {
+		c.expectExitCode = &code
+	}
+}
+
+// WithStallAfter marks the running command as stalled when no host produces output for longer
+// than the duration. Stalled is only reported, the command keeps running. A duration of 0 or
+// less disables it.
+func WithStallAfter(after time.Duration) CommandOption {
+	return func(c *Command) {
+		c.stallAfter = after
+	}
+}
+
+// WithAutoSudo runs the command again through non-interactive sudo (sudo -n) on each host where it
+// failed with a permission denied error, once. Windows hosts are never escalated.
+func WithAutoSudo() CommandOption {
+	return func(c *Command) {
+		c.autoSudo = true
+	}
+}
+
+// WithHostCommand resolves the command to run on each host with render instead of running the
+// same command everywhere, e.g. to fill in per-host template variables. A host whose command
+// cannot be rendered fails without being connected to, the other hosts are unaffected.
+func WithHostCommand(render func(host ssh.ClientInfo) (string, error)) CommandOption {
+	return func(c *Command) {
+		c.hostCommand = render
+	}
+}
+
+// WithTask runs task in the background instead of running the command on each host, for
+// operations that are more than a single command, e.g. a rollout in waves. The command is then only
+// its description. task reports the result of each host as it finishes, and must return once ctx
+// is done so the command can be cancelled. The status is resolved from the reported results like
+// for any command.
+func WithTask(task func(ctx context.Context, report func(CommandResult))) CommandOption {
+	return func(c *Command) {
+		c.task = task
+	}
+}
+
+// WithBinaryOutput captures each host's standard output as raw bytes and returns it base64 encoded
+// once the host finishes, with the result's Encoding set to base64. Standard error is returned as
+// text in the result's Stderr instead of being mixed into the bytes. Partial output is not published
+// while the host is running, as a prefix of the output cannot be encoded on its own.
+func WithBinaryOutput() CommandOption {
+	return func(c *Command) {
+		c.binary = true
+	}
+}
+
+// WithInitiatedBy records the user or agent that created the command, reported as InitiatedBy in
+// its state, listing and summary.
+func WithInitiatedBy(initiatedBy string) CommandOption {
+	return func(c *Command) {
+		c.initiatedBy = initiatedBy
+	}
+}
+
+// RunnerOption configures optional behaviour of a Runner.
+type RunnerOption func(*runner)
+
+// WithDefaultRetryPolicy sets the retry policy given to every command created by the runner.
+func WithDefaultRetryPolicy(policy ssh.RetryPolicy) RunnerOption {
+	return func(r *runner) {
+		r.retry = &policy
+	}
+}
+
+// WithUnreachableCooldown sets how long a host that failed to connect is remembered as unreachable.
+// A cooldown of 0 or less disables remembering unreachable hosts.
+func WithUnreachableCooldown(cooldown time.Duration) RunnerOption {
+	return func(r *runner) {
+		r.reachability = NewReachability(cooldown)
+	}
+}
+
+// WithAutoBackgroundAfter sets how long tools wait for a command to complete before leaving it
+// running in the background. A duration of 0 or less keeps the default.
+func WithAutoBackgroundAfter(after time.Duration) RunnerOption {
+	return func(r *runner) {
+		if after > 0 {
+			r.autoBackgroundAfter = after
+		}
+	}
+}
+
+// WithCompletionHook adds a hook called with the final state of every command created by the
+// runner, e.g. to persist it or emit audit events. See WithOnComplete.
+func WithCompletionHook(onComplete func(*CommandState)) RunnerOption {
+	return func(r *runner) {
+		r.onComplete = append(r.onComplete, onComplete)
+	}
+}
+
+// WithStatusHook adds a hook called with the state of every command created by the runner when it
+// is created and when it starts running, e.g. to persist commands before they finish. Completion
+// hooks are called with the final state. See WithCompletionHook.
+func WithStatusHook(onStatus func(*CommandState)) RunnerOption {
+	return func(r *runner) {
+		r.onStatus = append(r.onStatus, onStatus)
+	}
+}
+
+// WithHistory adds the commands of a previous run of the server, e.g. loaded with LoadHistory, so
+// they can still be listed and inspected. Commands that were still running when the server stopped
+// are restored as interrupted, which LoadHistory marks them as; any other command that is not
+// finished is skipped.
+func WithHistory(history []*CommandState) RunnerOption {
+	return func(r *runner) {
+		for _, state := range history {
+			cmd, err := restoreCommand(state)
+			if err != nil {
+				slog.Warn("skipping command from history", "command_id", state.ID, "error", err)
+				continue
+			}
+			r.commands[cmd.id] = cmd
+		}
+	}
+}
+
+// WithDefaultStallAfter sets the stall threshold given to every command created by the runner.
+// See WithStallAfter.
+func WithDefaultStallAfter(after time.Duration) RunnerOption {
+	return func(r *runner) {
+		r.stallAfter = after
+	}
+}
@@ -1,10 +1,16 @@
 package commands
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/blakerouse/ssh-mcp/ssh"
+	gossh "golang.org/x/crypto/ssh"
 )
 
 // CommandResult is a single result on that host
@@ -12,6 +18,156 @@ type CommandResult struct {
 	Host   string `json:"host"`
 	Result string `json:"result"`
 	Err    error  `json:"error"`
+
+	// ConnectFailed is true when the host could not be connected to, so the command never ran on it
+	ConnectFailed bool `json:"connect_failed,omitempty"`
+	// AuthFailed is true when the host answered but rejected the credentials, a connection failure
+	// that is fixed by the credentials rather than the network
+	AuthFailed bool `json:"auth_failed,omitempty"`
+	// Skipped is true when the host was not dialed because it was recently unreachable
+	Skipped bool `json:"skipped,omitempty"`
+	// TimedOut is true when the command was stopped for exceeding a timeout
+	TimedOut bool `json:"timed_out,omitempty"`
+	// TimeoutReason is which timeout stopped the command, TimeoutReasonIdle or TimeoutReasonHard
+	TimeoutReason string `json:"timeout_reason,omitempty"`
+	// JSON is the output parsed as JSON, only set when JSON parsing was requested and the output is valid JSON
+	JSON any `json:"json,omitempty"`
+	// JSONNote explains why the output could not be parsed as JSON
+	JSONNote string `json:"json_note,omitempty"`
+	// ExpectedExitCode is the exit code the command was expected to exit with, nil when not checked
+	ExpectedExitCode *int `json:"expected_exit_code,omitempty"`
+	// Escalated is true when the command failed with permission denied and was run again through sudo
+	Escalated bool `json:"escalated,omitempty"`
+	// Empty is true when the command succeeded without any output, whitespace only counts as none
+	Empty bool `json:"empty,omitempty"`
+	// Encoding is how Result is encoded, "base64" for binary output and empty for plain text
+	Encoding string `json:"encoding,omitempty"`
+	// Stderr is the standard error of a command run with binary output, which is kept out of the
+	// encoded Result. It is empty otherwise, as stderr is then part of Result.
+	Stderr string `json:"stderr,omitempty"`
+	// Command is the command the host was sent when it was rendered for each host, e.g. with
+	// host_vars. It is empty when every host is sent the command of the CommandState.
+	Command string `json:"command,omitempty"`
+	// ConnectMillis is the time spent establishing the SSH connection
+	ConnectMillis int64 `json:"connect_millis"`
+	// ExecMillis is the time spent executing the command once connected
+	ExecMillis int64 `json:"exec_millis"`
+
+	// exitCode is the exit code of a result decoded from JSON, whose error no longer carries it
+	exitCode *int
+	// running is true for the partial output of a host that has not finished
+	running bool
+}
+
+// EncodingBase64 is the Encoding of a result whose output is base64 encoded binary.
+const EncodingBase64 = "base64"
+
+const (
+	// TimeoutReasonIdle is the TimeoutReason of a command stopped for producing no output
+	TimeoutReasonIdle = "idle"
+	// TimeoutReasonHard is the TimeoutReason of a command stopped for running too long
+	TimeoutReasonHard = "hard"
+)
+
+// TimeoutError is the error of a host whose command was deliberately stopped for exceeding a
+// timeout, as opposed to being cancelled or failing on its own.
+type TimeoutError struct {
+	// Reason is TimeoutReasonIdle or TimeoutReasonHard
+	Reason string
+	// After is the timeout that was exceeded
+	After time.Duration
+}
+
+// Error implements the error interface.
+func (e *TimeoutError) Error() string {
+	if e.Reason == TimeoutReasonIdle {
+		return fmt.Sprintf("idle timeout exceeded: no output for %s", e.After)
+	}
+	return fmt.Sprintf("hard timeout exceeded: still running after %s", e.After)
+}
+
+// timedOutResult returns the result of a host stopped by the timeout, keeping its output so far.
+func timedOutResult(hostName string, output string, reason string, after time.Duration) CommandResult {
+	return CommandResult{
+		Host:          hostName,
+		Result:        output,
+		Err:           &TimeoutError{Reason: reason, After: after},
+		TimedOut:      true,
+		TimeoutReason: reason,
+	}
+}
+
+// UnexpectedExitCodeError is the error of a host whose command exited with a different exit code
+// than expected.
+type UnexpectedExitCodeError struct {
+	Code     int
+	Expected int
+	// Err is the original error of the command, nil when it exited with 0
+	Err error
+}
+
+// Error implements the error interface.
+func (e *UnexpectedExitCodeError) Error() string {
+	return fmt.Sprintf("unexpected exit code %d, expected %d", e.Code, e.Expected)
+}
+
+// Unwrap returns the original error of the command.
+func (e *UnexpectedExitCodeError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit status of the remote command. It is 0 when the command succeeded and
+// false is returned when the command did not run to completion (e.g. connection failure or cancelled)
+// or is still running.
+func (cr CommandResult) ExitCode() (int, bool) {
+	if cr.running {
+		return 0, false
+	}
+	if cr.exitCode != nil {
+		return *cr.exitCode, true
+	}
+	if cr.Err == nil {
+		// a checked result without an error exited with the expected code
+		if cr.ExpectedExitCode != nil {
+			return *cr.ExpectedExitCode, true
+		}
+		return 0, true
+	}
+	var unexpected *UnexpectedExitCodeError
+	if errors.As(cr.Err, &unexpected) {
+		return unexpected.Code, true
+	}
+	var exitErr *gossh.ExitError
+	if errors.As(cr.Err, &exitErr) {
+		return exitErr.ExitStatus(), true
+	}
+	return 0, false
+}
+
+// withEmpty returns the result with Empty set when the command succeeded without any output.
+func (cr CommandResult) withEmpty() CommandResult {
+	cr.Empty = cr.Err == nil && strings.TrimSpace(cr.Result) == ""
+	return cr
+}
+
+// withBase64 returns the result with its output base64 encoded, so binary output survives the
+// JSON serialization of the result byte for byte.
+func (cr CommandResult) withBase64() CommandResult {
+	cr.Result = base64.StdEncoding.EncodeToString([]byte(cr.Result))
+	cr.Encoding = EncodingBase64
+	return cr
+}
+
+// parseJSONOutput parses command output as JSON, returning a note instead when it is not valid JSON.
+func parseJSONOutput(output string) (any, string) {
+	if strings.TrimSpace(output) == "" {
+		return nil, "output is empty"
+	}
+	var parsed any
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		return nil, fmt.Sprintf("output is not valid JSON: %v", err)
+	}
+	return parsed, ""
 }
 
 // MarshalJSON implements custom JSON marshaling to properly handle the error field
@@ -20,41 +176,113 @@ func (cr CommandResult) MarshalJSON() ([]byte, error) {
 	if cr.Err != nil {
 		errStr = cr.Err.Error()
 	}
+	var exitCode *int
+	if code, ok := cr.ExitCode(); ok {
+		exitCode = &code
+	}
 	return json.Marshal(&struct {
-		Host   string `json:"host"`
-		Result string `json:"result"`
-		Error  string `json:"error,omitempty"`
+		Host             string `json:"host"`
+		Result           string `json:"result"`
+		Error            string `json:"error,omitempty"`
+		ExitCode         *int   `json:"exit_code,omitempty"`
+		ConnectFailed    bool   `json:"connect_failed,omitempty"`
+		AuthFailed       bool   `json:"auth_failed,omitempty"`
+		Skipped          bool   `json:"skipped,omitempty"`
+		TimedOut         bool   `json:"timed_out,omitempty"`
+		TimeoutReason    string `json:"timeout_reason,omitempty"`
+		JSON             any    `json:"json,omitempty"`
+		JSONNote         string `json:"json_note,omitempty"`
+		ExpectedExitCode *int   `json:"expected_exit_code,omitempty"`
+		Escalated        bool   `json:"escalated,omitempty"`
+		Empty            bool   `json:"empty,omitempty"`
+		Encoding         string `json:"encoding,omitempty"`
+		Stderr           string `json:"stderr,omitempty"`
+		Command          string `json:"command,omitempty"`
+		ConnectMillis    int64  `json:"connect_millis"`
+		ExecMillis       int64  `json:"exec_millis"`
 	}{
-		Host:   cr.Host,
-		Result: cr.Result,
-		Error:  errStr,
+		Host:             cr.Host,
+		Result:           cr.Result,
+		Error:            errStr,
+		ExitCode:         exitCode,
+		ConnectFailed:    cr.ConnectFailed,
+		AuthFailed:       cr.AuthFailed,
+		Skipped:          cr.Skipped,
+		TimedOut:         cr.TimedOut,
+		TimeoutReason:    cr.TimeoutReason,
+		JSON:             cr.JSON,
+		JSONNote:         cr.JSONNote,
+		ExpectedExitCode: cr.ExpectedExitCode,
+		Escalated:        cr.Escalated,
+		Empty:            cr.Empty,
+		Encoding:         cr.Encoding,
+		Stderr:           cr.Stderr,
+		Command:          cr.Command,
+		ConnectMillis:    cr.ConnectMillis,
+		ExecMillis:       cr.ExecMillis,
 	})
 }
 
+// UnmarshalJSON implements custom JSON unmarshaling, restoring the error field as an error
+func (cr *CommandResult) UnmarshalJSON(data []byte) error {
+	type result CommandResult
+	var decoded struct {
+		result
+		Error    string `json:"error,omitempty"`
+		ExitCode *int   `json:"exit_code,omitempty"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*cr = CommandResult(decoded.result)
+	cr.Err = nil
+	if decoded.Error != "" {
+		cr.Err = errors.New(decoded.Error)
+	}
+	cr.exitCode = decoded.ExitCode
+	return nil
+}
+
+// streamParallelism is the maximum number of hosts PerformOnHostsStream works on at once.
+const streamParallelism = 64
+
 // PerformOnHosts performs the command on all hosts in parallel
 func PerformOnHosts(hosts []ssh.ClientInfo, command func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error)) map[string]CommandResult {
+	return PerformOnHostsLimited(hosts, 0, command)
+}
+
+// PerformOnHostsLimited performs the command on the hosts with at most maxParallel hosts connected
+// at once, 0 or less performs it on all hosts in parallel. Results are keyed by host name like
+// PerformOnHosts.
+func PerformOnHostsLimited(hosts []ssh.ClientInfo, maxParallel int, command func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error)) map[string]CommandResult {
+	return performLimited(hosts, maxParallel, func(host ssh.ClientInfo) CommandResult {
+		return performOnHost(host, command)
+	})
+}
+
+// performLimited calls perform for every host with at most maxParallel calls running at once, 0 or
+// less is unlimited, and collects the results by host name.
+func performLimited(hosts []ssh.ClientInfo, maxParallel int, perform func(host ssh.ClientInfo) CommandResult) map[string]CommandResult {
 	var wg sync.WaitGroup
 	wg.Add(len(hosts))
 
 	var resultsMx sync.Mutex
 	results := make(map[string]CommandResult, len(hosts))
 
+	var slots chan struct{}
+	if maxParallel > 0 {
+		slots = make(chan struct{}, maxParallel)
+	}
 	for _, host := range hosts {
 		go func(host ssh.ClientInfo) {
 			defer wg.Done()
-			sshClient := ssh.NewClient(&host)
-			err := sshClient.Connect()
-			if err != nil {
-				resultsMx.Lock()
-				results[host.Name] = CommandResult{Host: host.Name, Err: err}
-				resultsMx.Unlock()
-				return
+			if slots != nil {
+				slots <- struct{}{}
+				defer func() { <-slots }()
 			}
-			defer sshClient.Close()
-
-			result, err := command(host, sshClient)
+			result := perform(host)
 			resultsMx.Lock()
-			results[host.Name] = CommandResult{Host: host.Name, Result: result, Err: err}
+			results[host.Name] = result
 			resultsMx.Unlock()
 		}(host)
 	}
@@ -62,3 +290,51 @@ func PerformOnHosts(hosts []ssh.ClientInfo, command func(host ssh.ClientInfo, ss
 
 	return results
 }
+
+// PerformOnHostsStream performs the command on the hosts with at most streamParallelism hosts in
+// flight, delivering each result to onResult as soon as it is available instead of collecting them.
+// This keeps peak memory and goroutine count bounded for very large groups.
+//
+// onResult is never called concurrently, so it does not need its own locking. It returns once
+// every host has been handled.
+func PerformOnHostsStream(hosts []ssh.ClientInfo, command func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error), onResult func(CommandResult)) {
+	workers := min(len(hosts), streamParallelism)
+	queue := make(chan ssh.ClientInfo)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	var onResultMx sync.Mutex
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for host := range queue {
+				result := performOnHost(host, command)
+				onResultMx.Lock()
+				onResult(result)
+				onResultMx.Unlock()
+			}
+		}()
+	}
+	for _, host := range hosts {
+		queue <- host
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// performOnHost connects to the host and runs the command, timing both steps.
+func performOnHost(host ssh.ClientInfo, command func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error)) CommandResult {
+	sshClient := ssh.NewClient(&host)
+	connectStart := time.Now()
+	err := sshClient.Connect()
+	connectMillis := time.Since(connectStart).Milliseconds()
+	if err != nil {
+		return CommandResult{Host: host.Name, Err: err, ConnectFailed: true, AuthFailed: ssh.ClassifyError(err) == ssh.ErrorClassAuth, ConnectMillis: connectMillis}
+	}
+	defer sshClient.Close()
+
+	execStart := time.Now()
+	result, err := command(host, sshClient)
+	execMillis := time.Since(execStart).Milliseconds()
+	return CommandResult{Host: host.Name, Result: result, Err: err, ConnectMillis: connectMillis, ExecMillis: execMillis}.withEmpty()
+}
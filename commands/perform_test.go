@@ -1,10 +1,14 @@
 package commands
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/blakerouse/ssh-mcp/ssh"
 )
@@ -73,6 +77,10 @@ func TestPerformCommandsOnHosts_SingleHost_ConnectionFailure(t *testing.T) {
 	if result.Result != "" {
 		t.Errorf("expected empty result for failed connection, got '%s'", result.Result)
 	}
+
+	if !result.ConnectFailed {
+		t.Error("expected result to be marked as a connection failure")
+	}
 }
 
 func TestPerformCommandsOnHosts_MultipleHosts_AllConnectionFailures(t *testing.T) {
@@ -363,3 +371,305 @@ func TestCommandResult_MarshalJSON_EmptyResult(t *testing.T) {
 		t.Errorf("expected error 'failed before output', got '%v'", unmarshaled["error"])
 	}
 }
+
+// TestCommandResult_MarshalJSON_Durations tests JSON marshaling of the timing breakdown
+func TestCommandResult_MarshalJSON_Durations(t *testing.T) {
+	result := CommandResult{
+		Host:          "timed-host",
+		Result:        "ok",
+		ConnectMillis: 120,
+		ExecMillis:    3400,
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("failed to marshal CommandResult: %v", err)
+	}
+
+	var unmarshaled map[string]any
+	err = json.Unmarshal(jsonData, &unmarshaled)
+	if err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+
+	if unmarshaled["connect_millis"] != float64(120) {
+		t.Errorf("expected connect_millis 120, got '%v'", unmarshaled["connect_millis"])
+	}
+	if unmarshaled["exec_millis"] != float64(3400) {
+		t.Errorf("expected exec_millis 3400, got '%v'", unmarshaled["exec_millis"])
+	}
+}
+
+func TestPerformOnHostsStream_DeliversEveryResult(t *testing.T) {
+	// more hosts than workers so the queue is exercised
+	hosts := make([]ssh.ClientInfo, streamParallelism+6)
+	for i := range hosts {
+		hosts[i] = ssh.ClientInfo{
+			Name:  fmt.Sprintf("host%d", i),
+			Group: "test",
+			Host:  "127.0.0.1",
+			Port:  "1",
+		}
+	}
+
+	command := func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+		return "", nil
+	}
+
+	seen := make(map[string]bool)
+	PerformOnHostsStream(hosts, command, func(result CommandResult) {
+		// onResult is serialized, so the map needs no locking
+		if seen[result.Host] {
+			t.Errorf("result for %s delivered twice", result.Host)
+		}
+		seen[result.Host] = true
+		if !result.ConnectFailed {
+			t.Errorf("expected connection failure for %s", result.Host)
+		}
+	})
+
+	if len(seen) != len(hosts) {
+		t.Errorf("expected %d results, got %d", len(hosts), len(seen))
+	}
+}
+
+func TestPerformOnHostsStream_EmptyHosts(t *testing.T) {
+	called := false
+	PerformOnHostsStream(nil, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+		return "", nil
+	}, func(result CommandResult) {
+		called = true
+	})
+	if called {
+		t.Error("onResult should not be called for empty hosts list")
+	}
+}
+
+func TestParseJSONOutput(t *testing.T) {
+	parsed, note := parseJSONOutput(`[{"Id": "abc", "State": {"Running": true}}]` + "\n")
+	if note != "" {
+		t.Fatalf("unexpected note: %s", note)
+	}
+	containers, ok := parsed.([]any)
+	if !ok || len(containers) != 1 {
+		t.Fatalf("expected a single element array, got %#v", parsed)
+	}
+
+	parsed, note = parseJSONOutput("Error: No such object: abc\n")
+	if parsed != nil {
+		t.Errorf("expected nil for invalid JSON, got %#v", parsed)
+	}
+	if !strings.Contains(note, "not valid JSON") {
+		t.Errorf("expected invalid JSON note, got '%s'", note)
+	}
+
+	if _, note = parseJSONOutput(""); note != "output is empty" {
+		t.Errorf("expected empty output note, got '%s'", note)
+	}
+}
+
+// TestCommandResult_MarshalJSON_Parsed tests that parsed JSON output is included as structured data
+func TestCommandResult_MarshalJSON_Parsed(t *testing.T) {
+	result := CommandResult{
+		Host:   "json-host",
+		Result: `{"ok": true}`,
+		JSON:   map[string]any{"ok": true},
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("failed to marshal CommandResult: %v", err)
+	}
+	if !strings.Contains(string(jsonData), `"json":{"ok":true}`) {
+		t.Errorf("expected parsed json field, got: %s", jsonData)
+	}
+}
+
+func TestPerformLimited_BoundsParallelism(t *testing.T) {
+	hosts := make([]ssh.ClientInfo, 12)
+	for i := range hosts {
+		hosts[i] = ssh.ClientInfo{Name: fmt.Sprintf("host%d", i), Group: "test"}
+	}
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	perform := func(host ssh.ClientInfo) CommandResult {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return CommandResult{Host: host.Name, Result: "ok"}
+	}
+
+	results := performLimited(hosts, 3, perform)
+	if peak > 3 {
+		t.Errorf("expected at most 3 hosts at once, got %d", peak)
+	}
+	if len(results) != len(hosts) {
+		t.Fatalf("expected %d results, got %d", len(hosts), len(results))
+	}
+	for _, host := range hosts {
+		if results[host.Name].Host != host.Name {
+			t.Errorf("expected result keyed by %s, got %+v", host.Name, results[host.Name])
+		}
+	}
+}
+
+func TestPerformOnHostsLimited_AllHostsGetResult(t *testing.T) {
+	hosts := make([]ssh.ClientInfo, 5)
+	for i := range hosts {
+		hosts[i] = ssh.ClientInfo{Name: fmt.Sprintf("host%d", i), Group: "test", Host: "127.0.0.1", Port: "1"}
+	}
+
+	results := PerformOnHostsLimited(hosts, 2, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+		return "", nil
+	})
+	if len(results) != len(hosts) {
+		t.Errorf("expected %d results, got %d", len(hosts), len(results))
+	}
+	for name, result := range results {
+		if !result.ConnectFailed {
+			t.Errorf("expected a connection failure for %s, got %+v", name, result)
+		}
+	}
+}
+
+func TestCommandResult_WithEmpty(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   CommandResult
+		expected bool
+	}{
+		{"no output", CommandResult{Host: "a"}, true},
+		{"whitespace only", CommandResult{Host: "a", Result: " \n\t\n"}, true},
+		{"output", CommandResult{Host: "a", Result: "match\n"}, false},
+		{"failed without output", CommandResult{Host: "a", Err: errors.New("exit status 1")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.withEmpty().Empty; got != tt.expected {
+				t.Errorf("expected empty %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	data, err := json.Marshal(CommandResult{Host: "a"}.withEmpty())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), `"empty":true`) {
+		t.Errorf("expected empty in the JSON, got %s", data)
+	}
+}
+
+func TestCommandResult_WithBase64(t *testing.T) {
+	raw := string([]byte{0x1f, 0x8b, 0x08, 0x00, 0xff, 0xfe, '\n', 0x00})
+	result := CommandResult{Host: "a", Result: raw}.withBase64()
+	if result.Encoding != EncodingBase64 {
+		t.Errorf("expected encoding %s, got %q", EncodingBase64, result.Encoding)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(result.Result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(decoded) != raw {
+		t.Errorf("expected the bytes to round trip, got %v", decoded)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), `"encoding":"base64"`) {
+		t.Errorf("expected encoding in the JSON, got %s", data)
+	}
+}
+
+func TestTimedOutResult(t *testing.T) {
+	tests := []struct {
+		reason   string
+		after    time.Duration
+		expected string
+	}{
+		{TimeoutReasonIdle, 30 * time.Second, "idle timeout exceeded: no output for 30s"},
+		{TimeoutReasonHard, 5 * time.Minute, "hard timeout exceeded: still running after 5m0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			result := timedOutResult("web1", "partial\n", tt.reason, tt.after)
+			if result.Err == nil || result.Err.Error() != tt.expected {
+				t.Errorf("expected error %q, got %v", tt.expected, result.Err)
+			}
+			if !result.TimedOut || result.TimeoutReason != tt.reason {
+				t.Errorf("expected timed out with reason %s, got %v %q", tt.reason, result.TimedOut, result.TimeoutReason)
+			}
+			if result.Result != "partial\n" {
+				t.Errorf("expected the output so far to be kept, got %q", result.Result)
+			}
+			var timeoutErr *TimeoutError
+			if !errors.As(result.Err, &timeoutErr) || timeoutErr.After != tt.after {
+				t.Errorf("expected a *TimeoutError after %s, got %#v", tt.after, result.Err)
+			}
+			if _, ok := result.ExitCode(); ok {
+				t.Error("expected no exit code for a timed out host")
+			}
+			// a timed out host fails the command like any other failure
+			if status := resolveStatus(map[string]CommandResult{"web1": result}, 1); status != CommandStatusFailed {
+				t.Errorf("expected status %s, got %s", CommandStatusFailed, status)
+			}
+
+			data, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(string(data), `"timeout_reason":"`+tt.reason+`"`) {
+				t.Errorf("expected timeout_reason in the JSON, got %s", data)
+			}
+		})
+	}
+}
+
+// TestCommandResult_MarshalJSON_ExitCode tests that exit_code is only set for commands that ran to completion
+func TestCommandResult_MarshalJSON_ExitCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		result   CommandResult
+		expected string
+	}{
+		{"succeeded", CommandResult{Host: "h", Result: "ok"}, `"exit_code":0`},
+		{"non-zero exit", CommandResult{Host: "h", Err: &UnexpectedExitCodeError{Code: 2, Expected: 0}}, `"exit_code":2`},
+		{"connection failure", CommandResult{Host: "h", Err: errors.New("dial tcp: connection refused"), ConnectFailed: true}, ""},
+		{"still running", CommandResult{Host: "h", Result: "partial", running: true}, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tc.result)
+			if err != nil {
+				t.Fatalf("failed to marshal CommandResult: %v", err)
+			}
+			if tc.expected == "" {
+				if strings.Contains(string(jsonData), "exit_code") {
+					t.Errorf("expected no exit_code, got: %s", jsonData)
+				}
+				return
+			}
+			if !strings.Contains(string(jsonData), tc.expected) {
+				t.Errorf("expected JSON to contain %s, got: %s", tc.expected, jsonData)
+			}
+
+			// the exit code survives a round trip, although the error no longer carries it
+			var decoded CommandResult
+			if err := json.Unmarshal(jsonData, &decoded); err != nil {
+				t.Fatalf("failed to unmarshal CommandResult: %v", err)
+			}
+			want, _ := tc.result.ExitCode()
+			if code, ok := decoded.ExitCode(); !ok || code != want {
+				t.Errorf("expected exit code %d after a round trip, got %d (%v)", want, code, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"sync"
+	"time"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// DefaultUnreachableCooldown is how long a host is remembered as unreachable after a failed connection.
+const DefaultUnreachableCooldown = time.Minute
+
+// Reachability remembers hosts that recently failed to connect, so they can be skipped instead of
+// waiting for the full connect timeout again.
+type Reachability struct {
+	mu          sync.Mutex
+	cooldown    time.Duration
+	unreachable map[string]time.Time
+
+	// now returns the current time, replaced in tests
+	now func() time.Time
+}
+
+// NewReachability creates a reachability cache. A cooldown of 0 or less disables it.
+func NewReachability(cooldown time.Duration) *Reachability {
+	return &Reachability{
+		cooldown:    cooldown,
+		unreachable: make(map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+// reachabilityKey identifies a host in the cache.
+func reachabilityKey(host ssh.ClientInfo) string {
+	return host.Group + ":" + host.Name
+}
+
+// MarkUnreachable records a failed connection to the host.
+func (r *Reachability) MarkUnreachable(host ssh.ClientInfo) {
+	if r.cooldown <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unreachable[reachabilityKey(host)] = r.now()
+}
+
+// MarkReachable clears the unreachable mark after a successful connection.
+func (r *Reachability) MarkReachable(host ssh.ClientInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.unreachable, reachabilityKey(host))
+}
+
+// Record updates the host from the outcome of connecting to it. Only network errors and timeouts
+// mark it unreachable: a host rejecting the credentials or its host key answered and stays
+// reachable, so fixing the credentials is not held back by the cooldown.
+func (r *Reachability) Record(host ssh.ClientInfo, err error) {
+	if err == nil {
+		r.MarkReachable(host)
+		return
+	}
+	switch ssh.ClassifyError(err) {
+	case ssh.ErrorClassNetwork, ssh.ErrorClassTimeout:
+		r.MarkUnreachable(host)
+	case ssh.ErrorClassAuth, ssh.ErrorClassHostKey:
+		r.MarkReachable(host)
+	}
+}
+
+// UnreachableSince returns when the host last failed to connect, if that was within the cooldown.
+func (r *Reachability) UnreachableSince(host ssh.ClientInfo) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := reachabilityKey(host)
+	since, ok := r.unreachable[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	if r.now().Sub(since) >= r.cooldown {
+		delete(r.unreachable, key)
+		return time.Time{}, false
+	}
+	return since, true
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+func TestReachability_Cooldown(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := NewReachability(time.Minute)
+	r.now = func() time.Time { return now }
+
+	host := ssh.ClientInfo{Group: "prod", Name: "db1"}
+	if _, ok := r.UnreachableSince(host); ok {
+		t.Fatal("expected unknown host to be reachable")
+	}
+
+	r.MarkUnreachable(host)
+	if since, ok := r.UnreachableSince(host); !ok || !since.Equal(now) {
+		t.Errorf("expected host to be unreachable since %v, got %v (%v)", now, since, ok)
+	}
+
+	// same name in another group is a different host
+	if _, ok := r.UnreachableSince(ssh.ClientInfo{Group: "staging", Name: "db1"}); ok {
+		t.Error("expected host in another group to be reachable")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := r.UnreachableSince(host); ok {
+		t.Error("expected mark to expire after the cooldown")
+	}
+}
+
+func TestReachability_MarkReachable(t *testing.T) {
+	r := NewReachability(time.Minute)
+	host := ssh.ClientInfo{Group: "prod", Name: "db1"}
+
+	r.MarkUnreachable(host)
+	r.MarkReachable(host)
+	if _, ok := r.UnreachableSince(host); ok {
+		t.Error("expected successful connection to clear the mark")
+	}
+}
+
+func TestReachability_Disabled(t *testing.T) {
+	r := NewReachability(0)
+	host := ssh.ClientInfo{Group: "prod", Name: "db1"}
+
+	r.MarkUnreachable(host)
+	if _, ok := r.UnreachableSince(host); ok {
+		t.Error("expected a zero cooldown to disable the cache")
+	}
+}
+
+func TestReachability_Record(t *testing.T) {
+	r := NewReachability(time.Minute)
+	host := ssh.ClientInfo{Group: "prod", Name: "db1"}
+
+	// a host rejecting the credentials answered, so it is not unreachable
+	r.Record(host, errors.New("failed to connect to SSH server: ssh: handshake failed: ssh: unable to authenticate, attempted methods [none password], no supported methods remain"))
+	if _, ok := r.UnreachableSince(host); ok {
+		t.Error("expected an authentication failure to not mark the host unreachable")
+	}
+
+	r.Record(host, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED})
+	if _, ok := r.UnreachableSince(host); !ok {
+		t.Error("expected a network error to mark the host unreachable")
+	}
+
+	// the host answering again with a rejection clears the mark
+	r.Record(host, errors.New("ssh: handshake failed: ssh: unable to authenticate"))
+	if _, ok := r.UnreachableSince(host); ok {
+		t.Error("expected an authentication failure to clear the mark")
+	}
+
+	r.Record(host, errors.New("failed to set up session"))
+	if _, ok := r.UnreachableSince(host); ok {
+		t.Error("expected an unclassified error to leave the host as it was")
+	}
+}
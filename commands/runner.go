@@ -2,6 +2,8 @@ package commands
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
 	"sync"
 	"time"
 
@@ -11,31 +13,96 @@ import (
 
 // Runner is an interface for managing background commands
 type Runner interface {
-	CreateCommand(commandStr string, hosts []ssh.ClientInfo) *Command
+	CreateCommand(commandStr string, hosts []ssh.ClientInfo, opts ...CommandOption) *Command
+	CreateCommandWithID(commandID string, commandStr string, hosts []ssh.ClientInfo, opts ...CommandOption) (*Command, error)
 	GetCommand(commandID string) (*Command, error)
 	GetMostRecentCommand() (*Command, error)
 	ListCommands() []*Command
 	CancelAllCommands()
+	// AutoBackgroundAfter is how long a tool waits for a command before leaving it running in the background.
+	AutoBackgroundAfter() time.Duration
+}
+
+// DefaultAutoBackgroundAfter is how long a tool waits for a command before it is moved to the background.
+const DefaultAutoBackgroundAfter = 30 * time.Second
+
+// commandIDRegexp matches the caller-provided command IDs that are accepted.
+var commandIDRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,127}$`)
+
+// ValidateCommandID checks that a caller-provided command ID is usable.
+func ValidateCommandID(commandID string) error {
+	if !commandIDRegexp.MatchString(commandID) {
+		return fmt.Errorf("invalid command ID %q: must be 1-128 characters of letters, digits, '.', '_', ':' or '-' and start with a letter or digit", commandID)
+	}
+	return nil
 }
 
 // runner is the implementation of Runner
 type runner struct {
 	commands map[string]*Command
 	mu       sync.RWMutex
+
+	// retry is the retry policy given to new commands, nil uses the ssh default
+	retry *ssh.RetryPolicy
+	// reachability remembers hosts that recently failed to connect, shared by all commands
+	reachability *Reachability
+	// autoBackgroundAfter is the default time tools wait for a command before backgrounding it
+	autoBackgroundAfter time.Duration
+	// onComplete are the completion hooks given to every command
+	onComplete []func(*CommandState)
+	// onStatus are the status hooks given to every command
+	onStatus []func(*CommandState)
+	// stallAfter is the stall threshold given to new commands, 0 disables it
+	stallAfter time.Duration
 }
 
 // NewRunner creates a new command runner
-func NewRunner() Runner {
+func NewRunner(opts ...RunnerOption) Runner {
 	r := &runner{
-		commands: make(map[string]*Command),
+		commands:            make(map[string]*Command),
+		reachability:        NewReachability(DefaultUnreachableCooldown),
+		autoBackgroundAfter: DefaultAutoBackgroundAfter,
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
 	return r
 }
 
-// CreateCommand creates a new command and returns it
-func (r *runner) CreateCommand(commandStr string, hosts []ssh.ClientInfo) *Command {
-	commandID := uuid.New().String()
+// CreateCommand creates a new command with a generated ID and returns it
+func (r *runner) CreateCommand(commandStr string, hosts []ssh.ClientInfo, opts ...CommandOption) *Command {
+	cmd := r.newCommand(uuid.New().String(), commandStr, hosts, opts...)
+
+	r.mu.Lock()
+	r.commands[cmd.id] = cmd
+	r.mu.Unlock()
+
+	cmd.notifyStatus()
+	return cmd
+}
+
+// CreateCommandWithID creates a new command with a caller-provided ID and returns it.
+// An error is returned when the ID is invalid or already used by another command.
+func (r *runner) CreateCommandWithID(commandID string, commandStr string, hosts []ssh.ClientInfo, opts ...CommandOption) (*Command, error) {
+	if err := ValidateCommandID(commandID); err != nil {
+		return nil, err
+	}
+	cmd := r.newCommand(commandID, commandStr, hosts, opts...)
 
+	r.mu.Lock()
+	if _, exists := r.commands[commandID]; exists {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("command ID %s is already in use", commandID)
+	}
+	r.commands[commandID] = cmd
+	r.mu.Unlock()
+
+	cmd.notifyStatus()
+	return cmd, nil
+}
+
+// newCommand builds a pending command with the runner defaults and options applied.
+func (r *runner) newCommand(commandID string, commandStr string, hosts []ssh.ClientInfo, opts ...CommandOption) *Command {
 	cmd := &Command{
 		id:        commandID,
 		status:    CommandStatusPending,
@@ -43,15 +110,24 @@ func (r *runner) CreateCommand(commandStr string, hosts []ssh.ClientInfo) *Comma
 		hosts:     hosts,
 		results:   make(map[string]CommandResult),
 		createdAt: time.Now(),
-	}
-
-	r.mu.Lock()
-	r.commands[commandID] = cmd
-	r.mu.Unlock()
+		retry:     r.retry,
 
+		reachability: r.reachability,
+		onComplete:   slices.Clone(r.onComplete),
+		onStatus:     slices.Clone(r.onStatus),
+		stallAfter:   r.stallAfter,
+	}
+	for _, opt := range opts {
+		opt(cmd)
+	}
 	return cmd
 }
 
+// AutoBackgroundAfter returns how long tools wait for a command before leaving it running in the background.
+func (r *runner) AutoBackgroundAfter() time.Duration {
+	return r.autoBackgroundAfter
+}
+
 // GetCommand retrieves a command by ID
 func (r *runner) GetCommand(commandID string) (*Command, error) {
 	r.mu.RLock()
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/blakerouse/ssh-mcp/ssh"
 )
@@ -9,11 +10,14 @@ import (
 // MockRunner is a mock implementation of Runner for testing purposes
 type MockRunner struct {
 	Commands          map[string]*Command
-	CreateCommandFunc func(commandStr string, hosts []ssh.ClientInfo) *Command
+	CreateCommandFunc func(commandStr string, hosts []ssh.ClientInfo, opts ...CommandOption) *Command
+	CreateWithIDFunc  func(commandID string, commandStr string, hosts []ssh.ClientInfo, opts ...CommandOption) (*Command, error)
 	GetCommandFunc    func(commandID string) (*Command, error)
 	GetMostRecentFunc func() (*Command, error)
 	ListCommandsFunc  func() []*Command
 	CancelAllFunc     func()
+	// AutoBackground overrides the DefaultAutoBackgroundAfter returned by AutoBackgroundAfter when set
+	AutoBackground time.Duration
 }
 
 // NewMockRunner creates a new mock runner
@@ -24,22 +28,53 @@ func NewMockRunner() *MockRunner {
 }
 
 // CreateCommand creates a new command (mock implementation)
-func (m *MockRunner) CreateCommand(commandStr string, hosts []ssh.ClientInfo) *Command {
+func (m *MockRunner) CreateCommand(commandStr string, hosts []ssh.ClientInfo, opts ...CommandOption) *Command {
 	if m.CreateCommandFunc != nil {
-		return m.CreateCommandFunc(commandStr, hosts)
+		return m.CreateCommandFunc(commandStr, hosts, opts...)
 	}
 	// Default implementation
 	cmd := &Command{
-		id:      fmt.Sprintf("mock-cmd-%d", len(m.Commands)),
-		status:  CommandStatusPending,
-		command: commandStr,
-		hosts:   hosts,
-		results: make(map[string]CommandResult),
+		id:        fmt.Sprintf("mock-cmd-%d", len(m.Commands)),
+		status:    CommandStatusPending,
+		command:   commandStr,
+		hosts:     hosts,
+		results:   make(map[string]CommandResult),
+		createdAt: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(cmd)
 	}
 	m.Commands[cmd.id] = cmd
 	return cmd
 }
 
+// CreateCommandWithID creates a new command with a caller-provided ID (mock implementation)
+func (m *MockRunner) CreateCommandWithID(commandID string, commandStr string, hosts []ssh.ClientInfo, opts ...CommandOption) (*Command, error) {
+	if m.CreateWithIDFunc != nil {
+		return m.CreateWithIDFunc(commandID, commandStr, hosts, opts...)
+	}
+	// Default implementation
+	if err := ValidateCommandID(commandID); err != nil {
+		return nil, err
+	}
+	if _, exists := m.Commands[commandID]; exists {
+		return nil, fmt.Errorf("command ID %s is already in use", commandID)
+	}
+	cmd := &Command{
+		id:        commandID,
+		status:    CommandStatusPending,
+		command:   commandStr,
+		hosts:     hosts,
+		results:   make(map[string]CommandResult),
+		createdAt: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(cmd)
+	}
+	m.Commands[cmd.id] = cmd
+	return cmd, nil
+}
+
 // GetCommand retrieves a command by ID (mock implementation)
 func (m *MockRunner) GetCommand(commandID string) (*Command, error) {
 	if m.GetCommandFunc != nil {
@@ -95,3 +130,11 @@ func (m *MockRunner) CancelAllCommands() {
 		}
 	}
 }
+
+// AutoBackgroundAfter returns AutoBackground, or DefaultAutoBackgroundAfter when it is not set (mock implementation)
+func (m *MockRunner) AutoBackgroundAfter() time.Duration {
+	if m.AutoBackground > 0 {
+		return m.AutoBackground
+	}
+	return DefaultAutoBackgroundAfter
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+func TestRunner_CreateCommand_GeneratedID(t *testing.T) {
+	r := NewRunner()
+	cmd := r.CreateCommand("uptime", []ssh.ClientInfo{{Name: "host1", Group: "test"}})
+
+	if _, err := uuid.Parse(cmd.ID()); err != nil {
+		t.Errorf("expected a generated UUID, got '%s'", cmd.ID())
+	}
+	if got, err := r.GetCommand(cmd.ID()); err != nil || got != cmd {
+		t.Errorf("expected command to be retrievable by its ID, got %v", err)
+	}
+}
+
+func TestRunner_CreateCommandWithID(t *testing.T) {
+	r := NewRunner()
+	cmd, err := r.CreateCommandWithID("deploy-42:web", "uptime", []ssh.ClientInfo{{Name: "host1", Group: "test"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmd.ID() != "deploy-42:web" {
+		t.Errorf("expected ID 'deploy-42:web', got '%s'", cmd.ID())
+	}
+	if got, err := r.GetCommand("deploy-42:web"); err != nil || got != cmd {
+		t.Errorf("expected command to be retrievable by its ID, got %v", err)
+	}
+
+	// duplicates are rejected and the original command is kept
+	if _, err := r.CreateCommandWithID("deploy-42:web", "reboot", nil); err == nil {
+		t.Error("expected error for duplicate command ID")
+	}
+	if got, _ := r.GetCommand("deploy-42:web"); got != cmd {
+		t.Error("expected the original command to be kept")
+	}
+}
+
+func TestValidateCommandID(t *testing.T) {
+	for _, id := range []string{"a", "deploy-42", "job_1.step:2"} {
+		if err := ValidateCommandID(id); err != nil {
+			t.Errorf("expected %q to be valid, got %v", id, err)
+		}
+	}
+	for _, id := range []string{"", "-leading", "has space", "semi;colon"} {
+		if err := ValidateCommandID(id); err == nil {
+			t.Errorf("expected %q to be invalid", id)
+		}
+	}
+}
+
+func TestRunner_AutoBackgroundAfter(t *testing.T) {
+	if got := NewRunner().AutoBackgroundAfter(); got != DefaultAutoBackgroundAfter {
+		t.Errorf("expected default of %v, got %v", DefaultAutoBackgroundAfter, got)
+	}
+	if got := NewRunner(WithAutoBackgroundAfter(10 * time.Second)).AutoBackgroundAfter(); got != 10*time.Second {
+		t.Errorf("expected 10s, got %v", got)
+	}
+	if got := NewRunner(WithAutoBackgroundAfter(0)).AutoBackgroundAfter(); got != DefaultAutoBackgroundAfter {
+		t.Errorf("expected 0 to keep the default, got %v", got)
+	}
+}
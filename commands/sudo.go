@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+// autoSudoMarker separates the output of the first attempt from the output of the run escalated
+// with sudo.
+const autoSudoMarker = "\n[auto_sudo: permission denied, retrying with sudo]\n"
+
+// permissionDeniedSignatures are lower case messages printed by common tools when the user lacks
+// the privileges for an operation.
+var permissionDeniedSignatures = []string{
+	"permission denied",
+	"operation not permitted",
+	"must be root",
+	"must be run as root",
+	"are you root",
+	"requires root",
+	"superuser privilege",
+	"only root can",
+}
+
+// isPermissionDenied returns true when the result is a completed run that failed because of
+// missing privileges: exit code 126 (not executable) or a non-zero exit code with a permission
+// denied message in the output. This is best-effort as the messages are not standardized.
+func isPermissionDenied(result CommandResult) bool {
+	if result.Err == nil || result.ConnectFailed || result.TimedOut {
+		return false
+	}
+	code, ok := result.ExitCode()
+	if !ok || code == 0 {
+		return false
+	}
+	if code == 126 {
+		return true
+	}
+	output := strings.ToLower(result.Result)
+	for _, signature := range permissionDeniedSignatures {
+		if strings.Contains(output, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSudoCommand returns true when the command already runs through sudo, so escalating again
+// would not help.
+func isSudoCommand(command string) bool {
+	command = strings.TrimSpace(command)
+	return command == "sudo" || strings.HasPrefix(command, "sudo ")
+}
+
+// EscalatedCommand returns the command auto sudo runs on the host when the command fails with a
+// permission denied error, false when the host is never escalated (Windows hosts and commands
+// already running through sudo).
+func EscalatedCommand(host ssh.ClientInfo, command string) (string, bool) {
+	if utils.IsWindows(host) || isSudoCommand(command) {
+		return "", false
+	}
+	return sudoCommand(command), true
+}
+
+// sudoCommand wraps the command to run through non-interactive sudo.
+func sudoCommand(command string) string {
+	return "sudo -n sh -c " + utils.ShellQuote(command)
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsPermissionDenied(t *testing.T) {
+	exit := func(code int) error {
+		return &UnexpectedExitCodeError{Code: code}
+	}
+	testCases := map[string]struct {
+		result   CommandResult
+		expected bool
+	}{
+		"succeeded":               {CommandResult{Result: "Permission denied"}, false},
+		"permission denied":       {CommandResult{Result: "cat: /etc/shadow: Permission denied\n", Err: exit(1)}, true},
+		"operation not permitted": {CommandResult{Result: "chown: changing ownership of 'f': Operation not permitted\n", Err: exit(1)}, true},
+		"must be root":            {CommandResult{Result: "E: This command must be run as root\n", Err: exit(100)}, true},
+		"not executable":          {CommandResult{Result: "", Err: exit(126)}, true},
+		"other failure":           {CommandResult{Result: "No such file or directory\n", Err: exit(1)}, false},
+		"connect failed":          {CommandResult{Result: "permission denied", Err: errors.New("failed to connect"), ConnectFailed: true}, false},
+		"timed out":               {CommandResult{Result: "permission denied", Err: exit(1), TimedOut: true}, false},
+		"no exit code":            {CommandResult{Result: "permission denied", Err: errors.New("command cancelled")}, false},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := isPermissionDenied(tc.result); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsSudoCommand(t *testing.T) {
+	testCases := map[string]bool{
+		"sudo systemctl restart nginx": true,
+		"  sudo -n true":               true,
+		"sudo":                         true,
+		"sudoedit /etc/hosts":          false,
+		"cat /etc/shadow":              false,
+	}
+	for command, expected := range testCases {
+		if got := isSudoCommand(command); got != expected {
+			t.Errorf("isSudoCommand(%q): expected %v, got %v", command, expected, got)
+		}
+	}
+}
+
+func TestCommand_SetParsedJSONEscalated(t *testing.T) {
+	cmd := &Command{results: map[string]CommandResult{
+		"web1": {Host: "web1", Result: "open: permission denied" + autoSudoMarker + `{"ok": true}`, Escalated: true},
+	}}
+	cmd.setParsedJSON("web1")
+
+	result := cmd.results["web1"]
+	if result.JSONNote != "" {
+		t.Fatalf("expected the escalated output to be parsed, got note %q", result.JSONNote)
+	}
+	if parsed, ok := result.JSON.(map[string]any); !ok || parsed["ok"] != true {
+		t.Errorf("unexpected parsed JSON: %v", result.JSON)
+	}
+}
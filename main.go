@@ -4,15 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"os/user"
 	"path"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/spf13/cobra"
 
 	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/sessions"
+	"github.com/blakerouse/ssh-mcp/ssh"
 	"github.com/blakerouse/ssh-mcp/storage"
 	"github.com/blakerouse/ssh-mcp/tools"
 )
@@ -24,7 +30,7 @@ var rootCmd = &cobra.Command{
 		// Start the server
 		err := run(cmd)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 	},
@@ -32,12 +38,44 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.PersistentFlags().String("storage", "", "Storage path for hosts (default: ~/.ssh-mcp/storage.db)")
+	rootCmd.PersistentFlags().String("storage-key", "", "Key to encrypt the stored passwords, key passphrases and jump hosts with, or a reference to it such as env:NAME or file:/path; once set, storage can only be opened with the same key (default: $SSH_MCP_STORAGE_KEY, no encryption)")
+	rootCmd.PersistentFlags().StringSlice("secret-env", nil, "Comma separated list of the environment variables the password_ref and key_passphrase_ref of hosts may read, an entry ending in '*' allows a prefix such as SSH_PASS_* (default: none; SSH_MCP_STORAGE_KEY is never allowed)")
+	rootCmd.PersistentFlags().String("secrets-dir", "", "Directory the file: password_ref and key_passphrase_ref of hosts may read from (default: none)")
+	rootCmd.PersistentFlags().String("upload-dir", "", "Directory the local_path of upload_file may read from (default: none, only inline content can be uploaded)")
+	rootCmd.PersistentFlags().String("default-user", "", "User to connect as for hosts without a user and no stored group or global default (default: the current OS user)")
+	rootCmd.PersistentFlags().String("log-level", "info", "Log level written to stderr (error, warn, info, debug)")
+	rootCmd.PersistentFlags().StringSlice("enabled-tools", nil, "Comma separated list of the only tools to expose (default: all tools)")
+	rootCmd.PersistentFlags().StringSlice("disabled-tools", nil, "Comma separated list of tools to not expose, e.g. perform_command")
+	rootCmd.PersistentFlags().String("known-hosts-data", "", "Known hosts entries (known_hosts file contents) to verify host keys against in memory instead of ~/.ssh/known_hosts (default: $SSH_MCP_KNOWN_HOSTS_DATA)")
+	rootCmd.PersistentFlags().Bool("strict-host-key-checking", false, "Reject hosts whose key is not already known instead of trusting and remembering it on first use")
+	rootCmd.PersistentFlags().StringSlice("allowed-groups", nil, "Comma separated list of the only groups tools may reference, calls referencing another group are rejected (default: all groups)")
+	rootCmd.PersistentFlags().Bool("maintenance", false, "Start in maintenance mode, refusing tools that change remote hosts until set_maintenance_mode disables it")
+	rootCmd.PersistentFlags().String("initiated-by", "", "Identity recorded as initiated_by on commands whose tool call does not set an initiated_by _meta field (default: the current OS user)")
+	rootCmd.PersistentFlags().Bool("text-fallback", false, "Also include a human-readable text rendering of structured tool results, for clients that do not display structured content")
+
+	defaultRetry := ssh.DefaultRetryPolicy()
+	rootCmd.PersistentFlags().Int("retry-attempts", defaultRetry.Attempts, "Total attempts when connecting to a host to run a command (1 disables retrying)")
+	rootCmd.PersistentFlags().Duration("retry-base-delay", defaultRetry.BaseDelay, "Delay before the first connection retry, doubled on each following retry")
+	rootCmd.PersistentFlags().Duration("retry-max-delay", defaultRetry.MaxDelay, "Maximum delay between connection retries")
+	rootCmd.PersistentFlags().Float64("retry-jitter", defaultRetry.Jitter, "Fraction (0.0-1.0) to randomize each retry delay by")
+	rootCmd.PersistentFlags().String("retry-on", "network,timeout", "Comma separated error classes to retry (network, timeout, auth, hostkey, other)")
+	rootCmd.PersistentFlags().Duration("unreachable-cooldown", commands.DefaultUnreachableCooldown, "How long a host that failed to connect is skipped by perform_command with skip_unreachable (0 disables)")
+	rootCmd.PersistentFlags().Duration("auto-background-after", commands.DefaultAutoBackgroundAfter, "How long perform_command and run_command_template wait for a command before moving it to the background (overridable per call with background_after_seconds)")
+	rootCmd.PersistentFlags().Duration("stall-after", 0, "Flag a running background command as stalled in get_command_status and list_commands when no host produced output for this long (0 disables)")
+	rootCmd.PersistentFlags().Int("max-total-connections", 0, "Maximum simultaneous SSH connections across all commands and tools, further connections wait for a free slot (0 is unlimited)")
+	rootCmd.PersistentFlags().Duration("status-wait-timeout", tools.DefaultStatusWaitTimeout, "How long get_command_status waits for a command to complete when wait is set (overridable per call with timeout_seconds)")
+	rootCmd.PersistentFlags().Int("os-info-parallelism", tools.DefaultOSInfoParallelism, "Default number of hosts update_os_info gathers OS information from at once (overridable per call with max_parallel, 0 is unlimited)")
+	rootCmd.PersistentFlags().Duration("command-history", 30*24*time.Hour, "How long finished commands are kept in storage and listed after a restart (0 keeps them forever)")
+
+	defaultKeepAlive := ssh.DefaultKeepAlivePolicy()
+	rootCmd.PersistentFlags().Duration("keepalive-interval", defaultKeepAlive.Interval, "Interval between keepalive requests on open SSH connections (0 disables them)")
+	rootCmd.PersistentFlags().Int("keepalive-count-max", defaultKeepAlive.MaxMissed, "Unanswered keepalive requests in a row after which a connection is closed as dead and reconnected on next use")
 }
 
 func main() {
 	err := rootCmd.Execute()
 	if err != nil && !errors.Is(err, context.Canceled) {
-		fmt.Printf("Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
@@ -46,6 +84,13 @@ func run(cmd *cobra.Command) error {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
+	// Logs must go to stderr, stdout carries the stdio MCP protocol stream
+	logLevel, err := parseLogLevel(cmd.Flag("log-level").Value.String())
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+
 	storagePath := cmd.Flag("storage").Value.String()
 	if storagePath == "" {
 		// Default to ~/.ssh-mcp/storage.db
@@ -55,23 +100,155 @@ func run(cmd *cobra.Command) error {
 		}
 		storagePath = path.Join(homeDir, ".ssh-mcp", "storage.db")
 	}
-	err := os.MkdirAll(path.Dir(storagePath), 0700)
+	err = os.MkdirAll(path.Dir(storagePath), 0700)
 	if err != nil {
 		return fmt.Errorf("failed to create storage directory: %w", err)
 	}
-	storageEngine, err := storage.NewEngine(storagePath)
+	storageKey := cmd.Flag("storage-key").Value.String()
+	if storageKey == "" {
+		storageKey = os.Getenv("SSH_MCP_STORAGE_KEY")
+	}
+	storageKey, err = ssh.ResolveSecret(storageKey)
+	if err != nil {
+		return fmt.Errorf("invalid --storage-key: %w", err)
+	}
+	secretEnv, err := cmd.Flags().GetStringSlice("secret-env")
+	if err != nil {
+		return err
+	}
+	ssh.SetSecretPolicy(ssh.SecretPolicy{EnvNames: secretEnv, Dir: cmd.Flag("secrets-dir").Value.String()})
+	storageEngine, err := storage.NewEngine(storagePath, storage.WithStorageKey(storageKey))
 	if err != nil {
 		return fmt.Errorf("failed to create storage engine: %w", err)
 	}
 	defer storageEngine.Close()
 
+	maintenance, err := cmd.Flags().GetBool("maintenance")
+	if err != nil {
+		return err
+	}
+	if maintenance {
+		err = storageEngine.SetMaintenanceMode(storage.MaintenanceMode{Enabled: true, Reason: "started with --maintenance", Since: time.Now().UTC()})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Record when each stored host was last connected to
+	ssh.SetOnConnect(func(info ssh.ClientInfo) {
+		if err := storageEngine.TouchLastSeen(info.Group, info.Name, time.Now()); err != nil {
+			slog.Warn("failed to record host last seen", "host", info.Name, "error", err)
+		}
+	})
+
+	// Resolve jump hosts given as 'group:name' to the stored host
+	ssh.SetJumpHostResolver(tools.JumpHostResolver(storageEngine))
+
+	retryPolicy, err := retryPolicyFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	keepAlivePolicy, err := keepAlivePolicyFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	ssh.SetDefaultKeepAlivePolicy(keepAlivePolicy)
+	ssh.SetDefaultUser(cmd.Flag("default-user").Value.String())
+
+	knownHostsData := cmd.Flag("known-hosts-data").Value.String()
+	if knownHostsData == "" {
+		knownHostsData = os.Getenv("SSH_MCP_KNOWN_HOSTS_DATA")
+	}
+	if err := ssh.SetKnownHostsData(knownHostsData); err != nil {
+		return fmt.Errorf("invalid --known-hosts-data: %w", err)
+	}
+	strictHostKeyChecking, err := cmd.Flags().GetBool("strict-host-key-checking")
+	if err != nil {
+		return err
+	}
+	ssh.SetStrictHostKeyChecking(strictHostKeyChecking)
+
+	maxConnections, err := cmd.Flags().GetInt("max-total-connections")
+	if err != nil {
+		return err
+	}
+	ssh.SetMaxConnections(maxConnections)
+
+	osInfoParallelism, err := cmd.Flags().GetInt("os-info-parallelism")
+	if err != nil {
+		return err
+	}
+	tools.SetOSInfoParallelism(osInfoParallelism)
+
+	statusWaitTimeout, err := cmd.Flags().GetDuration("status-wait-timeout")
+	if err != nil {
+		return err
+	}
+	if statusWaitTimeout <= 0 {
+		return fmt.Errorf("--status-wait-timeout must be greater than 0")
+	}
+	tools.SetStatusWaitTimeout(statusWaitTimeout)
+
+	unreachableCooldown, err := cmd.Flags().GetDuration("unreachable-cooldown")
+	if err != nil {
+		return err
+	}
+
+	autoBackgroundAfter, err := cmd.Flags().GetDuration("auto-background-after")
+	if err != nil {
+		return err
+	}
+	if autoBackgroundAfter <= 0 {
+		return fmt.Errorf("--auto-background-after must be greater than 0")
+	}
+
+	stallAfter, err := cmd.Flags().GetDuration("stall-after")
+	if err != nil {
+		return err
+	}
+
+	initiator := cmd.Flag("initiated-by").Value.String()
+	if initiator == "" {
+		// a stdio server has a single user, the one running it
+		if current, err := user.Current(); err == nil {
+			initiator = current.Username
+		}
+	}
+	tools.SetDefaultInitiator(initiator)
+
+	allowedGroups, err := cmd.Flags().GetStringSlice("allowed-groups")
+	if err != nil {
+		return err
+	}
+	tools.SetAllowedGroups(allowedGroups)
+	tools.SetUploadDir(cmd.Flag("upload-dir").Value.String())
+
+	history, err := loadCommandHistory(cmd, storageEngine, allowedGroups)
+	if err != nil {
+		return err
+	}
+
 	// Create runner for background command execution
-	commandRunner := commands.NewRunner()
+	commandRunner := commands.NewRunner(
+		commands.WithDefaultRetryPolicy(retryPolicy),
+		commands.WithUnreachableCooldown(unreachableCooldown),
+		commands.WithAutoBackgroundAfter(autoBackgroundAfter),
+		commands.WithDefaultStallAfter(stallAfter),
+		commands.WithCompletionHook(auditCommand),
+		commands.WithStatusHook(commands.SaveToHistory(storageEngine)),
+		commands.WithCompletionHook(commands.SaveToHistory(storageEngine)),
+		commands.WithHistory(history),
+	)
 
-	// Cancel all running commands when context is cancelled
+	// Keep interactive shells open across tool calls
+	sessionManager := sessions.NewManager()
+
+	// Cancel all running commands and close the shells when context is cancelled
 	go func() {
 		<-ctx.Done()
 		commandRunner.CancelAllCommands()
+		sessionManager.CloseAll()
 	}()
 
 	s := server.NewMCPServer(
@@ -81,15 +258,163 @@ func run(cmd *cobra.Command) error {
 		server.WithRecovery(),
 	)
 
-	for _, tool := range tools.Registry.Tools() {
+	textFallback, err := cmd.Flags().GetBool("text-fallback")
+	if err != nil {
+		return err
+	}
+
+	enabledTools, err := cmd.Flags().GetStringSlice("enabled-tools")
+	if err != nil {
+		return err
+	}
+	disabledTools, err := cmd.Flags().GetStringSlice("disabled-tools")
+	if err != nil {
+		return err
+	}
+	serverTools, err := tools.Registry.Filter(enabledTools, disabledTools)
+	if err != nil {
+		return fmt.Errorf("invalid --enabled-tools/--disabled-tools: %w", err)
+	}
+
+	for _, tool := range serverTools {
 		// Set command runner for tools that support background execution
 		if commandRunnerAware, ok := tool.(tools.CommandRunnerAware); ok {
 			commandRunnerAware.SetCommandRunner(commandRunner)
 		}
-		s.AddTool(tool.Definition(), tool.Handler(ctx, storageEngine))
+		if sessionManagerAware, ok := tool.(tools.SessionManagerAware); ok {
+			sessionManagerAware.SetSessionManager(sessionManager)
+		}
+		handler := tool.Handler(ctx, storageEngine)
+		if mutating, ok := tool.(tools.Mutating); ok && mutating.IsMutating() {
+			handler = tools.MaintenanceGuard(storageEngine, handler)
+		}
+		if textFallback {
+			handler = tools.TextFallback(handler)
+		}
+		s.AddTool(tool.Definition(), handler)
 	}
 
 	// start the stdio server
+	slog.Info("starting ssh-mcp server", "storage", storagePath, "tools", len(serverTools))
 	stdio := server.NewStdioServer(s)
 	return stdio.Listen(ctx, os.Stdin, os.Stdout)
 }
+
+// loadCommandHistory removes the finished commands older than --command-history from storage and
+// loads the rest within the allowed groups, so they can still be listed after a restart.
+func loadCommandHistory(cmd *cobra.Command, storageEngine storage.Store, allowedGroups []string) ([]*commands.CommandState, error) {
+	retention, err := cmd.Flags().GetDuration("command-history")
+	if err != nil {
+		return nil, err
+	}
+	if retention < 0 {
+		return nil, fmt.Errorf("--command-history cannot be negative")
+	}
+	if retention > 0 {
+		deleted, err := storageEngine.DeleteCommandsBefore(time.Now().Add(-retention))
+		if err != nil {
+			return nil, err
+		}
+		if deleted > 0 {
+			slog.Info("removed expired commands from history", "count", deleted)
+		}
+	}
+	history, err := commands.LoadHistory(storageEngine, allowedGroups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load command history: %w", err)
+	}
+	return history, nil
+}
+
+// auditCommand logs who ran a command on which hosts and how it ended. The command rendered for
+// each host, e.g. with host_vars, is logged as host_commands.
+func auditCommand(state *commands.CommandState) {
+	hosts := make([]string, len(state.Hosts))
+	hostCommands := map[string]string{}
+	for i, host := range state.Hosts {
+		hosts[i] = host.Group + ":" + host.Name
+		if command := state.Results[host.Name].Command; command != "" {
+			hostCommands[hosts[i]] = command
+		}
+	}
+	attrs := []any{
+		"id", state.ID,
+		"status", state.Status,
+		"command", state.Command,
+		"hosts", strings.Join(hosts, ","),
+		"initiated_by", state.InitiatedBy,
+	}
+	if len(hostCommands) > 0 {
+		attrs = append(attrs, "host_commands", hostCommands)
+	}
+	slog.Info("command audit", attrs...)
+}
+
+// parseLogLevel parses the --log-level flag value.
+func parseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "error":
+		return slog.LevelError, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("invalid --log-level %q: must be one of error, warn, info, debug", level)
+}
+
+// keepAlivePolicyFromFlags builds the connection keepalive policy from the command line flags.
+func keepAlivePolicyFromFlags(cmd *cobra.Command) (ssh.KeepAlivePolicy, error) {
+	flags := cmd.Flags()
+	var policy ssh.KeepAlivePolicy
+	var err error
+	if policy.Interval, err = flags.GetDuration("keepalive-interval"); err != nil {
+		return policy, err
+	}
+	if policy.MaxMissed, err = flags.GetInt("keepalive-count-max"); err != nil {
+		return policy, err
+	}
+	if policy.Interval < 0 {
+		return policy, errors.New("--keepalive-interval cannot be negative")
+	}
+	if policy.MaxMissed < 1 {
+		return policy, errors.New("--keepalive-count-max must be at least 1")
+	}
+	return policy, nil
+}
+
+// retryPolicyFromFlags builds the connection retry policy from the command line flags.
+func retryPolicyFromFlags(cmd *cobra.Command) (ssh.RetryPolicy, error) {
+	policy := ssh.DefaultRetryPolicy()
+	flags := cmd.Flags()
+
+	var err error
+	if policy.Attempts, err = flags.GetInt("retry-attempts"); err != nil {
+		return policy, err
+	}
+	if policy.BaseDelay, err = flags.GetDuration("retry-base-delay"); err != nil {
+		return policy, err
+	}
+	if policy.MaxDelay, err = flags.GetDuration("retry-max-delay"); err != nil {
+		return policy, err
+	}
+	if policy.Jitter, err = flags.GetFloat64("retry-jitter"); err != nil {
+		return policy, err
+	}
+	if policy.Attempts < 1 {
+		return policy, errors.New("--retry-attempts must be at least 1")
+	}
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		return policy, errors.New("--retry-jitter must be between 0.0 and 1.0")
+	}
+	retryOn, err := flags.GetString("retry-on")
+	if err != nil {
+		return policy, err
+	}
+	if policy.RetryOn, err = ssh.ParseErrorClasses(retryOn); err != nil {
+		return policy, fmt.Errorf("invalid --retry-on: %w", err)
+	}
+	return policy, nil
+}
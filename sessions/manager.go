@@ -0,0 +1,190 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/google/uuid"
+)
+
+// DefaultIdleTimeout is how long a session is kept open without input being sent or output read.
+const DefaultIdleTimeout = 30 * time.Minute
+
+// DefaultMaxSessions is the most sessions that can be open at once.
+const DefaultMaxSessions = 16
+
+// DefaultReapInterval is how often idle and exited sessions are closed in the background.
+const DefaultReapInterval = time.Minute
+
+// ManagerOption configures optional behaviour of a Manager.
+type ManagerOption func(*Manager)
+
+// WithIdleTimeout closes sessions that have no input sent or output read for longer than the
+// timeout. 0 keeps idle sessions open until they are closed.
+func WithIdleTimeout(timeout time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.idleTimeout = timeout
+	}
+}
+
+// WithMaxSessions limits the number of sessions open at once.
+func WithMaxSessions(maxSessions int) ManagerOption {
+	return func(m *Manager) {
+		m.maxSessions = maxSessions
+	}
+}
+
+// WithReapInterval sets how often idle and exited sessions are closed in the background, besides
+// whenever a session is opened or looked up. 0 disables the background reaper.
+func WithReapInterval(interval time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.reapInterval = interval
+	}
+}
+
+// Manager keeps the open interactive shell sessions by ID.
+type Manager struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	// opening is the number of sessions being connected, which already count against maxSessions
+	opening int
+
+	idleTimeout  time.Duration
+	maxSessions  int
+	reapInterval time.Duration
+
+	// stop ends the background reaper once
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewManager creates a new session manager. CloseAll must be called to stop its background reaper.
+func NewManager(opts ...ManagerOption) *Manager {
+	m := &Manager{
+		sessions:     make(map[string]*Session),
+		idleTimeout:  DefaultIdleTimeout,
+		maxSessions:  DefaultMaxSessions,
+		reapInterval: DefaultReapInterval,
+		stop:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.reapInterval > 0 {
+		go m.reap()
+	}
+	return m
+}
+
+// reap closes idle and exited sessions every reap interval until the manager is stopped.
+func (m *Manager) reap() {
+	ticker := time.NewTicker(m.reapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.closeIdle()
+		}
+	}
+}
+
+// Open connects to the host and starts a shell in a pseudo-terminal of the given type and size.
+func (m *Manager) Open(ctx context.Context, host ssh.ClientInfo, pty commands.PTYOptions) (*Session, error) {
+	m.closeIdle()
+	// reserve the slot before connecting, so concurrent opens cannot exceed the limit
+	m.mu.Lock()
+	count := len(m.sessions) + m.opening
+	if m.maxSessions > 0 && count >= m.maxSessions {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%d shell sessions are already open, close one with close_shell first", count)
+	}
+	m.opening++
+	m.mu.Unlock()
+
+	session, err := open(ctx, uuid.New().String(), host, pty)
+	m.mu.Lock()
+	m.opening--
+	if err == nil {
+		m.sessions[session.id] = session
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("opened shell session", "session_id", session.id, "group", host.Group, "host", host.Name)
+	return session, nil
+}
+
+// Get returns the open session with the ID.
+func (m *Manager) Get(sessionID string) (*Session, error) {
+	m.closeIdle()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("shell session %s not found", sessionID)
+	}
+	return session, nil
+}
+
+// Close closes the session with the ID and forgets it. Failing to close an already broken
+// connection is not an error, the session is gone either way.
+func (m *Manager) Close(sessionID string) error {
+	m.mu.Lock()
+	session, ok := m.sessions[sessionID]
+	delete(m.sessions, sessionID)
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("shell session %s not found", sessionID)
+	}
+	if err := session.Close(); err != nil {
+		slog.Debug("error closing shell session", "session_id", sessionID, "error", err)
+	}
+	slog.Info("closed shell session", "session_id", sessionID, "group", session.host.Group, "host", session.host.Name)
+	return nil
+}
+
+// CloseAll closes every open session and stops the background reaper, used when the server stops.
+func (m *Manager) CloseAll() {
+	m.stopOnce.Do(func() { close(m.stop) })
+	m.mu.Lock()
+	sessions := m.sessions
+	m.sessions = make(map[string]*Session)
+	m.mu.Unlock()
+	for _, session := range sessions {
+		_ = session.Close()
+	}
+}
+
+// closeIdle closes the sessions idle for longer than the idle timeout, and forgets the sessions
+// whose shell exited once their last output was read.
+func (m *Manager) closeIdle() {
+	var idle, exited []*Session
+	m.mu.Lock()
+	for id, session := range m.sessions {
+		switch {
+		case session.exited():
+			exited = append(exited, session)
+			delete(m.sessions, id)
+		case m.idleTimeout > 0 && time.Since(session.idleSince()) > m.idleTimeout:
+			idle = append(idle, session)
+			delete(m.sessions, id)
+		}
+	}
+	m.mu.Unlock()
+	for _, session := range idle {
+		slog.Info("closed idle shell session", "session_id", session.id, "group", session.host.Group, "host", session.host.Name)
+		_ = session.Close()
+	}
+	for _, session := range exited {
+		slog.Info("removed exited shell session", "session_id", session.id, "group", session.host.Group, "host", session.host.Name)
+		_ = session.Close()
+	}
+}
@@ -0,0 +1,306 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// maxBufferedOutput is the most unread output kept for a session, older output is dropped first.
+const maxBufferedOutput = 1 << 20
+
+// settleAfter is how long a read waits for more output once some has arrived, so output written
+// in several chunks (e.g. a command followed by its prompt) is returned together.
+const settleAfter = 200 * time.Millisecond
+
+// ErrSessionClosed is returned when writing to a session whose shell has exited or was closed.
+var ErrSessionClosed = errors.New("shell session is closed")
+
+// Session is an interactive shell in a pseudo-terminal on a single host. It stays open across
+// tool calls until the shell exits, it is closed or it is idle for too long.
+type Session struct {
+	id   string
+	host ssh.ClientInfo
+
+	client  *ssh.Client
+	session *gossh.Session
+	stdin   io.WriteCloser
+	// disconnect closes the connection once, whether the shell exited or the session was closed
+	disconnect sync.Once
+
+	mu sync.Mutex
+	// output is the output not read yet
+	output []byte
+	// dropped is the number of unread bytes dropped because output was full
+	dropped int
+	// lastActivityAt is the last time input was sent or output was read
+	lastActivityAt time.Time
+	closed         bool
+	exitCode       *int
+	err            error
+	// changed is closed and replaced whenever output arrives or the session closes
+	changed chan struct{}
+}
+
+// ReadResult is the output of a session since the previous read.
+type ReadResult struct {
+	SessionID string `json:"session_id"`
+	Host      string `json:"host"`
+	Output    string `json:"output"`
+	// DroppedBytes is the amount of older output dropped because it was not read in time
+	DroppedBytes int `json:"dropped_bytes,omitempty"`
+	// Matched is true when the output matched the pattern that was waited for
+	Matched bool `json:"matched,omitempty"`
+	// Closed is true when the shell has exited, no more output will arrive
+	Closed bool `json:"closed,omitempty"`
+	// ExitCode is the exit status of the shell once it has exited on its own
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// open connects to the host and starts a login shell in a pseudo-terminal.
+func open(ctx context.Context, id string, host ssh.ClientInfo, pty commands.PTYOptions) (*Session, error) {
+	client := ssh.NewClient(&host)
+	if err := client.ConnectContext(ctx); err != nil {
+		return nil, err
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	modes := gossh.TerminalModes{
+		gossh.ECHO:          1,
+		gossh.TTY_OP_ISPEED: 14400,
+		gossh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty(pty.Term, pty.Rows, pty.Cols, modes); err != nil {
+		session.Close()
+		client.Close()
+		return nil, fmt.Errorf("failed to request pty: %w", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		client.Close()
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+
+	now := time.Now()
+	s := &Session{
+		id:             id,
+		host:           host,
+		client:         client,
+		session:        session,
+		stdin:          stdin,
+		lastActivityAt: now,
+		changed:        make(chan struct{}),
+	}
+	// stderr is merged into stdout by the pty, both are captured in case the server does not
+	session.Stdout = outputWriter{s}
+	session.Stderr = outputWriter{s}
+	if err := session.Shell(); err != nil {
+		session.Close()
+		client.Close()
+		return nil, fmt.Errorf("failed to start shell: %w", err)
+	}
+	go s.wait()
+	return s, nil
+}
+
+// ID returns the ID of the session.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Host returns the host the session is open on.
+func (s *Session) Host() ssh.ClientInfo {
+	return s.host
+}
+
+// idleSince returns the last time input was sent or output was read.
+func (s *Session) idleSince() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastActivityAt
+}
+
+// exited returns true when the shell has exited or was closed and all of its output was read.
+func (s *Session) exited() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed && len(s.output) == 0
+}
+
+// Write sends input to the shell as if it was typed, control characters included (e.g. "\x03"
+// for Ctrl-C).
+func (s *Session) Write(input string) error {
+	s.mu.Lock()
+	closed := s.closed
+	s.lastActivityAt = time.Now()
+	s.mu.Unlock()
+	if closed {
+		return ErrSessionClosed
+	}
+	if _, err := io.WriteString(s.stdin, input); err != nil {
+		return fmt.Errorf("failed to send input: %w", err)
+	}
+	return nil
+}
+
+// Read returns the output since the previous read. With a wait it blocks until output arrives and
+// has settled, the shell exits or the wait expires. With until it keeps waiting until the output
+// matches the pattern instead, e.g. a prompt. Only ctx being done returns early with what arrived.
+func (s *Session) Read(ctx context.Context, wait time.Duration, until *regexp.Regexp) ReadResult {
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	var settle <-chan time.Time
+
+	var output []byte
+	dropped := 0
+	matched := false
+	for {
+		s.mu.Lock()
+		output = append(output, s.output...)
+		dropped += s.dropped
+		s.output = nil
+		s.dropped = 0
+		s.lastActivityAt = time.Now()
+		closed, changed := s.closed, s.changed
+		s.mu.Unlock()
+
+		if until != nil {
+			matched = until.Match(output)
+		}
+		if closed || wait <= 0 || matched {
+			break
+		}
+		if until == nil && len(output) > 0 && settle == nil {
+			settle = time.After(settleAfter)
+		}
+		stop := false
+		select {
+		case <-changed:
+			if settle != nil {
+				settle = time.After(settleAfter)
+			}
+		case <-settle:
+			stop = true
+		case <-deadline.C:
+			stop = true
+		case <-ctx.Done():
+			stop = true
+		}
+		if stop {
+			// collect whatever arrived while waiting
+			s.mu.Lock()
+			output = append(output, s.output...)
+			dropped += s.dropped
+			s.output = nil
+			s.dropped = 0
+			s.mu.Unlock()
+			if until != nil {
+				matched = until.Match(output)
+			}
+			break
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := ReadResult{
+		SessionID:    s.id,
+		Host:         s.host.Name,
+		Output:       string(output),
+		DroppedBytes: dropped,
+		Matched:      matched,
+		Closed:       s.closed,
+		ExitCode:     s.exitCode,
+	}
+	if s.err != nil {
+		result.Error = s.err.Error()
+	}
+	return result
+}
+
+// Close ends the shell and the connection to the host.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	s.markClosed(nil, nil)
+	s.mu.Unlock()
+	return s.closeConnection()
+}
+
+// closeConnection closes the shell and the connection to the host, only the first call does.
+func (s *Session) closeConnection() error {
+	var err error
+	s.disconnect.Do(func() {
+		_ = s.session.Close()
+		err = s.client.Close()
+	})
+	return err
+}
+
+// wait marks the session closed once the shell exits.
+func (s *Session) wait() {
+	err := s.session.Wait()
+	var exitCode *int
+	var exitErr *gossh.ExitError
+	if err == nil {
+		code := 0
+		exitCode = &code
+	} else if errors.As(err, &exitErr) {
+		code := exitErr.ExitStatus()
+		exitCode = &code
+		err = nil
+	}
+	s.mu.Lock()
+	s.markClosed(exitCode, err)
+	s.mu.Unlock()
+	_ = s.closeConnection()
+}
+
+// markClosed records the end of the shell, the first call wins. The lock must be held.
+func (s *Session) markClosed(exitCode *int, err error) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.exitCode = exitCode
+	s.err = err
+	close(s.changed)
+}
+
+// append adds output of the shell, dropping the oldest unread output beyond maxBufferedOutput.
+func (s *Session) append(p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.output = append(s.output, p...)
+	if over := len(s.output) - maxBufferedOutput; over > 0 {
+		s.output = s.output[over:]
+		s.dropped += over
+	}
+	if !s.closed {
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+}
+
+// outputWriter captures the output of the shell into the session.
+type outputWriter struct {
+	s *Session
+}
+
+// Write implements io.Writer.
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.s.append(p)
+	return len(p), nil
+}
@@ -0,0 +1,277 @@
+package sessions
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// startShellTestServer starts an SSH server whose shell prints a prompt, answers each line with
+// "got: <line>" and exits with status 3 on "exit". It only starts the shell after a pty-req.
+func startShellTestServer(t *testing.T) ssh.ClientInfo {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := gossh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	config := &gossh.ServerConfig{
+		PasswordCallback: func(conn gossh.ConnMetadata, pass []byte) (*gossh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sshConn, chans, reqs, err := gossh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				defer sshConn.Close()
+				go gossh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					channel, channelReqs, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go serveTestShell(channel, channelReqs)
+				}
+			}()
+		}
+	}()
+
+	// trust the new host key in memory only
+	require.NoError(t, ssh.SetKnownHostsData("unrelated.invalid "+string(gossh.MarshalAuthorizedKey(signer.PublicKey()))))
+	t.Cleanup(func() { _ = ssh.SetKnownHostsData("") })
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return ssh.ClientInfo{Group: "test", Name: "shell1", Host: host, Port: port, User: "tester", Pass: "secret"}
+}
+
+// serveTestShell runs the test shell on the channel.
+func serveTestShell(channel gossh.Channel, reqs <-chan *gossh.Request) {
+	defer channel.Close()
+	pty := false
+	for req := range reqs {
+		switch req.Type {
+		case "pty-req":
+			pty = true
+			_ = req.Reply(true, nil)
+		case "shell":
+			_ = req.Reply(pty, nil)
+			if !pty {
+				continue
+			}
+			go func() {
+				fmt.Fprint(channel, "$ ")
+				scanner := bufio.NewScanner(channel)
+				scanner.Split(scanCarriageReturns)
+				for scanner.Scan() {
+					line := scanner.Text()
+					if line == "exit" {
+						status := make([]byte, 4)
+						binary.BigEndian.PutUint32(status, 3)
+						_, _ = channel.SendRequest("exit-status", false, status)
+						channel.Close()
+						return
+					}
+					fmt.Fprintf(channel, "got: %s\r\n$ ", line)
+				}
+			}()
+		default:
+			_ = req.Reply(false, nil)
+		}
+	}
+}
+
+// scanCarriageReturns splits input into the lines typed into a terminal, ended by Enter.
+func scanCarriageReturns(data []byte, atEOF bool) (int, []byte, error) {
+	if i := strings.IndexByte(string(data), '\r'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func testPTY() commands.PTYOptions {
+	return commands.PTYOptions{Term: "xterm", Cols: 80, Rows: 24}
+}
+
+func TestSession_Interactive(t *testing.T) {
+	host := startShellTestServer(t)
+	manager := NewManager()
+	t.Cleanup(manager.CloseAll)
+
+	session, err := manager.Open(context.Background(), host, testPTY())
+	require.NoError(t, err)
+	result := session.Read(context.Background(), 5*time.Second, regexp.MustCompile(`\$ $`))
+	require.True(t, result.Matched)
+	require.Equal(t, "$ ", result.Output)
+	require.Equal(t, "shell1", result.Host)
+
+	require.NoError(t, session.Write("hello\r"))
+	result = session.Read(context.Background(), 5*time.Second, nil)
+	require.Equal(t, "got: hello\r\n$ ", result.Output)
+	require.False(t, result.Closed)
+
+	// nothing new without a wait
+	result = session.Read(context.Background(), 0, nil)
+	require.Empty(t, result.Output)
+
+	found, err := manager.Get(session.ID())
+	require.NoError(t, err)
+	require.Same(t, session, found)
+
+	require.NoError(t, session.Write("exit\r"))
+	result = session.Read(context.Background(), 5*time.Second, regexp.MustCompile(`never printed`))
+	require.True(t, result.Closed)
+	require.False(t, result.Matched)
+	require.NotNil(t, result.ExitCode)
+	require.Equal(t, 3, *result.ExitCode)
+	require.ErrorIs(t, session.Write("echo\r"), ErrSessionClosed)
+
+	require.NoError(t, manager.Close(session.ID()))
+	_, err = manager.Get(session.ID())
+	require.ErrorContains(t, err, "not found")
+}
+
+func TestSession_UntilTimesOut(t *testing.T) {
+	host := startShellTestServer(t)
+	manager := NewManager()
+	t.Cleanup(manager.CloseAll)
+
+	session, err := manager.Open(context.Background(), host, testPTY())
+	require.NoError(t, err)
+	result := session.Read(context.Background(), 300*time.Millisecond, regexp.MustCompile(`Password:`))
+	require.False(t, result.Matched)
+	require.Equal(t, "$ ", result.Output)
+}
+
+func TestSession_DropsOldestOutput(t *testing.T) {
+	s := &Session{changed: make(chan struct{})}
+	s.append([]byte(strings.Repeat("a", maxBufferedOutput)))
+	s.append([]byte("bc"))
+
+	result := s.Read(context.Background(), 0, nil)
+	require.Equal(t, 2, result.DroppedBytes)
+	require.Len(t, result.Output, maxBufferedOutput)
+	require.True(t, strings.HasSuffix(result.Output, "abc"))
+}
+
+func TestManager_MaxSessions(t *testing.T) {
+	host := startShellTestServer(t)
+	manager := NewManager(WithMaxSessions(1))
+	t.Cleanup(manager.CloseAll)
+
+	_, err := manager.Open(context.Background(), host, testPTY())
+	require.NoError(t, err)
+	_, err = manager.Open(context.Background(), host, testPTY())
+	require.ErrorContains(t, err, "1 shell sessions are already open")
+}
+
+func TestManager_ClosesIdleSessions(t *testing.T) {
+	host := startShellTestServer(t)
+	manager := NewManager(WithIdleTimeout(time.Minute))
+	t.Cleanup(manager.CloseAll)
+
+	session, err := manager.Open(context.Background(), host, testPTY())
+	require.NoError(t, err)
+	session.mu.Lock()
+	session.lastActivityAt = time.Now().Add(-2 * time.Minute)
+	session.mu.Unlock()
+
+	_, err = manager.Get(session.ID())
+	require.ErrorContains(t, err, "not found")
+	require.ErrorIs(t, session.Write("echo\r"), ErrSessionClosed)
+}
+
+func TestManager_MaxSessions_ConcurrentOpens(t *testing.T) {
+	host := startShellTestServer(t)
+	manager := NewManager(WithMaxSessions(1))
+	t.Cleanup(manager.CloseAll)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := manager.Open(context.Background(), host, testPTY())
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	opened := 0
+	for err := range errs {
+		if err == nil {
+			opened++
+		}
+	}
+	require.Equal(t, 1, opened)
+}
+
+func TestManager_FailedOpenReleasesSlot(t *testing.T) {
+	host := startShellTestServer(t)
+	manager := NewManager(WithMaxSessions(1))
+	t.Cleanup(manager.CloseAll)
+
+	unreachable := host
+	unreachable.Port = "1"
+	_, err := manager.Open(context.Background(), unreachable, testPTY())
+	require.Error(t, err)
+	_, err = manager.Open(context.Background(), host, testPTY())
+	require.NoError(t, err)
+}
+
+func TestManager_ReapsExitedSessions(t *testing.T) {
+	host := startShellTestServer(t)
+	manager := NewManager(WithIdleTimeout(0), WithReapInterval(10*time.Millisecond))
+	t.Cleanup(manager.CloseAll)
+
+	session, err := manager.Open(context.Background(), host, testPTY())
+	require.NoError(t, err)
+	require.NoError(t, session.Write("exit\r"))
+	require.Eventually(t, func() bool {
+		return session.Read(context.Background(), 0, nil).Closed
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the background reaper forgets it without the manager being used
+	require.Eventually(t, func() bool {
+		manager.mu.Lock()
+		defer manager.mu.Unlock()
+		return len(manager.sessions) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestManager_CloseUnknown(t *testing.T) {
+	manager := NewManager()
+	t.Cleanup(manager.CloseAll)
+	require.ErrorContains(t, manager.Close("missing"), "shell session missing not found")
+}
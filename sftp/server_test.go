@@ -0,0 +1,169 @@
+package sftp
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// sftpServerPaths are where OpenSSH installs its sftp-server on common systems.
+var sftpServerPaths = []string{
+	"/usr/lib/openssh/sftp-server",
+	"/usr/libexec/openssh/sftp-server",
+	"/usr/libexec/sftp-server",
+	"/usr/lib/ssh/sftp-server",
+}
+
+// findSFTPServer returns the path of the OpenSSH sftp-server, skipping the test when it is not
+// installed.
+func findSFTPServer(t *testing.T) string {
+	t.Helper()
+	for _, path := range sftpServerPaths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	if path, err := exec.LookPath("sftp-server"); err == nil {
+		return path
+	}
+	t.Skip("OpenSSH sftp-server is not installed")
+	return ""
+}
+
+// startSFTPServer starts an in-process SSH server accepting any password whose sftp subsystem runs
+// the OpenSSH sftp-server at path, and returns the host to connect to it.
+func startSFTPServer(t *testing.T, path string) ssh.ClientInfo {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := gossh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	config := &gossh.ServerConfig{
+		PasswordCallback: func(conn gossh.ConnMetadata, pass []byte) (*gossh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, chans, reqs, err := gossh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go gossh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					channel, channelReqs, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go serveSubsystem(channel, channelReqs, path)
+				}
+			}()
+		}
+	}()
+
+	// trust the new host key in memory only
+	require.NoError(t, ssh.SetKnownHostsData("unrelated.invalid "+string(gossh.MarshalAuthorizedKey(signer.PublicKey()))))
+	t.Cleanup(func() { _ = ssh.SetKnownHostsData("") })
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return ssh.ClientInfo{Group: "test", Name: "sftp1", Host: host, Port: port, User: "tester", Pass: "secret"}
+}
+
+// serveSubsystem runs the sftp-server at path for the sftp subsystem request of the channel.
+func serveSubsystem(channel gossh.Channel, reqs <-chan *gossh.Request, path string) {
+	defer channel.Close()
+	for req := range reqs {
+		if req.Type != "subsystem" || len(req.Payload) < 4 || string(req.Payload[4:]) != "sftp" {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+		go gossh.DiscardRequests(reqs)
+
+		cmd := exec.Command(path)
+		cmd.Stdin = channel
+		cmd.Stdout = channel
+		cmd.Stderr = channel.Stderr()
+		status := make([]byte, 4)
+		if err := cmd.Run(); err != nil {
+			binary.BigEndian.PutUint32(status, 1)
+		}
+		_, _ = channel.SendRequest("exit-status", false, status)
+		return
+	}
+}
+
+func TestClient_OpenSSHServer(t *testing.T) {
+	host := startSFTPServer(t, findSFTPServer(t))
+	sshClient := ssh.NewClient(&host)
+	require.NoError(t, sshClient.Connect())
+	defer sshClient.Close()
+	client, err := NewClient(sshClient)
+	require.NoError(t, err)
+	defer client.Close()
+
+	dir := t.TempDir()
+	partial := filepath.Join(dir, "app.conf.partial")
+	target := filepath.Join(dir, "app.conf")
+	require.NoError(t, os.WriteFile(target, []byte("old"), 0o644))
+
+	// larger than a single write and read request
+	data := bytes.Repeat([]byte{0x00, 0xff, 'a', '\n'}, 3*maxWriteSize)
+	written, err := client.WriteFile(partial, bytes.NewReader(data), 0o600, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), written)
+	info, err := os.Stat(partial)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	_, err = client.WriteFile(partial, bytes.NewReader(data), 0o600, false)
+	require.Error(t, err)
+
+	file, err := client.Open(partial)
+	require.NoError(t, err)
+	mode, err := file.Mode()
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), mode)
+	read, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	require.Equal(t, data, read)
+
+	require.Error(t, client.Rename(partial, target, false))
+	require.NoError(t, client.Rename(partial, target, true))
+	onDisk, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, data, onDisk)
+	require.NoFileExists(t, partial)
+
+	require.NoError(t, client.Remove(target))
+	require.NoFileExists(t, target)
+	var statusErr *StatusError
+	_, err = client.Open(target)
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, uint32(statusNoSuchFile), statusErr.Code)
+}
@@ -0,0 +1,490 @@
+// Package sftp is a minimal SFTP (protocol version 3) client, covering what the tools need to read,
+// write and move files on remote hosts over an existing SSH connection.
+package sftp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// protocolVersion is the SFTP version spoken by the client, supported by every OpenSSH server.
+const protocolVersion = 3
+
+// maxWriteSize is the most file data sent in a single write request, servers must accept at least
+// 32768 bytes.
+const maxWriteSize = 32 * 1024
+
+// maxReadSize is the most file data requested in a single read request.
+const maxReadSize = 32 * 1024
+
+// maxPacketSize bounds the responses the client accepts, its own responses are all small.
+const maxPacketSize = 256 * 1024
+
+// Packet types.
+const (
+	packetInit     = 1
+	packetVersion  = 2
+	packetOpen     = 3
+	packetClose    = 4
+	packetRead     = 5
+	packetWrite    = 6
+	packetFStat    = 8
+	packetFSetStat = 10
+	packetRemove   = 13
+	packetRename   = 18
+	packetStatus   = 101
+	packetHandle   = 102
+	packetData     = 103
+	packetAttrs    = 105
+	packetExtended = 200
+)
+
+// extensionPosixRename is the OpenSSH extension renaming over an existing file, as rename(2) does.
+const extensionPosixRename = "posix-rename@openssh.com"
+
+// Open flags.
+const (
+	openRead     = 0x01
+	openWrite    = 0x02
+	openCreate   = 0x08
+	openTruncate = 0x10
+	openExclude  = 0x20
+)
+
+// File attribute flags, marking which fields of the attributes are set.
+const (
+	attrSize        = 0x01
+	attrUIDGID      = 0x02
+	attrPermissions = 0x04
+)
+
+// Status codes.
+const (
+	statusOK               = 0
+	statusEOF              = 1
+	statusNoSuchFile       = 2
+	statusPermissionDenied = 3
+	statusFailure          = 4
+)
+
+// StatusError is a request the server answered with a status other than OK.
+type StatusError struct {
+	Code    uint32
+	Message string
+}
+
+// Error returns the error message.
+func (e *StatusError) Error() string {
+	name := fmt.Sprintf("status %d", e.Code)
+	switch e.Code {
+	case statusEOF:
+		name = "end of file"
+	case statusNoSuchFile:
+		name = "no such file"
+	case statusPermissionDenied:
+		name = "permission denied"
+	case statusFailure:
+		name = "failure"
+	}
+	if e.Message == "" {
+		return "sftp: " + name
+	}
+	return fmt.Sprintf("sftp: %s: %s", name, e.Message)
+}
+
+// Client is an SFTP session. Requests are sent one at a time, so a Client must not be used from
+// several goroutines at once.
+type Client struct {
+	r       io.Reader
+	w       io.WriteCloser
+	session io.Closer
+	nextID  uint32
+	// extensions are the names of the extensions announced by the server
+	extensions map[string]bool
+}
+
+// NewClient starts the sftp subsystem on the connected SSH client.
+func NewClient(sshClient *ssh.Client) (*Client, error) {
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	w, err := session.StdinPipe()
+	if err != nil {
+		_ = session.Close()
+		return nil, fmt.Errorf("failed to create stdin: %w", err)
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		_ = session.Close()
+		return nil, fmt.Errorf("failed to create stdout: %w", err)
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		_ = session.Close()
+		return nil, fmt.Errorf("failed to start the sftp subsystem: %w", err)
+	}
+	client, err := NewClientPipe(r, w)
+	if err != nil {
+		_ = session.Close()
+		return nil, err
+	}
+	client.session = session
+	return client, nil
+}
+
+// NewClientPipe starts an SFTP session with a server reading requests from w and writing responses
+// to r.
+func NewClientPipe(r io.Reader, w io.WriteCloser) (*Client, error) {
+	c := &Client{r: r, w: w}
+	if err := c.writePacket(packetInit, binary.BigEndian.AppendUint32(nil, protocolVersion)); err != nil {
+		return nil, fmt.Errorf("failed to initialize sftp: %w", err)
+	}
+	typ, payload, err := c.readPacket()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sftp: %w", err)
+	}
+	if typ != packetVersion || len(payload) < 4 {
+		return nil, fmt.Errorf("failed to initialize sftp: unexpected packet type %d", typ)
+	}
+	if version := binary.BigEndian.Uint32(payload); version < protocolVersion {
+		return nil, fmt.Errorf("sftp server only supports version %d", version)
+	}
+	// the version is followed by pairs of extension names and data
+	c.extensions = map[string]bool{}
+	for rest := payload[4:]; len(rest) > 0; {
+		name, next, ok := readString(rest)
+		if !ok {
+			break
+		}
+		if _, next, ok = readString(next); !ok {
+			break
+		}
+		c.extensions[name] = true
+		rest = next
+	}
+	return c, nil
+}
+
+// Close ends the SFTP session.
+func (c *Client) Close() error {
+	err := c.w.Close()
+	if c.session != nil {
+		if closeErr := c.session.Close(); closeErr != nil && !errors.Is(closeErr, io.EOF) {
+			err = errors.Join(err, closeErr)
+		}
+	}
+	return err
+}
+
+// WriteFile writes the contents of src to the file at path, creating it with mode or, with
+// overwrite, replacing an existing file and setting its mode. Returns the number of bytes written.
+func (c *Client) WriteFile(path string, src io.Reader, mode os.FileMode, overwrite bool) (int64, error) {
+	flags := uint32(openWrite | openCreate | openTruncate)
+	if !overwrite {
+		flags |= openExclude
+	}
+	attrs := binary.BigEndian.AppendUint32(nil, attrPermissions)
+	attrs = binary.BigEndian.AppendUint32(attrs, uint32(mode.Perm()))
+
+	open := appendString(nil, path)
+	open = binary.BigEndian.AppendUint32(open, flags)
+	handle, err := c.requestHandle(packetOpen, append(open, attrs...))
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	written, err := c.writeHandle(handle, src, attrs)
+	if closeErr := c.requestStatus(packetClose, appendString(nil, handle)); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close %s: %w", path, closeErr)
+	}
+	return written, err
+}
+
+// Open opens the file at path for reading. The returned File reads it sequentially from the start
+// and must be closed.
+func (c *Client) Open(path string) (*File, error) {
+	open := appendString(nil, path)
+	open = binary.BigEndian.AppendUint32(open, openRead)
+	// no attributes
+	open = binary.BigEndian.AppendUint32(open, 0)
+	handle, err := c.requestHandle(packetOpen, open)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return &File{client: c, path: path, handle: handle}, nil
+}
+
+// Remove removes the file at path.
+func (c *Client) Remove(path string) error {
+	if err := c.requestStatus(packetRemove, appendString(nil, path)); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// Rename renames the file at oldPath to newPath, failing when newPath already exists. With
+// overwrite an existing newPath is replaced instead, atomically when the server supports the
+// posix-rename@openssh.com extension and otherwise by removing it first.
+func (c *Client) Rename(oldPath string, newPath string, overwrite bool) error {
+	paths := appendString(appendString(nil, oldPath), newPath)
+	var err error
+	switch {
+	case overwrite && c.extensions[extensionPosixRename]:
+		err = c.requestStatus(packetExtended, append(appendString(nil, extensionPosixRename), paths...))
+	case overwrite:
+		var statusErr *StatusError
+		if err = c.requestStatus(packetRemove, appendString(nil, newPath)); errors.As(err, &statusErr) && statusErr.Code == statusNoSuchFile {
+			err = nil
+		}
+		if err == nil {
+			err = c.requestStatus(packetRename, paths)
+		}
+	default:
+		err = c.requestStatus(packetRename, paths)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", oldPath, newPath, err)
+	}
+	return nil
+}
+
+// writeHandle sets the attributes of the open file, as the mode given when opening is masked by the
+// umask and ignored for existing files, then copies src into it.
+func (c *Client) writeHandle(handle string, src io.Reader, attrs []byte) (int64, error) {
+	if err := c.requestStatus(packetFSetStat, append(appendString(nil, handle), attrs...)); err != nil {
+		return 0, fmt.Errorf("failed to set mode: %w", err)
+	}
+
+	var written int64
+	buf := make([]byte, maxWriteSize)
+	for {
+		n, readErr := io.ReadFull(src, buf)
+		if n > 0 {
+			payload := appendString(nil, handle)
+			payload = binary.BigEndian.AppendUint64(payload, uint64(written))
+			payload = appendString(payload, string(buf[:n]))
+			if err := c.requestStatus(packetWrite, payload); err != nil {
+				return written, fmt.Errorf("failed to write at offset %d: %w", written, err)
+			}
+			written += int64(n)
+		}
+		switch {
+		case errors.Is(readErr, io.EOF), errors.Is(readErr, io.ErrUnexpectedEOF):
+			return written, nil
+		case readErr != nil:
+			return written, fmt.Errorf("failed to read the source: %w", readErr)
+		}
+	}
+}
+
+// File is a remote file opened for reading with Open. Like its Client, a File must not be used
+// from several goroutines at once.
+type File struct {
+	client *Client
+	path   string
+	handle string
+	offset uint64
+}
+
+// Read reads the next bytes of the file into p, returning io.EOF at the end of the file.
+func (f *File) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	length := min(len(p), maxReadSize)
+	payload := appendString(nil, f.handle)
+	payload = binary.BigEndian.AppendUint64(payload, f.offset)
+	payload = binary.BigEndian.AppendUint32(payload, uint32(length))
+	respType, resp, err := f.client.request(packetRead, payload)
+	if err != nil {
+		return 0, err
+	}
+	switch respType {
+	case packetData:
+		data, _, ok := readString(resp)
+		if !ok || len(data) > length {
+			return 0, errors.New("sftp: malformed data")
+		}
+		n := copy(p, data)
+		f.offset += uint64(n)
+		return n, nil
+	case packetStatus:
+		err := parseStatus(resp)
+		var statusErr *StatusError
+		switch {
+		case errors.As(err, &statusErr) && statusErr.Code == statusEOF:
+			return 0, io.EOF
+		case err == nil:
+			return 0, errors.New("sftp: read answered without data")
+		}
+		return 0, fmt.Errorf("failed to read %s at offset %d: %w", f.path, f.offset, err)
+	}
+	return 0, fmt.Errorf("sftp: unexpected packet type %d", respType)
+}
+
+// Mode returns the permission bits of the file.
+func (f *File) Mode() (os.FileMode, error) {
+	respType, resp, err := f.client.request(packetFStat, appendString(nil, f.handle))
+	if err != nil {
+		return 0, err
+	}
+	switch respType {
+	case packetAttrs:
+		mode, ok := parsePermissions(resp)
+		if !ok {
+			return 0, fmt.Errorf("sftp: no permissions returned for %s", f.path)
+		}
+		return mode, nil
+	case packetStatus:
+		if err := parseStatus(resp); err != nil {
+			return 0, fmt.Errorf("failed to stat %s: %w", f.path, err)
+		}
+		return 0, errors.New("sftp: stat answered without attributes")
+	}
+	return 0, fmt.Errorf("sftp: unexpected packet type %d", respType)
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	if err := f.client.requestStatus(packetClose, appendString(nil, f.handle)); err != nil {
+		return fmt.Errorf("failed to close %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// requestHandle sends a request answered with a handle.
+func (c *Client) requestHandle(typ byte, payload []byte) (string, error) {
+	respType, resp, err := c.request(typ, payload)
+	if err != nil {
+		return "", err
+	}
+	switch respType {
+	case packetHandle:
+		handle, _, ok := readString(resp)
+		if !ok {
+			return "", errors.New("sftp: malformed handle")
+		}
+		return handle, nil
+	case packetStatus:
+		return "", parseStatus(resp)
+	}
+	return "", fmt.Errorf("sftp: unexpected packet type %d", respType)
+}
+
+// requestStatus sends a request answered with a status, returning nil for an OK status.
+func (c *Client) requestStatus(typ byte, payload []byte) error {
+	respType, resp, err := c.request(typ, payload)
+	if err != nil {
+		return err
+	}
+	if respType != packetStatus {
+		return fmt.Errorf("sftp: unexpected packet type %d", respType)
+	}
+	return parseStatus(resp)
+}
+
+// request sends a request with the next id and returns the type and payload of its response
+// without the id.
+func (c *Client) request(typ byte, payload []byte) (byte, []byte, error) {
+	c.nextID++
+	id := c.nextID
+	if err := c.writePacket(typ, append(binary.BigEndian.AppendUint32(nil, id), payload...)); err != nil {
+		return 0, nil, err
+	}
+	respType, resp, err := c.readPacket()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(resp) < 4 || binary.BigEndian.Uint32(resp) != id {
+		return 0, nil, errors.New("sftp: response does not match the request")
+	}
+	return respType, resp[4:], nil
+}
+
+// writePacket writes a length prefixed packet.
+func (c *Client) writePacket(typ byte, payload []byte) error {
+	packet := binary.BigEndian.AppendUint32(make([]byte, 0, 5+len(payload)), uint32(1+len(payload)))
+	packet = append(packet, typ)
+	_, err := c.w.Write(append(packet, payload...))
+	return err
+}
+
+// readPacket reads a length prefixed packet.
+func (c *Client) readPacket() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > maxPacketSize {
+		return 0, nil, fmt.Errorf("sftp: invalid packet length %d", length)
+	}
+	payload := make([]byte, length-1)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[4], payload, nil
+}
+
+// parseStatus returns the error of a status payload, nil for OK.
+func parseStatus(payload []byte) error {
+	if len(payload) < 4 {
+		return errors.New("sftp: malformed status")
+	}
+	code := binary.BigEndian.Uint32(payload)
+	if code == statusOK {
+		return nil
+	}
+	// the message is optional in servers predating version 3
+	message, _, _ := readString(payload[4:])
+	return &StatusError{Code: code, Message: message}
+}
+
+// parsePermissions returns the permission bits of file attributes, the fields before them are
+// skipped.
+func parsePermissions(attrs []byte) (os.FileMode, bool) {
+	if len(attrs) < 4 {
+		return 0, false
+	}
+	flags := binary.BigEndian.Uint32(attrs)
+	attrs = attrs[4:]
+	if flags&attrSize != 0 {
+		if len(attrs) < 8 {
+			return 0, false
+		}
+		attrs = attrs[8:]
+	}
+	if flags&attrUIDGID != 0 {
+		if len(attrs) < 8 {
+			return 0, false
+		}
+		attrs = attrs[8:]
+	}
+	if flags&attrPermissions == 0 || len(attrs) < 4 {
+		return 0, false
+	}
+	return os.FileMode(binary.BigEndian.Uint32(attrs)).Perm(), true
+}
+
+// appendString appends an SFTP string, a length prefixed byte sequence.
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// readString reads an SFTP string, returning the rest of the payload.
+func readString(b []byte) (string, []byte, bool) {
+	if len(b) < 4 {
+		return "", nil, false
+	}
+	length := binary.BigEndian.Uint32(b)
+	if uint64(len(b)-4) < uint64(length) {
+		return "", nil, false
+	}
+	return string(b[4 : 4+length]), b[4+length:], true
+}
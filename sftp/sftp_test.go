@@ -0,0 +1,307 @@
+package sftp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFile is a file written to the fake server.
+type fakeFile struct {
+	data []byte
+	mode uint32
+}
+
+// fakeServer implements the subset of an SFTP server used by the client on in-memory files.
+type fakeServer struct {
+	mu      sync.Mutex
+	files   map[string]*fakeFile
+	handles map[string]string
+	writes  int
+	// denied paths are answered with permission denied when opened
+	denied map[string]bool
+	// posixRename announces and implements the posix-rename@openssh.com extension
+	posixRename bool
+}
+
+// start connects a client to the server.
+func (s *fakeServer) start(t *testing.T) *Client {
+	if s.files == nil {
+		s.files = map[string]*fakeFile{}
+	}
+	s.handles = map[string]string{}
+	requestsR, requestsW := io.Pipe()
+	responsesR, responsesW := io.Pipe()
+	go s.serve(requestsR, responsesW)
+
+	client, err := NewClientPipe(responsesR, requestsW)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func (s *fakeServer) serve(r io.Reader, w io.WriteCloser) {
+	defer w.Close()
+	c := &Client{r: r, w: w}
+	for {
+		typ, payload, err := c.readPacket()
+		if err != nil {
+			return
+		}
+		if typ == packetInit {
+			version := binary.BigEndian.AppendUint32(nil, protocolVersion)
+			if s.posixRename {
+				version = appendString(appendString(version, extensionPosixRename), "1")
+			}
+			_ = c.writePacket(packetVersion, version)
+			continue
+		}
+		id := payload[:4]
+		respType, resp := s.handle(typ, payload[4:])
+		_ = c.writePacket(respType, append(append([]byte(nil), id...), resp...))
+	}
+}
+
+func (s *fakeServer) handle(typ byte, payload []byte) (byte, []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch typ {
+	case packetOpen:
+		path, rest, _ := readString(payload)
+		flags := binary.BigEndian.Uint32(rest)
+		if s.denied[path] {
+			return packetStatus, fakeStatus(statusPermissionDenied, path)
+		}
+		file, exists := s.files[path]
+		if flags&openWrite == 0 && !exists {
+			return packetStatus, fakeStatus(statusNoSuchFile, path)
+		}
+		if exists && flags&openExclude != 0 {
+			return packetStatus, fakeStatus(statusFailure, "file exists")
+		}
+		if !exists {
+			file = &fakeFile{mode: binary.BigEndian.Uint32(rest[8:])}
+			s.files[path] = file
+		}
+		if flags&openTruncate != 0 {
+			file.data = nil
+		}
+		handle := strconv.Itoa(len(s.handles))
+		s.handles[handle] = path
+		return packetHandle, appendString(nil, handle)
+	case packetFSetStat:
+		handle, rest, _ := readString(payload)
+		s.files[s.handles[handle]].mode = binary.BigEndian.Uint32(rest[4:])
+	case packetWrite:
+		handle, rest, _ := readString(payload)
+		offset := binary.BigEndian.Uint64(rest)
+		data, _, _ := readString(rest[8:])
+		file := s.files[s.handles[handle]]
+		file.data = append(file.data[:offset], data...)
+		s.writes++
+	case packetRead:
+		handle, rest, _ := readString(payload)
+		offset := binary.BigEndian.Uint64(rest)
+		length := binary.BigEndian.Uint32(rest[8:])
+		data := s.files[s.handles[handle]].data
+		if offset >= uint64(len(data)) {
+			return packetStatus, fakeStatus(statusEOF, "")
+		}
+		// short reads are allowed, return at most a third of the requested bytes
+		end := min(uint64(len(data)), offset+uint64(max(length/3, 1)))
+		return packetData, appendString(nil, string(data[offset:end]))
+	case packetFStat:
+		handle, _, _ := readString(payload)
+		file := s.files[s.handles[handle]]
+		attrs := binary.BigEndian.AppendUint32(nil, attrSize|attrPermissions)
+		attrs = binary.BigEndian.AppendUint64(attrs, uint64(len(file.data)))
+		// the file type bits are set as well, as real servers do
+		attrs = binary.BigEndian.AppendUint32(attrs, 0o100000|file.mode)
+		return packetAttrs, attrs
+	case packetClose:
+		handle, _, _ := readString(payload)
+		delete(s.handles, handle)
+	case packetRemove:
+		path, _, _ := readString(payload)
+		if _, exists := s.files[path]; !exists {
+			return packetStatus, fakeStatus(statusNoSuchFile, path)
+		}
+		delete(s.files, path)
+	case packetRename:
+		oldPath, rest, _ := readString(payload)
+		newPath, _, _ := readString(rest)
+		if _, exists := s.files[newPath]; exists {
+			return packetStatus, fakeStatus(statusFailure, "file exists")
+		}
+		return packetStatus, s.rename(oldPath, newPath)
+	case packetExtended:
+		name, rest, _ := readString(payload)
+		if !s.posixRename || name != extensionPosixRename {
+			return packetStatus, fakeStatus(8, "unsupported")
+		}
+		oldPath, rest, _ := readString(rest)
+		newPath, _, _ := readString(rest)
+		return packetStatus, s.rename(oldPath, newPath)
+	default:
+		return packetStatus, fakeStatus(8, "unsupported")
+	}
+	return packetStatus, fakeStatus(statusOK, "")
+}
+
+// rename moves the file at oldPath to newPath, replacing any file there.
+func (s *fakeServer) rename(oldPath string, newPath string) []byte {
+	file, exists := s.files[oldPath]
+	if !exists {
+		return fakeStatus(statusNoSuchFile, oldPath)
+	}
+	delete(s.files, oldPath)
+	s.files[newPath] = file
+	return fakeStatus(statusOK, "")
+}
+
+func fakeStatus(code uint32, message string) []byte {
+	status := binary.BigEndian.AppendUint32(nil, code)
+	status = appendString(status, message)
+	return appendString(status, "en")
+}
+
+func TestWriteFile(t *testing.T) {
+	server := &fakeServer{}
+	client := server.start(t)
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), maxWriteSize/8+3)
+	written, err := client.WriteFile("/tmp/app.tar", bytes.NewReader(data), 0o640, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), written)
+	require.Equal(t, data, server.files["/tmp/app.tar"].data)
+	require.Equal(t, uint32(0o640), server.files["/tmp/app.tar"].mode)
+	// split into write requests of at most maxWriteSize bytes
+	require.Equal(t, 3, server.writes)
+	require.Empty(t, server.handles)
+}
+
+func TestWriteFile_Empty(t *testing.T) {
+	server := &fakeServer{}
+	client := server.start(t)
+
+	written, err := client.WriteFile("/tmp/empty", bytes.NewReader(nil), 0o644, false)
+	require.NoError(t, err)
+	require.Zero(t, written)
+	require.Contains(t, server.files, "/tmp/empty")
+}
+
+func TestWriteFile_Overwrite(t *testing.T) {
+	server := &fakeServer{}
+	client := server.start(t)
+
+	_, err := client.WriteFile("/etc/motd", bytes.NewReader([]byte("first version")), 0o644, false)
+	require.NoError(t, err)
+
+	_, err = client.WriteFile("/etc/motd", bytes.NewReader([]byte("second")), 0o600, false)
+	require.ErrorContains(t, err, "file exists")
+	require.Equal(t, "first version", string(server.files["/etc/motd"].data))
+
+	_, err = client.WriteFile("/etc/motd", bytes.NewReader([]byte("second")), 0o600, true)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(server.files["/etc/motd"].data))
+	require.Equal(t, uint32(0o600), server.files["/etc/motd"].mode)
+}
+
+func TestWriteFile_StatusError(t *testing.T) {
+	server := &fakeServer{denied: map[string]bool{"/root/key": true}}
+	client := server.start(t)
+
+	_, err := client.WriteFile("/root/key", bytes.NewReader([]byte("secret")), os.FileMode(0o600), true)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, uint32(statusPermissionDenied), statusErr.Code)
+	require.EqualError(t, err, "failed to open /root/key: sftp: permission denied: /root/key")
+}
+
+func TestOpen(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), maxReadSize/8+3)
+	server := &fakeServer{files: map[string]*fakeFile{"/var/log/app.log": {data: data}}}
+	client := server.start(t)
+
+	file, err := client.Open("/var/log/app.log")
+	require.NoError(t, err)
+	read, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.Equal(t, data, read)
+	require.NoError(t, file.Close())
+	require.Empty(t, server.handles)
+
+	_, err = client.Open("/var/log/missing.log")
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, uint32(statusNoSuchFile), statusErr.Code)
+}
+
+func TestFileMode(t *testing.T) {
+	server := &fakeServer{files: map[string]*fakeFile{"/usr/local/bin/deploy": {data: []byte("#!/bin/sh"), mode: 0o750}}}
+	client := server.start(t)
+
+	file, err := client.Open("/usr/local/bin/deploy")
+	require.NoError(t, err)
+	defer file.Close()
+	mode, err := file.Mode()
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o750), mode)
+}
+
+func TestParsePermissions(t *testing.T) {
+	// size, uid and gid are skipped
+	attrs := binary.BigEndian.AppendUint32(nil, attrSize|attrUIDGID|attrPermissions)
+	attrs = binary.BigEndian.AppendUint64(attrs, 42)
+	attrs = binary.BigEndian.AppendUint32(attrs, 1000)
+	attrs = binary.BigEndian.AppendUint32(attrs, 1000)
+	attrs = binary.BigEndian.AppendUint32(attrs, 0o104755)
+	mode, ok := parsePermissions(attrs)
+	require.True(t, ok)
+	require.Equal(t, os.FileMode(0o755), mode)
+
+	_, ok = parsePermissions(binary.BigEndian.AppendUint32(nil, attrSize))
+	require.False(t, ok)
+	_, ok = parsePermissions(nil)
+	require.False(t, ok)
+}
+
+func TestRemove(t *testing.T) {
+	server := &fakeServer{files: map[string]*fakeFile{"/tmp/a": {}}}
+	client := server.start(t)
+
+	require.NoError(t, client.Remove("/tmp/a"))
+	require.NotContains(t, server.files, "/tmp/a")
+	require.ErrorContains(t, client.Remove("/tmp/a"), "no such file")
+}
+
+func TestRename(t *testing.T) {
+	for name, posixRename := range map[string]bool{"posix-rename": true, "rename": false} {
+		t.Run(name, func(t *testing.T) {
+			server := &fakeServer{posixRename: posixRename, files: map[string]*fakeFile{
+				"/tmp/new.partial": {data: []byte("new")},
+				"/etc/app.conf":    {data: []byte("old")},
+			}}
+			client := server.start(t)
+
+			// an existing file is only replaced with overwrite
+			err := client.Rename("/tmp/new.partial", "/etc/app.conf", false)
+			require.ErrorContains(t, err, "file exists")
+			require.Equal(t, "old", string(server.files["/etc/app.conf"].data))
+
+			require.NoError(t, client.Rename("/tmp/new.partial", "/etc/app.conf", true))
+			require.Equal(t, "new", string(server.files["/etc/app.conf"].data))
+			require.NotContains(t, server.files, "/tmp/new.partial")
+
+			// overwrite does not need an existing file
+			require.NoError(t, client.Rename("/etc/app.conf", "/etc/app2.conf", true))
+			require.Equal(t, "new", string(server.files["/etc/app2.conf"].data))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// AgentStatus describes the SSH agent that SSH_AUTH_SOCK points to.
+type AgentStatus struct {
+	// Socket is the value of SSH_AUTH_SOCK
+	Socket string `json:"socket"`
+	// Reachable is true when a connection to the socket succeeded
+	Reachable bool `json:"reachable"`
+	// Problem explains why the agent could not be used
+	Problem string `json:"problem,omitempty"`
+}
+
+// dialAgent connects to the agent at SSH_AUTH_SOCK. Both the connection and the status are nil
+// when SSH_AUTH_SOCK is not set, the connection is nil when the agent is unreachable.
+func dialAgent() (net.Conn, *AgentStatus) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, nil
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, &AgentStatus{Socket: socket, Problem: fmt.Sprintf("SSH_AUTH_SOCK is set to %s but the agent is unreachable: %v", socket, err)}
+	}
+	return conn, &AgentStatus{Socket: socket, Reachable: true}
+}
+
+// CheckAgent reports whether SSH_AUTH_SOCK points to a reachable agent, nil when it is not set.
+func CheckAgent() *AgentStatus {
+	conn, status := dialAgent()
+	if conn != nil {
+		conn.Close()
+	}
+	return status
+}
+
+// agentHint adds the reason the agent could not be used to an authentication failure, as the
+// keys the user expected to be offered were never tried. Any other error is returned unchanged.
+func agentHint(err error, status *AgentStatus) error {
+	if status == nil || status.Reachable || !strings.Contains(err.Error(), "unable to authenticate") {
+		return err
+	}
+	return fmt.Errorf("%w (%s)", err, status.Problem)
+}
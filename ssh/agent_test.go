@@ -0,0 +1,63 @@
+package ssh
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckAgent_Unset(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	if status := CheckAgent(); status != nil {
+		t.Errorf("expected no status without SSH_AUTH_SOCK, got %+v", status)
+	}
+}
+
+func TestCheckAgent_DeadSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	t.Setenv("SSH_AUTH_SOCK", socket)
+
+	status := CheckAgent()
+	if status == nil {
+		t.Fatal("expected a status for a set SSH_AUTH_SOCK")
+	}
+	if status.Reachable {
+		t.Error("expected the agent to be unreachable")
+	}
+	if status.Socket != socket || !strings.Contains(status.Problem, socket) {
+		t.Errorf("expected the problem to name the socket, got %+v", status)
+	}
+}
+
+func TestBuildAuthMethods_DeadAgentKeepsOtherMethods(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", filepath.Join(t.TempDir(), "agent.sock"))
+	t.Setenv("HOME", t.TempDir())
+
+	methods, status, err := buildAuthMethods("secret", "", "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(methods) != 1 {
+		t.Errorf("expected only the password method, got %d methods", len(methods))
+	}
+	if status == nil || status.Reachable {
+		t.Errorf("expected an unreachable agent status, got %+v", status)
+	}
+}
+
+func TestAgentHint(t *testing.T) {
+	dead := &AgentStatus{Socket: "/tmp/agent.sock", Problem: "SSH_AUTH_SOCK is set to /tmp/agent.sock but the agent is unreachable"}
+	authErr := errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none password], no supported methods remain")
+
+	if err := agentHint(authErr, dead); !strings.Contains(err.Error(), "agent is unreachable") || !errors.Is(err, authErr) {
+		t.Errorf("expected the agent problem to be added to an authentication failure, got %v", err)
+	}
+	if err := agentHint(authErr, nil); err != authErr {
+		t.Errorf("expected the error unchanged without SSH_AUTH_SOCK, got %v", err)
+	}
+	refused := errors.New("dial tcp 10.0.0.1:22: connect: connection refused")
+	if err := agentHint(refused, dead); err != refused {
+		t.Errorf("expected a non-authentication error unchanged, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigHost is a single host entry parsed from an OpenSSH client config file.
+type ConfigHost struct {
+	Alias        string
+	HostName     string
+	Port         string
+	User         string
+	IdentityFile string
+}
+
+// SkippedConfigHost is a host pattern from an OpenSSH client config file that could not be used.
+type SkippedConfigHost struct {
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason"`
+}
+
+// ParseConfig parses the Host blocks of an OpenSSH client config file.
+// Wildcard and negated patterns are skipped since they do not name a single host.
+func ParseConfig(r io.Reader) ([]ConfigHost, []SkippedConfigHost, error) {
+	var hosts []ConfigHost
+	var skipped []SkippedConfigHost
+
+	// current holds the indexes into hosts that the following options apply to
+	var current []int
+	inMatch := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keyword, value := splitConfigLine(line)
+		switch strings.ToLower(keyword) {
+		case "host":
+			current = nil
+			inMatch = false
+			for _, pattern := range strings.Fields(value) {
+				if strings.ContainsAny(pattern, "*?!") {
+					skipped = append(skipped, SkippedConfigHost{Pattern: pattern, Reason: "wildcard or negated pattern"})
+					continue
+				}
+				hosts = append(hosts, ConfigHost{Alias: pattern})
+				current = append(current, len(hosts)-1)
+			}
+			continue
+		case "match":
+			current = nil
+			inMatch = true
+			skipped = append(skipped, SkippedConfigHost{Pattern: "Match " + value, Reason: "match blocks are not supported"})
+			continue
+		}
+
+		if inMatch {
+			continue
+		}
+
+		// the first value obtained for each option wins, same as OpenSSH
+		for _, i := range current {
+			h := &hosts[i]
+			switch strings.ToLower(keyword) {
+			case "hostname":
+				if h.HostName == "" {
+					h.HostName = value
+				}
+			case "port":
+				if h.Port == "" {
+					h.Port = value
+				}
+			case "user":
+				if h.User == "" {
+					h.User = value
+				}
+			case "identityfile":
+				if h.IdentityFile == "" {
+					h.IdentityFile = ExpandHome(value)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read ssh config: %w", err)
+	}
+	return hosts, skipped, nil
+}
+
+// ToClientInfo converts the config entry into client information named after the host alias.
+func (h ConfigHost) ToClientInfo() ClientInfo {
+	host := h.HostName
+	if host == "" {
+		host = h.Alias
+	}
+	port := h.Port
+	if port == "" {
+		port = "22"
+	}
+	return ClientInfo{
+		Name:    h.Alias,
+		Host:    host,
+		Port:    port,
+		User:    h.User,
+		KeyPath: h.IdentityFile,
+	}
+}
+
+// splitConfigLine splits a config line into its keyword and value.
+// Both "Keyword value" and "Keyword=value" forms are supported.
+func splitConfigLine(line string) (string, string) {
+	idx := strings.IndexAny(line, " \t=")
+	if idx < 0 {
+		return line, ""
+	}
+	keyword := line[:idx]
+	value := strings.TrimSpace(line[idx:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	return keyword, strings.Trim(value, `"`)
+}
+
+// ExpandHome expands a leading ~ to the user's home directory.
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
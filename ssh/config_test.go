@@ -0,0 +1,71 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	config := `
+# global defaults
+Host *
+    User ignored
+
+Host web01 web02
+    HostName 10.0.1.5
+    Port 2222
+    User deploy
+    IdentityFile ~/.ssh/deploy_key
+
+Host db01
+    HostName=10.0.2.10
+    User=postgres
+    User=second
+
+Host *.internal !bastion
+    User internal
+
+Match host foo
+    User matched
+`
+	hosts, skipped, err := ParseConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(hosts) != 3 {
+		t.Fatalf("expected 3 hosts, got %d", len(hosts))
+	}
+	if len(skipped) != 4 {
+		t.Errorf("expected 4 skipped patterns, got %d: %v", len(skipped), skipped)
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	for _, h := range hosts[:2] {
+		if h.HostName != "10.0.1.5" || h.Port != "2222" || h.User != "deploy" {
+			t.Errorf("unexpected host entry: %+v", h)
+		}
+		if h.IdentityFile != filepath.Join(homeDir, ".ssh", "deploy_key") {
+			t.Errorf("expected identity file to be expanded, got '%s'", h.IdentityFile)
+		}
+	}
+
+	if hosts[2].Alias != "db01" || hosts[2].HostName != "10.0.2.10" {
+		t.Errorf("unexpected host entry: %+v", hosts[2])
+	}
+	if hosts[2].User != "postgres" {
+		t.Errorf("expected first user value to win, got '%s'", hosts[2].User)
+	}
+}
+
+func TestConfigHost_ToClientInfo_Defaults(t *testing.T) {
+	info := ConfigHost{Alias: "server"}.ToClientInfo()
+	if info.Name != "server" || info.Host != "server" {
+		t.Errorf("expected alias as name and host, got %+v", info)
+	}
+	if info.Port != "22" {
+		t.Errorf("expected default port '22', got '%s'", info.Port)
+	}
+}
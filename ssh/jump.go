@@ -0,0 +1,65 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxJumpHops is the most jump hosts a connection goes through, which also stops jump hosts that
+// reference each other.
+const maxJumpHops = 5
+
+// jumpHostResolver resolves a 'group:name' jump host reference to a stored host, nil when not set.
+var jumpHostResolver func(ref string) (ClientInfo, bool)
+
+// SetJumpHostResolver sets the function resolving a jump_host reference to a stored host, e.g. by
+// looking up 'group:name' in storage. References it does not resolve are parsed as connection
+// strings. It must be safe for concurrent use.
+func SetJumpHostResolver(fn func(ref string) (ClientInfo, bool)) {
+	jumpHostResolver = fn
+}
+
+// resolveJumpHost returns the host a jump_host reference points to: a stored host when the resolver
+// knows it, otherwise the host of the connection string.
+func resolveJumpHost(ref string) (ClientInfo, error) {
+	if jumpHostResolver != nil {
+		if info, ok := jumpHostResolver(ref); ok {
+			return info, nil
+		}
+	}
+	info, err := NewClientInfo("", ref)
+	if err != nil {
+		return ClientInfo{}, fmt.Errorf("invalid jump host %q, it is neither a stored 'group:name' host nor a connection string: %w", ref, err)
+	}
+	return *info, nil
+}
+
+// connectJumpHost connects to the client's jump host, itself through its own jump host when it has
+// one. The jump connection does not take a connection slot, it is part of the client's connection.
+func (c *Client) connectJumpHost(ctx context.Context) (*Client, error) {
+	if c.hops >= maxJumpHops {
+		return nil, fmt.Errorf("more than %d jump hosts to reach %s, check jump_host for a loop", maxJumpHops, c.info.Name)
+	}
+	info, err := resolveJumpHost(c.info.JumpHost)
+	if err != nil {
+		return nil, err
+	}
+	jump := &Client{info: &info, retry: c.retry, hops: c.hops + 1}
+	slog.Debug("connecting to jump host", "host", c.info.Name, "jump_host", c.info.JumpHost)
+	if err := jump.connect(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to jump host %s: %w", c.info.JumpHost, err)
+	}
+	return jump, nil
+}
+
+// dialThrough opens an SSH connection to addr tunnelled through the connected jump client.
+func dialThrough(ctx context.Context, jump *ssh.Client, addr string, cfg *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := jump.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("jump host failed to reach %s: %w", addr, err)
+	}
+	return clientHandshake(ctx, conn, addr, cfg)
+}
@@ -0,0 +1,163 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// startJumpTestServer starts an SSH server accepting the password. With forward it acts as a jump
+// host, forwarding direct-tcpip channels and recording their destinations.
+func startJumpTestServer(t *testing.T, password string, forward bool) (string, *[]string) {
+	t.Helper()
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) != password {
+				return nil, errPasswordRejected
+			}
+			return nil, nil
+		},
+	}
+
+	var mu sync.Mutex
+	var forwarded []string
+	server := startTestServer(t, config, func(conn *ssh.ServerConn, chans <-chan ssh.NewChannel, reqs <-chan *ssh.Request) {
+		go ssh.DiscardRequests(reqs)
+		for newChannel := range chans {
+			if !forward || newChannel.ChannelType() != "direct-tcpip" {
+				_ = newChannel.Reject(ssh.UnknownChannelType, "not supported")
+				continue
+			}
+			// host and port to connect to, followed by the originator
+			data := newChannel.ExtraData()
+			length := binary.BigEndian.Uint32(data)
+			addr := net.JoinHostPort(string(data[4:4+length]), strconv.Itoa(int(binary.BigEndian.Uint32(data[4+length:]))))
+			mu.Lock()
+			forwarded = append(forwarded, addr)
+			mu.Unlock()
+			target, err := net.Dial("tcp", addr)
+			if err != nil {
+				_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			channel, channelReqs, err := newChannel.Accept()
+			if err != nil {
+				target.Close()
+				continue
+			}
+			go ssh.DiscardRequests(channelReqs)
+			go func() {
+				_, _ = io.Copy(target, channel)
+				target.Close()
+			}()
+			go func() {
+				_, _ = io.Copy(channel, target)
+				channel.Close()
+			}()
+		}
+	})
+	return server.addr, &forwarded
+}
+
+// errPasswordRejected is returned by the test servers for a wrong password.
+var errPasswordRejected = errors.New("password rejected")
+
+// useInMemoryKnownHosts trusts new host keys in memory for the duration of the test.
+func useInMemoryKnownHosts(t *testing.T) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	require.NoError(t, SetKnownHostsData("unrelated.invalid "+string(ssh.MarshalAuthorizedKey(signer.PublicKey()))))
+	t.Cleanup(func() { _ = SetKnownHostsData("") })
+}
+
+func TestClient_ConnectThroughJumpHost(t *testing.T) {
+	useInMemoryKnownHosts(t)
+	jumpAddr, forwarded := startJumpTestServer(t, "jump-secret", true)
+	targetAddr, _ := startJumpTestServer(t, "target-secret", false)
+	targetHost, targetPort, err := net.SplitHostPort(targetAddr)
+	require.NoError(t, err)
+
+	info := &ClientInfo{
+		Name:     "db1",
+		Host:     targetHost,
+		Port:     targetPort,
+		User:     "deploy",
+		Pass:     "target-secret",
+		JumpHost: "ops:jump-secret@" + jumpAddr,
+	}
+	client := NewClient(info)
+	client.SetRetryPolicy(RetryPolicy{Attempts: 1})
+	require.NoError(t, client.Connect())
+	require.NotNil(t, client.jump)
+	require.Equal(t, []string{targetAddr}, *forwarded)
+	require.Equal(t, AuthMethodPassword, client.AuthMethod())
+	require.NoError(t, client.Close())
+}
+
+func TestClient_JumpHostFails(t *testing.T) {
+	useInMemoryKnownHosts(t)
+	jumpAddr, _ := startJumpTestServer(t, "jump-secret", true)
+
+	info := &ClientInfo{
+		Name:     "db1",
+		Host:     "127.0.0.1",
+		Port:     "1",
+		User:     "deploy",
+		Pass:     "target-secret",
+		JumpHost: "ops:wrong@" + jumpAddr,
+	}
+	client := NewClient(info)
+	client.SetRetryPolicy(RetryPolicy{Attempts: 1})
+	err := client.Connect()
+	require.ErrorContains(t, err, "failed to connect to jump host ops:wrong@"+jumpAddr)
+	require.Nil(t, client.jump)
+}
+
+func TestResolveJumpHost(t *testing.T) {
+	SetJumpHostResolver(func(ref string) (ClientInfo, bool) {
+		if ref == "infra:bastion" {
+			return ClientInfo{Group: "infra", Name: "bastion", Host: "10.0.0.1", Port: "22", User: "ops"}, true
+		}
+		return ClientInfo{}, false
+	})
+	t.Cleanup(func() { SetJumpHostResolver(nil) })
+
+	info, err := resolveJumpHost("infra:bastion")
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", info.Host)
+	require.Equal(t, "ops", info.User)
+
+	// not a stored host, so a connection string
+	info, err = resolveJumpHost("ssh://jump@bastion.example.com:2222")
+	require.NoError(t, err)
+	require.Equal(t, "bastion.example.com", info.Host)
+	require.Equal(t, "2222", info.Port)
+	require.Equal(t, "jump", info.User)
+
+	_, err = resolveJumpHost("ftp://bastion")
+	require.ErrorContains(t, err, "neither a stored 'group:name' host nor a connection string")
+}
+
+func TestClient_JumpHostLoop(t *testing.T) {
+	SetJumpHostResolver(func(ref string) (ClientInfo, bool) {
+		return ClientInfo{Group: "infra", Name: "bastion", Host: "10.0.0.1", Port: "22", Pass: "secret", JumpHost: "infra:bastion"}, true
+	})
+	t.Cleanup(func() { SetJumpHostResolver(nil) })
+
+	client := NewClient(&ClientInfo{Name: "db1", Host: "10.0.1.1", Port: "22", Pass: "secret", JumpHost: "infra:bastion"})
+	_, err := client.connectJumpHost(context.Background())
+	require.ErrorContains(t, err, "check jump_host for a loop")
+}
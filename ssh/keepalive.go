@@ -0,0 +1,157 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// keepAliveRequest is the global request OpenSSH clients send to check the server still answers.
+const keepAliveRequest = "keepalive@openssh.com"
+
+// KeepAlivePolicy controls the keepalive requests sent on established connections, like the
+// ServerAliveInterval and ServerAliveCountMax options of OpenSSH.
+type KeepAlivePolicy struct {
+	// Interval is the time between keepalive requests, 0 disables them
+	Interval time.Duration
+	// MaxMissed is the number of keepalive requests in a row left unanswered after which the
+	// connection is closed as dead
+	MaxMissed int
+}
+
+// DefaultKeepAlivePolicy returns the policy used when none is configured.
+func DefaultKeepAlivePolicy() KeepAlivePolicy {
+	return KeepAlivePolicy{
+		Interval:  30 * time.Second,
+		MaxMissed: 3,
+	}
+}
+
+// defaultKeepAlive is the policy of every new connection.
+var defaultKeepAlive = DefaultKeepAlivePolicy()
+
+// SetDefaultKeepAlivePolicy sets the keepalive policy of every new connection.
+func SetDefaultKeepAlivePolicy(policy KeepAlivePolicy) {
+	defaultKeepAlive = policy
+}
+
+// watchConnection returns a channel closed once the connection ends, for any reason. Until then it
+// sends keepalive requests by the default policy and closes the connection when the server stops
+// answering them, so a dead network fails the sessions on it instead of leaving them hanging.
+func watchConnection(name string, conn *ssh.Client) <-chan struct{} {
+	lost := make(chan struct{})
+	go func() {
+		_ = conn.Wait()
+		close(lost)
+	}()
+	if policy := defaultKeepAlive; policy.Interval > 0 {
+		go keepAlive(name, conn, lost, policy)
+	}
+	return lost
+}
+
+// keepAlive sends a keepalive request every interval until the connection is lost. A request
+// counts as answered on any reply, servers that do not know it reply with a failure.
+func keepAlive(name string, conn *ssh.Client, lost <-chan struct{}, policy KeepAlivePolicy) {
+	ticker := time.NewTicker(policy.Interval)
+	defer ticker.Stop()
+
+	missed := 0
+	for {
+		select {
+		case <-lost:
+			return
+		case <-ticker.C:
+		}
+
+		replied := make(chan error, 1)
+		go func() {
+			_, _, err := conn.SendRequest(keepAliveRequest, true, nil)
+			replied <- err
+		}()
+		timeout := time.NewTimer(policy.Interval)
+		select {
+		case err := <-replied:
+			timeout.Stop()
+			if err != nil {
+				// the connection is already gone
+				return
+			}
+			missed = 0
+			continue
+		case <-timeout.C:
+			missed++
+		case <-lost:
+			timeout.Stop()
+			return
+		}
+
+		if missed >= max(policy.MaxMissed, 1) {
+			slog.Warn("closing unresponsive ssh connection", "host", name, "missed_keepalives", missed)
+			_ = conn.Close()
+			return
+		}
+	}
+}
+
+// lostGrace is how long a failed session open waits for its connection to be reported lost, as
+// the connection ending and its sessions failing are noticed by different goroutines.
+const lostGrace = time.Second
+
+// connectionLost returns true when opening a session failed with err because the connection it was
+// opened on, whose end closes lost, is gone. A rejected session means the connection is fine.
+func connectionLost(err error, lost <-chan struct{}) bool {
+	var rejected *ssh.OpenChannelError
+	if errors.As(err, &rejected) {
+		return false
+	}
+	select {
+	case <-lost:
+		return true
+	case <-time.After(lostGrace):
+		return false
+	}
+}
+
+// reconnect replaces the lost connection conn with a new one, connecting by the client's retry
+// policy. A caller finding it already replaced, or being replaced, waits for that instead. c.mu is
+// not held while dialing, so Close can stop the reconnect rather than wait for it.
+func (c *Client) reconnect(conn *ssh.Client) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrNotConnected
+	}
+	if c.client != conn {
+		reconnected := c.reconnected
+		c.mu.Unlock()
+		if reconnected != nil {
+			<-reconnected
+		}
+		return nil
+	}
+	slog.Info("reconnecting to ssh server after the connection was lost", "host", c.info.Name)
+	lost := &connection{client: c.client, jump: c.jump}
+	c.client = nil
+	c.jump = nil
+	reconnected := make(chan struct{})
+	c.reconnected = reconnected
+	c.mu.Unlock()
+	lost.close()
+
+	// sessions are opened without a context, so reconnecting is only bounded by the retry policy
+	// and Close
+	err := c.connect(context.Background())
+	c.mu.Lock()
+	c.reconnected = nil
+	c.mu.Unlock()
+	close(reconnected)
+	if err != nil {
+		return fmt.Errorf("connection lost and reconnecting failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package ssh
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// startKeepAliveTestServer starts a server running every exec request as a successful no-op.
+// Without answer it never replies to global requests such as keepalives, like a server behind a
+// dead network.
+func startKeepAliveTestServer(t *testing.T, answer bool) *testServer {
+	t.Helper()
+	return startTestServer(t, &ssh.ServerConfig{NoClientAuth: true}, func(conn *ssh.ServerConn, chans <-chan ssh.NewChannel, reqs <-chan *ssh.Request) {
+		if answer {
+			go ssh.DiscardRequests(reqs)
+		}
+		for newChannel := range chans {
+			channel, channelReqs, err := newChannel.Accept()
+			if err != nil {
+				continue
+			}
+			go func() {
+				defer channel.Close()
+				for req := range channelReqs {
+					_ = req.Reply(req.Type == "exec", nil)
+					if req.Type == "exec" {
+						_, _ = channel.SendRequest("exit-status", false, []byte{0, 0, 0, 0})
+						return
+					}
+				}
+			}()
+		}
+	})
+}
+
+// connectTestClient connects a client to the server with the keepalive policy.
+func connectTestClient(t *testing.T, addr string, policy KeepAlivePolicy) *Client {
+	t.Helper()
+	useInMemoryKnownHosts(t)
+	SetDefaultKeepAlivePolicy(policy)
+	t.Cleanup(func() { SetDefaultKeepAlivePolicy(DefaultKeepAlivePolicy()) })
+
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	client := NewClient(&ClientInfo{Name: "flaky", Host: host, Port: port, User: "tester", Pass: "secret"})
+	client.SetRetryPolicy(RetryPolicy{Attempts: 1})
+	require.NoError(t, client.Connect())
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestKeepAlive_ClosesUnresponsiveConnection(t *testing.T) {
+	server := startKeepAliveTestServer(t, false)
+	client := connectTestClient(t, server.addr, KeepAlivePolicy{Interval: 50 * time.Millisecond, MaxMissed: 2})
+
+	select {
+	case <-client.lost:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the unresponsive connection to be closed")
+	}
+}
+
+func TestKeepAlive_KeepsAnsweredConnection(t *testing.T) {
+	server := startKeepAliveTestServer(t, true)
+	client := connectTestClient(t, server.addr, KeepAlivePolicy{Interval: 20 * time.Millisecond, MaxMissed: 1})
+
+	select {
+	case <-client.lost:
+		t.Fatal("expected the connection to stay open")
+	case <-time.After(300 * time.Millisecond):
+	}
+	_, err := client.Exec("true")
+	require.NoError(t, err)
+}
+
+func TestClient_ReconnectsLostConnection(t *testing.T) {
+	server := startKeepAliveTestServer(t, true)
+	client := connectTestClient(t, server.addr, KeepAlivePolicy{})
+
+	_, err := client.Exec("true")
+	require.NoError(t, err)
+	require.Equal(t, 1, server.connections())
+	require.Equal(t, AuthMethodNone, client.AuthMethod())
+
+	server.dropConnections()
+	<-client.lost
+	_, err = client.Exec("true")
+	require.NoError(t, err)
+	require.Equal(t, 2, server.connections())
+}
+
+func TestClient_NoReconnectAfterClose(t *testing.T) {
+	server := startKeepAliveTestServer(t, true)
+	client := connectTestClient(t, server.addr, KeepAlivePolicy{})
+
+	require.NoError(t, client.Close())
+	_, err := client.Exec("true")
+	require.Error(t, err)
+	require.Equal(t, 1, server.connections())
+}
+
+func TestClient_CloseStopsReconnect(t *testing.T) {
+	server := startKeepAliveTestServer(t, true)
+	client := connectTestClient(t, server.addr, KeepAlivePolicy{})
+
+	// reconnecting reaches a server that accepts but never answers the handshake
+	hung, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { hung.Close() })
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := hung.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+	_, client.info.Port, err = net.SplitHostPort(hung.Addr().String())
+	require.NoError(t, err)
+
+	server.dropConnections()
+	<-client.lost
+	execErr := make(chan error, 1)
+	go func() {
+		_, err := client.Exec("true")
+		execErr <- err
+	}()
+	select {
+	case conn := <-accepted:
+		defer conn.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a reconnect")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		_ = client.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("expected Close not to wait for the reconnect")
+	}
+	select {
+	case err := <-execErr:
+		require.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("expected Close to stop the reconnect")
+	}
+}
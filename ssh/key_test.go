@@ -0,0 +1,130 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// writeTestKey writes a new ed25519 private key, encrypted when a passphrase is given.
+func writeTestKey(t *testing.T, passphrase string) (string, ssh.PublicKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	var block *pem.Block
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "test", []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(priv, "test")
+	}
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed to convert public key: %v", err)
+	}
+	return path, sshPub
+}
+
+func TestLoadPrivateKey(t *testing.T) {
+	plain, plainPub := writeTestKey(t, "")
+	encrypted, encryptedPub := writeTestKey(t, "correct horse")
+
+	signer, err := LoadPrivateKey(plain, "")
+	if err != nil || string(signer.PublicKey().Marshal()) != string(plainPub.Marshal()) {
+		t.Errorf("expected the plain key to load, got %v", err)
+	}
+	signer, err = LoadPrivateKey(encrypted, "correct horse")
+	if err != nil || string(signer.PublicKey().Marshal()) != string(encryptedPub.Marshal()) {
+		t.Errorf("expected the encrypted key to load with its passphrase, got %v", err)
+	}
+	if _, err := LoadPrivateKey(encrypted, ""); err == nil || !strings.Contains(err.Error(), "set key_passphrase") {
+		t.Errorf("expected a hint to set key_passphrase, got %v", err)
+	}
+	if _, err := LoadPrivateKey(encrypted, "wrong"); err == nil || !strings.Contains(err.Error(), "failed to decrypt") {
+		t.Errorf("expected a decryption failure, got %v", err)
+	}
+}
+
+func TestBuildAuthMethods_KeyPassphraseReference(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	t.Setenv("HOME", t.TempDir())
+	encrypted, _ := writeTestKey(t, "correct horse")
+	setTestSecretPolicy(t, SecretPolicy{EnvNames: []string{"SSH_MCP_TEST_KEY_PASS*"}})
+
+	t.Setenv("SSH_MCP_TEST_KEY_PASS", "correct horse")
+	info := ClientInfo{KeyPath: encrypted, KeyPassphrase: "env:SSH_MCP_TEST_KEY_PASS", KeyPassphraseRef: true}
+	passphrase, err := info.ResolveKeyPassphrase()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	methods, _, err := buildAuthMethods("", encrypted, passphrase, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(methods) != 1 {
+		t.Errorf("expected only the configured key, got %d methods", len(methods))
+	}
+
+	info.KeyPassphrase = "env:SSH_MCP_TEST_KEY_PASS_UNSET"
+	if _, err := info.ResolveKeyPassphrase(); err == nil {
+		t.Error("expected an unresolvable passphrase reference to fail")
+	}
+}
+
+func TestClient_Connect_LaterKeyAfterRejectedKey(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	rejected, _ := writeTestKey(t, "")
+	accepted, acceptedPub := writeTestKey(t, "")
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(accepted, filepath.Join(home, ".ssh", "id_ed25519")); err != nil {
+		t.Fatal(err)
+	}
+
+	var offered atomic.Int32
+	server := startTestServer(t, &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			offered.Add(1)
+			if string(key.Marshal()) != string(acceptedPub.Marshal()) {
+				return nil, errors.New("unknown key")
+			}
+			return nil, nil
+		},
+	}, nil)
+	if err := SetKnownHostsData("unrelated.invalid " + string(ssh.MarshalAuthorizedKey(server.hostKey))); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = SetKnownHostsData("") })
+
+	host, port := server.hostPort(t)
+	client := NewClient(&ClientInfo{Name: "web01", Host: host, Port: port, User: "tester", KeyPath: rejected})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("expected the default key to be tried after the configured key was rejected, got %v", err)
+	}
+	defer client.Close()
+	if offered.Load() != 2 {
+		t.Errorf("expected both keys to be offered, got %d", offered.Load())
+	}
+	if client.AuthMethod() != AuthMethodDefaultKey {
+		t.Errorf("expected the default key to be used, got %q", client.AuthMethod())
+	}
+}
@@ -0,0 +1,181 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// strictHostKeyChecking rejects hosts whose key is not already known instead of adding them.
+var strictHostKeyChecking bool
+
+// errUnknownHostStrict is returned by the known_hosts file callback for unknown hosts when strict
+// host key checking is enabled.
+var errUnknownHostStrict = errors.New("host key not found in known_hosts and strict host key checking is enabled")
+
+// SetStrictHostKeyChecking sets whether connections to hosts with an unknown host key are
+// rejected, instead of the key being trusted on first use and remembered.
+func SetStrictHostKeyChecking(strict bool) {
+	strictHostKeyChecking = strict
+}
+
+// knownHostsEntry is a single line of known_hosts data.
+type knownHostsEntry struct {
+	patterns []string
+	key      ssh.PublicKey
+	revoked  bool
+}
+
+// inMemoryKnownHosts verifies host keys against known_hosts data held in memory, for
+// deployments without a writable (or any) ~/.ssh.
+type inMemoryKnownHosts struct {
+	mu      sync.Mutex
+	entries []knownHostsEntry
+}
+
+// knownHostsData is the in-memory known_hosts data, nil when ~/.ssh/known_hosts is used.
+var knownHostsData *inMemoryKnownHosts
+
+// SetKnownHostsData makes host key verification use the known_hosts formatted data instead of
+// ~/.ssh/known_hosts. Keys of new hosts are only remembered in memory. Empty data restores the
+// use of ~/.ssh/known_hosts.
+func SetKnownHostsData(data string) error {
+	if strings.TrimSpace(data) == "" {
+		knownHostsData = nil
+		return nil
+	}
+	db, err := parseKnownHostsData([]byte(data))
+	if err != nil {
+		return err
+	}
+	knownHostsData = db
+	return nil
+}
+
+// parseKnownHostsData parses known_hosts formatted data. @cert-authority lines are not supported
+// and are skipped.
+func parseKnownHostsData(data []byte) (*inMemoryKnownHosts, error) {
+	db := &inMemoryKnownHosts{}
+	rest := data
+	for len(bytes.TrimSpace(rest)) > 0 {
+		marker, hosts, key, _, next, err := ssh.ParseKnownHosts(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid known hosts data: %w", err)
+		}
+		rest = next
+		if marker == "cert-authority" {
+			continue
+		}
+		db.entries = append(db.entries, knownHostsEntry{patterns: hosts, key: key, revoked: marker == "revoked"})
+	}
+	return db, nil
+}
+
+// callback verifies the host key against the data, remembering the keys of new hosts unless
+// strict host key checking is enabled.
+func (db *inMemoryKnownHosts) callback(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	addresses := []string{knownhosts.Normalize(hostname)}
+	if remote != nil {
+		addresses = append(addresses, knownhosts.Normalize(remote.String()))
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var want []knownhosts.KnownKey
+	for _, entry := range db.entries {
+		if !entry.matches(addresses) {
+			continue
+		}
+		sameKey := bytes.Equal(entry.key.Marshal(), key.Marshal())
+		if entry.revoked {
+			if sameKey {
+				return &knownhosts.RevokedError{Revoked: knownhosts.KnownKey{Key: entry.key}}
+			}
+			continue
+		}
+		if sameKey {
+			slog.Debug("host key matched known hosts data", "host", hostname, "type", key.Type())
+			return nil
+		}
+		want = append(want, knownhosts.KnownKey{Key: entry.key})
+	}
+	if len(want) > 0 {
+		err := &knownhosts.KeyError{Want: want}
+		slog.Warn("host key verification failed", "host", hostname, "error", err)
+		return err
+	}
+
+	if strictHostKeyChecking {
+		return fmt.Errorf("host key for %s not found in known hosts data and strict host key checking is enabled", hostname)
+	}
+	db.entries = append(db.entries, knownHostsEntry{patterns: []string{addresses[0]}, key: key})
+	slog.Info("trusted new host key in memory", "host", hostname, "type", key.Type())
+	return nil
+}
+
+// matches returns true when any of the normalized addresses matches the entry's host patterns,
+// honoring hashed hosts, wildcards and negated patterns.
+func (e knownHostsEntry) matches(addresses []string) bool {
+	for _, address := range addresses {
+		matched := false
+		negated := false
+		for _, pattern := range e.patterns {
+			negate := strings.HasPrefix(pattern, "!")
+			pattern = strings.TrimPrefix(pattern, "!")
+			if matchKnownHostsPattern(pattern, address) {
+				if negate {
+					negated = true
+				} else {
+					matched = true
+				}
+			}
+		}
+		if matched && !negated {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKnownHostsPattern matches a single known_hosts host pattern against a normalized address.
+func matchKnownHostsPattern(pattern string, address string) bool {
+	if strings.HasPrefix(pattern, "|1|") {
+		return matchHashedHost(pattern, address)
+	}
+	if strings.ContainsAny(pattern, "*?") {
+		matched, err := path.Match(pattern, address)
+		return err == nil && matched
+	}
+	return pattern == address
+}
+
+// matchHashedHost matches a "|1|salt|hash" hashed host against an address.
+func matchHashedHost(pattern string, address string) bool {
+	parts := strings.Split(pattern, "|")
+	if len(parts) != 4 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	hash, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(address))
+	return hmac.Equal(mac.Sum(nil), hash)
+}
@@ -0,0 +1,71 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func testPublicKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	return key
+}
+
+func TestInMemoryKnownHosts(t *testing.T) {
+	webKey := testPublicKey(t)
+	dbKey := testPublicKey(t)
+	wildcardKey := testPublicKey(t)
+	otherKey := testPublicKey(t)
+
+	data := "# comment\n" +
+		knownhosts.Line([]string{"web01", "[web01]:2222"}, webKey) + "\n" +
+		knownhosts.Line([]string{knownhosts.HashHostname("db01")}, dbKey) + "\n" +
+		knownhosts.Line([]string{"*.example.com", "!bad.example.com"}, wildcardKey) + "\n"
+	db, err := parseKnownHostsData([]byte(data))
+	require.NoError(t, err)
+
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	require.NoError(t, db.callback("web01:22", remote, webKey))
+	require.NoError(t, db.callback("web01:2222", remote, webKey))
+	require.NoError(t, db.callback("db01:22", remote, dbKey))
+	require.NoError(t, db.callback("app.example.com:22", remote, wildcardKey))
+
+	// a changed key is rejected
+	var keyErr *knownhosts.KeyError
+	require.True(t, errors.As(db.callback("web01:22", remote, otherKey), &keyErr))
+	require.Len(t, keyErr.Want, 1)
+
+	// negated patterns do not match, so the host is unknown and trusted on first use
+	require.NoError(t, db.callback("bad.example.com:22", remote, otherKey))
+	require.NoError(t, db.callback("bad.example.com:22", remote, otherKey))
+	require.Error(t, db.callback("bad.example.com:22", remote, webKey))
+}
+
+func TestInMemoryKnownHosts_Strict(t *testing.T) {
+	SetStrictHostKeyChecking(true)
+	defer SetStrictHostKeyChecking(false)
+
+	db, err := parseKnownHostsData([]byte(knownhosts.Line([]string{"web01"}, testPublicKey(t)) + "\n"))
+	require.NoError(t, err)
+	require.Error(t, db.callback("new01:22", nil, testPublicKey(t)))
+}
+
+func TestSetKnownHostsData(t *testing.T) {
+	defer func() { _ = SetKnownHostsData("") }()
+
+	require.Error(t, SetKnownHostsData("web01 not-a-key\n"))
+	require.NoError(t, SetKnownHostsData(knownhosts.Line([]string{"web01"}, testPublicKey(t))))
+	require.NotNil(t, knownHostsData)
+	require.NoError(t, SetKnownHostsData(""))
+	require.Nil(t, knownHostsData)
+}
@@ -0,0 +1,28 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// legacyHostKeyAlgorithms returns the secure host key algorithms followed by the SHA-1 based
+// ssh-rsa ones, used for hosts with LegacySSHRSA set.
+func legacyHostKeyAlgorithms() []string {
+	return append(ssh.SupportedAlgorithms().HostKeys, ssh.KeyAlgoRSA, ssh.CertAlgoRSAv01)
+}
+
+// legacyHostKeyHint adds a hint to enable legacy_ssh_rsa when err is a host key negotiation
+// failure with a server that only offers ssh-rsa. Any other error is returned unchanged.
+func legacyHostKeyHint(err error) error {
+	var negotiation *ssh.AlgorithmNegotiationError
+	if !errors.As(err, &negotiation) || negotiation.What != "host key" {
+		return err
+	}
+	if !slices.Contains(negotiation.RequestedAlgorithms, ssh.KeyAlgoRSA) && !slices.Contains(negotiation.RequestedAlgorithms, ssh.CertAlgoRSAv01) {
+		return err
+	}
+	return fmt.Errorf("%w (the server only offers the SHA-1 ssh-rsa host key algorithm, set legacy_ssh_rsa on the host to allow it)", err)
+}
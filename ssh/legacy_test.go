@@ -0,0 +1,61 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestLegacyHostKeyHint_SSHRSAOnly(t *testing.T) {
+	negotiation := &ssh.AlgorithmNegotiationError{
+		What:                "host key",
+		SupportedAlgorithms: []string{ssh.KeyAlgoED25519, ssh.KeyAlgoRSASHA256},
+		RequestedAlgorithms: []string{ssh.KeyAlgoRSA},
+	}
+	err := legacyHostKeyHint(fmt.Errorf("ssh: handshake failed: %w", negotiation))
+	if !strings.Contains(err.Error(), "legacy_ssh_rsa") {
+		t.Errorf("expected hint to mention legacy_ssh_rsa, got %q", err.Error())
+	}
+	if !strings.Contains(err.Error(), "no common algorithm for host key") {
+		t.Errorf("expected original error to be kept, got %q", err.Error())
+	}
+	var target *ssh.AlgorithmNegotiationError
+	if !errors.As(err, &target) {
+		t.Errorf("expected the negotiation error to stay unwrappable")
+	}
+}
+
+func TestLegacyHostKeyHint_OtherErrors(t *testing.T) {
+	errs := []error{
+		errors.New("connection refused"),
+		&ssh.AlgorithmNegotiationError{
+			What:                "host key",
+			SupportedAlgorithms: []string{ssh.KeyAlgoED25519},
+			RequestedAlgorithms: []string{ssh.KeyAlgoECDSA256},
+		},
+		&ssh.AlgorithmNegotiationError{
+			What:                "key exchange",
+			SupportedAlgorithms: []string{"curve25519-sha256"},
+			RequestedAlgorithms: []string{ssh.KeyAlgoRSA},
+		},
+	}
+	for _, err := range errs {
+		if got := legacyHostKeyHint(err); got != err {
+			t.Errorf("expected %q to be returned unchanged, got %q", err, got)
+		}
+	}
+}
+
+func TestLegacyHostKeyAlgorithms(t *testing.T) {
+	algorithms := legacyHostKeyAlgorithms()
+	if !slices.Contains(algorithms, ssh.KeyAlgoRSA) {
+		t.Errorf("expected %s in %v", ssh.KeyAlgoRSA, algorithms)
+	}
+	if algorithms[0] == ssh.KeyAlgoRSA {
+		t.Errorf("expected secure algorithms to be preferred, got %v", algorithms)
+	}
+}
@@ -0,0 +1,79 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// connectionLimiter bounds the number of simultaneous SSH connections across the whole server.
+type connectionLimiter struct {
+	mu    sync.Mutex
+	max   int
+	inUse int
+	// freed is closed and replaced every time a slot is released, waking up waiters
+	freed chan struct{}
+}
+
+// connections is the server-wide connection limiter, unlimited until SetMaxConnections is called.
+var connections = &connectionLimiter{freed: make(chan struct{})}
+
+// SetMaxConnections sets the maximum number of simultaneous SSH connections across all commands
+// and tools. Connecting waits for a free slot once the limit is reached. 0 or less is unlimited.
+func SetMaxConnections(max int) {
+	connections.mu.Lock()
+	defer connections.mu.Unlock()
+	connections.max = max
+	connections.wake()
+}
+
+// ConnectionUsage returns the number of open SSH connections and the maximum allowed, 0 when unlimited.
+func ConnectionUsage() (inUse int, max int) {
+	connections.mu.Lock()
+	defer connections.mu.Unlock()
+	return connections.inUse, connections.max
+}
+
+// acquire waits for a free connection slot, returning early when ctx is done.
+func (l *connectionLimiter) acquire(ctx context.Context) error {
+	return l.acquireN(ctx, 1)
+}
+
+// acquireN waits until n slots are free and takes them together, so callers needing several
+// connections at once cannot each hold some while waiting for the others.
+func (l *connectionLimiter) acquireN(ctx context.Context, n int) error {
+	for {
+		l.mu.Lock()
+		if l.max > 0 && n > l.max {
+			l.mu.Unlock()
+			return fmt.Errorf("%d connections are needed at once but at most %d are allowed", n, l.max)
+		}
+		if l.max <= 0 || l.inUse+n <= l.max {
+			l.inUse += n
+			l.mu.Unlock()
+			return nil
+		}
+		freed := l.freed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-freed:
+		}
+	}
+}
+
+// release frees a slot taken by acquire.
+func (l *connectionLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inUse--
+	l.wake()
+}
+
+// wake wakes up every waiter, must be called with mu held.
+func (l *connectionLimiter) wake() {
+	close(l.freed)
+	l.freed = make(chan struct{})
+}
@@ -0,0 +1,114 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// probeTimeout bounds how long ProbeAuth waits for the server when the context has no deadline.
+const probeTimeout = 15 * time.Second
+
+// errProbeOnly is returned by the probe's authentication callbacks so no credentials are sent.
+var errProbeOnly = errors.New("probing authentication methods only")
+
+// AuthProbe describes the authentication an SSH server offers to a user.
+type AuthProbe struct {
+	// Methods are the advertised authentication methods: publickey, password and/or keyboard-interactive
+	Methods []string `json:"methods"`
+	// NoAuthRequired is true when the server accepted the user without any credentials
+	NoAuthRequired bool `json:"no_auth_required,omitempty"`
+	// HostKeyType is the algorithm of the host key presented by the server
+	HostKeyType string `json:"host_key_type"`
+	// HostKeyFingerprint is the SHA256 fingerprint of the host key presented by the server
+	HostKeyFingerprint string `json:"host_key_fingerprint"`
+	// Banner is the pre-authentication banner sent by the server
+	Banner string `json:"banner,omitempty"`
+}
+
+// ProbeAuth connects to the server as user and reports the authentication methods it advertises
+// without completing a login. Every method fails locally before any credential is sent, so the
+// probe cannot lock out the user. The host key is recorded but not verified or remembered.
+//
+// A keyboard-interactive method is only detected when the server sends a challenge for it, which
+// is the case for the usual PAM setups.
+func ProbeAuth(ctx context.Context, host string, port string, user string) (*AuthProbe, error) {
+	if err := connections.acquire(ctx); err != nil {
+		return nil, fmt.Errorf("waiting for a free connection slot: %w", err)
+	}
+	defer connections.release()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, probeTimeout)
+		defer cancel()
+	}
+
+	var mu sync.Mutex
+	probe := &AuthProbe{Methods: []string{}}
+	advertised := func(method string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !slices.Contains(probe.Methods, method) {
+			probe.Methods = append(probe.Methods, method)
+		}
+	}
+
+	cfg := &ssh.ClientConfig{
+		User: resolveUser(user),
+		Auth: []ssh.AuthMethod{
+			ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+				advertised("publickey")
+				return nil, errProbeOnly
+			}),
+			ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+				advertised("keyboard-interactive")
+				return nil, errProbeOnly
+			}),
+			ssh.PasswordCallback(func() (string, error) {
+				advertised("password")
+				return "", errProbeOnly
+			}),
+		},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			probe.HostKeyType = key.Type()
+			probe.HostKeyFingerprint = ssh.FingerprintSHA256(key)
+			return nil
+		},
+		BannerCallback: func(message string) error {
+			probe.Banner = message
+			return nil
+		},
+	}
+
+	address := net.JoinHostPort(host, port)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, cfg)
+	if err == nil {
+		// the server let the user in without any credentials
+		ssh.NewClient(sshConn, chans, reqs).Close()
+		probe.NoAuthRequired = true
+		return probe, nil
+	}
+	if probe.HostKeyFingerprint == "" {
+		// the handshake did not get as far as authentication
+		return nil, fmt.Errorf("ssh handshake with %s failed: %w", address, err)
+	}
+	slices.Sort(probe.Methods)
+	return probe, nil
+}
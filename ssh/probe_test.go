@@ -0,0 +1,71 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// startProbeServer starts an SSH server that runs the handshake for each connection with config,
+// recording every password it receives. It returns the host and port to connect to.
+func startProbeServer(t *testing.T, config *ssh.ServerConfig) (string, string, ssh.PublicKey, *[]string) {
+	t.Helper()
+	var passwords []string
+	if config.PasswordCallback != nil {
+		callback := config.PasswordCallback
+		config.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			passwords = append(passwords, string(password))
+			return callback(conn, password)
+		}
+	}
+
+	server := startTestServer(t, config, nil)
+	host, port := server.hostPort(t)
+	return host, port, server.hostKey, &passwords
+}
+
+func TestProbeAuth_AdvertisedMethods(t *testing.T) {
+	host, port, hostKey, passwords := startProbeServer(t, &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			return nil, errors.New("denied")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			return nil, errors.New("denied")
+		},
+		KeyboardInteractiveCallback: func(conn ssh.ConnMetadata, client ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			if _, err := client("", "", []string{"Password: "}, []bool{false}); err != nil {
+				return nil, err
+			}
+			return nil, errors.New("denied")
+		},
+		BannerCallback: func(conn ssh.ConnMetadata) string {
+			return "authorized use only\n"
+		},
+	})
+
+	probe, err := ProbeAuth(context.Background(), host, port, "deploy")
+	require.NoError(t, err)
+	require.Equal(t, []string{"keyboard-interactive", "password", "publickey"}, probe.Methods)
+	require.False(t, probe.NoAuthRequired)
+	require.Equal(t, hostKey.Type(), probe.HostKeyType)
+	require.Equal(t, ssh.FingerprintSHA256(hostKey), probe.HostKeyFingerprint)
+	require.Equal(t, "authorized use only\n", probe.Banner)
+	require.Empty(t, *passwords, "no password should be sent while probing")
+}
+
+func TestProbeAuth_NoAuthRequired(t *testing.T) {
+	host, port, _, _ := startProbeServer(t, &ssh.ServerConfig{NoClientAuth: true})
+
+	probe, err := ProbeAuth(context.Background(), host, port, "guest")
+	require.NoError(t, err)
+	require.True(t, probe.NoAuthRequired)
+	require.Empty(t, probe.Methods)
+}
+
+func TestProbeAuth_ConnectionRefused(t *testing.T) {
+	_, err := ProbeAuth(context.Background(), "127.0.0.1", "1", "deploy")
+	require.ErrorContains(t, err, "failed to connect")
+}
@@ -0,0 +1,136 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ErrorClass is a category of connection error used to decide if it is retryable.
+type ErrorClass string
+
+const (
+	ErrorClassNetwork ErrorClass = "network"
+	ErrorClassTimeout ErrorClass = "timeout"
+	ErrorClassAuth    ErrorClass = "auth"
+	ErrorClassHostKey ErrorClass = "hostkey"
+	ErrorClassOther   ErrorClass = "other"
+)
+
+// RetryPolicy controls how failed operations are retried.
+type RetryPolicy struct {
+	// Attempts is the total number of attempts, 1 disables retrying
+	Attempts int
+	// BaseDelay is the delay before the first retry, doubled on every following retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries
+	MaxDelay time.Duration
+	// Jitter randomizes each delay by up to this fraction (0.0 - 1.0)
+	Jitter float64
+	// RetryOn is the set of error classes that are retried
+	RetryOn []ErrorClass
+}
+
+// DefaultRetryPolicy returns the policy used when none is configured, which does not retry.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:  1,
+		BaseDelay: 500 * time.Millisecond,
+		MaxDelay:  10 * time.Second,
+		Jitter:    0.2,
+		RetryOn:   []ErrorClass{ErrorClassNetwork, ErrorClassTimeout},
+	}
+}
+
+// ParseErrorClasses parses a comma separated list of error classes.
+func ParseErrorClasses(s string) ([]ErrorClass, error) {
+	var classes []ErrorClass
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		class := ErrorClass(part)
+		switch class {
+		case ErrorClassNetwork, ErrorClassTimeout, ErrorClassAuth, ErrorClassHostKey, ErrorClassOther:
+			classes = append(classes, class)
+		default:
+			return nil, fmt.Errorf("unknown error class '%s': must be one of network, timeout, auth, hostkey, other", part)
+		}
+	}
+	return classes, nil
+}
+
+// ClassifyError returns the class of a connection error.
+func ClassifyError(err error) ErrorClass {
+	var keyErr *knownhosts.KeyError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &keyErr):
+		return ErrorClassHostKey
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case strings.Contains(err.Error(), "unable to authenticate"), strings.Contains(err.Error(), "no authentication method"):
+		return ErrorClassAuth
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EHOSTUNREACH),
+		errors.Is(err, syscall.ENETUNREACH), errors.As(err, new(*net.OpError)), errors.As(err, new(*net.DNSError)):
+		return ErrorClassNetwork
+	}
+	return ErrorClassOther
+}
+
+// ShouldRetry returns true when the error belongs to a retryable class.
+func (p RetryPolicy) ShouldRetry(err error) bool {
+	class := ClassifyError(err)
+	for _, retryable := range p.RetryOn {
+		if class == retryable {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns the delay before the given retry (starting at 1).
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 && delay > 0 {
+		delta := float64(delay) * p.Jitter
+		delay = time.Duration(float64(delay) - delta + rand.Float64()*2*delta)
+	}
+	return delay
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error or the attempts are exhausted.
+// Retrying stops when ctx is done while waiting for the next attempt.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	attempts := max(p.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts || !p.ShouldRetry(err) {
+			return err
+		}
+		delay := p.Delay(attempt)
+		slog.Debug("retrying after error", "attempt", attempt, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w, stopped retrying: %w", err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
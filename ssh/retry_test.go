@@ -0,0 +1,138 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Do_RetriesRetryableErrors(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, RetryOn: []ErrorClass{ErrorClassNetwork}}
+
+	calls := 0
+	err := policy.Do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("dial: %w", syscall.ECONNREFUSED)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestRetryPolicy_Do_StopsOnNonRetryableError(t *testing.T) {
+	policy := RetryPolicy{Attempts: 5, RetryOn: []ErrorClass{ErrorClassNetwork}}
+
+	calls := 0
+	err := policy.Do(context.Background(), func() error {
+		calls++
+		return errors.New("ssh: handshake failed: ssh: unable to authenticate")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("expected auth failure to not be retried, got %d calls", calls)
+	}
+}
+
+func TestRetryPolicy_Do_ExhaustsAttempts(t *testing.T) {
+	policy := RetryPolicy{Attempts: 2, RetryOn: []ErrorClass{ErrorClassNetwork}}
+
+	calls := 0
+	err := policy.Do(context.Background(), func() error {
+		calls++
+		return &net.OpError{Op: "dial", Err: syscall.ECONNRESET}
+	})
+	if err == nil {
+		t.Fatal("expected error after exhausting attempts")
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestRetryPolicy_Do_StopsWhenCancelled(t *testing.T) {
+	policy := RetryPolicy{Attempts: 5, BaseDelay: time.Hour, RetryOn: []ErrorClass{ErrorClassNetwork}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	calls := 0
+	start := time.Now()
+	err := policy.Do(ctx, func() error {
+		calls++
+		return &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the cancellation in the error, got %v", err)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Errorf("expected the last error to be kept, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected no retry after cancellation, got %d calls", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the backoff to stop on cancellation, waited %s", elapsed)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 350 * time.Millisecond}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 350 * time.Millisecond, 350 * time.Millisecond}
+	for i, want := range expected {
+		if got := policy.Delay(i + 1); got != want {
+			t.Errorf("retry %d: expected delay %v, got %v", i+1, want, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		got := policy.Delay(1)
+		if got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Errorf("expected jittered delay within 50ms-150ms, got %v", got)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected ErrorClass
+	}{
+		{fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED), ErrorClassNetwork},
+		{&net.DNSError{Err: "no such host", Name: "x"}, ErrorClassNetwork},
+		{&net.DNSError{Err: "timeout", Name: "x", IsTimeout: true}, ErrorClassTimeout},
+		{errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none]"), ErrorClassAuth},
+		{errors.New("something else"), ErrorClassOther},
+	}
+	for _, tc := range testCases {
+		if got := ClassifyError(tc.err); got != tc.expected {
+			t.Errorf("ClassifyError(%v): expected %s, got %s", tc.err, tc.expected, got)
+		}
+	}
+}
+
+func TestParseErrorClasses(t *testing.T) {
+	classes, err := ParseErrorClasses("network, timeout")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(classes) != 2 || classes[0] != ErrorClassNetwork || classes[1] != ErrorClassTimeout {
+		t.Errorf("unexpected classes: %v", classes)
+	}
+
+	if _, err := ParseErrorClasses("network,bogus"); err == nil {
+		t.Error("expected error for unknown error class")
+	}
+}
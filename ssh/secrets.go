@@ -0,0 +1,188 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SecretResolver resolves the part of a secret reference after the scheme (e.g. "DB_PASS" for "env:DB_PASS").
+type SecretResolver func(ref string) (string, error)
+
+var (
+	secretSchemesMu sync.RWMutex
+	secretSchemes   = map[string]SecretResolver{
+		"env":  resolveEnvSecret,
+		"file": resolveFileSecret,
+	}
+)
+
+// refusedEnvSecrets are the environment variables a host secret reference may never read, whatever
+// the policy allows.
+var refusedEnvSecrets = []string{"SSH_MCP_STORAGE_KEY"}
+
+// SecretPolicy is what the secret references of hosts may read. References are given by the tool
+// caller and the secret is sent to a host of its choosing, so only what the operator allows can
+// be read. The zero policy allows no env: or file: reference.
+type SecretPolicy struct {
+	// EnvNames are the environment variables env: references may read, an entry ending in '*'
+	// allows every variable starting with the text before it
+	EnvNames []string
+	// Dir is the directory file: references may read from, empty to allow no file
+	Dir string
+}
+
+var (
+	secretPolicyMu sync.RWMutex
+	secretPolicy   SecretPolicy
+)
+
+// SetSecretPolicy sets what the secret references of hosts may read.
+func SetSecretPolicy(policy SecretPolicy) {
+	secretPolicyMu.Lock()
+	defer secretPolicyMu.Unlock()
+	secretPolicy = policy
+}
+
+// RegisterSecretScheme adds or replaces the resolver used for references of the form "scheme:ref".
+func RegisterSecretScheme(scheme string, resolver SecretResolver) {
+	secretSchemesMu.Lock()
+	defer secretSchemesMu.Unlock()
+	secretSchemes[scheme] = resolver
+}
+
+// IsSecretReference returns true when the value is a reference to a secret using a registered scheme.
+func IsSecretReference(value string) bool {
+	_, _, ok := lookupSecretScheme(value)
+	return ok
+}
+
+// CheckSecretReference returns an error unless ref is a secret reference the secret policy allows
+// to be resolved for a host.
+func CheckSecretReference(ref string) error {
+	scheme, _, ok := lookupSecretScheme(ref)
+	if !ok {
+		return fmt.Errorf("%q is not a secret reference such as env:NAME or file:/path", ref)
+	}
+	name := strings.TrimPrefix(ref, scheme+":")
+	if name == "" {
+		return fmt.Errorf("secret reference %q is missing a name after %q", ref, scheme+":")
+	}
+
+	secretPolicyMu.RLock()
+	policy := secretPolicy
+	secretPolicyMu.RUnlock()
+	switch scheme {
+	case "env":
+		if slices.Contains(refusedEnvSecrets, name) || !envSecretAllowed(policy.EnvNames, name) {
+			return fmt.Errorf("secret reference %q is not allowed, the environment variables hosts may read are set with --secret-env", ref)
+		}
+	case "file":
+		if policy.Dir == "" || !fileInDir(policy.Dir, name) {
+			return fmt.Errorf("secret reference %q is not allowed, the files hosts may read must be in the --secrets-dir directory", ref)
+		}
+	}
+	return nil
+}
+
+// ResolveSecretReference returns the secret a reference stored for a host points to, when the
+// secret policy allows it.
+func ResolveSecretReference(ref string) (string, error) {
+	if err := CheckSecretReference(ref); err != nil {
+		return "", err
+	}
+	return ResolveSecret(ref)
+}
+
+// ResolveSecret returns the secret a reference points to, without checking the secret policy, for
+// values given by the operator such as --storage-key. Values that are not a reference to a
+// registered scheme are returned unchanged, so plain values keep working.
+func ResolveSecret(value string) (string, error) {
+	scheme, resolver, ok := lookupSecretScheme(value)
+	if !ok {
+		return value, nil
+	}
+	ref := strings.TrimPrefix(value, scheme+":")
+	if ref == "" {
+		return "", fmt.Errorf("secret reference %q is missing a name after %q", value, scheme+":")
+	}
+	secret, err := resolver(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret reference %q: %w", value, err)
+	}
+	return secret, nil
+}
+
+// lookupSecretScheme returns the scheme and resolver for a secret reference.
+func lookupSecretScheme(value string) (string, SecretResolver, bool) {
+	scheme, _, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", nil, false
+	}
+	secretSchemesMu.RLock()
+	defer secretSchemesMu.RUnlock()
+	resolver, ok := secretSchemes[scheme]
+	return scheme, resolver, ok
+}
+
+// envSecretAllowed returns true when the environment variable is in the allowed names or prefixes.
+func envSecretAllowed(allowed []string, name string) bool {
+	for _, entry := range allowed {
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if entry == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fileInDir returns true when the file, with symlinks followed, is inside dir.
+func fileInDir(dir string, path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		// a missing file is reported when it is read, it must still be named within dir
+		realPath = filepath.Clean(path)
+		realDir = filepath.Clean(dir)
+	}
+	rel, err := filepath.Rel(realDir, realPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && rel != "."
+}
+
+// resolveEnvSecret reads a secret from an environment variable.
+func resolveEnvSecret(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s is empty", name)
+	}
+	return value, nil
+}
+
+// resolveFileSecret reads a secret from a file, ignoring a trailing newline.
+func resolveFileSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return "", errors.New("secret file is empty")
+	}
+	return value, nil
+}
@@ -0,0 +1,171 @@
+package ssh
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSecret_Plain(t *testing.T) {
+	for _, value := range []string{"", "hunter2", "pass:with:colons"} {
+		got, err := ResolveSecret(value)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", value, err)
+		}
+		if got != value {
+			t.Errorf("expected %q unchanged, got %q", value, got)
+		}
+	}
+}
+
+func TestResolveSecret_Env(t *testing.T) {
+	t.Setenv("SSH_MCP_TEST_PASS", "s3cret")
+
+	got, err := ResolveSecret("env:SSH_MCP_TEST_PASS")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "s3cret" {
+		t.Errorf("expected 's3cret', got '%s'", got)
+	}
+
+	if _, err := ResolveSecret("env:SSH_MCP_TEST_MISSING"); err == nil {
+		t.Error("expected error for missing environment variable")
+	}
+	if _, err := ResolveSecret("env:"); err == nil {
+		t.Error("expected error for empty reference")
+	}
+}
+
+func TestResolveSecret_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ResolveSecret("file:" + path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "s3cret" {
+		t.Errorf("expected 's3cret', got '%s'", got)
+	}
+
+	if _, err := ResolveSecret("file:" + filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestRegisterSecretScheme(t *testing.T) {
+	RegisterSecretScheme("vault", func(ref string) (string, error) {
+		if ref == "db" {
+			return "from-vault", nil
+		}
+		return "", errors.New("not found")
+	})
+	t.Cleanup(func() {
+		secretSchemesMu.Lock()
+		delete(secretSchemes, "vault")
+		secretSchemesMu.Unlock()
+	})
+
+	if !IsSecretReference("vault:db") {
+		t.Error("expected vault:db to be a secret reference")
+	}
+	got, err := ResolveSecret("vault:db")
+	if err != nil || got != "from-vault" {
+		t.Errorf("expected 'from-vault', got '%s' (%v)", got, err)
+	}
+	if _, err := ResolveSecret("vault:other"); err == nil {
+		t.Error("expected error from resolver")
+	}
+}
+
+// setTestSecretPolicy sets the secret policy for the duration of the test.
+func setTestSecretPolicy(t *testing.T, policy SecretPolicy) {
+	SetSecretPolicy(policy)
+	t.Cleanup(func() { SetSecretPolicy(SecretPolicy{}) })
+}
+
+func TestCheckSecretReference(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "db")
+	if err := os.WriteFile(outside, []byte("s3cret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	setTestSecretPolicy(t, SecretPolicy{EnvNames: []string{"DB_PASS", "SSH_PASS_*", "SSH_MCP_*"}, Dir: dir})
+
+	allowed := []string{"env:DB_PASS", "env:SSH_PASS_WEB", "file:" + filepath.Join(dir, "db")}
+	for _, ref := range allowed {
+		if err := CheckSecretReference(ref); err != nil {
+			t.Errorf("expected %q to be allowed, got %v", ref, err)
+		}
+	}
+	refused := []string{
+		"plain",
+		"env:",
+		"env:HOME",
+		"env:DB_PASSWORD",
+		"env:SSH_MCP_STORAGE_KEY",
+		"file:" + outside,
+		"file:" + filepath.Join(dir, "..", "db"),
+		"file:" + filepath.Join(dir, "link"),
+		"file:db",
+		"file:" + dir,
+	}
+	for _, ref := range refused {
+		if err := CheckSecretReference(ref); err == nil {
+			t.Errorf("expected %q to be refused", ref)
+		}
+	}
+}
+
+func TestCheckSecretReference_ZeroPolicy(t *testing.T) {
+	t.Setenv("SSH_MCP_TEST_PASS", "s3cret")
+	path := filepath.Join(t.TempDir(), "db")
+	if err := os.WriteFile(path, []byte("s3cret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ref := range []string{"env:SSH_MCP_TEST_PASS", "file:" + path} {
+		if _, err := ResolveSecretReference(ref); err == nil {
+			t.Errorf("expected %q to be refused without a policy", ref)
+		}
+	}
+}
+
+func TestClientInfo_ResolvePassword(t *testing.T) {
+	t.Setenv("SSH_MCP_TEST_PASS", "s3cret")
+	setTestSecretPolicy(t, SecretPolicy{EnvNames: []string{"SSH_MCP_TEST_PASS"}})
+
+	// only a value marked as a reference is resolved
+	info := ClientInfo{Pass: "env:SSH_MCP_TEST_PASS", KeyPassphrase: "env:SSH_MCP_TEST_PASS"}
+	got, err := info.ResolvePassword()
+	if err != nil || got != "env:SSH_MCP_TEST_PASS" {
+		t.Errorf("expected the password as is, got '%s' (%v)", got, err)
+	}
+	got, err = info.ResolveKeyPassphrase()
+	if err != nil || got != "env:SSH_MCP_TEST_PASS" {
+		t.Errorf("expected the passphrase as is, got '%s' (%v)", got, err)
+	}
+
+	info.PassRef = true
+	info.KeyPassphraseRef = true
+	got, err = info.ResolvePassword()
+	if err != nil || got != "s3cret" {
+		t.Errorf("expected 's3cret', got '%s' (%v)", got, err)
+	}
+	got, err = info.ResolveKeyPassphrase()
+	if err != nil || got != "s3cret" {
+		t.Errorf("expected 's3cret', got '%s' (%v)", got, err)
+	}
+
+	info.Pass = "env:HOME"
+	if _, err := info.ResolvePassword(); err == nil {
+		t.Error("expected a reference outside the policy to fail")
+	}
+}
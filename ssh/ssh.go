@@ -1,12 +1,19 @@
 package ssh
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
@@ -29,9 +36,24 @@ type ClientInfo struct {
 	Host  string `yaml:"host" json:"host" jsonschema_description:"The host of the client"`
 	Port  string `yaml:"port" json:"port" jsonschema_description:"The port of the client"`
 	User  string `yaml:"user" json:"user" jsonschema_description:"The user of the client (optional, defaults to current user)"`
-	Pass  string `yaml:"pass,omitempty" json:"pass,omitempty" jsonschema_description:"The password of the client (optional, will use SSH agent if not provided)"`
+	Pass  string `yaml:"pass,omitempty" json:"pass,omitempty" jsonschema_description:"The password of the client, or a secret reference such as env:NAME or file:/path resolved at connect time when pass_ref is set (optional, will use SSH agent if not provided)"`
+	// PassRef is true when Pass is a secret reference rather than the password itself
+	PassRef bool `yaml:"pass_ref,omitempty" json:"pass_ref,omitempty" jsonschema_description:"Whether pass is a secret reference (optional)"`
+
+	KeyPath       string `yaml:"key_path,omitempty" json:"key_path,omitempty" jsonschema_description:"Path to a private key file used for authentication (optional)"`
+	KeyPassphrase string `yaml:"key_passphrase,omitempty" json:"key_passphrase,omitempty" jsonschema_description:"Passphrase of the encrypted private key at key_path, or a secret reference such as env:NAME or file:/path resolved at connect time when key_passphrase_ref is set (optional)"`
+	// KeyPassphraseRef is true when KeyPassphrase is a secret reference rather than the passphrase itself
+	KeyPassphraseRef bool `yaml:"key_passphrase_ref,omitempty" json:"key_passphrase_ref,omitempty" jsonschema_description:"Whether key_passphrase is a secret reference (optional)"`
+
+	LegacySSHRSA bool `yaml:"legacy_ssh_rsa,omitempty" json:"legacy_ssh_rsa,omitempty" jsonschema_description:"Also accept the SHA-1 ssh-rsa host key algorithm, for old servers that offer nothing else (optional)"`
+
+	JumpHost string `yaml:"jump_host,omitempty" json:"jump_host,omitempty" jsonschema_description:"Bastion to connect through, a stored host as 'group:name' or a connection string (optional)"`
+
+	Description string `yaml:"description,omitempty" json:"description,omitempty" jsonschema_description:"Free-form notes about the host, e.g. how it should be treated (optional)"`
 
 	OS OSInfo `yaml:"os" json:"os" jsonschema_description:"The operating system information"`
+
+	LastSeen *time.Time `yaml:"last_seen,omitempty" json:"last_seen,omitempty" jsonschema_description:"When a connection to the host last succeeded"`
 }
 
 // NewClientInfo returns client information from the connection string.
@@ -94,46 +116,245 @@ func NewClientInfo(name string, connStr string) (*ClientInfo, error) {
 	}, nil
 }
 
+// defaultUser is the user to connect as when a host does not specify one.
+var defaultUser string
+
+// SetDefaultUser sets the user to connect as when a host does not specify one.
+// An empty user falls back to the current OS user.
+func SetDefaultUser(user string) {
+	defaultUser = user
+}
+
+// resolveUser returns the user to connect as, in order of precedence: the host's user,
+// the default user and finally the current OS user.
+func resolveUser(user string) string {
+	if user != "" {
+		return user
+	}
+	if defaultUser != "" {
+		return defaultUser
+	}
+	user = os.Getenv("USER")
+	if user == "" {
+		user = os.Getenv("USERNAME") // Windows fallback
+	}
+	return user
+}
+
+// onConnect is called after every successful connection, nil when not set.
+var onConnect func(info ClientInfo)
+
+// SetOnConnect sets a function called with the host's information after every successful
+// connection, e.g. to record when the host was last seen. It must be safe for concurrent use.
+func SetOnConnect(fn func(info ClientInfo)) {
+	onConnect = fn
+}
+
 // Client is an SSH client.
 type Client struct {
-	info *ClientInfo
+	info  *ClientInfo
+	retry RetryPolicy
 
+	// mu guards the connection, which is replaced when it is lost
+	mu     sync.Mutex
 	client *ssh.Client
+	// lost is closed once the current connection ends
+	lost <-chan struct{}
+	// closed is true once Close is called, the connection is never replaced after
+	closed bool
+	// cancel stops the connection being made, so Close does not wait for it; nil when not connecting
+	cancel context.CancelFunc
+	// reconnected is closed once the lost connection being replaced is, nil when not reconnecting
+	reconnected chan struct{}
+	// authMethod is the authentication method the connection was made with
+	authMethod string
+	// slot is true while the client holds a connection slot
+	slot bool
+	// jump is the connection to the jump host the client is connected through, nil when direct
+	jump *Client
+	// hops is the number of jump hosts between the server and this client
+	hops int
 }
 
 // NewClient creates the client with the hostPort and configuration.
 func NewClient(info *ClientInfo) *Client {
 	return &Client{
-		info: info,
+		info:  info,
+		retry: DefaultRetryPolicy(),
 	}
 }
 
+// SetRetryPolicy overrides the retry policy used when connecting.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
 // Connect connects to the SSH server.
 func (c *Client) Connect() error {
-	var err error
-	host := fmt.Sprintf("%s:%s", c.info.Host, c.info.Port)
+	return c.ConnectContext(context.Background())
+}
 
-	// Use current user if not specified
-	user := c.info.User
-	if user == "" {
-		user = os.Getenv("USER")
-		if user == "" {
-			user = os.Getenv("USERNAME") // Windows fallback
+// ConnectContext connects to the SSH server, first waiting for a free connection slot when the
+// server-wide connection limit is reached. Waiting stops when ctx is done. The slot is held until
+// Close is called.
+func (c *Client) ConnectContext(ctx context.Context) error {
+	if err := connections.acquire(ctx); err != nil {
+		return fmt.Errorf("waiting for a free connection slot: %w", err)
+	}
+	return c.connectWithSlot(ctx)
+}
+
+// ConnectAll connects every client, first waiting until a connection slot is free for each of
+// them and taking them together, so tools needing several connections at once cannot deadlock
+// each holding some of the slots. When one fails the ones already connected are closed.
+func ConnectAll(ctx context.Context, clients ...*Client) error {
+	if err := connections.acquireN(ctx, len(clients)); err != nil {
+		return fmt.Errorf("waiting for free connection slots: %w", err)
+	}
+	for i, c := range clients {
+		if err := c.connectWithSlot(ctx); err != nil {
+			for range clients[i+1:] {
+				connections.release()
+			}
+			for _, connected := range clients[:i] {
+				_ = connected.Close()
+			}
+			return fmt.Errorf("failed to connect to %s:%s: %w", c.info.Group, c.info.Name, err)
 		}
 	}
+	return nil
+}
+
+// connectWithSlot connects to the SSH server with a connection slot already acquired for it,
+// releasing the slot when connecting fails.
+func (c *Client) connectWithSlot(ctx context.Context) error {
+	c.mu.Lock()
+	c.slot = true
+	c.closed = false
+	c.mu.Unlock()
+	err := c.connect(ctx)
+	if err != nil {
+		c.releaseSlot()
+		return err
+	}
+	if onConnect != nil {
+		onConnect(*c.info)
+	}
+	return nil
+}
+
+// releaseSlot releases the connection slot held by the client, if any. Only the first of
+// concurrent calls releases it.
+func (c *Client) releaseSlot() {
+	c.mu.Lock()
+	slot := c.slot
+	c.slot = false
+	c.mu.Unlock()
+	if slot {
+		connections.release()
+	}
+}
+
+// connection is an established connection to the SSH server.
+type connection struct {
+	client *ssh.Client
+	// lost is closed once the connection ends
+	lost <-chan struct{}
+	// authMethod is the authentication method the connection was made with
+	authMethod string
+	// jump is the connection to the jump host the connection goes through, nil when direct
+	jump *Client
+}
+
+// close closes the connection, then the jump host connection carrying it.
+func (conn *connection) close() {
+	_ = conn.client.Close()
+	if conn.jump != nil {
+		_ = conn.jump.Close()
+	}
+}
+
+// connect dials the SSH server, retrying by the client's retry policy until ctx is done or Close
+// is called, and makes it the client's connection.
+func (c *Client) connect(ctx context.Context) error {
+	ctx, done := c.cancellable(ctx)
+	defer done()
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		conn.close()
+		return ErrNotConnected
+	}
+	c.setConnection(conn)
+	return nil
+}
+
+// cancellable returns a context that Close cancels, so it does not wait for a connection being
+// made. done must be called once connecting ends.
+func (c *Client) cancellable(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	c.mu.Lock()
+	c.cancel = cancel
+	c.mu.Unlock()
+	return ctx, func() {
+		c.mu.Lock()
+		c.cancel = nil
+		c.mu.Unlock()
+		cancel()
+	}
+}
+
+// setConnection makes conn the client's connection, with c.mu held.
+func (c *Client) setConnection(conn *connection) {
+	c.client = conn.client
+	c.lost = conn.lost
+	c.authMethod = conn.authMethod
+	c.jump = conn.jump
+}
+
+// dial connects to the SSH server, retrying by the client's retry policy until ctx is done. The
+// client's own connection is left alone, so c.mu is not held while dialing.
+func (c *Client) dial(ctx context.Context) (*connection, error) {
+	var err error
+	conn := &connection{}
+	host := fmt.Sprintf("%s:%s", c.info.Host, c.info.Port)
+
+	// Use the default user or current user if not specified
+	user := resolveUser(c.info.User)
 
 	// Build authentication methods
-	authMethods := buildAuthMethods(c.info.Pass)
+	password, err := c.info.ResolvePassword()
+	if err != nil {
+		return nil, err
+	}
+	keyPassphrase, err := c.info.ResolveKeyPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	authMethods, agentStatus, err := buildAuthMethods(password, c.info.KeyPath, keyPassphrase, func(method string) {
+		// methods are tried in order until one succeeds, so the last one tried is the one used
+		conn.authMethod = method
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	// If no auth methods available, return error
 	if len(authMethods) == 0 {
-		return errors.New("no authentication method available: provide password, ensure SSH_AUTH_SOCK is set, or add SSH keys to ~/.ssh/")
+		if agentStatus != nil {
+			return nil, fmt.Errorf("no authentication method available: %s; provide password or add SSH keys to ~/.ssh/", agentStatus.Problem)
+		}
+		return nil, errors.New("no authentication method available: provide password, ensure SSH_AUTH_SOCK is set, or add SSH keys to ~/.ssh/")
 	}
 
 	// Get host key callback for secure host verification
 	hostKeyCallback, err := getHostKeyCallback()
 	if err != nil {
-		return fmt.Errorf("failed to get host key callback: %w", err)
+		return nil, fmt.Errorf("failed to get host key callback: %w", err)
 	}
 
 	cfg := &ssh.ClientConfig{
@@ -141,32 +362,125 @@ func (c *Client) Connect() error {
 		Auth:            authMethods,
 		HostKeyCallback: hostKeyCallback,
 	}
-	c.client, err = ssh.Dial("tcp", host, cfg)
+	if c.info.LegacySSHRSA {
+		cfg.HostKeyAlgorithms = legacyHostKeyAlgorithms()
+	}
+	dial := func() (*ssh.Client, error) {
+		var dialer net.Dialer
+		tcpConn, err := dialer.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return nil, err
+		}
+		return clientHandshake(ctx, tcpConn, host, cfg)
+	}
+	if c.info.JumpHost != "" {
+		conn.jump, err = c.connectJumpHost(ctx)
+		if err != nil {
+			return nil, err
+		}
+		dial = func() (*ssh.Client, error) {
+			return dialThrough(ctx, conn.jump.client, host, cfg)
+		}
+	}
+
+	slog.Debug("connecting to ssh server", "host", c.info.Name, "address", host, "user", user, "jump_host", c.info.JumpHost)
+	err = c.retry.Do(ctx, func() error {
+		conn.client, err = dial()
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to connect to SSH server: %w", err)
+		if conn.jump != nil {
+			_ = conn.jump.Close()
+		}
+		return nil, fmt.Errorf("failed to connect to SSH server: %w", legacyHostKeyHint(agentHint(err, agentStatus)))
 	}
-	return nil
+	conn.lost = watchConnection(c.info.Name, conn.client)
+	slog.Debug("connected to ssh server", "host", c.info.Name, "address", host)
+	return conn, nil
+}
+
+// clientHandshake starts an SSH connection over conn, closing conn when ctx is done first so a
+// server that stops answering mid-handshake cannot hold it up.
+func clientHandshake(ctx context.Context, conn net.Conn, addr string, cfg *ssh.ClientConfig) (*ssh.Client, error) {
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if !stop() {
+		// ctx was done and conn closed, whatever the handshake returned
+		if err == nil {
+			_ = sshConn.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(sshConn, chans, reqs), nil
 }
 
 // Close closes the connection to the SSH server.
 func (c *Client) Close() error {
+	defer c.releaseSlot()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.cancel != nil {
+		c.cancel()
+	}
+	var err error
 	if c.client != nil {
-		return c.client.Close()
+		err = c.client.Close()
 	}
-	return nil
+	if c.jump != nil {
+		// the jump connection carries the client's, so it is closed last
+		_ = c.jump.Close()
+	}
+	return err
 }
 
-// NewSession creates a new SSH session
-func (c *Client) NewSession() (*ssh.Session, error) {
+// AuthMethod returns the authentication method the client connected with, one of the AuthMethod
+// constants, empty when not connected.
+func (c *Client) AuthMethod() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.client == nil {
+		return ""
+	}
+	if c.authMethod == "" {
+		return AuthMethodNone
+	}
+	return c.authMethod
+}
+
+// NewSession creates a new SSH session. When the connection was lost, e.g. the server stopped
+// answering keepalives, it reconnects first. Nothing ran on the lost connection for the session,
+// so this is safe for any command.
+func (c *Client) NewSession() (*ssh.Session, error) {
+	c.mu.Lock()
+	conn, lost := c.client, c.lost
+	c.mu.Unlock()
+	if conn == nil {
+		return nil, ErrNotConnected
+	}
+	session, err := conn.NewSession()
+	if err == nil || !connectionLost(err, lost) {
+		return session, err
+	}
+	if err := c.reconnect(conn); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	conn = c.client
+	c.mu.Unlock()
+	if conn == nil {
 		return nil, ErrNotConnected
 	}
-	return c.client.NewSession()
+	return conn.NewSession()
 }
 
 // Exec runs a command on the remote SSH server.
 func (c *Client) Exec(cmd string) ([]byte, error) {
-	session, err := c.client.NewSession()
+	session, err := c.NewSession()
 	if err != nil {
 		return nil, err
 	}
@@ -179,14 +493,44 @@ func (c *Client) Exec(cmd string) ([]byte, error) {
 	return output, nil
 }
 
-// loadPrivateKey loads a private key from a file
-func loadPrivateKey(path string) (ssh.Signer, error) {
+// ExecWithStdin runs a command on the remote SSH server with stdin as its standard input, for
+// passing secrets without exposing them in the command line.
+func (c *Client) ExecWithStdin(cmd string, stdin string) ([]byte, error) {
+	session, err := c.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	session.Stdin = strings.NewReader(stdin)
+	output, err := session.CombinedOutput(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// LoadPrivateKey loads a private key from a file, decrypting it with the passphrase when one is
+// given. An encrypted key without a passphrase fails with a hint to set key_passphrase.
+func LoadPrivateKey(path string, passphrase string) (ssh.Signer, error) {
 	key, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	if passphrase != "" {
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt private key %s: %w", path, err)
+		}
+		return signer, nil
+	}
+
 	signer, err := ssh.ParsePrivateKey(key)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return nil, fmt.Errorf("private key %s is encrypted, set key_passphrase: %w", path, err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -194,24 +538,95 @@ func loadPrivateKey(path string) (ssh.Signer, error) {
 	return signer, nil
 }
 
-// buildAuthMethods builds a list of SSH authentication methods based on available credentials
-func buildAuthMethods(password string) []ssh.AuthMethod {
+// Authentication methods reported by AuthMethod, in the order they are tried.
+const (
+	// AuthMethodPassword is the password of the host
+	AuthMethodPassword = "password"
+	// AuthMethodKeyPath is the private key at the key_path of the host
+	AuthMethodKeyPath = "key_path"
+	// AuthMethodAgent is a key of the SSH agent
+	AuthMethodAgent = "agent"
+	// AuthMethodDefaultKey is one of the default private keys in ~/.ssh
+	AuthMethodDefaultKey = "default_key"
+	// AuthMethodNone is reported when the server let the client in without authenticating
+	AuthMethodNone = "none"
+)
+
+// ResolvePassword returns the password of the host, resolving it when it is a secret reference.
+func (info *ClientInfo) ResolvePassword() (string, error) {
+	if !info.PassRef {
+		if IsSecretReference(info.Pass) {
+			slog.Warn("password looks like a secret reference but is used as is, set it again with password_ref to resolve it", "host", info.Name)
+		}
+		return info.Pass, nil
+	}
+	password, err := ResolveSecretReference(info.Pass)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve password: %w", err)
+	}
+	return password, nil
+}
+
+// ResolveKeyPassphrase returns the key passphrase of the host, resolving it when it is a secret
+// reference.
+func (info *ClientInfo) ResolveKeyPassphrase() (string, error) {
+	if !info.KeyPassphraseRef {
+		if IsSecretReference(info.KeyPassphrase) {
+			slog.Warn("key passphrase looks like a secret reference but is used as is, set it again with key_passphrase_ref to resolve it", "host", info.Name)
+		}
+		return info.KeyPassphrase, nil
+	}
+	passphrase, err := ResolveSecretReference(info.KeyPassphrase)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve key passphrase: %w", err)
+	}
+	return passphrase, nil
+}
+
+// buildAuthMethods builds a list of SSH authentication methods based on available credentials,
+// with secret references already resolved. The status of the SSH agent is returned for
+// diagnostics, nil when SSH_AUTH_SOCK is not set. tried, when not nil, is called with the AuthMethod constant of
+// each method as it is tried; for keys that is when the server accepted the key and it signs.
+func buildAuthMethods(password string, keyPath string, keyPassphrase string, tried func(method string)) ([]ssh.AuthMethod, *AgentStatus, error) {
+	if tried == nil {
+		tried = func(string) {}
+	}
 	authMethods := []ssh.AuthMethod{}
 
 	// If password is provided, use password authentication first
 	if password != "" {
-		authMethods = append(authMethods, ssh.Password(password))
+		authMethods = append(authMethods, ssh.PasswordCallback(func() (string, error) {
+			tried(AuthMethodPassword)
+			return password, nil
+		}))
+		slog.Debug("using password authentication")
 	}
 
-	// Try to use SSH agent
-	sshAuthSock := os.Getenv("SSH_AUTH_SOCK")
-	if sshAuthSock != "" {
-		if agentConn, err := net.Dial("unix", sshAuthSock); err == nil {
-			authMethods = append(authMethods, ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers))
+	// The keys are offered through a single publickey method, as the client does not try another
+	// method with the same name once one failed: the configured key of the host first, then the keys
+	// of the SSH agent, then the default keys
+	var keySigners []ssh.Signer
+	if keyPath != "" {
+		if signer, err := LoadPrivateKey(keyPath, keyPassphrase); err == nil {
+			keySigners = append(keySigners, trackSigner(signer, func() { tried(AuthMethodKeyPath) }))
+			slog.Debug("using configured private key", "path", keyPath)
+		} else {
+			slog.Warn("failed to load configured private key", "path", keyPath, "error", err)
 		}
 	}
 
+	// Try to use SSH agent, an unreachable agent is reported but the other methods are still tried
+	agentConn, agentStatus := dialAgent()
+	var agentClient agent.ExtendedAgent
+	if agentConn != nil {
+		agentClient = agent.NewClient(agentConn)
+		slog.Debug("using ssh agent", "socket", agentStatus.Socket)
+	} else if agentStatus != nil {
+		slog.Warn("ssh agent unavailable", "socket", agentStatus.Socket, "problem", agentStatus.Problem)
+	}
+
 	// Try to load SSH keys from standard locations
+	var defaultSigners []ssh.Signer
 	homeDir, err := os.UserHomeDir()
 	if err == nil {
 		keyPaths := []string{
@@ -221,24 +636,96 @@ func buildAuthMethods(password string) []ssh.AuthMethod {
 			filepath.Join(homeDir, ".ssh", "id_dsa"),
 		}
 
-		var signers []ssh.Signer
 		for _, keyPath := range keyPaths {
-			if signer, err := loadPrivateKey(keyPath); err == nil {
-				signers = append(signers, signer)
+			if signer, err := LoadPrivateKey(keyPath, ""); err == nil {
+				defaultSigners = append(defaultSigners, trackSigner(signer, func() { tried(AuthMethodDefaultKey) }))
+				slog.Debug("using default private key", "path", keyPath)
 			}
 		}
+	}
 
-		if len(signers) > 0 {
-			authMethods = append(authMethods, ssh.PublicKeys(signers...))
-		}
+	if len(keySigners) > 0 || agentClient != nil || len(defaultSigners) > 0 {
+		authMethods = append(authMethods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			signers := slices.Clone(keySigners)
+			if agentClient != nil {
+				// the agent's keys are only listed once public keys are tried
+				agentSigners, err := agentClient.Signers()
+				if err != nil {
+					slog.Warn("failed to list the keys of the ssh agent", "socket", agentStatus.Socket, "error", err)
+				}
+				for _, signer := range agentSigners {
+					signers = append(signers, trackSigner(signer, func() { tried(AuthMethodAgent) }))
+				}
+			}
+			return append(signers, defaultSigners...), nil
+		}))
 	}
 
-	return authMethods
+	return authMethods, agentStatus, nil
 }
 
-// getHostKeyCallback returns a HostKeyCallback that uses the known_hosts file
-// It will automatically add new hosts to the known_hosts file
+// trackSigner returns signer calling onSign each time it signs, keeping the algorithm interfaces
+// of signer so RSA keys still sign with SHA-2.
+func trackSigner(signer ssh.Signer, onSign func()) ssh.Signer {
+	algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+	if !ok {
+		return &trackedSigner{Signer: signer, onSign: onSign}
+	}
+	tracked := &trackedAlgorithmSigner{AlgorithmSigner: algorithmSigner, onSign: onSign}
+	if multi, ok := signer.(ssh.MultiAlgorithmSigner); ok {
+		return &trackedMultiAlgorithmSigner{trackedAlgorithmSigner: tracked, algorithms: multi.Algorithms()}
+	}
+	return tracked
+}
+
+// trackedSigner is a signer calling onSign each time it signs.
+type trackedSigner struct {
+	ssh.Signer
+	onSign func()
+}
+
+// Sign signs data after calling onSign.
+func (s *trackedSigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	s.onSign()
+	return s.Signer.Sign(rand, data)
+}
+
+// trackedAlgorithmSigner is an algorithm signer calling onSign each time it signs.
+type trackedAlgorithmSigner struct {
+	ssh.AlgorithmSigner
+	onSign func()
+}
+
+// Sign signs data after calling onSign.
+func (s *trackedAlgorithmSigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	s.onSign()
+	return s.AlgorithmSigner.Sign(rand, data)
+}
+
+// SignWithAlgorithm signs data with algorithm after calling onSign.
+func (s *trackedAlgorithmSigner) SignWithAlgorithm(rand io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	s.onSign()
+	return s.AlgorithmSigner.SignWithAlgorithm(rand, data, algorithm)
+}
+
+// trackedMultiAlgorithmSigner is a multi algorithm signer calling onSign each time it signs.
+type trackedMultiAlgorithmSigner struct {
+	*trackedAlgorithmSigner
+	algorithms []string
+}
+
+// Algorithms returns the algorithms of the tracked signer.
+func (s *trackedMultiAlgorithmSigner) Algorithms() []string {
+	return s.algorithms
+}
+
+// getHostKeyCallback returns a HostKeyCallback that uses the known_hosts file, or the in-memory
+// known hosts data when set. New hosts are automatically added unless strict host key checking is enabled.
 func getHostKeyCallback() (ssh.HostKeyCallback, error) {
+	if db := knownHostsData; db != nil {
+		return db.callback, nil
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user home directory: %w", err)
@@ -273,6 +760,10 @@ func getHostKeyCallback() (ssh.HostKeyCallback, error) {
 			// Check if this is a "host key not found" error
 			var keyErr *knownhosts.KeyError
 			if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+				if strictHostKeyChecking {
+					slog.Warn("host key verification failed", "host", hostname, "error", errUnknownHostStrict)
+					return fmt.Errorf("%s: %w", hostname, errUnknownHostStrict)
+				}
 				// Host not in known_hosts, add it
 				f, fileErr := os.OpenFile(knownHostsPath, os.O_APPEND|os.O_WRONLY, 0600)
 				if fileErr != nil {
@@ -287,12 +778,15 @@ func getHostKeyCallback() (ssh.HostKeyCallback, error) {
 				}
 
 				// Host was added, so accept this connection
+				slog.Info("added new host key to known_hosts", "host", hostname, "type", key.Type())
 				return nil
 			}
 			// Some other error (key mismatch, etc.)
+			slog.Warn("host key verification failed", "host", hostname, "error", err)
 			return err
 		}
 		// Host key matched
+		slog.Debug("host key matched known_hosts", "host", hostname, "type", key.Type())
 		return nil
 	}, nil
 }
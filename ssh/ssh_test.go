@@ -165,3 +165,21 @@ func TestNewClientInfo_WithoutSchemeUserAndHost(t *testing.T) {
 		t.Errorf("expected user 'user', got '%s'", info.User)
 	}
 }
+
+func TestResolveUser_Precedence(t *testing.T) {
+	t.Setenv("USER", "osuser")
+	t.Cleanup(func() { SetDefaultUser("") })
+
+	SetDefaultUser("")
+	if got := resolveUser(""); got != "osuser" {
+		t.Errorf("expected OS user 'osuser', got '%s'", got)
+	}
+
+	SetDefaultUser("deploy")
+	if got := resolveUser(""); got != "deploy" {
+		t.Errorf("expected default user 'deploy', got '%s'", got)
+	}
+	if got := resolveUser("admin"); got != "admin" {
+		t.Errorf("expected host user 'admin', got '%s'", got)
+	}
+}
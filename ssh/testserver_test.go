@@ -0,0 +1,96 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// testServerHandler serves a connection to a testServer once its handshake succeeded. The
+// connection is closed when it returns.
+type testServerHandler func(conn *ssh.ServerConn, chans <-chan ssh.NewChannel, reqs <-chan *ssh.Request)
+
+// testServer is an in-process SSH server listening on localhost.
+type testServer struct {
+	addr    string
+	hostKey ssh.PublicKey
+
+	mu    sync.Mutex
+	conns []net.Conn
+	// accepted is the number of connections accepted
+	accepted int
+}
+
+// startTestServer starts a server running the handshake of each connection with config, after
+// adding a new host key to it, and then handle. A nil handle closes the connection right after
+// the handshake. The server stops when the test ends.
+func startTestServer(t *testing.T, config *ssh.ServerConfig, handle testServerHandler) *testServer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &testServer{addr: listener.Addr().String(), hostKey: signer.PublicKey()}
+	t.Cleanup(func() {
+		listener.Close()
+		server.dropConnections()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.conns = append(server.conns, conn)
+			server.accepted++
+			server.mu.Unlock()
+			go func() {
+				defer conn.Close()
+				sshConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				defer sshConn.Close()
+				if handle != nil {
+					handle(sshConn, chans, reqs)
+				}
+			}()
+		}
+	}()
+	return server
+}
+
+// hostPort returns the host and port to connect to the server.
+func (s *testServer) hostPort(t *testing.T) (string, string) {
+	t.Helper()
+	host, port, err := net.SplitHostPort(s.addr)
+	require.NoError(t, err)
+	return host, port
+}
+
+// dropConnections closes every accepted connection, as if the network between them failed.
+func (s *testServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+// connections returns the number of connections accepted.
+func (s *testServer) connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accepted
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+const commandsPrefix = "command:"
+
+// commandRecordGzip is the version byte of a command record stored as gzip compressed JSON, as the
+// output it carries compresses well. Records written before compression are plain JSON starting
+// with '{' and are still read.
+const commandRecordGzip byte = 1
+
+// CommandRecord is a command kept in the command history, so it survives a restart of the
+// server. State is the command's state encoded as JSON by the commands package, storage does not
+// interpret it.
+type CommandRecord struct {
+	ID        string          `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	State     json.RawMessage `json:"state"`
+}
+
+// makeCommandKey creates a key for storing a command record.
+// Format: command:id
+func makeCommandKey(id string) []byte {
+	return []byte(commandsPrefix + id)
+}
+
+// SetCommand saves a command record, replacing any previous record with the same ID.
+func (e *Engine) SetCommand(record CommandRecord) error {
+	if record.ID == "" {
+		return errors.New("command ID cannot be empty")
+	}
+	if len(record.State) == 0 {
+		return errors.New("command state cannot be empty")
+	}
+
+	value, err := encodeCommandRecord(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal command: %w", err)
+	}
+
+	err = e.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(makeCommandKey(record.ID), value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store command: %w", err)
+	}
+	return nil
+}
+
+// ListCommands retrieves all command records sorted by creation time, oldest first. Records that
+// cannot be decoded are skipped and logged so one corrupt record does not lose the whole history.
+func (e *Engine) ListCommands() ([]CommandRecord, error) {
+	var records []CommandRecord
+	err := e.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(commandsPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			var record CommandRecord
+			err := item.Value(func(val []byte) error {
+				var err error
+				record, err = decodeCommandRecord(val)
+				return err
+			})
+			if err != nil {
+				slog.Warn("skipping corrupt command record", "key", string(item.Key()), "error", err)
+				continue
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commands: %w", err)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	return records, nil
+}
+
+// DeleteCommandsBefore removes the command records created before the cutoff and returns how many
+// were removed.
+func (e *Engine) DeleteCommandsBefore(cutoff time.Time) (int, error) {
+	records, err := e.ListCommands()
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	err = e.db.Update(func(txn *badger.Txn) error {
+		for _, record := range records {
+			if !record.CreatedAt.Before(cutoff) {
+				// sorted oldest first, so the rest are all kept
+				break
+			}
+			if err := txn.Delete(makeCommandKey(record.ID)); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete commands: %w", err)
+	}
+	return deleted, nil
+}
+
+// encodeCommandRecord encodes a command record as its version byte followed by gzip compressed JSON.
+func encodeCommandRecord(record CommandRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(commandRecordGzip)
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(record); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeCommandRecord decodes a command record written by encodeCommandRecord, or as plain JSON
+// before records were compressed.
+func decodeCommandRecord(value []byte) (CommandRecord, error) {
+	var record CommandRecord
+	if len(value) == 0 {
+		return record, errors.New("empty command record")
+	}
+	switch value[0] {
+	case '{':
+		err := json.Unmarshal(value, &record)
+		return record, err
+	case commandRecordGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(value[1:]))
+		if err != nil {
+			return record, err
+		}
+		defer zr.Close()
+		err = json.NewDecoder(zr).Decode(&record)
+		return record, err
+	}
+	return record, fmt.Errorf("unknown command record version %d", value[0])
+}
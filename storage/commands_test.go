@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Commands(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, e.SetCommand(CommandRecord{ID: "newer", CreatedAt: now, State: json.RawMessage(`{"id":"newer"}`)}))
+	require.NoError(t, e.SetCommand(CommandRecord{ID: "older", CreatedAt: now.Add(-time.Hour), State: json.RawMessage(`{"id":"older"}`)}))
+	require.Error(t, e.SetCommand(CommandRecord{CreatedAt: now, State: json.RawMessage(`{}`)}))
+	require.Error(t, e.SetCommand(CommandRecord{ID: "empty", CreatedAt: now}))
+	require.NoError(t, e.Close())
+
+	// the history survives a restart, oldest first
+	e, err = NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	records, err := e.ListCommands()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "older", records[0].ID)
+	require.Equal(t, "newer", records[1].ID)
+	require.JSONEq(t, `{"id":"newer"}`, string(records[1].State))
+
+	deleted, err := e.DeleteCommandsBefore(now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+	records, err = e.ListCommands()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "newer", records[0].ID)
+}
+
+func TestEngine_ListCommands_SkipsCorruptRecords(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t))
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.SetCommand(CommandRecord{ID: "good", CreatedAt: time.Now(), State: json.RawMessage(`{"id":"good"}`)}))
+	require.NoError(t, e.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(makeCommandKey("broken"), []byte("{not json"))
+	}))
+
+	records, err := e.ListCommands()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "good", records[0].ID)
+}
+
+func TestEngine_Commands_Compressed(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t))
+	require.NoError(t, err)
+	defer e.Close()
+
+	// a log-heavy command output, repetitive like most logs
+	var output strings.Builder
+	for i := range 2000 {
+		fmt.Fprintf(&output, "2026-10-16T04:%02d:%02dZ INFO request served method=GET path=/api/v1/items status=200 duration_ms=%d\n", i/60%60, i%60, i%97)
+	}
+	state, err := json.Marshal(map[string]string{"id": "logs", "output": output.String()})
+	require.NoError(t, err)
+	record := CommandRecord{ID: "logs", CreatedAt: time.Now().UTC(), State: state}
+	require.NoError(t, e.SetCommand(record))
+
+	raw, err := json.Marshal(record)
+	require.NoError(t, err)
+	var stored []byte
+	require.NoError(t, e.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(makeCommandKey("logs"))
+		if err != nil {
+			return err
+		}
+		stored, err = item.ValueCopy(nil)
+		return err
+	}))
+	require.Equal(t, commandRecordGzip, stored[0])
+	require.Less(t, len(stored)*10, len(raw), "expected at least 10x smaller, stored %d of %d bytes", len(stored), len(raw))
+
+	// records written before compression still load
+	require.NoError(t, e.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(makeCommandKey("legacy"), []byte(`{"id":"legacy","created_at":"2026-01-01T00:00:00Z","state":{"id":"legacy"}}`))
+	}))
+	records, err := e.ListCommands()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "legacy", records[0].ID)
+	require.JSONEq(t, string(state), string(records[1].State))
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+const defaultUserPrefix = "config:default_user"
+
+// makeDefaultUserKey creates a key for storing a default user.
+// Format: config:default_user for the global default, config:default_user:group for a group default
+func makeDefaultUserKey(group string) []byte {
+	if group == "" {
+		return []byte(defaultUserPrefix)
+	}
+	return []byte(defaultUserPrefix + ":" + group)
+}
+
+// SetDefaultUser saves the user to connect as for hosts in a group that do not specify one.
+// An empty group sets the global default. An empty user removes the default.
+func (e *Engine) SetDefaultUser(group, user string) error {
+	key := makeDefaultUserKey(group)
+	err := e.db.Update(func(txn *badger.Txn) error {
+		if user == "" {
+			return txn.Delete(key)
+		}
+		return txn.Set(key, []byte(user))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store default user: %w", err)
+	}
+	return nil
+}
+
+// GetDefaultUser retrieves the default user for a group, or the global default when group is empty.
+func (e *Engine) GetDefaultUser(group string) (string, bool) {
+	var user string
+	err := e.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(makeDefaultUserKey(group))
+		if err != nil {
+			return err
+		}
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		user = string(value)
+		return nil
+	})
+	if err != nil {
+		return "", false
+	}
+	return user, true
+}
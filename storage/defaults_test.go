@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_SetAndGetDefaultUser(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	_, ok := e.GetDefaultUser("")
+	require.False(t, ok)
+
+	require.NoError(t, e.SetDefaultUser("", "deploy"))
+	require.NoError(t, e.SetDefaultUser("production", "ops"))
+
+	user, ok := e.GetDefaultUser("")
+	require.True(t, ok)
+	require.Equal(t, "deploy", user)
+
+	user, ok = e.GetDefaultUser("production")
+	require.True(t, ok)
+	require.Equal(t, "ops", user)
+
+	_, ok = e.GetDefaultUser("staging")
+	require.False(t, ok)
+
+	// an empty user removes the default
+	require.NoError(t, e.SetDefaultUser("production", ""))
+	_, ok = e.GetDefaultUser("production")
+	require.False(t, ok)
+
+	// defaults must not show up as hosts or groups
+	groups, err := e.ListGroups()
+	require.NoError(t, err)
+	require.Empty(t, groups)
+}
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	badger "github.com/dgraph-io/badger/v4"
+	"golang.org/x/crypto/scrypt"
+)
+
+// encryptionKey stores the salt the storage key is derived with and a value encrypted with it, to
+// tell a wrong key apart from corrupt records.
+const encryptionKey = "config:encryption"
+
+// encryptedPrefix marks a secret encrypted at rest, values without it are plaintext.
+const encryptedPrefix = "enc:v1:"
+
+// encryptionCheck is the plaintext of the check value.
+const encryptionCheck = "ssh-mcp"
+
+// scrypt parameters deriving the AES-256 key from the storage key.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+)
+
+// encryptionRecord is the stored encryptionKey value.
+type encryptionRecord struct {
+	Salt  []byte `json:"salt"`
+	Check string `json:"check"`
+}
+
+// EngineOption configures optional behaviour of an Engine.
+type EngineOption func(*engineOptions)
+
+// engineOptions are the options given to NewEngine.
+type engineOptions struct {
+	storageKey string
+}
+
+// WithStorageKey encrypts the secrets of every host (the password, key passphrase and jump host, as
+// a connection string may carry the bastion's password) at rest with AES-GCM, using a key derived
+// from storageKey. Secrets are decrypted transparently when hosts are read. Storage encrypted once
+// always requires the same key.
+func WithStorageKey(storageKey string) EngineOption {
+	return func(o *engineOptions) {
+		o.storageKey = storageKey
+	}
+}
+
+// setupEncryption enables encryption with the storage key, or checks that storage is not encrypted
+// without one. Enabling it for the first time encrypts the secrets already stored.
+func (e *Engine) setupEncryption(storageKey string) error {
+	var record *encryptionRecord
+	err := e.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(encryptionKey))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			record = &encryptionRecord{}
+			return json.Unmarshal(val, record)
+		})
+	})
+	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return fmt.Errorf("failed to read encryption settings: %w", err)
+	}
+
+	if storageKey == "" {
+		if record != nil {
+			return errors.New("storage is encrypted, a storage key is required to open it")
+		}
+		return nil
+	}
+
+	if record != nil {
+		e.secrets, err = newSecretCipher(storageKey, record.Salt)
+		if err != nil {
+			return err
+		}
+		if check, err := e.decryptSecret(record.Check); err != nil || check != encryptionCheck {
+			e.secrets = nil
+			return errors.New("wrong storage key, it does not match the key storage was encrypted with")
+		}
+		return nil
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	e.secrets, err = newSecretCipher(storageKey, salt)
+	if err != nil {
+		return err
+	}
+	check, err := e.encryptSecret(encryptionCheck)
+	if err != nil {
+		return err
+	}
+	settings, err := json.Marshal(encryptionRecord{Salt: salt, Check: check})
+	if err != nil {
+		return fmt.Errorf("failed to marshal encryption settings: %w", err)
+	}
+
+	// encrypt the stored secrets together with saving the settings, so a failure leaves storage
+	// unencrypted rather than half encrypted
+	hosts, err := e.List()
+	if err != nil {
+		return err
+	}
+	err = e.db.Update(func(txn *badger.Txn) error {
+		for _, info := range hosts {
+			if info.Pass == "" && info.KeyPassphrase == "" && info.JumpHost == "" {
+				continue
+			}
+			value, err := e.marshalHost(info)
+			if err != nil {
+				return err
+			}
+			if err := txn.Set(makeKey(info.Group, info.Name), value); err != nil {
+				return err
+			}
+		}
+		return txn.Set([]byte(encryptionKey), settings)
+	})
+	if err != nil {
+		e.secrets = nil
+		return fmt.Errorf("failed to encrypt stored secrets: %w", err)
+	}
+	slog.Info("enabled storage encryption", "hosts", len(hosts))
+	return nil
+}
+
+// newSecretCipher derives the AES-256-GCM cipher from the storage key and salt.
+func newSecretCipher(storageKey string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(storageKey), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive the encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptSecret encrypts a secret, returned unchanged when empty or encryption is disabled.
+func (e *Engine) encryptSecret(secret string) (string, error) {
+	if e.secrets == nil || secret == "" {
+		return secret, nil
+	}
+	nonce := make([]byte, e.secrets.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := e.secrets.Seal(nonce, nonce, []byte(secret), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret decrypts a secret encrypted by encryptSecret, plaintext values are returned
+// unchanged.
+func (e *Engine) decryptSecret(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	if e.secrets == nil {
+		return "", errors.New("secret is encrypted but no storage key is set")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < e.secrets.NonceSize() {
+		return "", errors.New("malformed encrypted secret")
+	}
+	nonce, ciphertext := sealed[:e.secrets.NonceSize()], sealed[e.secrets.NonceSize():]
+	plaintext, err := e.secrets.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// marshalHost encodes a host for storage with its secrets encrypted.
+func (e *Engine) marshalHost(info ssh.ClientInfo) ([]byte, error) {
+	var err error
+	if info.Pass, err = e.encryptSecret(info.Pass); err != nil {
+		return nil, err
+	}
+	if info.KeyPassphrase, err = e.encryptSecret(info.KeyPassphrase); err != nil {
+		return nil, err
+	}
+	if info.JumpHost, err = e.encryptSecret(info.JumpHost); err != nil {
+		return nil, err
+	}
+	return json.Marshal(info)
+}
+
+// unmarshalHost decodes a stored host with its secrets decrypted.
+func (e *Engine) unmarshalHost(value []byte) (ssh.ClientInfo, error) {
+	var info ssh.ClientInfo
+	if err := json.Unmarshal(value, &info); err != nil {
+		return ssh.ClientInfo{}, err
+	}
+	var err error
+	if info.Pass, err = e.decryptSecret(info.Pass); err != nil {
+		return ssh.ClientInfo{}, fmt.Errorf("password: %w", err)
+	}
+	if info.KeyPassphrase, err = e.decryptSecret(info.KeyPassphrase); err != nil {
+		return ssh.ClientInfo{}, fmt.Errorf("key passphrase: %w", err)
+	}
+	if info.JumpHost, err = e.decryptSecret(info.JumpHost); err != nil {
+		return ssh.ClientInfo{}, fmt.Errorf("jump host: %w", err)
+	}
+	return info, nil
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// rawHost returns the host record as stored, without decrypting it.
+func rawHost(t *testing.T, e *Engine, group, name string) string {
+	t.Helper()
+	var raw string
+	err := e.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(makeKey(group, name))
+		if err != nil {
+			return err
+		}
+		value, err := item.ValueCopy(nil)
+		raw = string(value)
+		return err
+	})
+	require.NoError(t, err)
+	return raw
+}
+
+func TestEngine_Encryption(t *testing.T) {
+	path := tempDBPath(t)
+
+	// hosts stored before encryption is enabled are encrypted when it is
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	before := dummyClientInfo("prod", "db1")
+	before.Pass = "hunter2"
+	require.NoError(t, e.Set(before))
+	require.Contains(t, rawHost(t, e, "prod", "db1"), "hunter2")
+	require.NoError(t, e.Close())
+
+	e, err = NewEngine(path, WithStorageKey("s3cret"))
+	require.NoError(t, err)
+	after := dummyClientInfo("prod", "web1")
+	after.Pass = "env:WEB_PASS"
+	after.KeyPassphrase = "correct horse"
+	after.JumpHost = "ops:bastion-pw@bastion.example.com"
+	require.NoError(t, e.Set(after))
+	for _, name := range []string{"db1", "web1"} {
+		raw := rawHost(t, e, "prod", name)
+		require.Contains(t, raw, encryptedPrefix)
+		require.NotContains(t, raw, "hunter2")
+		require.NotContains(t, raw, "correct horse")
+		require.NotContains(t, raw, "bastion-pw")
+	}
+
+	host, ok := e.Get("prod", "db1")
+	require.True(t, ok)
+	require.Equal(t, "hunter2", host.Pass)
+	hosts, err := e.List()
+	require.NoError(t, err)
+	require.Len(t, hosts, 2)
+	require.Equal(t, "correct horse", hosts[1].KeyPassphrase)
+	require.Equal(t, "env:WEB_PASS", hosts[1].Pass)
+	require.Equal(t, "ops:bastion-pw@bastion.example.com", hosts[1].JumpHost)
+
+	// secrets survive renames
+	require.NoError(t, e.Rename("prod", "web1", "web2"))
+	host, ok = e.Get("prod", "web2")
+	require.True(t, ok)
+	require.Equal(t, "correct horse", host.KeyPassphrase)
+	require.NotContains(t, rawHost(t, e, "prod", "web2"), "correct horse")
+	require.NoError(t, e.Close())
+
+	// once encrypted, storage only opens with the same key
+	_, err = NewEngine(path)
+	require.ErrorContains(t, err, "storage key is required")
+	_, err = NewEngine(path, WithStorageKey("wrong"))
+	require.ErrorContains(t, err, "wrong storage key")
+
+	e, err = NewEngine(path, WithStorageKey("s3cret"))
+	require.NoError(t, err)
+	defer e.Close()
+	host, ok = e.Get("prod", "db1")
+	require.True(t, ok)
+	require.Equal(t, "hunter2", host.Pass)
+}
+
+func TestEngine_DecryptSecret_Tampered(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t), WithStorageKey("s3cret"))
+	require.NoError(t, err)
+	defer e.Close()
+
+	encrypted, err := e.encryptSecret("hunter2")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(encrypted, encryptedPrefix))
+
+	tampered := encrypted[:len(encrypted)-2] + "AA"
+	if tampered == encrypted {
+		tampered = encrypted[:len(encrypted)-2] + "BB"
+	}
+	_, err = e.decryptSecret(tampered)
+	require.Error(t, err)
+
+	plain, err := e.decryptSecret("hunter2")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", plain)
+}
+
+func TestEngine_Rename_EncryptsPlaintextSecrets(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t), WithStorageKey("s3cret"))
+	require.NoError(t, err)
+	defer e.Close()
+
+	// a secret left in plaintext, e.g. written before encryption covered it
+	setRawHost(t, e, "host:prod:legacy", `{"name":"legacy","group":"prod","host":"10.0.0.1","port":"22","pass":"hunter2"}`)
+
+	require.NoError(t, e.Rename("prod", "legacy", "db1"))
+	raw := rawHost(t, e, "prod", "db1")
+	require.Contains(t, raw, encryptedPrefix)
+	require.NotContains(t, raw, "hunter2")
+	host, ok := e.Get("prod", "db1")
+	require.True(t, ok)
+	require.Equal(t, "hunter2", host.Pass)
+}
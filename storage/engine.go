@@ -1,8 +1,12 @@
 package storage
 
 import (
-	"encoding/json"
+	"crypto/cipher"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/blakerouse/ssh-mcp/ssh"
 	badger "github.com/dgraph-io/badger/v4"
@@ -16,20 +20,38 @@ type Engine struct {
 
 	// path to store the database
 	path string
+
+	// lastSeenWrites is when each host's last seen was last written, to throttle the writes
+	lastSeenMu     sync.Mutex
+	lastSeenWrites map[string]time.Time
+
+	// secrets encrypts the secrets of the hosts at rest, nil when encryption is disabled
+	secrets cipher.AEAD
 }
 
 // NewEngine creates a new storage Engine instance.
-func NewEngine(path string) (*Engine, error) {
+func NewEngine(path string, engineOpts ...EngineOption) (*Engine, error) {
+	var options engineOptions
+	for _, opt := range engineOpts {
+		opt(&options)
+	}
+
 	opts := badger.DefaultOptions(path)
-	opts.Logger = nil // Disable logging
+	opts.Logger = badgerLogger{}
 	db, err := badger.Open(opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open badger database: %w", err)
 	}
+	slog.Debug("opened storage", "path", path)
 
 	e := &Engine{
-		db:   db,
-		path: path,
+		db:             db,
+		path:           path,
+		lastSeenWrites: make(map[string]time.Time),
+	}
+	if err := e.setupEncryption(options.storageKey); err != nil {
+		_ = db.Close()
+		return nil, err
 	}
 	return e, nil
 }
@@ -59,7 +81,8 @@ func (e *Engine) Get(group, name string) (ssh.ClientInfo, bool) {
 			return err
 		}
 		return item.Value(func(val []byte) error {
-			return json.Unmarshal(val, &info)
+			info, err = e.unmarshalHost(val)
+			return err
 		})
 	})
 
@@ -67,6 +90,7 @@ func (e *Engine) Get(group, name string) (ssh.ClientInfo, bool) {
 		if err == badger.ErrKeyNotFound {
 			return ssh.ClientInfo{}, false
 		}
+		slog.Warn("failed to read host record", "key", string(key), "error", err)
 		return ssh.ClientInfo{}, false
 	}
 	return info, true
@@ -83,7 +107,7 @@ func (e *Engine) Set(info ssh.ClientInfo) error {
 	}
 
 	key := makeKey(info.Group, info.Name)
-	value, err := json.Marshal(info)
+	value, err := e.marshalHost(info)
 	if err != nil {
 		return fmt.Errorf("failed to marshal client info: %w", err)
 	}
@@ -94,6 +118,60 @@ func (e *Engine) Set(info ssh.ClientInfo) error {
 	if err != nil {
 		return fmt.Errorf("failed to store client info: %w", err)
 	}
+	slog.Debug("stored host", "group", info.Group, "name", info.Name)
+	return nil
+}
+
+// SetBatch saves the SSH client information for many hosts at once. Every record is validated
+// before anything is written, so a single invalid record leaves storage unchanged. The records
+// are written in a single transaction, falling back to a write batch when there are too many for
+// one transaction.
+func (e *Engine) SetBatch(infos []ssh.ClientInfo) error {
+	keys := make([][]byte, len(infos))
+	values := make([][]byte, len(infos))
+	seen := make(map[string]bool, len(infos))
+	for i, info := range infos {
+		if info.Group == "" {
+			return fmt.Errorf("host %d: group cannot be empty", i)
+		}
+		if info.Name == "" {
+			return fmt.Errorf("host %d: name cannot be empty", i)
+		}
+		keys[i] = makeKey(info.Group, info.Name)
+		if seen[string(keys[i])] {
+			return fmt.Errorf("host %s:%s is specified more than once", info.Group, info.Name)
+		}
+		seen[string(keys[i])] = true
+
+		value, err := e.marshalHost(info)
+		if err != nil {
+			return fmt.Errorf("failed to marshal client info for %s:%s: %w", info.Group, info.Name, err)
+		}
+		values[i] = value
+	}
+
+	err := e.db.Update(func(txn *badger.Txn) error {
+		for i := range keys {
+			if err := txn.Set(keys[i], values[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, badger.ErrTxnTooBig) {
+		wb := e.db.NewWriteBatch()
+		defer wb.Cancel()
+		for i := range keys {
+			if err := wb.Set(keys[i], values[i]); err != nil {
+				return fmt.Errorf("failed to store client info: %w", err)
+			}
+		}
+		err = wb.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store client info: %w", err)
+	}
+	slog.Debug("stored hosts", "count", len(infos))
 	return nil
 }
 
@@ -106,9 +184,107 @@ func (e *Engine) Delete(group, name string) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete client info: %w", err)
 	}
+	slog.Debug("deleted host", "group", group, "name", name)
 	return nil
 }
 
+// Rename moves a host in a group to a new name, see Update. Fails when a host with the new name
+// already exists in the group.
+func (e *Engine) Rename(group, name, newName string) error {
+	info, ok := e.Get(group, name)
+	if !ok {
+		return fmt.Errorf("host %s not found in group %s", name, group)
+	}
+	info.Name = newName
+	return e.Update(group, name, info)
+}
+
+// Update replaces the host stored under group and name with info in one transaction. info may have
+// another group or name, moving the host, as long as no other host is stored under them. Hosts using
+// the moved host as their jump host are changed to reference it by its new 'group:name'.
+func (e *Engine) Update(group, name string, info ssh.ClientInfo) error {
+	if info.Group == "" {
+		return fmt.Errorf("group cannot be empty")
+	}
+	if info.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+	key := makeKey(group, name)
+	newKey := makeKey(info.Group, info.Name)
+	value, err := e.marshalHost(info)
+	if err != nil {
+		return fmt.Errorf("failed to marshal client info: %w", err)
+	}
+
+	var dependents []string
+	err = e.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(key); err != nil {
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				return fmt.Errorf("host %s not found in group %s", name, group)
+			}
+			return err
+		}
+		if string(newKey) != string(key) {
+			if _, err := txn.Get(newKey); err == nil {
+				return fmt.Errorf("host %s already exists in group %s", info.Name, info.Group)
+			} else if !errors.Is(err, badger.ErrKeyNotFound) {
+				return err
+			}
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+			dependents, err = e.moveJumpHostReferences(txn, group+":"+name, info.Group+":"+info.Name)
+			if err != nil {
+				return err
+			}
+		}
+		return txn.Set(newKey, value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update host: %w", err)
+	}
+	slog.Debug("updated host", "group", group, "name", name, "new_group", info.Group, "new_name", info.Name)
+	if len(dependents) > 0 {
+		slog.Info("updated the jump host of hosts using the moved host", "jump_host", info.Group+":"+info.Name, "hosts", dependents)
+	}
+	return nil
+}
+
+// moveJumpHostReferences changes the jump host of every host referencing ref to newRef within txn,
+// returning the 'group:name' of the changed hosts. Records that cannot be parsed are left alone.
+func (e *Engine) moveJumpHostReferences(txn *badger.Txn, ref, newRef string) ([]string, error) {
+	var changed []ssh.ClientInfo
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(hostsPrefix)
+	it := txn.NewIterator(opts)
+	for it.Rewind(); it.Valid(); it.Next() {
+		var info ssh.ClientInfo
+		err := it.Item().Value(func(val []byte) error {
+			var err error
+			info, err = e.unmarshalHost(val)
+			return err
+		})
+		if err == nil && info.JumpHost == ref {
+			info.JumpHost = newRef
+			changed = append(changed, info)
+		}
+	}
+	it.Close()
+
+	dependents := make([]string, 0, len(changed))
+	for _, info := range changed {
+		value, err := e.marshalHost(info)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal client info: %w", err)
+		}
+		if err := txn.Set(makeKey(info.Group, info.Name), value); err != nil {
+			return nil, err
+		}
+		dependents = append(dependents, info.Group+":"+info.Name)
+	}
+	return dependents, nil
+}
+
 // List retrieves all hosts across all groups.
 func (e *Engine) List() ([]ssh.ClientInfo, error) {
 	return e.listWithPrefix(hostsPrefix)
@@ -155,7 +331,9 @@ func (e *Engine) ListGroups() ([]string, error) {
 	return groups, nil
 }
 
-// listWithPrefix is a helper function to list hosts with a given prefix.
+// listWithPrefix is a helper function to list hosts with a given prefix. Records that cannot be
+// parsed are skipped and logged so one corrupt record does not hide every other host, use
+// CheckHosts to find them.
 func (e *Engine) listWithPrefix(prefix string) ([]ssh.ClientInfo, error) {
 	var hosts []ssh.ClientInfo
 	prefixBytes := []byte(prefix)
@@ -168,17 +346,17 @@ func (e *Engine) listWithPrefix(prefix string) ([]ssh.ClientInfo, error) {
 
 		for it.Rewind(); it.Valid(); it.Next() {
 			item := it.Item()
+			var info ssh.ClientInfo
 			err := item.Value(func(val []byte) error {
-				var info ssh.ClientInfo
-				if err := json.Unmarshal(val, &info); err != nil {
-					return err
-				}
-				hosts = append(hosts, info)
-				return nil
+				var err error
+				info, err = e.unmarshalHost(val)
+				return err
 			})
 			if err != nil {
-				return err
+				slog.Warn("skipping corrupt host record, run check_storage to repair it", "key", string(item.Key()), "error", err)
+				continue
 			}
+			hosts = append(hosts, info)
 		}
 		return nil
 	})
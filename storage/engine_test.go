@@ -57,6 +57,21 @@ func TestNewEngine_DBExists(t *testing.T) {
 	require.Equal(t, info, got)
 }
 
+func TestEngine_SetAndGet_Description(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	info := dummyClientInfo("production", "db1")
+	info.Description = "primary DB, do not reboot during business hours"
+	require.NoError(t, e.Set(info))
+
+	got, ok := e.Get("production", "db1")
+	require.True(t, ok)
+	require.Equal(t, info.Description, got.Description)
+}
+
 func TestEngine_SetAndGet(t *testing.T) {
 	path := tempDBPath(t)
 	e, err := NewEngine(path)
@@ -72,6 +87,39 @@ func TestEngine_SetAndGet(t *testing.T) {
 	require.Equal(t, info, got)
 }
 
+func TestEngine_SetBatch(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	infos := []ssh.ClientInfo{dummyClientInfo("production", "host1"), dummyClientInfo("staging", "host2")}
+	require.NoError(t, e.SetBatch(infos))
+
+	hosts, err := e.List()
+	require.NoError(t, err)
+	require.ElementsMatch(t, infos, hosts)
+}
+
+func TestEngine_SetBatch_PartialFailure(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.Set(dummyClientInfo("production", "existing")))
+
+	err = e.SetBatch([]ssh.ClientInfo{dummyClientInfo("production", "host1"), dummyClientInfo("", "host2")})
+	require.Error(t, err)
+	err = e.SetBatch([]ssh.ClientInfo{dummyClientInfo("production", "host1"), dummyClientInfo("production", "host1")})
+	require.Error(t, err)
+
+	// nothing from the failed batches was written
+	hosts, err := e.List()
+	require.NoError(t, err)
+	require.Equal(t, []ssh.ClientInfo{dummyClientInfo("production", "existing")}, hosts)
+}
+
 func TestEngine_Get_NotFound(t *testing.T) {
 	path := tempDBPath(t)
 	e, err := NewEngine(path)
@@ -98,6 +146,93 @@ func TestEngine_Delete(t *testing.T) {
 	require.False(t, ok)
 }
 
+func TestEngine_Rename(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.Set(dummyClientInfo("development", "host1")))
+	require.NoError(t, e.Set(dummyClientInfo("development", "host2")))
+
+	require.NoError(t, e.Rename("development", "host1", "web1"))
+	_, ok := e.Get("development", "host1")
+	require.False(t, ok)
+	renamed, ok := e.Get("development", "web1")
+	require.True(t, ok)
+	require.Equal(t, "web1", renamed.Name)
+	require.Equal(t, "127.0.0.1", renamed.Host)
+
+	// the new name must be free and the host must exist
+	require.Error(t, e.Rename("development", "web1", "host2"))
+	require.Error(t, e.Rename("development", "missing", "web3"))
+	_, ok = e.Get("development", "web1")
+	require.True(t, ok)
+}
+
+func TestEngine_Update(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.Set(dummyClientInfo("development", "host1")))
+	require.NoError(t, e.Set(dummyClientInfo("production", "host2")))
+
+	// in place
+	updated := dummyClientInfo("development", "host1")
+	updated.Port = "2222"
+	require.NoError(t, e.Update("development", "host1", updated))
+	stored, ok := e.Get("development", "host1")
+	require.True(t, ok)
+	require.Equal(t, "2222", stored.Port)
+
+	// moved to another group and name
+	updated.Group = "staging"
+	updated.Name = "web1"
+	require.NoError(t, e.Update("development", "host1", updated))
+	_, ok = e.Get("development", "host1")
+	require.False(t, ok)
+	stored, ok = e.Get("staging", "web1")
+	require.True(t, ok)
+	require.Equal(t, "2222", stored.Port)
+
+	// the new group and name must be free and the host must exist
+	require.ErrorContains(t, e.Update("staging", "web1", dummyClientInfo("production", "host2")), "already exists")
+	require.ErrorContains(t, e.Update("development", "missing", dummyClientInfo("development", "web3")), "not found")
+	_, ok = e.Get("staging", "web1")
+	require.True(t, ok)
+}
+
+func TestEngine_Update_MovesJumpHostReferences(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t))
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.Set(dummyClientInfo("ops", "bastion")))
+	behind := dummyClientInfo("production", "db1")
+	behind.JumpHost = "ops:bastion"
+	require.NoError(t, e.Set(behind))
+	other := dummyClientInfo("production", "db2")
+	other.JumpHost = "bastion.example.com"
+	require.NoError(t, e.Set(other))
+
+	moved := dummyClientInfo("infra", "bastion1")
+	require.NoError(t, e.Update("ops", "bastion", moved))
+	stored, ok := e.Get("production", "db1")
+	require.True(t, ok)
+	require.Equal(t, "infra:bastion1", stored.JumpHost)
+	stored, ok = e.Get("production", "db2")
+	require.True(t, ok)
+	require.Equal(t, "bastion.example.com", stored.JumpHost)
+
+	// renaming is a move too
+	require.NoError(t, e.Rename("infra", "bastion1", "bastion2"))
+	stored, ok = e.Get("production", "db1")
+	require.True(t, ok)
+	require.Equal(t, "infra:bastion2", stored.JumpHost)
+}
+
 func TestEngine_List(t *testing.T) {
 	path := tempDBPath(t)
 	e, err := NewEngine(path)
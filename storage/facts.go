@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+const factsPrefix = "factcache:"
+
+// Fact is the cached output of a command for a single host.
+type Fact struct {
+	Key        string    `json:"key"`
+	Group      string    `json:"group"`
+	Name       string    `json:"name"`
+	Command    string    `json:"command"`
+	Output     string    `json:"output"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// makeFactKey creates a key for storing a cached fact.
+// Format: factcache:key:group:name
+func makeFactKey(key, group, name string) []byte {
+	return []byte(factsPrefix + key + ":" + group + ":" + name)
+}
+
+// SetFact saves the latest cached fact for a host, replacing any previous value.
+func (e *Engine) SetFact(fact Fact) error {
+	if fact.Key == "" {
+		return errors.New("fact key cannot be empty")
+	}
+	if strings.Contains(fact.Key, ":") {
+		return errors.New("fact key cannot contain ':'")
+	}
+	if fact.Group == "" {
+		return errors.New("group cannot be empty")
+	}
+	if fact.Name == "" {
+		return errors.New("name cannot be empty")
+	}
+
+	value, err := json.Marshal(fact)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fact: %w", err)
+	}
+
+	err = e.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(makeFactKey(fact.Key, fact.Group, fact.Name), value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store fact: %w", err)
+	}
+	return nil
+}
+
+// GetFact retrieves the cached fact for a host.
+func (e *Engine) GetFact(key, group, name string) (Fact, bool) {
+	var fact Fact
+	err := e.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(makeFactKey(key, group, name))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &fact)
+		})
+	})
+	if err != nil {
+		return Fact{}, false
+	}
+	return fact, true
+}
+
+// ListFacts retrieves the cached facts for all hosts under a key. Facts that cannot be decoded are
+// skipped and logged, they are refreshed the next time the command is cached.
+func (e *Engine) ListFacts(key string) ([]Fact, error) {
+	var facts []Fact
+	err := e.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(factsPrefix + key + ":")
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			var fact Fact
+			err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &fact)
+			})
+			if err != nil {
+				slog.Warn("skipping corrupt fact record", "key", string(item.Key()), "error", err)
+				continue
+			}
+			facts = append(facts, fact)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list facts: %w", err)
+	}
+	return facts, nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_SetAndGetFact(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	fact := Fact{
+		Key:        "uptime",
+		Group:      "production",
+		Name:       "host1",
+		Command:    "uptime",
+		Output:     "up 3 days",
+		CapturedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	require.NoError(t, e.SetFact(fact))
+
+	got, ok := e.GetFact("uptime", "production", "host1")
+	require.True(t, ok)
+	require.Equal(t, fact, got)
+
+	_, ok = e.GetFact("uptime", "production", "host2")
+	require.False(t, ok)
+}
+
+func TestEngine_ListFacts(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.SetFact(Fact{Key: "uptime", Group: "production", Name: "host1"}))
+	require.NoError(t, e.SetFact(Fact{Key: "uptime", Group: "staging", Name: "host2"}))
+	require.NoError(t, e.SetFact(Fact{Key: "uptime2", Group: "production", Name: "host1"}))
+
+	facts, err := e.ListFacts("uptime")
+	require.NoError(t, err)
+	require.Len(t, facts, 2)
+
+	// facts must not show up as hosts
+	hosts, err := e.List()
+	require.NoError(t, err)
+	require.Empty(t, hosts)
+}
+
+func TestEngine_ListFacts_SkipsCorruptRecords(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t))
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.SetFact(Fact{Key: "uptime", Group: "production", Name: "host1", Output: "up 3 days"}))
+	require.NoError(t, e.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(makeFactKey("uptime", "production", "host2"), []byte("{not json"))
+	}))
+
+	facts, err := e.ListFacts("uptime")
+	require.NoError(t, err)
+	require.Len(t, facts, 1)
+	require.Equal(t, "host1", facts[0].Name)
+}
+
+func TestEngine_SetFact_InvalidKey(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	err = e.SetFact(Fact{Key: "bad:key", Group: "production", Name: "host1"})
+	require.Error(t, err)
+
+	err = e.SetFact(Fact{Group: "production", Name: "host1"})
+	require.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// PruneEmptyGroups removes the group configuration (the group default user) of every group that
+// no longer has any hosts, so it does not apply to hosts later added under a recycled group name.
+// Returns the pruned groups sorted by name. With dryRun nothing is removed.
+func (e *Engine) PruneEmptyGroups(dryRun bool) ([]string, error) {
+	var pruned []string
+	err := e.db.Update(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false // We only need keys
+
+		// collect the groups that still have hosts
+		groups := make(map[string]bool)
+		opts.Prefix = []byte(hostsPrefix)
+		it := txn.NewIterator(opts)
+		for it.Rewind(); it.Valid(); it.Next() {
+			if parts := splitKey(string(it.Item().Key())); parts.Group != "" {
+				groups[parts.Group] = true
+			}
+		}
+		it.Close()
+
+		// collect the group configuration of groups without hosts, the global default is kept
+		groupPrefix := defaultUserPrefix + ":"
+		var keys [][]byte
+		opts.Prefix = []byte(groupPrefix)
+		it = txn.NewIterator(opts)
+		for it.Rewind(); it.Valid(); it.Next() {
+			group := strings.TrimPrefix(string(it.Item().Key()), groupPrefix)
+			if !groups[group] {
+				keys = append(keys, it.Item().KeyCopy(nil))
+				pruned = append(pruned, group)
+			}
+		}
+		it.Close()
+
+		if dryRun {
+			return nil
+		}
+		for _, key := range keys {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune empty groups: %w", err)
+	}
+	sort.Strings(pruned)
+	return pruned, nil
+}
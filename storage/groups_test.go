@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+func TestEngine_PruneEmptyGroups(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.Set(ssh.ClientInfo{Group: "production", Name: "web1", Host: "10.0.0.1", Port: "22"}))
+	require.NoError(t, e.SetDefaultUser("", "deploy"))
+	require.NoError(t, e.SetDefaultUser("production", "ops"))
+	require.NoError(t, e.SetDefaultUser("staging", "qa"))
+	require.NoError(t, e.SetDefaultUser("legacy", "root"))
+
+	// a dry run only reports
+	pruned, err := e.PruneEmptyGroups(true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"legacy", "staging"}, pruned)
+	_, ok := e.GetDefaultUser("staging")
+	require.True(t, ok)
+
+	pruned, err = e.PruneEmptyGroups(false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"legacy", "staging"}, pruned)
+
+	_, ok = e.GetDefaultUser("staging")
+	require.False(t, ok)
+	_, ok = e.GetDefaultUser("legacy")
+	require.False(t, ok)
+	user, ok := e.GetDefaultUser("production")
+	require.True(t, ok)
+	require.Equal(t, "ops", user)
+	user, ok = e.GetDefaultUser("")
+	require.True(t, ok)
+	require.Equal(t, "deploy", user)
+
+	// nothing left to prune
+	pruned, err = e.PruneEmptyGroups(false)
+	require.NoError(t, err)
+	require.Empty(t, pruned)
+}
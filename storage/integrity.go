@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+const quarantinePrefix = "quarantine:"
+
+// CorruptRecord is a stored host record that cannot be parsed.
+type CorruptRecord struct {
+	Key   string `json:"key"`
+	Group string `json:"group"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// CheckHosts parses every stored host record, decrypting its secrets, and returns the ones that are
+// corrupt, the same records listing skips.
+func (e *Engine) CheckHosts() ([]CorruptRecord, error) {
+	var corrupt []CorruptRecord
+	err := e.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(hostsPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			err := item.Value(func(val []byte) error {
+				_, err := e.unmarshalHost(val)
+				return err
+			})
+			if err != nil {
+				corrupt = append(corrupt, newCorruptRecord(string(item.KeyCopy(nil)), err))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check hosts: %w", err)
+	}
+	return corrupt, nil
+}
+
+// QuarantineHost moves the raw value of a host record to a quarantine key so it no longer appears
+// in listings but can still be inspected or recovered. Returns the quarantine key.
+func (e *Engine) QuarantineHost(key string) (string, error) {
+	quarantineKey := quarantinePrefix + key
+	err := e.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if err := txn.Set([]byte(quarantineKey), value); err != nil {
+			return err
+		}
+		return txn.Delete([]byte(key))
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to quarantine %s: %w", key, err)
+	}
+	slog.Warn("quarantined corrupt host record", "key", key, "quarantine_key", quarantineKey)
+	return quarantineKey, nil
+}
+
+// DeleteKey removes a raw key, used to remove corrupt host records.
+func (e *Engine) DeleteKey(key string) error {
+	err := e.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	slog.Warn("deleted corrupt host record", "key", key)
+	return nil
+}
+
+// newCorruptRecord returns the corrupt record for the key and its parse error.
+func newCorruptRecord(key string, err error) CorruptRecord {
+	parts := splitKey(key)
+	return CorruptRecord{
+		Key:   key,
+		Group: parts.Group,
+		Name:  parts.Name,
+		Error: err.Error(),
+	}
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func setRawHost(t *testing.T, e *Engine, key string, value string) {
+	t.Helper()
+	require.NoError(t, e.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(key), []byte(value))
+	}))
+}
+
+func TestEngine_List_SkipsCorruptRecords(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t))
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.Set(dummyClientInfo("production", "host1")))
+	setRawHost(t, e, "host:production:broken", "{not json")
+	require.NoError(t, e.Set(dummyClientInfo("production", "host2")))
+
+	list, err := e.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	group, err := e.ListGroup("production")
+	require.NoError(t, err)
+	require.Len(t, group, 2)
+}
+
+func TestEngine_CheckHosts(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t))
+	require.NoError(t, err)
+	defer e.Close()
+
+	corrupt, err := e.CheckHosts()
+	require.NoError(t, err)
+	require.Empty(t, corrupt)
+
+	require.NoError(t, e.Set(dummyClientInfo("production", "host1")))
+	setRawHost(t, e, "host:staging:broken", "{not json")
+
+	corrupt, err = e.CheckHosts()
+	require.NoError(t, err)
+	require.Len(t, corrupt, 1)
+	require.Equal(t, "host:staging:broken", corrupt[0].Key)
+	require.Equal(t, "staging", corrupt[0].Group)
+	require.Equal(t, "broken", corrupt[0].Name)
+	require.NotEmpty(t, corrupt[0].Error)
+}
+
+func TestEngine_CheckHosts_UndecryptableSecret(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t), WithStorageKey("s3cret"))
+	require.NoError(t, err)
+	defer e.Close()
+	other, err := NewEngine(tempDBPath(t), WithStorageKey("other"))
+	require.NoError(t, err)
+	defer other.Close()
+
+	// a record encrypted under a different key parses as JSON but cannot be decrypted
+	host := dummyClientInfo("production", "foreign")
+	host.Pass = "hunter2"
+	value, err := other.marshalHost(host)
+	require.NoError(t, err)
+	setRawHost(t, e, "host:production:foreign", string(value))
+	require.NoError(t, e.Set(dummyClientInfo("production", "host1")))
+
+	list, err := e.List()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	corrupt, err := e.CheckHosts()
+	require.NoError(t, err)
+	require.Len(t, corrupt, 1)
+	require.Equal(t, "host:production:foreign", corrupt[0].Key)
+	require.Contains(t, corrupt[0].Error, "password")
+}
+
+func TestEngine_QuarantineHost(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t))
+	require.NoError(t, err)
+	defer e.Close()
+
+	setRawHost(t, e, "host:staging:broken", "{not json")
+
+	quarantineKey, err := e.QuarantineHost("host:staging:broken")
+	require.NoError(t, err)
+	require.Equal(t, "quarantine:host:staging:broken", quarantineKey)
+
+	corrupt, err := e.CheckHosts()
+	require.NoError(t, err)
+	require.Empty(t, corrupt)
+
+	// the raw value is kept under the quarantine key
+	require.NoError(t, e.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(quarantineKey))
+		if err != nil {
+			return err
+		}
+		value, err := item.ValueCopy(nil)
+		require.Equal(t, "{not json", string(value))
+		return err
+	}))
+
+	// the quarantined record is no longer a group
+	groups, err := e.ListGroups()
+	require.NoError(t, err)
+	require.Empty(t, groups)
+}
+
+func TestEngine_QuarantineHost_Missing(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t))
+	require.NoError(t, err)
+	defer e.Close()
+
+	_, err = e.QuarantineHost("host:staging:missing")
+	require.Error(t, err)
+}
+
+func TestEngine_DeleteKey(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t))
+	require.NoError(t, err)
+	defer e.Close()
+
+	setRawHost(t, e, "host:staging:broken", "{not json")
+	require.NoError(t, e.DeleteKey("host:staging:broken"))
+
+	corrupt, err := e.CheckHosts()
+	require.NoError(t, err)
+	require.Empty(t, corrupt)
+}
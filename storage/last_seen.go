@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// LastSeenInterval is the minimum time between two last seen updates of the same host, so busy
+// hosts do not cause a storage write on every connection.
+const LastSeenInterval = time.Minute
+
+// TouchLastSeen records that a connection to the host succeeded at the given time. Updates within
+// LastSeenInterval of the previous one are skipped, as are hosts that are not stored.
+func (e *Engine) TouchLastSeen(group, name string, seen time.Time) error {
+	if group == "" || name == "" {
+		return nil
+	}
+	key := makeKey(group, name)
+
+	e.lastSeenMu.Lock()
+	if last, ok := e.lastSeenWrites[string(key)]; ok && seen.Sub(last) < LastSeenInterval {
+		e.lastSeenMu.Unlock()
+		return nil
+	}
+	e.lastSeenWrites[string(key)] = seen
+	e.lastSeenMu.Unlock()
+
+	// read and write in one transaction so concurrent edits to the host are not lost
+	err := e.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		var info ssh.ClientInfo
+		err = item.Value(func(val []byte) error {
+			info, err = e.unmarshalHost(val)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		seen := seen.UTC()
+		info.LastSeen = &seen
+		value, err := e.marshalHost(info)
+		if err != nil {
+			return err
+		}
+		return txn.Set(key, value)
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update last seen: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_TouchLastSeen(t *testing.T) {
+	e, err := NewEngine(tempDBPath(t))
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.Set(dummyClientInfo("production", "host1")))
+
+	first := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, e.TouchLastSeen("production", "host1", first))
+	info, ok := e.Get("production", "host1")
+	require.True(t, ok)
+	require.NotNil(t, info.LastSeen)
+	require.True(t, first.Equal(*info.LastSeen))
+
+	// updates within the interval are throttled
+	require.NoError(t, e.TouchLastSeen("production", "host1", first.Add(30*time.Second)))
+	info, _ = e.Get("production", "host1")
+	require.True(t, first.Equal(*info.LastSeen))
+
+	later := first.Add(2 * LastSeenInterval)
+	require.NoError(t, e.TouchLastSeen("production", "host1", later))
+	info, _ = e.Get("production", "host1")
+	require.True(t, later.Equal(*info.LastSeen))
+
+	// hosts that are not stored are ignored
+	require.NoError(t, e.TouchLastSeen("ad-hoc", "10.0.0.1:22", later))
+	_, ok = e.Get("ad-hoc", "10.0.0.1:22")
+	require.False(t, ok)
+}
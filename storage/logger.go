@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// badgerLogger forwards Badger's internal logging to slog.
+//
+// Badger is chatty at the info level (compaction, value log GC, etc.), so its
+// info messages are logged at debug to keep the default output useful.
+type badgerLogger struct{}
+
+func (badgerLogger) Errorf(format string, args ...any) {
+	slog.Error(badgerMessage(format, args...), "component", "badger")
+}
+
+func (badgerLogger) Warningf(format string, args ...any) {
+	slog.Warn(badgerMessage(format, args...), "component", "badger")
+}
+
+func (badgerLogger) Infof(format string, args ...any) {
+	slog.Debug(badgerMessage(format, args...), "component", "badger")
+}
+
+func (badgerLogger) Debugf(format string, args ...any) {
+	slog.Debug(badgerMessage(format, args...), "component", "badger")
+}
+
+// badgerMessage formats a Badger log line, dropping the trailing newline Badger adds.
+func badgerMessage(format string, args ...any) string {
+	return strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+}
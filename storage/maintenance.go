@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+const maintenanceKey = "config:maintenance"
+
+// MaintenanceMode is the persisted maintenance mode state.
+type MaintenanceMode struct {
+	Enabled bool      `json:"enabled"`
+	Reason  string    `json:"reason,omitempty"`
+	Since   time.Time `json:"since,omitempty"`
+}
+
+// SetMaintenanceMode saves the maintenance mode state.
+func (e *Engine) SetMaintenanceMode(mode MaintenanceMode) error {
+	value, err := json.Marshal(mode)
+	if err != nil {
+		return fmt.Errorf("failed to marshal maintenance mode: %w", err)
+	}
+	err = e.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(maintenanceKey), value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store maintenance mode: %w", err)
+	}
+	return nil
+}
+
+// GetMaintenanceMode retrieves the maintenance mode state, which is disabled when never set.
+func (e *Engine) GetMaintenanceMode() (MaintenanceMode, error) {
+	var mode MaintenanceMode
+	err := e.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(maintenanceKey))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &mode)
+		})
+	})
+	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return MaintenanceMode{}, fmt.Errorf("failed to read maintenance mode: %w", err)
+	}
+	return mode, nil
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_MaintenanceMode(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+
+	mode, err := e.GetMaintenanceMode()
+	require.NoError(t, err)
+	require.False(t, mode.Enabled)
+
+	enabled := MaintenanceMode{Enabled: true, Reason: "change freeze", Since: time.Now().UTC().Truncate(time.Second)}
+	require.NoError(t, e.SetMaintenanceMode(enabled))
+	require.NoError(t, e.Close())
+
+	// the mode survives a restart
+	e, err = NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	mode, err = e.GetMaintenanceMode()
+	require.NoError(t, err)
+	require.Equal(t, enabled, mode)
+}
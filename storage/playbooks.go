@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+const playbooksPrefix = "playbook:"
+
+// Playbook step failure handling.
+const (
+	// OnFailureAbort stops the playbook when the step fails
+	OnFailureAbort = "abort"
+	// OnFailureContinue runs the next step even when the step fails
+	OnFailureContinue = "continue"
+)
+
+// PlaybookStep is a single command of a playbook and the hosts it runs on.
+type PlaybookStep struct {
+	Name    string `json:"name,omitempty"`
+	Command string `json:"command"`
+	// Group and NameOfHosts select the hosts of the step, the hosts given when the playbook is run
+	// are used when neither is set
+	Group       string   `json:"group,omitempty"`
+	NameOfHosts []string `json:"name_of_hosts,omitempty"`
+	// OnFailure is abort (the default) or continue
+	OnFailure string `json:"on_failure,omitempty"`
+}
+
+// Playbook is a named, ordered list of commands run one after the other.
+type Playbook struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Steps       []PlaybookStep `json:"steps"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// makePlaybookKey creates a key for storing a playbook.
+// Format: playbook:name
+func makePlaybookKey(name string) []byte {
+	return []byte(playbooksPrefix + name)
+}
+
+// Validate checks that the playbook has a name and that every step is runnable.
+func (p Playbook) Validate() error {
+	if p.Name == "" {
+		return errors.New("playbook name cannot be empty")
+	}
+	if len(p.Steps) == 0 {
+		return errors.New("playbook must have at least one step")
+	}
+	for i, step := range p.Steps {
+		if step.Command == "" {
+			return fmt.Errorf("step %d: command cannot be empty", i+1)
+		}
+		if step.Group != "" && len(step.NameOfHosts) > 0 {
+			return fmt.Errorf("step %d: cannot specify both 'group' and 'name_of_hosts'", i+1)
+		}
+		switch step.OnFailure {
+		case "", OnFailureAbort, OnFailureContinue:
+		default:
+			return fmt.Errorf("step %d: invalid on_failure %q: must be abort or continue", i+1, step.OnFailure)
+		}
+	}
+	return nil
+}
+
+// SetPlaybook saves a playbook, replacing any previous playbook with the same name.
+func (e *Engine) SetPlaybook(playbook Playbook) error {
+	if err := playbook.Validate(); err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(playbook)
+	if err != nil {
+		return fmt.Errorf("failed to marshal playbook: %w", err)
+	}
+
+	err = e.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(makePlaybookKey(playbook.Name), value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store playbook: %w", err)
+	}
+	return nil
+}
+
+// GetPlaybook retrieves a playbook by name.
+func (e *Engine) GetPlaybook(name string) (Playbook, bool) {
+	var playbook Playbook
+	err := e.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(makePlaybookKey(name))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &playbook)
+		})
+	})
+	if err != nil {
+		return Playbook{}, false
+	}
+	return playbook, true
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_SetAndGetPlaybook(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	playbook := Playbook{
+		Name:        "deploy-web",
+		Description: "Pull and restart the web tier",
+		Steps: []PlaybookStep{
+			{Name: "pull", Command: "git -C /srv/app pull", Group: "web"},
+			{Command: "systemctl restart app", NameOfHosts: []string{"web:web1"}, OnFailure: OnFailureContinue},
+		},
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	require.NoError(t, e.SetPlaybook(playbook))
+
+	got, ok := e.GetPlaybook("deploy-web")
+	require.True(t, ok)
+	require.Equal(t, playbook, got)
+
+	_, ok = e.GetPlaybook("missing")
+	require.False(t, ok)
+}
+
+func TestPlaybook_Validate(t *testing.T) {
+	testCases := map[string]Playbook{
+		"no name":    {Steps: []PlaybookStep{{Command: "uptime"}}},
+		"no steps":   {Name: "empty"},
+		"no command": {Name: "bad", Steps: []PlaybookStep{{Group: "web"}}},
+		"both selectors": {Name: "bad", Steps: []PlaybookStep{
+			{Command: "uptime", Group: "web", NameOfHosts: []string{"web:web1"}},
+		}},
+		"invalid on_failure": {Name: "bad", Steps: []PlaybookStep{{Command: "uptime", OnFailure: "retry"}}},
+	}
+	for name, playbook := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Error(t, playbook.Validate())
+		})
+	}
+}
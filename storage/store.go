@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// HostStore stores the hosts, keyed by group and name.
+type HostStore interface {
+	// Get retrieves a host, false when it does not exist.
+	Get(group, name string) (ssh.ClientInfo, bool)
+	// Set stores a host, replacing any host with the same group and name.
+	Set(info ssh.ClientInfo) error
+	// SetBatch stores several hosts at once, either all of them are stored or none.
+	SetBatch(infos []ssh.ClientInfo) error
+	// Delete removes a host, removing a missing host is not an error.
+	Delete(group, name string) error
+	// Rename changes the name of a host within its group.
+	Rename(group, name, newName string) error
+	// Update replaces a host with info, which may move it to another group or name.
+	Update(group, name string, info ssh.ClientInfo) error
+	// List retrieves all hosts.
+	List() ([]ssh.ClientInfo, error)
+	// ListGroup retrieves all hosts in a group.
+	ListGroup(group string) ([]ssh.ClientInfo, error)
+	// ListGroups retrieves the names of the groups that have hosts.
+	ListGroups() ([]string, error)
+	// TouchLastSeen records when a host was last connected to.
+	TouchLastSeen(group, name string, seen time.Time) error
+}
+
+// ConfigStore stores the server and group configuration.
+type ConfigStore interface {
+	// SetDefaultUser sets the default user of a group, or the global default for an empty group.
+	SetDefaultUser(group, user string) error
+	// GetDefaultUser retrieves the default user of a group, or the global default for an empty group.
+	GetDefaultUser(group string) (string, bool)
+	// PruneEmptyGroups removes the configuration of groups without hosts.
+	PruneEmptyGroups(dryRun bool) ([]string, error)
+	// SetMaintenanceMode stores the maintenance mode.
+	SetMaintenanceMode(mode MaintenanceMode) error
+	// GetMaintenanceMode retrieves the maintenance mode.
+	GetMaintenanceMode() (MaintenanceMode, error)
+}
+
+// TemplateStore stores the command templates.
+type TemplateStore interface {
+	SetTemplate(tmpl CommandTemplate) error
+	GetTemplate(name string) (CommandTemplate, bool)
+	ListTemplates() ([]CommandTemplate, error)
+}
+
+// PlaybookStore stores the playbooks.
+type PlaybookStore interface {
+	SetPlaybook(playbook Playbook) error
+	GetPlaybook(name string) (Playbook, bool)
+}
+
+// FactStore stores the cached facts of the hosts.
+type FactStore interface {
+	SetFact(fact Fact) error
+	GetFact(key, group, name string) (Fact, bool)
+	ListFacts(key string) ([]Fact, error)
+}
+
+// CommandStore stores the history of commands, saved as they start and again once they finish.
+type CommandStore interface {
+	SetCommand(record CommandRecord) error
+	ListCommands() ([]CommandRecord, error)
+	DeleteCommandsBefore(cutoff time.Time) (int, error)
+}
+
+// IntegrityStore finds and repairs host records that cannot be parsed.
+type IntegrityStore interface {
+	CheckHosts() ([]CorruptRecord, error)
+	QuarantineHost(key string) (string, error)
+	DeleteKey(key string) error
+}
+
+// Store is the storage backend the tools depend on. Engine, backed by an embedded BadgerDB, is the
+// default implementation; another backend (e.g. a shared SQL database) only has to implement
+// this interface.
+type Store interface {
+	HostStore
+	ConfigStore
+	TemplateStore
+	PlaybookStore
+	FactStore
+	CommandStore
+	IntegrityStore
+
+	// Close releases the resources of the store.
+	Close() error
+}
+
+// Engine must implement Store.
+var _ Store = (*Engine)(nil)
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+const templatesPrefix = "template:"
+
+// CommandTemplate is a named, reusable command.
+//
+// The command may contain Go text/template placeholders (e.g. {{.unit}}) that are filled in
+// with variables when the template is run.
+type CommandTemplate struct {
+	Name        string    `json:"name"`
+	Command     string    `json:"command"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// makeTemplateKey creates a key for storing a command template.
+// Format: template:name
+func makeTemplateKey(name string) []byte {
+	return []byte(templatesPrefix + name)
+}
+
+// SetTemplate saves a command template, replacing any previous template with the same name.
+func (e *Engine) SetTemplate(tmpl CommandTemplate) error {
+	if tmpl.Name == "" {
+		return errors.New("template name cannot be empty")
+	}
+	if tmpl.Command == "" {
+		return errors.New("template command cannot be empty")
+	}
+
+	value, err := json.Marshal(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template: %w", err)
+	}
+
+	err = e.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(makeTemplateKey(tmpl.Name), value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store template: %w", err)
+	}
+	return nil
+}
+
+// GetTemplate retrieves a command template by name.
+func (e *Engine) GetTemplate(name string) (CommandTemplate, bool) {
+	var tmpl CommandTemplate
+	err := e.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(makeTemplateKey(name))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &tmpl)
+		})
+	})
+	if err != nil {
+		return CommandTemplate{}, false
+	}
+	return tmpl, true
+}
+
+// ListTemplates retrieves all command templates sorted by name.
+func (e *Engine) ListTemplates() ([]CommandTemplate, error) {
+	var templates []CommandTemplate
+	err := e.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(templatesPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var tmpl CommandTemplate
+				if err := json.Unmarshal(val, &tmpl); err != nil {
+					return err
+				}
+				templates = append(templates, tmpl)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_SetAndGetTemplate(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	tmpl := CommandTemplate{
+		Name:        "failed-units",
+		Command:     "systemctl --failed --no-legend",
+		Description: "Show failed systemd units",
+		UpdatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	require.NoError(t, e.SetTemplate(tmpl))
+
+	got, ok := e.GetTemplate("failed-units")
+	require.True(t, ok)
+	require.Equal(t, tmpl, got)
+
+	_, ok = e.GetTemplate("missing")
+	require.False(t, ok)
+}
+
+func TestEngine_ListTemplates(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.SetTemplate(CommandTemplate{Name: "uptime", Command: "uptime"}))
+	require.NoError(t, e.SetTemplate(CommandTemplate{Name: "df", Command: "df -h"}))
+	require.NoError(t, e.Set(dummyClientInfo("production", "host1")))
+
+	templates, err := e.ListTemplates()
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	require.Equal(t, "df", templates[0].Name)
+	require.Equal(t, "uptime", templates[1].Name)
+
+	// templates must not show up as hosts
+	hosts, err := e.List()
+	require.NoError(t, err)
+	require.Len(t, hosts, 1)
+}
+
+func TestEngine_SetTemplate_Invalid(t *testing.T) {
+	path := tempDBPath(t)
+	e, err := NewEngine(path)
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.Error(t, e.SetTemplate(CommandTemplate{Command: "uptime"}))
+	require.Error(t, e.SetTemplate(CommandTemplate{Name: "uptime"}))
+}
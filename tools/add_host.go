@@ -35,11 +35,38 @@ func (c *AddHost) Definition() mcp.Tool {
 		mcp.WithString("name_of_host",
 			mcp.Description("Name of the host (optional, defaults to hostname)"),
 		),
+		mcp.WithString("description",
+			mcp.Description("Free-form notes about the host returned by get_hosts, e.g. 'primary DB, do not reboot during business hours' (optional)"),
+		),
+		mcp.WithBoolean("legacy_ssh_rsa",
+			mcp.Description("Also accept the SHA-1 'ssh-rsa' host key algorithm for this host only, needed for old servers that fail with 'no common algorithm for host key' (default: false)"),
+		),
+		mcp.WithString("jump_host",
+			mcp.Description("Bastion to connect through when the host is not directly reachable, either a stored host as 'group:name' or a connection string such as 'ssh://user@bastion.example.com:2222' (optional)"),
+		),
+		mcp.WithString("key_path",
+			mcp.Description("Path to the private key to authenticate with on the machine running this server, e.g. '~/.ssh/deploy_ed25519', tried before the SSH agent and default keys (optional)"),
+		),
+		mcp.WithString("key_passphrase",
+			mcp.Description("Passphrase of an encrypted key_path (optional, requires key_path, mutually exclusive with key_passphrase_ref)"),
+		),
+		mcp.WithString("key_passphrase_ref",
+			mcp.Description("Reference to the passphrase of key_path resolved at connect time instead of storing it, e.g. 'env:KEY_PASS' or 'file:/run/secrets/key', when allowed by the server's --secret-env or --secrets-dir (optional, requires key_path)"),
+		),
+		mcp.WithString("password_ref",
+			mcp.Description("Reference to the password resolved at connect time instead of storing it, e.g. 'env:DB_PASS' or 'file:/run/secrets/db', when allowed by the server's --secret-env or --secrets-dir (mutually exclusive with a password in ssh_connection_string)"),
+		),
+		mcp.WithBoolean("require_os_info",
+			mcp.Description("Fail without storing the host when its OS cannot be detected (default: false, the host is stored without OS information and a warning is returned, run update_os_info to retry)"),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace the host if one with the same name already exists in the group (default: false, returns an error instead)"),
+		),
 	)
 }
 
 // Handle is the function that is called when the tool is invoked.
-func (c *AddHost) Handler(ctx context.Context, storageEngine *storage.Engine) server.ToolHandlerFunc {
+func (c *AddHost) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
 	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		group, err := request.RequireString("group")
 		if err != nil {
@@ -50,6 +77,9 @@ func (c *AddHost) Handler(ctx context.Context, storageEngine *storage.Engine) se
 		if group == "" {
 			return mcp.NewToolResultError("group cannot be empty"), nil
 		}
+		if err := checkGroupAllowed(group); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
 
 		sshConnectionString, err := request.RequireString("ssh_connection_string")
 		if err != nil {
@@ -64,8 +94,42 @@ func (c *AddHost) Handler(ctx context.Context, storageEngine *storage.Engine) se
 
 		// Set the group
 		clientInfo.Group = group
+		clientInfo.Description = request.GetString("description", "")
+		clientInfo.LegacySSHRSA = request.GetBool("legacy_ssh_rsa", false)
+
+		if jumpHost := request.GetString("jump_host", ""); jumpHost != "" {
+			if err := validateJumpHost(storageEngine, jumpHost); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			clientInfo.JumpHost = jumpHost
+		}
+
+		if err := setPrivateKey(clientInfo, request); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if passwordRef := request.GetString("password_ref", ""); passwordRef != "" {
+			if clientInfo.Pass != "" {
+				return mcp.NewToolResultError("cannot specify both a password in ssh_connection_string and 'password_ref'"), nil
+			}
+			if err := ssh.CheckSecretReference(passwordRef); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid password_ref: %v", err)), nil
+			}
+			clientInfo.Pass = passwordRef
+			clientInfo.PassRef = true
+		}
+
+		// Refuse to clobber an existing host unless explicitly requested
+		if !request.GetBool("overwrite", false) {
+			if _, exists := storageEngine.Get(group, clientInfo.Name); exists {
+				return mcp.NewToolResultError(fmt.Sprintf("host %s already exists in group %s, set overwrite=true to replace it", clientInfo.Name, group)), nil
+			}
+		}
 
-		sshClient := ssh.NewClient(clientInfo)
+		// connect using the stored default user when none is given, but keep the user empty in
+		// storage so later changes to the defaults still apply
+		connectInfo := applyDefaultUsers(storageEngine, []ssh.ClientInfo{*clientInfo})[0]
+		sshClient := ssh.NewClient(&connectInfo)
 
 		// connect over ssh
 		err = sshClient.Connect()
@@ -74,10 +138,15 @@ func (c *AddHost) Handler(ctx context.Context, storageEngine *storage.Engine) se
 		}
 		defer sshClient.Close()
 
-		// Detect OS and gather system information (supports Linux and Windows)
+		// Detect OS and gather system information (supports Linux and Windows). A host whose OS
+		// cannot be detected is still stored with blank OS fields unless strictly required.
+		var warning string
 		osRelease, uname, err := utils.GatherOSInfo(sshClient)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Errorf("failed to gather OS information: %w", err).Error()), nil
+			if request.GetBool("require_os_info", false) {
+				return mcp.NewToolResultError(fmt.Errorf("failed to gather OS information: %w", err).Error()), nil
+			}
+			warning = fmt.Sprintf("warning: failed to gather OS information, the host was stored without it and is treated as Linux until update_os_info succeeds: %v", err)
 		}
 
 		// set the OS info and store it for usage later
@@ -88,6 +157,56 @@ func (c *AddHost) Handler(ctx context.Context, storageEngine *storage.Engine) se
 			return mcp.NewToolResultError(fmt.Errorf("failed to add host to storage: %w", err).Error()), nil
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("successfully added %s to group %s", clientInfo.Name, group)), nil
+		message := fmt.Sprintf("successfully added %s to group %s", clientInfo.Name, group)
+		if warning != "" {
+			message += "\n" + warning
+		}
+		return mcp.NewToolResultText(message), nil
+	}
+}
+
+// setPrivateKey sets the key_path and key_passphrase or key_passphrase_ref arguments on the host,
+// first checking that the key can be loaded so a wrong path or passphrase is reported now rather
+// than on every connect.
+func setPrivateKey(clientInfo *ssh.ClientInfo, request mcp.CallToolRequest) error {
+	keyPath := request.GetString("key_path", "")
+	keyPassphrase := request.GetString("key_passphrase", "")
+	keyPassphraseRef := request.GetString("key_passphrase_ref", "")
+	if keyPassphrase != "" && keyPassphraseRef != "" {
+		return fmt.Errorf("cannot specify both 'key_passphrase' and 'key_passphrase_ref'")
+	}
+	isRef := keyPassphraseRef != ""
+	if isRef {
+		keyPassphrase = keyPassphraseRef
+	}
+	if keyPath == "" {
+		if keyPassphrase != "" {
+			return fmt.Errorf("'key_passphrase' requires 'key_path'")
+		}
+		return nil
+	}
+	keyPath = ssh.ExpandHome(keyPath)
+	if err := validatePrivateKey(keyPath, keyPassphrase, isRef); err != nil {
+		return err
+	}
+	clientInfo.KeyPath = keyPath
+	clientInfo.KeyPassphrase = keyPassphrase
+	clientInfo.KeyPassphraseRef = isRef
+	return nil
+}
+
+// validatePrivateKey checks that the key at keyPath can be loaded with the passphrase, which is a
+// secret reference when isRef is set.
+func validatePrivateKey(keyPath string, keyPassphrase string, isRef bool) error {
+	passphrase := keyPassphrase
+	if isRef {
+		var err error
+		if passphrase, err = ssh.ResolveSecretReference(keyPassphrase); err != nil {
+			return fmt.Errorf("invalid key_passphrase_ref: %w", err)
+		}
+	}
+	if _, err := ssh.LoadPrivateKey(keyPath, passphrase); err != nil {
+		return fmt.Errorf("invalid key_path: %w", err)
 	}
+	return nil
 }
@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// allowedGroups is the set of groups this server is scoped to, nil allows every group.
+var allowedGroups map[string]bool
+
+// SetAllowedGroups scopes the server to the given groups. Any tool call referencing another group
+// is rejected. An empty list allows every group.
+func SetAllowedGroups(groups []string) {
+	if len(groups) == 0 {
+		allowedGroups = nil
+		return
+	}
+	allowedGroups = make(map[string]bool, len(groups))
+	for _, group := range groups {
+		allowedGroups[group] = true
+	}
+}
+
+// groupAllowed returns true when the server may act on the group.
+func groupAllowed(group string) bool {
+	return allowedGroups == nil || allowedGroups[group]
+}
+
+// checkGroupAllowed returns an authorization error when the server is not allowed to act on the group.
+func checkGroupAllowed(group string) error {
+	if groupAllowed(group) {
+		return nil
+	}
+	allowed := make([]string, 0, len(allowedGroups))
+	for g := range allowedGroups {
+		allowed = append(allowed, g)
+	}
+	sort.Strings(allowed)
+	return fmt.Errorf("not authorized: group %q is not in the allowed groups of this server (%s)", group, strings.Join(allowed, ", "))
+}
+
+// commandAllowed returns true when the server may act on every group the command runs on.
+func commandAllowed(cmd *commands.Command) bool {
+	return checkCommandAllowed(cmd) == nil
+}
+
+// checkCommandAllowed returns an authorization error when the command runs on a group the server
+// is not allowed to act on.
+func checkCommandAllowed(cmd *commands.Command) error {
+	for _, group := range cmd.Groups() {
+		if err := checkGroupAllowed(group); err != nil {
+			return fmt.Errorf("command %s: %w", cmd.ID(), err)
+		}
+	}
+	return nil
+}
+
+// filterAllowedGroups returns only the groups the server is allowed to act on.
+func filterAllowedGroups(groups []string) []string {
+	if allowedGroups == nil {
+		return groups
+	}
+	filtered := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if groupAllowed(group) {
+			filtered = append(filtered, group)
+		}
+	}
+	return filtered
+}
+
+// filterAllowedHosts returns only the hosts in groups the server is allowed to act on.
+func filterAllowedHosts(hosts []ssh.ClientInfo) []ssh.ClientInfo {
+	if allowedGroups == nil {
+		return hosts
+	}
+	filtered := make([]ssh.ClientInfo, 0, len(hosts))
+	for _, host := range hosts {
+		if groupAllowed(host.Group) {
+			filtered = append(filtered, host)
+		}
+	}
+	return filtered
+}
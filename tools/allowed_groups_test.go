@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+func restrictGroups(t *testing.T, groups ...string) {
+	SetAllowedGroups(groups)
+	t.Cleanup(func() { SetAllowedGroups(nil) })
+}
+
+func TestGetHostsFromRequest_DisallowedGroup(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "prod", "web1", "10.0.0.1")
+	addTestHost(t, engine, "dev", "web1", "10.0.1.1")
+	restrictGroups(t, "dev")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"group": "prod"}
+	_, err := getHostsFromRequest(engine, request)
+	require.ErrorContains(t, err, `not authorized: group "prod" is not in the allowed groups of this server (dev)`)
+
+	request.Params.Arguments = map[string]any{"name_of_hosts": []any{"dev:web1", "prod:web1"}}
+	_, err = getHostsFromRequest(engine, request)
+	require.ErrorContains(t, err, "not authorized")
+
+	request.Params.Arguments = map[string]any{"groups": []any{"dev", "prod"}}
+	_, err = getHostsFromRequest(engine, request)
+	require.ErrorContains(t, err, "not authorized")
+
+	request.Params.Arguments = map[string]any{"group": "dev"}
+	found, err := getHostsFromRequest(engine, request)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+}
+
+func TestGetHostsWithAdHocFromRequest_AdHocNotAllowed(t *testing.T) {
+	engine := setupTestStorage(t)
+	restrictGroups(t, "dev")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"ad_hoc_hosts": []any{"admin@10.0.9.9"}}
+	_, err := getHostsWithAdHocFromRequest(engine, request)
+	require.ErrorContains(t, err, `group "ad-hoc"`)
+}
+
+func TestAddHost_DisallowedGroup(t *testing.T) {
+	engine := setupTestStorage(t)
+	restrictGroups(t, "dev")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"group": "prod", "ssh_connection_string": "user@10.0.0.1"}
+	result, err := (&AddHost{}).Handler(context.Background(), engine)(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "not authorized")
+}
+
+func TestGetGroupsAndHosts_FilteredToAllowedGroups(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "prod", "web1", "10.0.0.1")
+	addTestHost(t, engine, "dev", "web1", "10.0.1.1")
+	restrictGroups(t, "dev")
+
+	result, err := (&GetGroups{}).Handler(context.Background(), engine)(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"dev"}, result.StructuredContent.(map[string]any)["groups"])
+
+	result, err = (&GetHosts{}).Handler(context.Background(), engine)(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	hosts := result.StructuredContent.(map[string]any)["hosts"]
+	require.Len(t, hosts, 1)
+}
+
+func TestCommandTools_DisallowedGroup(t *testing.T) {
+	mock := commands.NewMockRunner()
+	dev := mock.CreateCommand("uptime", []ssh.ClientInfo{{Group: "dev", Name: "web1"}})
+	dev.SetStatusForTest(commands.CommandStatusCompleted)
+	mixed := mock.CreateCommand("uptime", []ssh.ClientInfo{{Group: "dev", Name: "web1"}, {Group: "prod", Name: "web1"}})
+	mixed.SetStatusForTest(commands.CommandStatusCompleted)
+	restrictGroups(t, "dev")
+	engine := setupTestStorage(t)
+
+	call := func(tool Tool, args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := tool.Handler(context.Background(), engine)(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	result := call(&ListCommands{commandRunner: mock}, map[string]any{})
+	require.False(t, result.IsError)
+	listed := result.StructuredContent.(map[string]any)["commands"].([]*commands.CommandListItem)
+	require.Len(t, listed, 1)
+	require.Equal(t, dev.ID(), listed[0].ID)
+
+	result = call(&GetCommandStatus{commandRunner: mock}, map[string]any{"command_id": mixed.ID()})
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "not authorized")
+
+	// the most recent command is the most recent one on the allowed groups
+	result = call(&GetCommandStatus{commandRunner: mock}, map[string]any{"summary_only": true})
+	require.False(t, result.IsError)
+	require.Equal(t, dev.ID(), result.StructuredContent.(*commands.CommandSummary).ID)
+
+	result = call(&CompareCommands{commandRunner: mock}, map[string]any{"before_command_id": dev.ID(), "after_command_id": mixed.ID()})
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "not authorized")
+
+	result = call(&CancelCommand{commandRunner: mock}, map[string]any{"command_id": mixed.ID()})
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "not authorized")
+}
@@ -35,7 +35,7 @@ func (c *CancelCommand) Definition() mcp.Tool {
 }
 
 // Handler is the function that is called when the tool is invoked.
-func (c *CancelCommand) Handler(ctx context.Context, storageEngine *storage.Engine) server.ToolHandlerFunc {
+func (c *CancelCommand) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
 	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if c.commandRunner == nil {
 			panic("command runner not available")
@@ -48,6 +48,9 @@ func (c *CancelCommand) Handler(ctx context.Context, storageEngine *storage.Engi
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
+		if err := checkCommandAllowed(cmd); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
 		err = cmd.Cancel()
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
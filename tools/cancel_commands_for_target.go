@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&CancelCommandsForTarget{})
+}
+
+// CancelCommandsForTarget is a tool that cancels every running command targeting a host or group.
+type CancelCommandsForTarget struct {
+	commandRunner commands.Runner
+}
+
+// SetCommandRunner sets the command runner
+func (c *CancelCommandsForTarget) SetCommandRunner(runner commands.Runner) {
+	c.commandRunner = runner
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *CancelCommandsForTarget) Definition() mcp.Tool {
+	return mcp.NewTool("cancel_commands_for_target",
+		mcp.WithDescription("Cancels every running background command that targets a host or any host in a group, e.g. before taking a host offline. Returns the IDs of the cancelled commands."),
+		mcp.WithString("group", mcp.Description("Cancel commands running on any host in this group (mutually exclusive with host)")),
+		mcp.WithString("host", mcp.Description("Cancel commands running on this host, in format 'group:name' (mutually exclusive with group)")),
+	)
+}
+
+// Handler is the function that is called when the tool is invoked.
+func (c *CancelCommandsForTarget) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if c.commandRunner == nil {
+			panic("command runner not available")
+		}
+
+		target, err := cancelTargetFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		cancelled := []string{}
+		for _, cmd := range c.commandRunner.ListCommands() {
+			if cmd.Status() != commands.CommandStatusRunning || !cmd.TargetsHost(target.Group, target.Name) || !commandAllowed(cmd) {
+				continue
+			}
+			// the command may have finished since its status was checked
+			if err := cmd.Cancel(); err == nil {
+				cancelled = append(cancelled, cmd.ID())
+			}
+		}
+		sort.Strings(cancelled)
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"cancelled": cancelled}), nil
+	}
+}
+
+// cancelTargetFromRequest reads the 'group' or 'host' selector, the name is empty when selecting a group.
+func cancelTargetFromRequest(request mcp.CallToolRequest) (utils.HostIdentifier, error) {
+	group := request.GetString("group", "")
+	host := request.GetString("host", "")
+
+	if group != "" && host != "" {
+		return utils.HostIdentifier{}, errors.New("cannot specify both 'group' and 'host'")
+	}
+	if group != "" {
+		if err := checkGroupAllowed(group); err != nil {
+			return utils.HostIdentifier{}, err
+		}
+		return utils.HostIdentifier{Group: group}, nil
+	}
+	if host == "" {
+		return utils.HostIdentifier{}, errors.New("must specify either 'group' or 'host'")
+	}
+	identifiers, err := utils.ParseHostIdentifiers([]string{host})
+	if err != nil {
+		return utils.HostIdentifier{}, err
+	}
+	if err := checkGroupAllowed(identifiers[0].Group); err != nil {
+		return utils.HostIdentifier{}, err
+	}
+	return identifiers[0], nil
+}
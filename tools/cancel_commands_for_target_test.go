@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// Tests for CancelCommandsForTarget tool
+
+func TestCancelCommandsForTarget_Host(t *testing.T) {
+	mock := commands.NewMockRunner()
+
+	onDB := mock.CreateCommand("sleep 100", []ssh.ClientInfo{
+		{Name: "prod-db", Group: "prod"},
+		{Name: "prod-web", Group: "prod"},
+	})
+	onDB.SetStatusForTest(commands.CommandStatusRunning)
+	onWeb := mock.CreateCommand("sleep 100", []ssh.ClientInfo{{Name: "prod-web", Group: "prod"}})
+	onWeb.SetStatusForTest(commands.CommandStatusRunning)
+	finished := mock.CreateCommand("uptime", []ssh.ClientInfo{{Name: "prod-db", Group: "prod"}})
+	finished.SetStatusForTest(commands.CommandStatusCompleted)
+
+	tool := &CancelCommandsForTarget{commandRunner: mock}
+	handler := tool.Handler(context.Background(), setupTestStorage(t))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"host": "prod:prod-db",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	structured, ok := result.StructuredContent.(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, []string{onDB.ID()}, structured["cancelled"])
+}
+
+func TestCancelCommandsForTarget_Group(t *testing.T) {
+	mock := commands.NewMockRunner()
+
+	prod := mock.CreateCommand("sleep 100", []ssh.ClientInfo{{Name: "web1", Group: "prod"}})
+	prod.SetStatusForTest(commands.CommandStatusRunning)
+	staging := mock.CreateCommand("sleep 100", []ssh.ClientInfo{{Name: "web1", Group: "staging"}})
+	staging.SetStatusForTest(commands.CommandStatusRunning)
+
+	tool := &CancelCommandsForTarget{commandRunner: mock}
+	handler := tool.Handler(context.Background(), setupTestStorage(t))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"group": "prod",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	structured, ok := result.StructuredContent.(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, []string{prod.ID()}, structured["cancelled"])
+}
+
+func TestCancelCommandsForTarget_InvalidSelector(t *testing.T) {
+	tool := &CancelCommandsForTarget{commandRunner: commands.NewMockRunner()}
+	handler := tool.Handler(context.Background(), setupTestStorage(t))
+
+	for _, args := range []map[string]interface{}{
+		{},
+		{"group": "prod", "host": "prod:web1"},
+		{"host": "missing-colon"},
+	} {
+		result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		require.NoError(t, err)
+		require.True(t, result.IsError, "expected error for %v", args)
+	}
+}
@@ -0,0 +1,304 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&CheckDiskSpace{})
+}
+
+// FilesystemUsage is the space usage of a single mounted filesystem.
+type FilesystemUsage struct {
+	Filesystem     string  `json:"filesystem"`
+	Mountpoint     string  `json:"mountpoint"`
+	SizeBytes      int64   `json:"size_bytes"`
+	UsedBytes      int64   `json:"used_bytes"`
+	AvailableBytes int64   `json:"available_bytes"`
+	UsePercent     float64 `json:"use_percent"`
+	// AboveThreshold is true when UsePercent is above the requested threshold_percent
+	AboveThreshold bool `json:"above_threshold,omitempty"`
+}
+
+// DiskSpaceResult is the filesystem usage of a single host.
+type DiskSpaceResult struct {
+	Host        string            `json:"host"`
+	Filesystems []FilesystemUsage `json:"filesystems"`
+	// LowSpace is true when any filesystem of the host is above the requested threshold_percent
+	LowSpace bool `json:"low_space,omitempty"`
+	// Warning reports the errors and exit status of a command that failed after reporting some
+	// filesystems, e.g. df on a stale network mount
+	Warning string `json:"warning,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// diskSpaceOutput is the output of the disk space command on a host.
+type diskSpaceOutput struct {
+	stdout   string
+	stderr   string
+	exitCode int
+}
+
+// CheckDiskSpace is a tool that reports the space usage of the mounted filesystems.
+type CheckDiskSpace struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *CheckDiskSpace) Definition() mcp.Tool {
+	return mcp.NewTool("check_disk_space",
+		mcp.WithDescription("Reports the size, used and available bytes and use percent of each mounted filesystem per host ('df -P' on Linux and macOS, Get-PSDrive on Windows). Filesystems reported by a command that also failed, e.g. on a stale network mount, are still returned with its errors and exit status as the host's warning; a host only fails when no filesystem could be read. Set threshold_percent to flag filesystems above it and the hosts running low on disk. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to check the disk space of all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithNumber("threshold_percent", mcp.Description("Flag filesystems whose use percent is above this value (0-100) with above_threshold and their hosts with low_space (optional)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *CheckDiskSpace) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var threshold *float64
+		if raw, ok := request.GetArguments()["threshold_percent"]; ok && raw != nil {
+			value, ok := raw.(float64)
+			if !ok || value < 0 || value > 100 {
+				return mcp.NewToolResultError("threshold_percent must be a number between 0 and 100"), nil
+			}
+			threshold = &value
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		windows := make(map[string]bool, len(found))
+		for _, host := range found {
+			windows[host.Name] = utils.IsWindows(host)
+		}
+
+		var mu sync.Mutex
+		outputs := make(map[string]diskSpaceOutput, len(found))
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			output, err := runDiskSpaceCommand(sshClient, diskSpaceCommand(windows[host.Name]))
+			if err != nil {
+				return "", fmt.Errorf("failed to check disk space: %w", err)
+			}
+			mu.Lock()
+			outputs[host.Name] = output
+			mu.Unlock()
+			return output.stdout, nil
+		})
+
+		space := make([]DiskSpaceResult, 0, len(results))
+		lowSpace := []string{}
+		for name, result := range results {
+			if result.Err != nil {
+				space = append(space, DiskSpaceResult{Host: name, Filesystems: []FilesystemUsage{}, Error: result.Err.Error()})
+				continue
+			}
+			hostSpace := diskSpaceResult(name, outputs[name], windows[name])
+			if threshold != nil {
+				for i, fs := range hostSpace.Filesystems {
+					if fs.UsePercent > *threshold {
+						hostSpace.Filesystems[i].AboveThreshold = true
+						hostSpace.LowSpace = true
+					}
+				}
+			}
+			if hostSpace.LowSpace {
+				lowSpace = append(lowSpace, name)
+			}
+			space = append(space, hostSpace)
+		}
+		sort.Slice(space, func(i, j int) bool {
+			return space[i].Host < space[j].Host
+		})
+		sort.Strings(lowSpace)
+
+		response := map[string]any{"hosts": space}
+		if threshold != nil {
+			response["low_space_hosts"] = lowSpace
+		}
+		return mcp.NewToolResultStructuredOnly(response), nil
+	}
+}
+
+// runDiskSpaceCommand runs the disk space command keeping stdout apart from stderr, so the usage
+// it reports is still parsed when it also fails on some filesystems. Only a failure to run the
+// command is returned as an error.
+func runDiskSpaceCommand(sshClient *ssh.Client, command string) (diskSpaceOutput, error) {
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return diskSpaceOutput{}, err
+	}
+	defer session.Close()
+
+	var stdout, stderr strings.Builder
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	output := diskSpaceOutput{}
+	if err := session.Run(command); err != nil {
+		var exitErr *gossh.ExitError
+		if !errors.As(err, &exitErr) {
+			return diskSpaceOutput{}, err
+		}
+		output.exitCode = exitErr.ExitStatus()
+	}
+	output.stdout = stdout.String()
+	output.stderr = stderr.String()
+	return output, nil
+}
+
+// diskSpaceResult parses the disk space command output of a host, whatever its exit status. The
+// errors and exit status of the command are a warning when filesystems were parsed, the host only
+// fails when none were.
+func diskSpaceResult(name string, output diskSpaceOutput, windows bool) DiskSpaceResult {
+	result := DiskSpaceResult{Host: name, Filesystems: []FilesystemUsage{}}
+	parse := parseDF
+	if windows {
+		parse = parsePSDrive
+	}
+	filesystems, err := parse(output.stdout)
+
+	var problem string
+	if output.exitCode != 0 {
+		problem = fmt.Sprintf("exit status %d", output.exitCode)
+	}
+	if stderr := strings.TrimSpace(output.stderr); stderr != "" {
+		if problem != "" {
+			problem += ": "
+		}
+		problem += stderr
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		if problem != "" {
+			result.Error += " (" + problem + ")"
+		}
+		return result
+	}
+	result.Filesystems = filesystems
+	result.Warning = problem
+	return result
+}
+
+// diskSpaceCommand returns the command that lists the usage of the mounted filesystems.
+func diskSpaceCommand(windows bool) string {
+	if windows {
+		// drives without media report no Used and Free, which casts to 0
+		return utils.PowerShellCommand("Get-PSDrive -PSProvider FileSystem | ForEach-Object { $_.Name + [char]9 + [string][long]$_.Used + [char]9 + [string][long]$_.Free + [char]9 + $_.Root }")
+	}
+	// -P gives the same single line POSIX format with 1024 byte blocks on GNU and BSD df
+	return "df -P -k"
+}
+
+// parseDF parses the output of 'df -P -k'. Filesystems without any blocks (e.g. proc) are skipped.
+func parseDF(output string) ([]FilesystemUsage, error) {
+	filesystems := []FilesystemUsage{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		var blocks [3]int64
+		valid := true
+		for i := range blocks {
+			n, err := strconv.ParseInt(fields[i+1], 10, 64)
+			if err != nil {
+				// the header line or an error message
+				valid = false
+				break
+			}
+			blocks[i] = n * 1024
+		}
+		if !valid || blocks[0] == 0 {
+			continue
+		}
+		filesystems = append(filesystems, FilesystemUsage{
+			Filesystem:     fields[0],
+			Mountpoint:     strings.Join(fields[5:], " "),
+			SizeBytes:      blocks[0],
+			UsedBytes:      blocks[1],
+			AvailableBytes: blocks[2],
+			UsePercent:     usePercent(blocks[1], blocks[2]),
+		})
+	}
+	if len(filesystems) == 0 {
+		return nil, noFilesystemsError(output)
+	}
+	return filesystems, nil
+}
+
+// noFilesystemsError is the error of an output without any filesystem, including the output.
+func noFilesystemsError(output string) error {
+	if output = strings.TrimSpace(output); output != "" {
+		return fmt.Errorf("no filesystems reported: %s", output)
+	}
+	return errors.New("no filesystems reported")
+}
+
+// parsePSDrive parses "<name>\t<used>\t<free>\t<root>" lines of the Get-PSDrive script. Drives
+// without media are skipped.
+func parsePSDrive(output string) ([]FilesystemUsage, error) {
+	filesystems := []FilesystemUsage{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		used, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		free, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		if used+free == 0 {
+			continue
+		}
+		filesystems = append(filesystems, FilesystemUsage{
+			Filesystem:     fields[0],
+			Mountpoint:     fields[3],
+			SizeBytes:      used + free,
+			UsedBytes:      used,
+			AvailableBytes: free,
+			UsePercent:     usePercent(used, free),
+		})
+	}
+	if len(filesystems) == 0 {
+		return nil, noFilesystemsError(output)
+	}
+	return filesystems, nil
+}
+
+// usePercent returns the used share of the space available to users rounded to one decimal, the
+// same way df computes its capacity (space reserved for root is not counted as available).
+func usePercent(used int64, available int64) float64 {
+	if used+available == 0 {
+		return 0
+	}
+	return math.Round(float64(used)*1000/float64(used+available)) / 10
+}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for CheckDiskSpace tool
+
+func TestParseDF(t *testing.T) {
+	output := "Filesystem     1024-blocks      Used Available Capacity Mounted on\n" +
+		"/dev/sda1         41152736  37036032   2003304      95% /\n" +
+		"tmpfs              1013272         0   1013272       0% /dev/shm\n" +
+		"proc                     0         0         0       -  /proc\n" +
+		"/dev/sdb1         10255636   1024000   9231636      10% /mnt/backup disk\n"
+
+	filesystems, err := parseDF(output)
+	require.NoError(t, err)
+	require.Len(t, filesystems, 3)
+	require.Equal(t, FilesystemUsage{
+		Filesystem:     "/dev/sda1",
+		Mountpoint:     "/",
+		SizeBytes:      41152736 * 1024,
+		UsedBytes:      37036032 * 1024,
+		AvailableBytes: 2003304 * 1024,
+		UsePercent:     94.9,
+	}, filesystems[0])
+	require.Equal(t, float64(0), filesystems[1].UsePercent)
+	require.Equal(t, "/mnt/backup disk", filesystems[2].Mountpoint)
+}
+
+func TestParseDF_NoOutput(t *testing.T) {
+	_, err := parseDF("df: command not found\n")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "command not found")
+}
+
+func TestDiskSpaceResult_FailedCommand(t *testing.T) {
+	stdout := "Filesystem     1024-blocks      Used Available Capacity Mounted on\n" +
+		"/dev/sda1         41152736  37036032   2003304      95% /\n"
+
+	// df exits 1 when a mount cannot be read but still reports the others
+	result := diskSpaceResult("web1", diskSpaceOutput{stdout: stdout, stderr: "df: /mnt/nfs: Stale file handle\n", exitCode: 1}, false)
+	require.Empty(t, result.Error)
+	require.Len(t, result.Filesystems, 1)
+	require.Equal(t, "/", result.Filesystems[0].Mountpoint)
+	require.Equal(t, "exit status 1: df: /mnt/nfs: Stale file handle", result.Warning)
+
+	result = diskSpaceResult("web1", diskSpaceOutput{stdout: stdout}, false)
+	require.Empty(t, result.Warning)
+	require.Len(t, result.Filesystems, 1)
+
+	// nothing parsed fails the host with the reason
+	result = diskSpaceResult("web1", diskSpaceOutput{stderr: "sh: df: command not found\n", exitCode: 127}, false)
+	require.Empty(t, result.Filesystems)
+	require.Equal(t, "no filesystems reported (exit status 127: sh: df: command not found)", result.Error)
+}
+
+func TestParsePSDrive(t *testing.T) {
+	output := "C\t80000000000\t20000000000\tC:\\\r\nD\t0\t0\tD:\\\r\n"
+
+	filesystems, err := parsePSDrive(output)
+	require.NoError(t, err)
+	require.Equal(t, []FilesystemUsage{{
+		Filesystem:     "C",
+		Mountpoint:     `C:\`,
+		SizeBytes:      100000000000,
+		UsedBytes:      80000000000,
+		AvailableBytes: 20000000000,
+		UsePercent:     80,
+	}}, filesystems)
+}
+
+func TestCheckDiskSpace_InvalidThreshold(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+
+	tool := &CheckDiskSpace{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"group":             "production",
+				"threshold_percent": float64(120),
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
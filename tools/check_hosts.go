@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&CheckHosts{})
+}
+
+// CheckHostResult is the health of the connection to a single host.
+type CheckHostResult struct {
+	Host  string `json:"host"`
+	Group string `json:"group"`
+	// Reachable is true when the connection and authentication succeeded
+	Reachable bool `json:"reachable"`
+	// Healthy is true when a no-op command also ran successfully
+	Healthy bool `json:"healthy"`
+	// AuthMethod is how the connection authenticated, one of the ssh.AuthMethod constants
+	AuthMethod string `json:"auth_method,omitempty"`
+	// ConnectMillis is the time spent connecting and authenticating
+	ConnectMillis int64 `json:"connect_millis"`
+	// ExecMillis is the round trip of the no-op command
+	ExecMillis int64 `json:"exec_millis,omitempty"`
+	// ErrorClass is the kind of failure to connect (network, timeout, auth, hostkey or other)
+	ErrorClass string `json:"error_class,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CheckHostsResult is the health of every targeted host.
+type CheckHostsResult struct {
+	Hosts     []CheckHostResult `json:"hosts"`
+	Healthy   int               `json:"healthy"`
+	Unhealthy int               `json:"unhealthy"`
+}
+
+// CheckHosts is a tool that checks the targeted hosts can be connected to and run commands.
+type CheckHosts struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *CheckHosts) Definition() mcp.Tool {
+	return mcp.NewTool("check_hosts",
+		mcp.WithDescription("Checks that hosts can be reached by connecting to each one in parallel and running a no-op command ('true', or 'exit 0' on Windows). Returns per host whether it is reachable and healthy, the auth_method used (password, key_path, agent, default_key or none), the connect and exec latency in milliseconds, and for failures the error, with an error_class (network, timeout, auth, hostkey or other) when the host could not be connected to."),
+		mcp.WithString("group",
+			mcp.Description("Group name to check all hosts in that group (mutually exclusive with name_of_hosts and groups)"),
+		),
+		mcp.WithArray("groups",
+			mcp.Description("Array of group names to check every host in all of them, each host once (mutually exclusive with group and name_of_hosts)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group and groups)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithNumber("max_parallel", mcp.Description("Maximum number of hosts to check at once (default: all at once)")),
+	)
+}
+
+// Handler is the function that is called when the tool is invoked.
+func (c *CheckHosts) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		maxParallel := request.GetInt("max_parallel", 0)
+		if maxParallel < 1 && request.GetArguments()["max_parallel"] != nil {
+			return mcp.NewToolResultError("max_parallel must be at least 1"), nil
+		}
+
+		// the auth method is only known while connected, so it is recorded from within the check
+		var authMethodsMx sync.Mutex
+		authMethods := make(map[string]string, len(found))
+		results := commands.PerformOnHostsLimited(found, maxParallel, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			authMethodsMx.Lock()
+			authMethods[host.Name] = sshClient.AuthMethod()
+			authMethodsMx.Unlock()
+			_, err := sshClient.Exec(noopCommand(host))
+			return "", err
+		})
+
+		checked := CheckHostsResult{Hosts: make([]CheckHostResult, 0, len(found))}
+		for _, host := range found {
+			checked.Hosts = append(checked.Hosts, checkHostResult(host, results[host.Name], authMethods[host.Name]))
+		}
+		sort.Slice(checked.Hosts, func(i, j int) bool {
+			if checked.Hosts[i].Group != checked.Hosts[j].Group {
+				return checked.Hosts[i].Group < checked.Hosts[j].Group
+			}
+			return checked.Hosts[i].Host < checked.Hosts[j].Host
+		})
+		for _, host := range checked.Hosts {
+			if host.Healthy {
+				checked.Healthy++
+			} else {
+				checked.Unhealthy++
+			}
+		}
+		return mcp.NewToolResultStructuredOnly(checked), nil
+	}
+}
+
+// noopCommand returns the command that does nothing successfully on the host.
+func noopCommand(host ssh.ClientInfo) string {
+	if utils.IsWindows(host) {
+		return "exit 0"
+	}
+	return "true"
+}
+
+// checkHostResult converts the result of checking a host.
+func checkHostResult(host ssh.ClientInfo, result commands.CommandResult, authMethod string) CheckHostResult {
+	checked := CheckHostResult{
+		Host:          host.Name,
+		Group:         host.Group,
+		Reachable:     !result.ConnectFailed,
+		Healthy:       result.Err == nil,
+		AuthMethod:    authMethod,
+		ConnectMillis: result.ConnectMillis,
+		ExecMillis:    result.ExecMillis,
+	}
+	if result.Err != nil {
+		checked.Error = result.Err.Error()
+		if result.ConnectFailed {
+			checked.ErrorClass = string(ssh.ClassifyError(result.Err))
+		}
+	}
+	return checked
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// Tests for CheckHosts tool
+
+func TestCheckHosts_Unreachable(t *testing.T) {
+	// a port nothing listens on refuses the connection right away
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+
+	engine := setupTestStorage(t)
+	require.NoError(t, engine.Set(ssh.ClientInfo{Group: "production", Name: "server1", Host: "127.0.0.1", Port: port, User: "testuser", Pass: "testpass"}))
+
+	tool := &CheckHosts{}
+	handler := tool.Handler(context.Background(), engine)
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+		"group": "production",
+	}}}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	checked := result.StructuredContent.(CheckHostsResult)
+	require.Equal(t, 0, checked.Healthy)
+	require.Equal(t, 1, checked.Unhealthy)
+	require.Len(t, checked.Hosts, 1)
+	require.Equal(t, "server1", checked.Hosts[0].Host)
+	require.False(t, checked.Hosts[0].Reachable)
+	require.False(t, checked.Hosts[0].Healthy)
+	require.Equal(t, string(ssh.ErrorClassNetwork), checked.Hosts[0].ErrorClass)
+	require.NotEmpty(t, checked.Hosts[0].Error)
+	require.Empty(t, checked.Hosts[0].AuthMethod)
+}
+
+func TestCheckHosts_Invalid(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+
+	tool := &CheckHosts{}
+	handler := tool.Handler(context.Background(), engine)
+
+	testCases := map[string]map[string]interface{}{
+		"no hosts":     {},
+		"unknown host": {"name_of_hosts": []interface{}{"production:missing"}},
+		"max_parallel": {"group": "production", "max_parallel": 0},
+	}
+	for name, args := range testCases {
+		t.Run(name, func(t *testing.T) {
+			request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}}
+			result, err := handler(context.Background(), request)
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+		})
+	}
+}
+
+func TestCheckHostResult(t *testing.T) {
+	host := ssh.ClientInfo{Group: "production", Name: "server1"}
+
+	healthy := checkHostResult(host, commands.CommandResult{Host: "server1", ConnectMillis: 12, ExecMillis: 3}, ssh.AuthMethodAgent)
+	require.Equal(t, CheckHostResult{Host: "server1", Group: "production", Reachable: true, Healthy: true, AuthMethod: "agent", ConnectMillis: 12, ExecMillis: 3}, healthy)
+
+	// connected but the command could not run, e.g. a restricted shell
+	failed := checkHostResult(host, commands.CommandResult{Host: "server1", Err: errors.New("exit status 1")}, ssh.AuthMethodPassword)
+	require.True(t, failed.Reachable)
+	require.False(t, failed.Healthy)
+	require.Equal(t, "exit status 1", failed.Error)
+	require.Empty(t, failed.ErrorClass)
+}
+
+func TestNoopCommand(t *testing.T) {
+	require.Equal(t, "true", noopCommand(ssh.ClientInfo{OS: ssh.OSInfo{Uname: "Linux test 5.15.0"}}))
+	require.Equal(t, "exit 0", noopCommand(ssh.ClientInfo{OS: ssh.OSInfo{Uname: "Windows 10.0.20348"}}))
+}
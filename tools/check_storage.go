@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&CheckStorage{})
+}
+
+// CheckStorageRecord is a corrupt host record and what was done with it.
+type CheckStorageRecord struct {
+	storage.CorruptRecord
+
+	// Action is quarantined or deleted when the record was repaired
+	Action string `json:"action,omitempty"`
+	// QuarantineKey is where the raw value was moved when quarantined
+	QuarantineKey string `json:"quarantine_key,omitempty"`
+	// RepairFailure is why the record could not be repaired
+	RepairFailure string `json:"repair_failure,omitempty"`
+}
+
+// CheckStorage is a tool that finds and repairs corrupt host records.
+type CheckStorage struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *CheckStorage) Definition() mcp.Tool {
+	return mcp.NewTool("check_storage",
+		mcp.WithDescription("Checks every stored host record and reports the ones that cannot be parsed. Corrupt records are skipped by get_hosts and every other listing. Set repair=true to move them to a quarantine key, where they are kept for inspection but no longer listed, or to delete them with repair_action=delete."),
+		mcp.WithBoolean("repair",
+			mcp.Description("Repair the corrupt records found (default: false, only report them)"),
+		),
+		mcp.WithString("repair_action",
+			mcp.Description("How corrupt records are repaired (default: quarantine)"),
+			mcp.Enum("quarantine", "delete"),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *CheckStorage) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		repair := request.GetBool("repair", false)
+		action := request.GetString("repair_action", "quarantine")
+		if action != "quarantine" && action != "delete" {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid repair_action %q: must be quarantine or delete", action)), nil
+		}
+
+		corrupt, err := storageEngine.CheckHosts()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		records := make([]CheckStorageRecord, 0, len(corrupt))
+		for _, record := range corrupt {
+			if !groupAllowed(record.Group) {
+				continue
+			}
+			checked := CheckStorageRecord{CorruptRecord: record}
+			if repair {
+				checked.repair(storageEngine, action)
+			}
+			records = append(records, checked)
+		}
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{
+			"healthy": len(records) == 0,
+			"corrupt": records,
+		}), nil
+	}
+}
+
+// repair quarantines or deletes the corrupt record, recording the outcome.
+func (r *CheckStorageRecord) repair(storageEngine storage.Store, action string) {
+	var err error
+	if action == "delete" {
+		err = storageEngine.DeleteKey(r.Key)
+	} else {
+		r.QuarantineKey, err = storageEngine.QuarantineHost(r.Key)
+	}
+	if err != nil {
+		r.RepairFailure = err.Error()
+		return
+	}
+	r.Action = action + "d"
+}
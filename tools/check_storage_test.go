@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStorage_Healthy(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+
+	tool := &CheckStorage{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"repair": true}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	structured := result.StructuredContent.(map[string]any)
+	require.Equal(t, true, structured["healthy"])
+	require.Empty(t, structured["corrupt"])
+}
+
+func TestCheckStorage_InvalidRepairAction(t *testing.T) {
+	engine := setupTestStorage(t)
+
+	tool := &CheckStorage{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"repair": true, "repair_action": "fix"}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+const (
+	// sudoProbeCommand checks for sudo and runs a no-op through it without prompting
+	sudoProbeCommand = `command -v sudo >/dev/null 2>&1 || { echo SUDO_MISSING; exit 0; }; sudo -n true 2>&1 && echo SUDO_OK; true`
+	// sudoPasswordProbeCommand runs a no-op through sudo with the password read from stdin,
+	// ignoring cached credentials so the password itself is verified
+	sudoPasswordProbeCommand = `sudo -S -k -p '' true 2>&1 && echo SUDO_OK; true`
+	// sudoPasswordSeparator separates the output of the two probes
+	sudoPasswordSeparator = "@@PASSWORD@@"
+)
+
+// Sudo access statuses reported by check_sudo.
+const (
+	SudoPasswordless     = "passwordless"
+	SudoPasswordRequired = "password_required"
+	SudoNotPermitted     = "not_permitted"
+	SudoNotInstalled     = "not_installed"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&CheckSudo{})
+}
+
+// CheckSudoResult is the sudo access of the connecting user on a single host.
+type CheckSudoResult struct {
+	Host      string `json:"host"`
+	Available bool   `json:"available"`
+	Status    string `json:"status,omitempty"`
+	// PasswordValid is whether the host's password was accepted by sudo, only set when it was probed
+	PasswordValid *bool  `json:"password_valid,omitempty"`
+	Detail        string `json:"detail,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// CheckSudo is a tool that verifies sudo access without running anything destructive.
+type CheckSudo struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *CheckSudo) Definition() mcp.Tool {
+	return mcp.NewTool("check_sudo",
+		mcp.WithDescription("Pre-flights sudo access for the connecting user on each Linux host by running 'sudo -n true', which never prompts or changes anything. Reports whether sudo is available and the status: passwordless, password_required, not_permitted or not_installed. When a password is required and the host has a password configured, it is passed to 'sudo -S' over stdin to verify it is accepted (reported in password_valid). You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to check all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("check_password",
+			mcp.Description("Verify the host's configured password with sudo when a password is required (default: true)"),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *CheckSudo) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		checkPassword := request.GetBool("check_password", true)
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			if utils.IsWindows(host) {
+				return "", fmt.Errorf("sudo is not supported on Windows hosts")
+			}
+			output, err := sshClient.Exec(sudoProbeCommand)
+			if err != nil {
+				return "", fmt.Errorf("failed to check sudo: %w", err)
+			}
+			if !checkPassword || host.Pass == "" || !strings.Contains(string(output), "password is required") {
+				return string(output), nil
+			}
+
+			password, err := host.ResolvePassword()
+			if err != nil {
+				return "", err
+			}
+			passwordOutput, err := sshClient.ExecWithStdin(sudoPasswordProbeCommand, password+"\n")
+			if err != nil {
+				return "", fmt.Errorf("failed to check sudo password: %w", err)
+			}
+			return string(output) + sudoPasswordSeparator + "\n" + string(passwordOutput), nil
+		})
+
+		hosts := make([]CheckSudoResult, 0, len(results))
+		for name, result := range results {
+			if result.Err != nil {
+				hosts = append(hosts, CheckSudoResult{Host: name, Error: result.Err.Error()})
+				continue
+			}
+			hosts = append(hosts, parseCheckSudo(name, result.Result))
+		}
+		sort.Slice(hosts, func(i, j int) bool {
+			return hosts[i].Host < hosts[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": hosts}), nil
+	}
+}
+
+// sudoNotPermitted returns true when the sudo output reports that the user may not use sudo.
+func sudoNotPermitted(output string) bool {
+	return strings.Contains(output, "not in the sudoers file") ||
+		strings.Contains(output, "may not run sudo") ||
+		strings.Contains(output, "is not allowed to execute")
+}
+
+// parseCheckSudo classifies the output of the sudo probes for a host.
+func parseCheckSudo(host string, output string) CheckSudoResult {
+	result := CheckSudoResult{Host: host}
+	probe, passwordProbe, probedPassword := strings.Cut(output, sudoPasswordSeparator)
+
+	switch {
+	case strings.Contains(probe, "SUDO_MISSING"):
+		result.Status = SudoNotInstalled
+		return result
+	case strings.Contains(probe, "SUDO_OK"):
+		result.Available = true
+		result.Status = SudoPasswordless
+		return result
+	case sudoNotPermitted(probe):
+		result.Status = SudoNotPermitted
+		result.Detail = strings.TrimSpace(probe)
+		return result
+	case !strings.Contains(probe, "password is required"):
+		result.Status = SudoNotPermitted
+		result.Detail = strings.TrimSpace(probe)
+		return result
+	}
+
+	result.Available = true
+	result.Status = SudoPasswordRequired
+	if !probedPassword {
+		result.Detail = "no password to verify, the user may still not be permitted to use sudo"
+		return result
+	}
+	valid := strings.Contains(passwordProbe, "SUDO_OK")
+	if !valid && sudoNotPermitted(passwordProbe) {
+		result.Available = false
+		result.Status = SudoNotPermitted
+		result.Detail = strings.TrimSpace(passwordProbe)
+		return result
+	}
+	result.PasswordValid = &valid
+	if !valid {
+		result.Detail = "sudo rejected the host's password"
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for CheckSudo tool
+
+func TestParseCheckSudo(t *testing.T) {
+	valid, invalid := true, false
+	testCases := map[string]struct {
+		output   string
+		expected CheckSudoResult
+	}{
+		"not installed": {
+			output:   "SUDO_MISSING\n",
+			expected: CheckSudoResult{Host: "web01", Status: SudoNotInstalled},
+		},
+		"passwordless": {
+			output:   "SUDO_OK\n",
+			expected: CheckSudoResult{Host: "web01", Available: true, Status: SudoPasswordless},
+		},
+		"not in sudoers": {
+			output:   "alice is not in the sudoers file.  This incident will be reported.\n",
+			expected: CheckSudoResult{Host: "web01", Status: SudoNotPermitted, Detail: "alice is not in the sudoers file.  This incident will be reported."},
+		},
+		"password required without password": {
+			output:   "sudo: a password is required\n",
+			expected: CheckSudoResult{Host: "web01", Available: true, Status: SudoPasswordRequired, Detail: "no password to verify, the user may still not be permitted to use sudo"},
+		},
+		"password accepted": {
+			output:   "sudo: a password is required\n" + sudoPasswordSeparator + "\nSUDO_OK\n",
+			expected: CheckSudoResult{Host: "web01", Available: true, Status: SudoPasswordRequired, PasswordValid: &valid},
+		},
+		"password rejected": {
+			output:   "sudo: a password is required\n" + sudoPasswordSeparator + "\nSorry, try again.\nsudo: no password was provided\n",
+			expected: CheckSudoResult{Host: "web01", Available: true, Status: SudoPasswordRequired, PasswordValid: &invalid, Detail: "sudo rejected the host's password"},
+		},
+		"password accepted but not permitted": {
+			output:   "sudo: a password is required\n" + sudoPasswordSeparator + "\nalice is not in the sudoers file.\n",
+			expected: CheckSudoResult{Host: "web01", Status: SudoNotPermitted, Detail: "alice is not in the sudoers file."},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, parseCheckSudo("web01", tc.output))
+		})
+	}
+}
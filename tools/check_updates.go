@@ -0,0 +1,271 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+// securitySeparator separates the pending updates from the security advisories in the script output
+const securitySeparator = "@@SECURITY@@"
+
+// checkUpdatesScript detects the package manager and lists the pending updates, printing the
+// manager name on the first line. The package managers exit non-zero when updates are pending so
+// the script always ends with true.
+const checkUpdatesScript = `if command -v apt-get >/dev/null 2>&1; then echo MANAGER=apt; apt list --upgradable 2>/dev/null; ` +
+	`elif command -v dnf >/dev/null 2>&1; then echo MANAGER=dnf; dnf -q check-update 2>/dev/null; echo ` + securitySeparator + `; dnf -q updateinfo list --security 2>/dev/null; ` +
+	`elif command -v yum >/dev/null 2>&1; then echo MANAGER=yum; yum -q check-update 2>/dev/null; echo ` + securitySeparator + `; yum -q updateinfo list security 2>/dev/null; ` +
+	`elif command -v brew >/dev/null 2>&1; then echo MANAGER=brew; brew outdated --verbose 2>/dev/null; ` +
+	`else echo MANAGER=none; fi; true`
+
+// checkWindowsUpdatesScript lists the pending Windows updates through the Windows Update Agent API
+// as "<title>\t<is security update>" lines.
+const checkWindowsUpdatesScript = `'MANAGER=windows_update'; ` +
+	`$r = (New-Object -ComObject Microsoft.Update.Session).CreateUpdateSearcher().Search('IsInstalled=0 and IsHidden=0'); ` +
+	`foreach ($u in $r.Updates) { $u.Title + [char]9 + (@($u.Categories | Where-Object { $_.Name -eq 'Security Updates' }).Count -gt 0) }`
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&CheckUpdates{})
+}
+
+// PackageUpdate is a single pending package update.
+type PackageUpdate struct {
+	Name     string `json:"name"`
+	Version  string `json:"version,omitempty"`
+	Security bool   `json:"security,omitempty"`
+}
+
+// CheckUpdatesResult is the pending updates for a single host.
+type CheckUpdatesResult struct {
+	Host    string `json:"host"`
+	Manager string `json:"manager,omitempty"`
+	Total   int    `json:"total"`
+	// Security is the number of security updates, nil when the manager does not distinguish them
+	Security *int            `json:"security,omitempty"`
+	Updates  []PackageUpdate `json:"updates"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// CheckUpdates is a tool that lists the pending OS package updates on remote machines.
+type CheckUpdates struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *CheckUpdates) Definition() mcp.Tool {
+	return mcp.NewTool("check_updates",
+		mcp.WithDescription("Lists the pending package updates on each host using its package manager (apt, dnf, yum or brew, and the Windows Update Agent on Windows). Returns the per-host total and, where the manager distinguishes them, the number of security updates, plus fleet-wide totals to help prioritize patching. Nothing is installed. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to check all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("security_only",
+			mcp.Description("Only list the security updates, the totals still count every update (default: false)"),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *CheckUpdates) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		securityOnly := request.GetBool("security_only", false)
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			cmd := checkUpdatesScript
+			if utils.IsWindows(host) {
+				cmd = utils.PowerShellCommand(checkWindowsUpdatesScript)
+			}
+			output, err := sshClient.Exec(cmd)
+			if err != nil {
+				return "", fmt.Errorf("failed to check for updates: %w", err)
+			}
+			return string(output), nil
+		})
+
+		totalUpdates, totalSecurity := 0, 0
+		hosts := make([]CheckUpdatesResult, 0, len(results))
+		for name, result := range results {
+			var hostResult CheckUpdatesResult
+			if result.Err != nil {
+				hostResult = CheckUpdatesResult{Host: name, Updates: []PackageUpdate{}, Error: result.Err.Error()}
+			} else {
+				hostResult = parseCheckUpdates(name, result.Result)
+			}
+			totalUpdates += hostResult.Total
+			if hostResult.Security != nil {
+				totalSecurity += *hostResult.Security
+			}
+			if securityOnly {
+				filtered := []PackageUpdate{}
+				for _, update := range hostResult.Updates {
+					if update.Security {
+						filtered = append(filtered, update)
+					}
+				}
+				hostResult.Updates = filtered
+			}
+			hosts = append(hosts, hostResult)
+		}
+		sort.Slice(hosts, func(i, j int) bool {
+			return hosts[i].Host < hosts[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{
+			"hosts":          hosts,
+			"total_updates":  totalUpdates,
+			"total_security": totalSecurity,
+		}), nil
+	}
+}
+
+// parseCheckUpdates parses the output of the check updates scripts for a host.
+func parseCheckUpdates(host string, output string) CheckUpdatesResult {
+	result := CheckUpdatesResult{Host: host, Updates: []PackageUpdate{}}
+
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	firstLine, rest, _ := strings.Cut(strings.TrimLeft(output, "\n"), "\n")
+	manager, ok := strings.CutPrefix(strings.TrimSpace(firstLine), "MANAGER=")
+	if !ok {
+		result.Error = "unexpected output while checking for updates"
+		return result
+	}
+	result.Manager = manager
+
+	updates, advisories, _ := strings.Cut(rest, securitySeparator)
+	distinguishesSecurity := true
+	switch manager {
+	case "apt":
+		result.Updates = parseAptUpgradable(updates)
+	case "dnf", "yum":
+		result.Updates = parseYumCheckUpdate(updates, advisories)
+	case "brew":
+		result.Updates = parseBrewOutdated(updates)
+		distinguishesSecurity = false
+	case "windows_update":
+		result.Updates = parseWindowsUpdates(updates)
+	default:
+		result.Manager = ""
+		result.Error = "no supported package manager found (apt, dnf, yum or brew)"
+		return result
+	}
+
+	result.Total = len(result.Updates)
+	if distinguishesSecurity {
+		security := 0
+		for _, update := range result.Updates {
+			if update.Security {
+				security++
+			}
+		}
+		result.Security = &security
+	}
+	return result
+}
+
+// parseAptUpgradable parses "name/suite[,suite] version arch [upgradable from: old]" lines from
+// apt list --upgradable. Updates coming from a -security suite are security updates.
+func parseAptUpgradable(output string) []PackageUpdate {
+	updates := []PackageUpdate{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		name, suites, ok := strings.Cut(fields[0], "/")
+		if !ok {
+			continue
+		}
+		updates = append(updates, PackageUpdate{
+			Name:     name,
+			Version:  fields[1],
+			Security: strings.Contains(suites, "-security"),
+		})
+	}
+	return updates
+}
+
+// parseYumCheckUpdate parses "name.arch version repo" lines from dnf/yum check-update. A package
+// is a security update when a security advisory lists a newer build of it.
+func parseYumCheckUpdate(output string, advisories string) []PackageUpdate {
+	// advisory lines are "<advisory> <severity/type> <name-version-release.arch>"
+	var securityPackages []string
+	for _, line := range strings.Split(advisories, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 {
+			securityPackages = append(securityPackages, fields[len(fields)-1])
+		}
+	}
+
+	updates := []PackageUpdate{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "Obsoleting Packages") {
+			// the obsoleted packages are listed again after this header
+			break
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		dot := strings.LastIndex(fields[0], ".")
+		if dot <= 0 {
+			continue
+		}
+		name := fields[0][:dot]
+		security := false
+		for _, pkg := range securityPackages {
+			if rest, ok := strings.CutPrefix(pkg, name+"-"); ok && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+				security = true
+				break
+			}
+		}
+		updates = append(updates, PackageUpdate{Name: name, Version: fields[1], Security: security})
+	}
+	return updates
+}
+
+// parseBrewOutdated parses "name (installed) < latest" lines from brew outdated --verbose.
+func parseBrewOutdated(output string) []PackageUpdate {
+	updates := []PackageUpdate{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		update := PackageUpdate{Name: strings.Fields(line)[0]}
+		if _, latest, ok := strings.Cut(line, "< "); ok {
+			update.Version = strings.TrimSpace(latest)
+		}
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// parseWindowsUpdates parses "<title>\t<is security update>" lines.
+func parseWindowsUpdates(output string) []PackageUpdate {
+	updates := []PackageUpdate{}
+	for _, line := range strings.Split(output, "\n") {
+		title, security, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok || title == "" {
+			continue
+		}
+		updates = append(updates, PackageUpdate{Name: title, Security: strings.EqualFold(security, "true")})
+	}
+	return updates
+}
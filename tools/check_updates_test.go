@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for CheckUpdates tool
+
+func TestParseCheckUpdates_Apt(t *testing.T) {
+	output := "MANAGER=apt\n" +
+		"Listing...\n" +
+		"curl/jammy-updates,jammy-security 7.81.0-1ubuntu1.16 amd64 [upgradable from: 7.81.0-1ubuntu1.15]\n" +
+		"vim/jammy-updates 2:8.2.3995-1ubuntu2.17 amd64 [upgradable from: 2:8.2.3995-1ubuntu2.16]\n"
+
+	result := parseCheckUpdates("web01", output)
+	require.Empty(t, result.Error)
+	require.Equal(t, "apt", result.Manager)
+	require.Equal(t, 2, result.Total)
+	require.NotNil(t, result.Security)
+	require.Equal(t, 1, *result.Security)
+	require.Equal(t, PackageUpdate{Name: "curl", Version: "7.81.0-1ubuntu1.16", Security: true}, result.Updates[0])
+	require.False(t, result.Updates[1].Security)
+}
+
+func TestParseCheckUpdates_Dnf(t *testing.T) {
+	output := "MANAGER=dnf\n" +
+		"\n" +
+		"curl.x86_64          8.0.1-2.fc38        updates\n" +
+		"kernel.x86_64        6.5.6-100.fc38      updates\n" +
+		"Obsoleting Packages\n" +
+		"grub2-tools.x86_64   1:2.06-100.fc38     updates\n" +
+		securitySeparator + "\n" +
+		"FEDORA-2023-abc123 Moderate/Sec.  curl-8.0.1-2.fc38.x86_64\n"
+
+	result := parseCheckUpdates("db01", output)
+	require.Empty(t, result.Error)
+	require.Equal(t, 2, result.Total)
+	require.Equal(t, 1, *result.Security)
+	require.Equal(t, PackageUpdate{Name: "curl", Version: "8.0.1-2.fc38", Security: true}, result.Updates[0])
+	require.Equal(t, "kernel", result.Updates[1].Name)
+	require.False(t, result.Updates[1].Security)
+}
+
+func TestParseCheckUpdates_Brew(t *testing.T) {
+	output := "MANAGER=brew\ngit (2.42.0) < 2.43.0\nwget (1.21.3) < 1.21.4\n"
+
+	result := parseCheckUpdates("mac01", output)
+	require.Equal(t, 2, result.Total)
+	require.Nil(t, result.Security)
+	require.Equal(t, PackageUpdate{Name: "git", Version: "2.43.0"}, result.Updates[0])
+}
+
+func TestParseCheckUpdates_Windows(t *testing.T) {
+	output := "MANAGER=windows_update\r\n" +
+		"2024-01 Cumulative Update for Windows Server 2022 (KB5034129)\tTrue\r\n" +
+		"Update for Microsoft Defender Antivirus (KB2267602)\tFalse\r\n"
+
+	result := parseCheckUpdates("win01", output)
+	require.Equal(t, "windows_update", result.Manager)
+	require.Equal(t, 2, result.Total)
+	require.Equal(t, 1, *result.Security)
+	require.True(t, result.Updates[0].Security)
+}
+
+func TestParseCheckUpdates_NoManager(t *testing.T) {
+	result := parseCheckUpdates("box01", "MANAGER=none\n")
+	require.Contains(t, result.Error, "no supported package manager")
+	require.Empty(t, result.Updates)
+
+	result = parseCheckUpdates("box01", "garbage\n")
+	require.NotEmpty(t, result.Error)
+}
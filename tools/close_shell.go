@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/sessions"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&CloseShell{})
+}
+
+// CloseShell is a tool that closes an open shell session.
+type CloseShell struct {
+	sessionManager *sessions.Manager
+}
+
+// SetSessionManager sets the session manager
+func (c *CloseShell) SetSessionManager(manager *sessions.Manager) {
+	c.sessionManager = manager
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *CloseShell) Definition() mcp.Tool {
+	return mcp.NewTool("close_shell",
+		mcp.WithDescription("Closes a shell opened with open_shell and disconnects from the host. Anything still running in the shell is ended with it."),
+		mcp.WithString("session_id", mcp.Required(), mcp.Description("The session ID returned by open_shell")),
+	)
+}
+
+// Handler is the function that is called when the tool is invoked.
+func (c *CloseShell) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if c.sessionManager == nil {
+			panic("session manager not available")
+		}
+		sessionID, err := request.RequireString("session_id")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if err := c.sessionManager.Close(sessionID); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Shell session %s has been closed", sessionID)), nil
+	}
+}
@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+// Host comparison statuses reported by compare_commands.
+const (
+	ComparisonUnchanged  = "unchanged"
+	ComparisonChanged    = "changed"
+	ComparisonOnlyBefore = "only_in_before"
+	ComparisonOnlyAfter  = "only_in_after"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&CompareCommands{})
+}
+
+// HostComparison is the difference between two runs on a single host.
+type HostComparison struct {
+	Host        string   `json:"host"`
+	Status      string   `json:"status"`
+	Added       []string `json:"added,omitempty"`
+	Removed     []string `json:"removed,omitempty"`
+	BeforeError string   `json:"before_error,omitempty"`
+	AfterError  string   `json:"after_error,omitempty"`
+}
+
+// CompareCommands is a tool that compares the per-host output of two command runs.
+type CompareCommands struct {
+	commandRunner commands.Runner
+}
+
+// SetCommandRunner sets the command runner
+func (c *CompareCommands) SetCommandRunner(runner commands.Runner) {
+	c.commandRunner = runner
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *CompareCommands) Definition() mcp.Tool {
+	return mcp.NewTool("compare_commands",
+		mcp.WithDescription("Compares the results of two finished runs of the same command, e.g. before and after a change. Hosts are aligned by 'group:name' and each is reported as unchanged, changed (with the added and removed output lines), only_in_before or only_in_after."),
+		mcp.WithString("before_command_id", mcp.Required(), mcp.Description("ID of the earlier command run")),
+		mcp.WithString("after_command_id", mcp.Required(), mcp.Description("ID of the later command run")),
+	)
+}
+
+// Handler is the function that is called when the tool is invoked.
+func (c *CompareCommands) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if c.commandRunner == nil {
+			panic("command runner not available")
+		}
+
+		var states []*commands.CommandState
+		for _, arg := range []string{"before_command_id", "after_command_id"} {
+			commandID, err := request.RequireString(arg)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			cmd, err := c.commandRunner.GetCommand(commandID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if err := checkCommandAllowed(cmd); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if !cmd.Status().IsTerminal() {
+				return mcp.NewToolResultError(fmt.Sprintf("command %s is still %s", commandID, cmd.Status())), nil
+			}
+			states = append(states, cmd.ToState())
+		}
+		before, after := states[0], states[1]
+
+		hosts := compareCommandStates(before, after)
+		changed := 0
+		for _, host := range hosts {
+			if host.Status != ComparisonUnchanged {
+				changed++
+			}
+		}
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{
+			"same_command": before.Command == after.Command,
+			"hosts":        hosts,
+			"changed":      changed,
+		}), nil
+	}
+}
+
+// compareCommandStates compares the results of two runs per host, sorted by host identifier.
+func compareCommandStates(before, after *commands.CommandState) []HostComparison {
+	beforeResults := resultsByIdentifier(before)
+	afterResults := resultsByIdentifier(after)
+
+	identifiers := make(map[string]struct{}, len(beforeResults)+len(afterResults))
+	for id := range beforeResults {
+		identifiers[id] = struct{}{}
+	}
+	for id := range afterResults {
+		identifiers[id] = struct{}{}
+	}
+
+	comparisons := make([]HostComparison, 0, len(identifiers))
+	for id := range identifiers {
+		beforeResult, inBefore := beforeResults[id]
+		afterResult, inAfter := afterResults[id]
+		comparison := HostComparison{Host: id}
+		if inBefore && beforeResult.Err != nil {
+			comparison.BeforeError = beforeResult.Err.Error()
+		}
+		if inAfter && afterResult.Err != nil {
+			comparison.AfterError = afterResult.Err.Error()
+		}
+		switch {
+		case !inAfter:
+			comparison.Status = ComparisonOnlyBefore
+		case !inBefore:
+			comparison.Status = ComparisonOnlyAfter
+		default:
+			comparison.Added, comparison.Removed = diffLines(beforeResult.Result, afterResult.Result)
+			comparison.Status = ComparisonUnchanged
+			if len(comparison.Added) > 0 || len(comparison.Removed) > 0 || comparison.BeforeError != comparison.AfterError {
+				comparison.Status = ComparisonChanged
+			}
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	sort.Slice(comparisons, func(i, j int) bool {
+		return comparisons[i].Host < comparisons[j].Host
+	})
+	return comparisons
+}
+
+// resultsByIdentifier keys the command results by 'group:name', results are keyed by name only.
+func resultsByIdentifier(state *commands.CommandState) map[string]commands.CommandResult {
+	groups := make(map[string]string, len(state.Hosts))
+	for _, host := range state.Hosts {
+		groups[host.Name] = host.Group
+	}
+	results := make(map[string]commands.CommandResult, len(state.Results))
+	for name, result := range state.Results {
+		results[groups[name]+":"+name] = result
+	}
+	return results
+}
+
+// diffLines returns the lines only present in after (added) and only present in before (removed),
+// counting repeated lines, in the order they appear.
+func diffLines(before, after string) (added []string, removed []string) {
+	beforeLines := splitOutputLines(before)
+	afterLines := splitOutputLines(after)
+
+	remaining := make(map[string]int, len(beforeLines))
+	for _, line := range beforeLines {
+		remaining[line]++
+	}
+	for _, line := range afterLines {
+		if remaining[line] > 0 {
+			remaining[line]--
+			continue
+		}
+		added = append(added, line)
+	}
+	for _, line := range beforeLines {
+		if remaining[line] > 0 {
+			remaining[line]--
+			removed = append(removed, line)
+		}
+	}
+	return added, removed
+}
+
+// splitOutputLines splits output into lines, ignoring the trailing newline.
+func splitOutputLines(output string) []string {
+	output = strings.TrimRight(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
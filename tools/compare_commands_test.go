@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// Tests for CompareCommands tool
+
+func TestDiffLines(t *testing.T) {
+	added, removed := diffLines("a\nb\nb\nc\n", "b\nc\nd\nb\n")
+	require.Equal(t, []string{"d"}, added)
+	require.Equal(t, []string{"a"}, removed)
+
+	added, removed = diffLines("same\r\n", "same\n")
+	require.Empty(t, added)
+	require.Empty(t, removed)
+}
+
+func TestCompareCommands(t *testing.T) {
+	mock := commands.NewMockRunner()
+
+	before := mock.CreateCommand("nginx -v", []ssh.ClientInfo{{Group: "prod", Name: "web01"}, {Group: "prod", Name: "web02"}, {Group: "prod", Name: "web03"}})
+	before.SetResultForTest("web01", commands.CommandResult{Host: "web01", Result: "nginx/1.24\n"})
+	before.SetResultForTest("web02", commands.CommandResult{Host: "web02", Result: "nginx/1.24\n"})
+	before.SetResultForTest("web03", commands.CommandResult{Host: "web03", Result: "nginx/1.24\n"})
+	before.SetStatusForTest(commands.CommandStatusCompleted)
+
+	after := mock.CreateCommand("nginx -v", []ssh.ClientInfo{{Group: "prod", Name: "web01"}, {Group: "prod", Name: "web02"}, {Group: "prod", Name: "web04"}})
+	after.SetResultForTest("web01", commands.CommandResult{Host: "web01", Result: "nginx/1.26\n"})
+	after.SetResultForTest("web02", commands.CommandResult{Host: "web02", Result: "nginx/1.24\n"})
+	after.SetResultForTest("web04", commands.CommandResult{Host: "web04", Err: errors.New("connection refused")})
+	after.SetStatusForTest(commands.CommandStatusFailed)
+
+	tool := &CompareCommands{commandRunner: mock}
+	handler := tool.Handler(context.Background(), setupTestStorage(t))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"before_command_id": before.ID(),
+				"after_command_id":  after.ID(),
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	structured, ok := result.StructuredContent.(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, true, structured["same_command"])
+	require.Equal(t, 3, structured["changed"])
+	require.Equal(t, []HostComparison{
+		{Host: "prod:web01", Status: ComparisonChanged, Added: []string{"nginx/1.26"}, Removed: []string{"nginx/1.24"}},
+		{Host: "prod:web02", Status: ComparisonUnchanged},
+		{Host: "prod:web03", Status: ComparisonOnlyBefore},
+		{Host: "prod:web04", Status: ComparisonOnlyAfter, AfterError: "connection refused"},
+	}, structured["hosts"])
+}
+
+func TestCompareCommands_StillRunning(t *testing.T) {
+	mock := commands.NewMockRunner()
+	before := mock.CreateCommand("uptime", nil)
+	before.SetStatusForTest(commands.CommandStatusCompleted)
+	after := mock.CreateCommand("uptime", nil)
+	after.SetStatusForTest(commands.CommandStatusRunning)
+
+	tool := &CompareCommands{commandRunner: mock}
+	handler := tool.Handler(context.Background(), setupTestStorage(t))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"before_command_id": before.ID(),
+				"after_command_id":  after.ID(),
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
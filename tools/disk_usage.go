@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&DiskUsage{})
+}
+
+// DiskUsageEntry is the size of a single directory.
+type DiskUsageEntry struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// DiskUsageResult is the disk usage breakdown for a single host.
+type DiskUsageResult struct {
+	Host       string           `json:"host"`
+	Path       string           `json:"path"`
+	TotalBytes int64            `json:"total_bytes"`
+	Entries    []DiskUsageEntry `json:"entries"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// DiskUsage is a tool that gathers a disk usage breakdown by directory.
+type DiskUsage struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *DiskUsage) Definition() mcp.Tool {
+	return mcp.NewTool("disk_usage",
+		mcp.WithDescription("Gathers the disk usage of each directory directly under a path on Linux and Windows hosts. Returns the subdirectory sizes in bytes per host, sorted largest first. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to gather disk usage for all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("path", mcp.Required(), mcp.Description("The remote directory to break down")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *DiskUsage) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, err := request.RequireString("path")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if path == "" {
+			return mcp.NewToolResultError("path cannot be empty"), nil
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		windows := make(map[string]bool, len(found))
+		for _, host := range found {
+			windows[host.Name] = utils.IsWindows(host)
+		}
+
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			output, err := sshClient.Exec(diskUsageCommand(path, windows[host.Name]))
+			if err != nil {
+				return "", fmt.Errorf("failed to gather disk usage: %w", err)
+			}
+			return string(output), nil
+		})
+
+		usage := make([]DiskUsageResult, 0, len(results))
+		for name, result := range results {
+			hostUsage := DiskUsageResult{Host: name, Path: path}
+			if result.Err != nil {
+				hostUsage.Error = result.Err.Error()
+			} else {
+				// du reports in kilobytes, the PowerShell script in bytes
+				unit := int64(1024)
+				if windows[name] {
+					unit = 1
+				}
+				hostUsage.Entries, hostUsage.TotalBytes, err = parseDiskUsage(result.Result, path, unit)
+				if err != nil {
+					hostUsage.Error = err.Error()
+				}
+			}
+			usage = append(usage, hostUsage)
+		}
+		sort.Slice(usage, func(i, j int) bool {
+			return usage[i].Host < usage[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": usage}), nil
+	}
+}
+
+// diskUsageCommand returns the command that prints "<size>\t<path>" for each directory under path.
+func diskUsageCommand(path string, windows bool) string {
+	if windows {
+		return utils.PowerShellCommand(fmt.Sprintf(
+			"Get-ChildItem -LiteralPath %s -Directory -Force -ErrorAction SilentlyContinue | ForEach-Object { $s = (Get-ChildItem -LiteralPath $_.FullName -Recurse -File -Force -ErrorAction SilentlyContinue | Measure-Object -Property Length -Sum).Sum; [string][long]$s + [char]9 + $_.FullName }",
+			utils.PowerShellQuote(path)))
+	}
+	// -d is understood by both GNU and BSD du, errors for unreadable directories
+	// are kept in the output but do not fail the command
+	return fmt.Sprintf("du -k -d 1 %s 2>&1 || true", utils.ShellQuote(path))
+}
+
+// parseDiskUsage parses "<size>\t<path>" lines into entries sorted by size descending.
+// The line for path itself is returned as the total instead of an entry.
+func parseDiskUsage(output string, path string, unit int64) ([]DiskUsageEntry, int64, error) {
+	var entries []DiskUsageEntry
+	var total int64
+	var sawTotal bool
+	root := strings.TrimRight(path, `/\`)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		size, dir, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64)
+		if err != nil {
+			// not a size line (e.g. a permission error from du)
+			continue
+		}
+		if strings.TrimRight(dir, `/\`) == root {
+			total = n * unit
+			sawTotal = true
+			continue
+		}
+		entries = append(entries, DiskUsageEntry{Path: dir, Bytes: n * unit})
+	}
+	if len(entries) == 0 && !sawTotal {
+		return nil, 0, fmt.Errorf("no disk usage reported for %s: %s", path, strings.TrimSpace(output))
+	}
+	if !sawTotal {
+		for _, entry := range entries {
+			total += entry.Bytes
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Bytes > entries[j].Bytes
+	})
+	return entries, total, nil
+}
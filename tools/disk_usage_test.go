@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for DiskUsage tool
+
+func TestParseDiskUsage_Linux(t *testing.T) {
+	output := "4\t/var/empty\n" +
+		"du: cannot read directory '/var/cache/private': Permission denied\n" +
+		"2048\t/var/log\n" +
+		"512\t/var/lib\n" +
+		"2564\t/var\n"
+
+	entries, total, err := parseDiskUsage(output, "/var/", 1024)
+	require.NoError(t, err)
+	require.Equal(t, int64(2564*1024), total)
+	require.Equal(t, []DiskUsageEntry{
+		{Path: "/var/log", Bytes: 2048 * 1024},
+		{Path: "/var/lib", Bytes: 512 * 1024},
+		{Path: "/var/empty", Bytes: 4 * 1024},
+	}, entries)
+}
+
+func TestDiskUsageCommand_WindowsQuotedPath(t *testing.T) {
+	// a double quote must not end the command line and run what follows it
+	command := diskUsageCommand(`C:\x" & whoami & "`, true)
+	require.NotContains(t, command, "whoami")
+	require.Contains(t, powerShellScript(t, command), `Get-ChildItem -LiteralPath 'C:\x" & whoami & "' -Directory`)
+}
+
+func TestParseDiskUsage_WindowsTotalsEntries(t *testing.T) {
+	output := "100\tC:\\Data\\a\r\n300\tC:\\Data\\b\r\n"
+
+	entries, total, err := parseDiskUsage(output, `C:\Data`, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(400), total)
+	require.Equal(t, "C:\\Data\\b", entries[0].Path)
+}
+
+func TestParseDiskUsage_NoOutput(t *testing.T) {
+	_, _, err := parseDiskUsage("du: cannot access '/nope': No such file or directory\n", "/nope", 1024)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "No such file or directory")
+}
+
+func TestDiskUsage_MissingPath(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+
+	tool := &DiskUsage{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"group": "production",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
+
+func TestDiskUsage_NoHostSelector(t *testing.T) {
+	engine := setupTestStorage(t)
+
+	tool := &DiskUsage{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"path": "/var",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&GetCachedFact{})
+}
+
+// GetCachedFact is a tool that reads back facts cached by perform_and_cache.
+type GetCachedFact struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *GetCachedFact) Definition() mcp.Tool {
+	return mcp.NewTool("get_cached_fact",
+		mcp.WithDescription("Retrieves the cached output stored by perform_and_cache for a fact key, including when it was captured. You can specify individual hosts or an entire group, or neither to return the fact for every host that has it cached."),
+		mcp.WithString("fact_key", mcp.Required(), mcp.Description("The key the fact was cached under")),
+		mcp.WithString("group",
+			mcp.Description("Group name to get the fact for all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *GetCachedFact) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		factKey, err := request.RequireString("fact_key")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var facts []storage.Fact
+		var missing []string
+		if request.GetString("group", "") == "" && len(request.GetStringSlice("name_of_hosts", []string{})) == 0 {
+			all, err := storageEngine.ListFacts(factKey)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			for _, fact := range all {
+				if groupAllowed(fact.Group) {
+					facts = append(facts, fact)
+				}
+			}
+		} else {
+			found, err := getHostsFromRequest(storageEngine, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			for _, host := range found {
+				fact, ok := storageEngine.GetFact(factKey, host.Group, host.Name)
+				if !ok {
+					missing = append(missing, fmt.Sprintf("%s:%s", host.Group, host.Name))
+					continue
+				}
+				facts = append(facts, fact)
+			}
+		}
+
+		if len(facts) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("no cached facts found for key: %s", factKey)), nil
+		}
+		return mcp.NewToolResultStructuredOnly(map[string]any{"facts": facts, "missing": missing}), nil
+	}
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+// Tests for GetCachedFact tool
+
+func TestGetCachedFact_AllHosts(t *testing.T) {
+	engine := setupTestStorage(t)
+	require.NoError(t, engine.SetFact(storage.Fact{Key: "uptime", Group: "production", Name: "server1", Output: "up 1 day", CapturedAt: time.Now()}))
+	require.NoError(t, engine.SetFact(storage.Fact{Key: "uptime", Group: "staging", Name: "server2", Output: "up 2 days", CapturedAt: time.Now()}))
+
+	tool := &GetCachedFact{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"fact_key": "uptime",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	structured, ok := result.StructuredContent.(map[string]any)
+	require.True(t, ok)
+	require.Len(t, structured["facts"], 2)
+}
+
+func TestGetCachedFact_ByGroupReportsMissing(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	addTestHost(t, engine, "production", "server2", "10.0.1.2")
+	require.NoError(t, engine.SetFact(storage.Fact{Key: "uptime", Group: "production", Name: "server1", Output: "up 1 day", CapturedAt: time.Now()}))
+
+	tool := &GetCachedFact{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"fact_key": "uptime",
+				"group":    "production",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	structured, ok := result.StructuredContent.(map[string]any)
+	require.True(t, ok)
+	require.Len(t, structured["facts"], 1)
+	require.Equal(t, []string{"production:server2"}, structured["missing"])
+}
+
+func TestGetCachedFact_NotCached(t *testing.T) {
+	engine := setupTestStorage(t)
+
+	tool := &GetCachedFact{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"fact_key": "uptime",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -11,6 +12,23 @@ import (
 	"github.com/blakerouse/ssh-mcp/storage"
 )
 
+// DefaultStatusWaitTimeout is how long get_command_status waits for a command to complete when
+// wait is set, unless overridden by the server or timeout_seconds.
+const DefaultStatusWaitTimeout = 30 * time.Second
+
+// statusWaitTimeout is how long get_command_status waits for a command to complete by default. It
+// is independent of the auto-background threshold of the command tools.
+var statusWaitTimeout = DefaultStatusWaitTimeout
+
+// SetStatusWaitTimeout sets how long get_command_status waits for a command to complete when wait
+// is set without timeout_seconds.
+func SetStatusWaitTimeout(timeout time.Duration) {
+	statusWaitTimeout = timeout
+}
+
+// defaultOutputLimit is the page size of a host's output when paging without an output_limit.
+const defaultOutputLimit = 64 * 1024
+
 func init() {
 	// register the tool in the registry
 	Registry.Register(&GetCommandStatus{})
@@ -29,14 +47,39 @@ func (g *GetCommandStatus) SetCommandRunner(runner commands.Runner) {
 // Definition returns the mcp.Tool definition.
 func (g *GetCommandStatus) Definition() mcp.Tool {
 	return mcp.NewTool("get_command_status",
-		mcp.WithDescription("Retrieves the status and results of a background command by its command ID. For running commands, returns a snapshot of the partial output captured so far. Set wait=true to wait up to 30 seconds for completion. If no ID is provided, returns the most recent command."),
+		mcp.WithDescription("Retrieves the status and results of a background command by its command ID. For running commands, returns a snapshot of the partial output captured so far and last_activity_at, when any host last produced output (stalled is set when the server flags a command without output for too long). Set wait=true to wait for completion, up to timeout_seconds (default: the server's --status-wait-timeout, 30s unless changed). If no ID is provided, returns the most recent command."),
 		mcp.WithString("command_id", mcp.Description("The command ID returned when starting a background command (optional - defaults to most recent command)")),
-		mcp.WithBoolean("wait", mcp.Description("Wait up to 30 seconds for the command to complete before returning (default: false)")),
+		mcp.WithBoolean("wait", mcp.Description("Wait up to timeout_seconds for the command to complete before returning (default: false)")),
+		mcp.WithNumber("timeout_seconds", mcp.Description("Seconds to wait for the command to complete, implies wait. Useful to wait longer for a slow command or shorter to poll more often (default: the server's --status-wait-timeout, 30s unless changed)")),
+		resultFormatOption(),
+		outputFormatOption(),
+		mcp.WithObject("output_cursor", mcp.Description("Map of host name to byte offset, as returned in output_cursor by a previous call. Only output appended after the offset is returned for each host (optional - defaults to full output)")),
+		mcp.WithString("host", mcp.Description("Only return the result of this host (optional - required with output_offset and output_limit)")),
+		mcp.WithNumber("output_offset", mcp.Description("Byte offset into the host's output to return a page from, use next_offset of output_page to read the following page. output_page.has_more tells whether more output remains, past_end is set when the offset is beyond the output (optional - requires host, defaults to 0)")),
+		mcp.WithNumber("output_limit", mcp.Description("Maximum number of bytes of the host's output to return (optional - requires host, defaults to 65536 when paging)")),
+		mcp.WithBoolean("summary_only", mcp.Description("Only return the status, the number of hosts that succeeded, failed and are still running, the timing and the command error, without any per-host results. Keeps polling commands on many hosts cheap (default: false, cannot be combined with output_cursor, host or a format other than structured)")),
 	)
 }
 
+// mostRecentAllowedCommand returns the most recently created command on the allowed groups.
+func (g *GetCommandStatus) mostRecentAllowedCommand() (*commands.Command, error) {
+	if allowedGroups == nil {
+		return g.commandRunner.GetMostRecentCommand()
+	}
+	var mostRecent *commands.Command
+	for _, cmd := range g.commandRunner.ListCommands() {
+		if commandAllowed(cmd) && (mostRecent == nil || cmd.CreatedAt().After(mostRecent.CreatedAt())) {
+			mostRecent = cmd
+		}
+	}
+	if mostRecent == nil {
+		return nil, fmt.Errorf("no commands found")
+	}
+	return mostRecent, nil
+}
+
 // Handler is the function that is called when the tool is invoked.
-func (g *GetCommandStatus) Handler(ctx context.Context, storageEngine *storage.Engine) server.ToolHandlerFunc {
+func (g *GetCommandStatus) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
 	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if g.commandRunner == nil {
 			panic("command runner not available")
@@ -47,7 +90,7 @@ func (g *GetCommandStatus) Handler(ctx context.Context, storageEngine *storage.E
 
 		commandID := request.GetString("command_id", "")
 		if commandID == "" {
-			cmd, err = g.commandRunner.GetMostRecentCommand()
+			cmd, err = g.mostRecentAllowedCommand()
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
@@ -56,20 +99,169 @@ func (g *GetCommandStatus) Handler(ctx context.Context, storageEngine *storage.E
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
+			if err := checkCommandAllowed(cmd); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		cursor, err := parseOutputCursor(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		// If wait is requested, wait up to 30 seconds for completion
-		if request.GetBool("wait", false) {
-			return g.waitForCompletion(reqCtx, cmd)
+		page, err := parseOutputPage(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if page != nil && cursor != nil {
+			return mcp.NewToolResultError("cannot specify both 'output_cursor' and 'host'"), nil
 		}
 
-		return mcp.NewToolResultStructuredOnly(cmd.ToState()), nil
+		format, err := resultFormatFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		outputFormat, err := outputFormatFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		summaryOnly := request.GetBool("summary_only", false)
+		if summaryOnly && (cursor != nil || page != nil) {
+			return mcp.NewToolResultError("cannot specify 'summary_only' with 'output_cursor' or 'host'"), nil
+		}
+		if summaryOnly && format != "structured" {
+			return mcp.NewToolResultError("'summary_only' only supports the structured format"), nil
+		}
+
+		timeout, err := statusWaitFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// If wait is requested, wait up to the timeout for completion
+		if request.GetBool("wait", false) || request.GetFloat("timeout_seconds", 0) > 0 {
+			if !g.waitForCompletion(reqCtx, cmd, timeout) {
+				return mcp.NewToolResultError("request cancelled"), nil
+			}
+		}
+
+		if summaryOnly {
+			return mcp.NewToolResultStructuredOnly(cmd.ToSummary()), nil
+		}
+
+		state := cmd.ToState()
+		if cursor != nil {
+			state.ApplyOutputCursor(cursor)
+		}
+		if page != nil {
+			if err := state.ApplyOutputPage(page.host, page.offset, page.limit); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		applyOutputFormat(state, outputFormat)
+
+		return commandStateResult(state, format), nil
+	}
+}
+
+// parseOutputCursor parses the optional output_cursor argument into a per-host offset map.
+// Returns nil when no cursor was provided.
+func parseOutputCursor(request mcp.CallToolRequest) (map[string]int, error) {
+	raw, ok := request.GetArguments()["output_cursor"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("output_cursor must be an object mapping host name to byte offset")
+	}
+	cursor := make(map[string]int, len(obj))
+	for host, value := range obj {
+		offset, ok := value.(float64)
+		if !ok || offset < 0 || offset != float64(int(offset)) {
+			return nil, fmt.Errorf("invalid output_cursor offset for host %s: must be a non-negative integer", host)
+		}
+		cursor[host] = int(offset)
+	}
+	return cursor, nil
+}
+
+// outputPageRequest is the host and byte range of its output requested by get_command_status.
+type outputPageRequest struct {
+	host   string
+	offset int
+	limit  int
+}
+
+// parseOutputPage parses the optional host, output_offset and output_limit arguments. Returns nil
+// when no host was provided. Without output_offset and output_limit the host's full output is
+// returned.
+func parseOutputPage(request mcp.CallToolRequest) (*outputPageRequest, error) {
+	args := request.GetArguments()
+	_, hasOffset := args["output_offset"]
+	_, hasLimit := args["output_limit"]
+	host := request.GetString("host", "")
+	if host == "" {
+		if hasOffset || hasLimit {
+			return nil, fmt.Errorf("'host' is required with output_offset and output_limit")
+		}
+		return nil, nil
+	}
+
+	page := &outputPageRequest{host: host}
+	if !hasOffset && !hasLimit {
+		return page, nil
+	}
+	offset, err := nonNegativeIntArgument(args, "output_offset")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := nonNegativeIntArgument(args, "output_limit")
+	if err != nil {
+		return nil, err
+	}
+	if hasLimit && limit == 0 {
+		return nil, fmt.Errorf("output_limit must be at least 1")
+	}
+	if !hasLimit {
+		limit = defaultOutputLimit
+	}
+	page.offset = offset
+	page.limit = limit
+	return page, nil
+}
+
+// nonNegativeIntArgument returns the named argument as a non-negative integer, 0 when missing.
+func nonNegativeIntArgument(args map[string]any, name string) (int, error) {
+	raw, ok := args[name]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+	value, ok := raw.(float64)
+	if !ok || value < 0 || value != float64(int(value)) {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return int(value), nil
+}
+
+// statusWaitFromRequest returns how long to wait for the command to complete, using the server's
+// default unless timeout_seconds is provided.
+func statusWaitFromRequest(request mcp.CallToolRequest) (time.Duration, error) {
+	seconds := request.GetFloat("timeout_seconds", 0)
+	if seconds < 0 {
+		return 0, fmt.Errorf("timeout_seconds cannot be negative")
+	}
+	if seconds == 0 {
+		return statusWaitTimeout, nil
 	}
+	return time.Duration(seconds * float64(time.Second)), nil
 }
 
-// waitForCompletion waits up to 30 seconds for a command to complete
-func (g *GetCommandStatus) waitForCompletion(ctx context.Context, cmd *commands.Command) (*mcp.CallToolResult, error) {
-	const timeout = 30
+// waitForCompletion waits up to timeout for a command to complete.
+// Returns false if the context is cancelled before then.
+func (g *GetCommandStatus) waitForCompletion(ctx context.Context, cmd *commands.Command, timeout time.Duration) bool {
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -77,13 +269,10 @@ func (g *GetCommandStatus) waitForCompletion(ctx context.Context, cmd *commands.
 	for {
 		select {
 		case <-ctx.Done():
-			return mcp.NewToolResultError("request cancelled"), nil
+			return false
 		case <-ticker.C:
-			if cmd.Status() == commands.CommandStatusCompleted ||
-				cmd.Status() == commands.CommandStatusFailed ||
-				cmd.Status() == commands.CommandStatusCancelled ||
-				time.Since(startTime) >= timeout*time.Second {
-				return mcp.NewToolResultStructuredOnly(cmd.ToState()), nil
+			if cmd.Status().IsTerminal() || time.Since(startTime) >= timeout {
+				return true
 			}
 		}
 	}
@@ -2,6 +2,8 @@ package tools
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -493,3 +495,444 @@ func TestGetCommandStatus_NilRunner(t *testing.T) {
 
 	_, _ = handler(context.Background(), request)
 }
+
+// TestGetCommandStatus_OutputCursor tests that only output after the cursor is returned
+func TestGetCommandStatus_OutputCursor(t *testing.T) {
+	mock := commands.NewMockRunner()
+
+	hosts := []ssh.ClientInfo{
+		{Name: "host1", Host: "example.com", Port: "22", Group: "prod"},
+		{Name: "host2", Host: "example.org", Port: "22", Group: "prod"},
+	}
+
+	cmd := mock.CreateCommand("tail -f log", hosts)
+	cmd.SetStatusForTest(commands.CommandStatusRunning)
+	cmd.SetResultForTest("host1", commands.CommandResult{Host: "host1", Result: "line1\nline2\n"})
+	cmd.SetResultForTest("host2", commands.CommandResult{Host: "host2", Result: "other\n"})
+
+	tool := &GetCommandStatus{
+		commandRunner: mock,
+	}
+
+	storageEngine := createTestStorage(t)
+	defer storageEngine.Close()
+
+	handler := tool.Handler(context.Background(), storageEngine)
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"command_id":    cmd.ID(),
+				"output_cursor": map[string]interface{}{"host1": float64(6)},
+			},
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatal("expected successful result")
+	}
+
+	state, ok := result.StructuredContent.(*commands.CommandState)
+	if !ok {
+		t.Fatalf("expected *commands.CommandState, got %T", result.StructuredContent)
+	}
+	if state.Results["host1"].Result != "line2\n" {
+		t.Errorf("expected only new output for host1, got %q", state.Results["host1"].Result)
+	}
+	if state.Results["host2"].Result != "other\n" {
+		t.Errorf("expected full output for host2 missing from cursor, got %q", state.Results["host2"].Result)
+	}
+	if state.OutputCursor["host1"] != 12 || state.OutputCursor["host2"] != 6 {
+		t.Errorf("unexpected updated cursor: %v", state.OutputCursor)
+	}
+}
+
+// TestGetCommandStatus_OutputCursor_Invalid tests that an invalid cursor is rejected
+func TestGetCommandStatus_OutputCursor_Invalid(t *testing.T) {
+	mock := commands.NewMockRunner()
+
+	hosts := []ssh.ClientInfo{
+		{Name: "host1", Host: "example.com", Port: "22", Group: "prod"},
+	}
+
+	cmd := mock.CreateCommand("echo test", hosts)
+	cmd.SetStatusForTest(commands.CommandStatusCompleted)
+
+	tool := &GetCommandStatus{
+		commandRunner: mock,
+	}
+
+	storageEngine := createTestStorage(t)
+	defer storageEngine.Close()
+
+	handler := tool.Handler(context.Background(), storageEngine)
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"command_id":    cmd.ID(),
+				"output_cursor": map[string]interface{}{"host1": float64(-1)},
+			},
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("expected error result for negative cursor offset")
+	}
+}
+
+// TestGetCommandStatus_MarkdownFormat tests rendering the results as a Markdown table
+func TestGetCommandStatus_MarkdownFormat(t *testing.T) {
+	mock := commands.NewMockRunner()
+	cmd := mock.CreateCommand("uptime", []ssh.ClientInfo{{Name: "host1", Group: "prod"}})
+	cmd.SetResultForTest("host1", commands.CommandResult{Host: "host1", Result: "up 3 days\n"})
+	cmd.SetStatusForTest(commands.CommandStatusCompleted)
+
+	tool := &GetCommandStatus{commandRunner: mock}
+	storageEngine := createTestStorage(t)
+	defer storageEngine.Close()
+	handler := tool.Handler(context.Background(), storageEngine)
+
+	for _, format := range []string{"markdown", "both"} {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Arguments: map[string]interface{}{
+					"command_id": cmd.ID(),
+					"format":     format,
+				},
+			},
+		}
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.IsError {
+			t.Fatalf("expected successful result for format %s", format)
+		}
+		text, ok := result.Content[0].(mcp.TextContent)
+		if !ok {
+			t.Fatalf("expected text content for format %s", format)
+		}
+		if !strings.Contains(text.Text, "| prod:host1 | ok | 0 | up 3 days |") {
+			t.Errorf("expected table row for host1, got: %s", text.Text)
+		}
+		if format == "both" && result.StructuredContent == nil {
+			t.Error("expected structured content for format both")
+		}
+	}
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"command_id": cmd.ID(),
+				"format":     "html",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("expected error for invalid format")
+	}
+}
+
+// TestGetCommandStatus_OutputFormat tests stripping and converting ANSI codes in the output
+func TestGetCommandStatus_OutputFormat(t *testing.T) {
+	mock := commands.NewMockRunner()
+	cmd := mock.CreateCommand("ls --color", []ssh.ClientInfo{{Name: "host1", Group: "prod"}})
+	cmd.SetResultForTest("host1", commands.CommandResult{Host: "host1", Result: "\x1b[34mdir\x1b[0m file\n"})
+	cmd.SetStatusForTest(commands.CommandStatusCompleted)
+
+	tool := &GetCommandStatus{commandRunner: mock}
+	storageEngine := createTestStorage(t)
+	defer storageEngine.Close()
+	handler := tool.Handler(context.Background(), storageEngine)
+
+	expected := map[string]string{
+		"raw":   "\x1b[34mdir\x1b[0m file\n",
+		"plain": "dir file\n",
+		"html":  "<span style=\"color:#0000ee\">dir</span> file\n",
+	}
+	for format, output := range expected {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Arguments: map[string]interface{}{
+					"command_id":    cmd.ID(),
+					"output_format": format,
+				},
+			},
+		}
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		state, ok := result.StructuredContent.(*commands.CommandState)
+		if !ok {
+			t.Fatalf("expected *commands.CommandState, got %T", result.StructuredContent)
+		}
+		if state.Results["host1"].Result != output {
+			t.Errorf("%s: expected %q, got %q", format, output, state.Results["host1"].Result)
+		}
+	}
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"command_id":    cmd.ID(),
+				"output_format": "rtf",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("expected error result for invalid output_format")
+	}
+}
+
+// TestGetCommandStatus_OutputPage tests paging through a single host's output
+func TestGetCommandStatus_OutputPage(t *testing.T) {
+	mock := commands.NewMockRunner()
+
+	hosts := []ssh.ClientInfo{
+		{Name: "host1", Host: "example.com", Port: "22", Group: "prod"},
+		{Name: "host2", Host: "example.org", Port: "22", Group: "prod"},
+	}
+
+	cmd := mock.CreateCommand("cat big.log", hosts)
+	cmd.SetStatusForTest(commands.CommandStatusCompleted)
+	cmd.SetResultForTest("host1", commands.CommandResult{Host: "host1", Result: "line1\nline2\nline3\n"})
+	cmd.SetResultForTest("host2", commands.CommandResult{Host: "host2", Result: "other\n"})
+
+	tool := &GetCommandStatus{
+		commandRunner: mock,
+	}
+
+	storageEngine := createTestStorage(t)
+	defer storageEngine.Close()
+
+	handler := tool.Handler(context.Background(), storageEngine)
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"command_id":    cmd.ID(),
+				"host":          "host1",
+				"output_offset": float64(6),
+				"output_limit":  float64(6),
+			},
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatal("expected successful result")
+	}
+
+	state, ok := result.StructuredContent.(*commands.CommandState)
+	if !ok {
+		t.Fatalf("expected *commands.CommandState, got %T", result.StructuredContent)
+	}
+	if len(state.Results) != 1 || state.Results["host1"].Result != "line2\n" {
+		t.Errorf("expected only the requested page of host1, got %v", state.Results)
+	}
+	if state.OutputPage == nil || state.OutputPage.NextOffset != 12 || !state.OutputPage.HasMore {
+		t.Errorf("unexpected output page: %+v", state.OutputPage)
+	}
+}
+
+// TestGetCommandStatus_OutputPage_Invalid tests that invalid paging arguments are rejected
+func TestGetCommandStatus_OutputPage_Invalid(t *testing.T) {
+	mock := commands.NewMockRunner()
+
+	hosts := []ssh.ClientInfo{
+		{Name: "host1", Host: "example.com", Port: "22", Group: "prod"},
+	}
+
+	cmd := mock.CreateCommand("echo test", hosts)
+	cmd.SetStatusForTest(commands.CommandStatusCompleted)
+	cmd.SetResultForTest("host1", commands.CommandResult{Host: "host1", Result: "test\n"})
+
+	tool := &GetCommandStatus{
+		commandRunner: mock,
+	}
+
+	storageEngine := createTestStorage(t)
+	defer storageEngine.Close()
+
+	handler := tool.Handler(context.Background(), storageEngine)
+
+	testCases := map[string]map[string]interface{}{
+		"offset without host": {"output_offset": float64(0)},
+		"negative offset":     {"host": "host1", "output_offset": float64(-1)},
+		"zero limit":          {"host": "host1", "output_limit": float64(0)},
+		"fractional limit":    {"host": "host1", "output_limit": 1.5},
+		"unknown host":        {"host": "host9", "output_offset": float64(0)},
+		"with cursor":         {"host": "host1", "output_cursor": map[string]interface{}{"host1": float64(1)}},
+	}
+	for name, args := range testCases {
+		t.Run(name, func(t *testing.T) {
+			args["command_id"] = cmd.ID()
+			request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}}
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !result.IsError {
+				t.Error("expected error result")
+			}
+		})
+	}
+}
+
+// TestGetCommandStatus_SummaryOnly tests that summary_only returns counts without results
+func TestGetCommandStatus_SummaryOnly(t *testing.T) {
+	mock := commands.NewMockRunner()
+
+	hosts := []ssh.ClientInfo{
+		{Name: "host1", Host: "example.com", Port: "22", Group: "prod"},
+		{Name: "host2", Host: "example.org", Port: "22", Group: "prod"},
+	}
+
+	cmd := mock.CreateCommand("uptime", hosts)
+	cmd.SetStatusForTest(commands.CommandStatusFailed)
+	cmd.SetResultForTest("host1", commands.CommandResult{Host: "host1", Result: "up"})
+	cmd.SetResultForTest("host2", commands.CommandResult{Host: "host2", Err: errors.New("exit status 1")})
+
+	tool := &GetCommandStatus{
+		commandRunner: mock,
+	}
+
+	storageEngine := createTestStorage(t)
+	defer storageEngine.Close()
+
+	handler := tool.Handler(context.Background(), storageEngine)
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"command_id":   cmd.ID(),
+				"summary_only": true,
+			},
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatal("expected successful result")
+	}
+
+	summary, ok := result.StructuredContent.(*commands.CommandSummary)
+	if !ok {
+		t.Fatalf("expected *commands.CommandSummary, got %T", result.StructuredContent)
+	}
+	if summary.Status != commands.CommandStatusFailed || summary.Succeeded != 1 || summary.Failed != 1 || summary.Running != 0 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"command_id": cmd.ID(), "summary_only": true, "host": "host1"},
+		{"command_id": cmd.ID(), "summary_only": true, "format": "markdown"},
+	} {
+		result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.IsError {
+			t.Errorf("expected summary_only to be rejected with %v", args)
+		}
+	}
+}
+
+// TestGetCommandStatus_TimeoutSeconds tests timeout_seconds waits for the given time without wait
+func TestGetCommandStatus_TimeoutSeconds(t *testing.T) {
+	mock := commands.NewMockRunner()
+
+	hosts := []ssh.ClientInfo{
+		{Name: "host1", Host: "example.com", Port: "22", Group: "prod"},
+	}
+
+	// Create a command that stays running
+	cmd := mock.CreateCommand("sleep infinity", hosts)
+	cmd.SetStatusForTest(commands.CommandStatusRunning)
+
+	tool := &GetCommandStatus{
+		commandRunner: mock,
+	}
+
+	storageEngine := createTestStorage(t)
+	defer storageEngine.Close()
+
+	handler := tool.Handler(context.Background(), storageEngine)
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"command_id":      cmd.ID(),
+				"timeout_seconds": 1,
+			},
+		},
+	}
+
+	start := time.Now()
+	result, err := handler(context.Background(), request)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Error("expected successful result even after timeout")
+	}
+	if elapsed < 900*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("expected ~1s timeout, took %v", elapsed)
+	}
+
+	request.Params.Arguments = map[string]interface{}{
+		"command_id":      cmd.ID(),
+		"timeout_seconds": -1,
+	}
+	result, err = handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("expected error for negative timeout_seconds")
+	}
+}
+
+// TestStatusWaitFromRequest tests the server default is used without timeout_seconds
+func TestStatusWaitFromRequest(t *testing.T) {
+	SetStatusWaitTimeout(5 * time.Minute)
+	t.Cleanup(func() { SetStatusWaitTimeout(DefaultStatusWaitTimeout) })
+
+	timeout, err := statusWaitFromRequest(mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if timeout != 5*time.Minute {
+		t.Errorf("expected the server default of 5m, got %v", timeout)
+	}
+
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{"timeout_seconds": 90}}}
+	timeout, err = statusWaitFromRequest(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if timeout != 90*time.Second {
+		t.Errorf("expected 90s, got %v", timeout)
+	}
+}
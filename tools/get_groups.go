@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -19,21 +20,52 @@ func init() {
 // GetGroups is a tool that retrieves the list of groups from the SSH configuration.
 type GetGroups struct{}
 
+// GroupCount is the number of hosts stored in a group.
+type GroupCount struct {
+	Name  string `json:"name"`
+	Hosts int    `json:"hosts"`
+}
+
 // Definition returns the mcp.Tool definition.
 func (c *GetGroups) Definition() mcp.Tool {
 	return mcp.NewTool("get_groups",
 		mcp.WithDescription("Retrieves the list of all groups from the SSH configuration."),
+		mcp.WithBoolean("include_counts", mcp.Description("Also return the number of hosts in each group and the total number of hosts")),
 	)
 }
 
 // Handle is the function that is called when the tool is invoked.
-func (c *GetGroups) Handler(ctx context.Context, storageEngine *storage.Engine) server.ToolHandlerFunc {
+func (c *GetGroups) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
 	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		groups, err := storageEngine.ListGroups()
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Errorf("failed to list groups: %w", err).Error()), nil
 		}
+		groups = filterAllowedGroups(groups)
+		sort.Strings(groups)
+
+		if !request.GetBool("include_counts", false) {
+			return mcp.NewToolResultStructured(map[string]any{"groups": groups}, strings.Join(groups, ", ")), nil
+		}
+
+		counts := make([]GroupCount, 0, len(groups))
+		lines := make([]string, 0, len(groups)+1)
+		total := 0
+		for _, group := range groups {
+			hosts, err := storageEngine.ListGroup(group)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Errorf("failed to list hosts in group %s: %w", group, err).Error()), nil
+			}
+			counts = append(counts, GroupCount{Name: group, Hosts: len(hosts)})
+			lines = append(lines, fmt.Sprintf("%s: %d", group, len(hosts)))
+			total += len(hosts)
+		}
+		lines = append(lines, fmt.Sprintf("total: %d", total))
 
-		return mcp.NewToolResultStructured(map[string]any{"groups": groups}, strings.Join(groups, ", ")), nil
+		return mcp.NewToolResultStructured(map[string]any{
+			"groups":      groups,
+			"counts":      counts,
+			"total_hosts": total,
+		}, strings.Join(lines, "\n")), nil
 	}
 }
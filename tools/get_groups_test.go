@@ -6,6 +6,8 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/storage"
 )
 
 // Tests for GetGroups tool
@@ -65,3 +67,52 @@ func TestGetGroups_SingleGroup(t *testing.T) {
 	require.NotNil(t, result)
 	require.False(t, result.IsError)
 }
+
+func TestGetGroups_IncludeCounts(t *testing.T) {
+	engine := setupTestStorage(t)
+
+	addTestHost(t, engine, "staging", "server3", "10.0.2.1")
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	addTestHost(t, engine, "production", "server2", "10.0.1.2")
+
+	tool := &GetGroups{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"include_counts": true}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	structured, ok := result.StructuredContent.(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, []string{"production", "staging"}, structured["groups"])
+	require.Equal(t, []GroupCount{
+		{Name: "production", Hosts: 2},
+		{Name: "staging", Hosts: 1},
+	}, structured["counts"])
+	require.Equal(t, 3, structured["total_hosts"])
+}
+
+// groupsOnlyStore is a Store that is not a *storage.Engine, overriding ListGroups.
+type groupsOnlyStore struct {
+	storage.Store
+	groups []string
+}
+
+func (s *groupsOnlyStore) ListGroups() ([]string, error) {
+	return s.groups, nil
+}
+
+func TestGetGroups_AlternateStore(t *testing.T) {
+	store := &groupsOnlyStore{Store: setupTestStorage(t), groups: []string{"sql-b", "sql-a"}}
+	tool := &GetGroups{}
+	handler := tool.Handler(context.Background(), store)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Equal(t, map[string]any{"groups": []string{"sql-a", "sql-b"}}, result.StructuredContent)
+}
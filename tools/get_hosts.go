@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -23,22 +24,32 @@ type GetHosts struct{}
 // Definition returns the mcp.Tool definition.
 func (c *GetHosts) Definition() mcp.Tool {
 	return mcp.NewTool("get_hosts",
-		mcp.WithDescription("Retrieves the list of hosts from the SSH configuration. Can optionally filter by group."),
+		mcp.WithDescription("Retrieves the list of hosts from the SSH configuration, including when a connection to each host last succeeded (last_seen). Can optionally filter by group, or to hosts that have gone dark."),
 		mcp.WithString("group",
 			mcp.Description("Optional group name to filter hosts by"),
 		),
+		mcp.WithNumber("not_seen_for_days",
+			mcp.Description("Only return hosts that have not been connected to successfully for at least this many days, including hosts never seen (optional)"),
+		),
 	)
 }
 
 // Handle is the function that is called when the tool is invoked.
-func (c *GetHosts) Handler(ctx context.Context, storageEngine *storage.Engine) server.ToolHandlerFunc {
+func (c *GetHosts) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
 	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		group := request.GetString("group", "")
+		notSeenForDays := request.GetFloat("not_seen_for_days", 0)
+		if notSeenForDays < 0 {
+			return mcp.NewToolResultError("not_seen_for_days cannot be negative"), nil
+		}
 
 		var hosts []ssh.ClientInfo
 		var err error
 
 		if group != "" {
+			if err := checkGroupAllowed(group); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
 			hosts, err = storageEngine.ListGroup(group)
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Errorf("failed to list hosts in group %s: %w", group, err).Error()), nil
@@ -48,6 +59,18 @@ func (c *GetHosts) Handler(ctx context.Context, storageEngine *storage.Engine) s
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Errorf("failed to list hosts: %w", err).Error()), nil
 			}
+			hosts = filterAllowedHosts(hosts)
+		}
+
+		if notSeenForDays > 0 {
+			cutoff := time.Now().Add(-time.Duration(notSeenForDays * float64(24*time.Hour)))
+			dark := make([]ssh.ClientInfo, 0, len(hosts))
+			for _, host := range hosts {
+				if host.LastSeen == nil || host.LastSeen.Before(cutoff) {
+					dark = append(dark, host)
+				}
+			}
+			hosts = dark
 		}
 
 		list := make([]string, 0, len(hosts))
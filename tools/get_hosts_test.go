@@ -3,9 +3,12 @@ package tools
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
 )
 
 // Tests for GetHosts tool
@@ -101,3 +104,37 @@ func TestGetHosts_NonexistentGroup(t *testing.T) {
 	// Should return error or empty list
 	// The actual behavior depends on implementation
 }
+
+func TestGetHosts_NotSeenForDays(t *testing.T) {
+	engine := setupTestStorage(t)
+
+	addTestHost(t, engine, "production", "recent", "10.0.1.1")
+	addTestHost(t, engine, "production", "dark", "10.0.1.2")
+	addTestHost(t, engine, "production", "never", "10.0.1.3")
+	require.NoError(t, engine.TouchLastSeen("production", "recent", time.Now().Add(-time.Hour)))
+	require.NoError(t, engine.TouchLastSeen("production", "dark", time.Now().Add(-10*24*time.Hour)))
+
+	tool := &GetHosts{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"not_seen_for_days": float64(7),
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	structured, ok := result.StructuredContent.(map[string]any)
+	require.True(t, ok)
+	hosts, ok := structured["hosts"].([]ssh.ClientInfo)
+	require.True(t, ok)
+	names := []string{}
+	for _, host := range hosts {
+		names = append(names, host.Name)
+	}
+	require.ElementsMatch(t, []string{"dark", "never"}, names)
+}
@@ -16,13 +16,21 @@ func init() {
 	Registry.Register(&GetOSInfo{})
 }
 
+// OSInfoResult is the cached information of a host with its os-release parsed into fields.
+type OSInfoResult struct {
+	ssh.ClientInfo
+
+	// OSReleaseFields are the KEY=VALUE pairs of the os-release, e.g. ID, VERSION_ID and PRETTY_NAME
+	OSReleaseFields map[string]string `json:"os_release_fields,omitempty"`
+}
+
 // GetOSInfo is a tool that retrieves the operating system information from a remote machine.
 type GetOSInfo struct{}
 
 // Definition returns the mcp.Tool definition.
 func (c *GetOSInfo) Definition() mcp.Tool {
 	return mcp.NewTool("get_os_info",
-		mcp.WithDescription("Retrieves the cached operating system information for Linux and Windows hosts. You can specify individual hosts or an entire group."),
+		mcp.WithDescription("Retrieves the cached operating system information for Linux and Windows hosts: the raw os-release and uname output, plus every os-release field parsed into os_release_fields (e.g. ID, VERSION_ID, PRETTY_NAME). You can specify individual hosts or an entire group."),
 		mcp.WithString("group",
 			mcp.Description("Group name to get OS info for all hosts in that group (mutually exclusive with name_of_hosts)"),
 		),
@@ -34,40 +42,23 @@ func (c *GetOSInfo) Definition() mcp.Tool {
 }
 
 // Handle is the function that is called when the tool is invoked.
-func (c *GetOSInfo) Handler(ctx context.Context, storageEngine *storage.Engine) server.ToolHandlerFunc {
+func (c *GetOSInfo) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
 	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Get hosts either by group or by individual host identifiers
-		var found []ssh.ClientInfo
-		var err error
-		group := request.GetString("group", "")
-		sshNameOfHosts := request.GetStringSlice("name_of_hosts", []string{})
-
-		if group != "" && len(sshNameOfHosts) > 0 {
-			return mcp.NewToolResultError("cannot specify both 'group' and 'name_of_hosts'"), nil
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		if group != "" {
-			found, err = utils.GetHostsFromGroup(storageEngine, group)
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
+		hosts := make([]OSInfoResult, 0, len(found))
+		for _, host := range found {
+			result := OSInfoResult{ClientInfo: host}
+			if fields := utils.ParseOSRelease(host.OS.OSRelease); len(fields) > 0 {
+				result.OSReleaseFields = fields
 			}
-		} else if len(sshNameOfHosts) > 0 {
-			identifiers, err := utils.ParseHostIdentifiers(sshNameOfHosts)
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
-			}
-			found, err = utils.GetHostsFromStorage(storageEngine, identifiers)
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
-			}
-		} else {
-			return mcp.NewToolResultError("must specify either 'group' or 'name_of_hosts'"), nil
-		}
-
-		if len(found) == 0 {
-			return mcp.NewToolResultError("no matching hosts found"), nil
+			hosts = append(hosts, result)
 		}
 
-		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": found}), nil
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": hosts}), nil
 	}
 }
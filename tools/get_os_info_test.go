@@ -6,6 +6,8 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
 )
 
 // Tests for GetOSInfo tool
@@ -184,3 +186,31 @@ func TestGetOSInfo_EmptyGroup(t *testing.T) {
 	// Should return an error
 	require.True(t, result.IsError)
 }
+
+func TestGetOSInfo_ParsedOSRelease(t *testing.T) {
+	engine := setupTestStorage(t)
+	require.NoError(t, engine.Set(ssh.ClientInfo{
+		Group: "production",
+		Name:  "server1",
+		Host:  "10.0.1.1",
+		Port:  "22",
+		OS: ssh.OSInfo{
+			OSRelease: "NAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\nID=ubuntu\n",
+			Uname:     "Linux server1 5.15.0",
+		},
+	}))
+
+	tool := &GetOSInfo{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"name_of_hosts": []any{"production:server1"}}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	hosts := result.StructuredContent.(map[string]any)["hosts"].([]OSInfoResult)
+	require.Len(t, hosts, 1)
+	require.Equal(t, "server1", hosts[0].Name)
+	require.Equal(t, map[string]string{"NAME": "Ubuntu", "VERSION_ID": "22.04", "ID": "ubuntu"}, hosts[0].OSReleaseFields)
+}
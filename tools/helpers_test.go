@@ -1,8 +1,11 @@
 package tools
 
 import (
+	"encoding/base64"
 	"path/filepath"
+	"strings"
 	"testing"
+	"unicode/utf16"
 
 	"github.com/blakerouse/ssh-mcp/ssh"
 	"github.com/blakerouse/ssh-mcp/storage"
@@ -38,3 +41,27 @@ func addTestHost(t *testing.T, engine *storage.Engine, group, name, host string)
 	err := engine.Set(info)
 	require.NoError(t, err)
 }
+
+// Helper function to add test hosts to storage that do not specify a user
+func addTestHostWithoutUser(t *testing.T, engine *storage.Engine, group, name, host string) {
+	err := engine.Set(ssh.ClientInfo{
+		Name:  name,
+		Group: group,
+		Host:  host,
+		Port:  "22",
+	})
+	require.NoError(t, err)
+}
+
+// Helper function to decode the script of a command built by utils.PowerShellCommand
+func powerShellScript(t *testing.T, command string) string {
+	encoded, ok := strings.CutPrefix(command, "powershell -NoProfile -NonInteractive -EncodedCommand ")
+	require.True(t, ok, "not an encoded PowerShell command: %s", command)
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = uint16(raw[2*i]) | uint16(raw[2*i+1])<<8
+	}
+	return string(utf16.Decode(units))
+}
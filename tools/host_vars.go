@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// hostVarsOption is the host_vars argument shared by the command tools.
+func hostVarsOption() mcp.ToolOption {
+	return mcp.WithObject("host_vars",
+		mcp.Description("Per-host template variables keyed by 'group:name', e.g. {\"prod:web1\": {\"port\": 8080}}. When set, the command is rendered for each host as a Go template with the host's Name, Group, Host, Port and User plus its variables, e.g. 'curl localhost:{{.port}}'. String values are inserted shell-quoted, use {{raw .name}} to insert one as is. A host whose command references a missing variable fails on its own, the other hosts still run (optional)"),
+	)
+}
+
+// hostVarsFromRequest parses the optional host_vars argument into variables keyed by 'group:name'.
+// Every key must be one of the targeted hosts. Returns nil when host_vars was not provided.
+func hostVarsFromRequest(request mcp.CallToolRequest, hosts []ssh.ClientInfo) (map[string]map[string]any, error) {
+	raw, ok := request.GetArguments()["host_vars"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("host_vars must be an object mapping 'group:name' to variables")
+	}
+
+	targeted := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		targeted[host.Group+":"+host.Name] = true
+	}
+	hostVars := make(map[string]map[string]any, len(obj))
+	for id, value := range obj {
+		if !targeted[id] {
+			return nil, fmt.Errorf("host_vars references %s, which is not one of the targeted hosts (keys must be 'group:name')", id)
+		}
+		vars, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("host_vars for %s must be an object", id)
+		}
+		hostVars[id] = vars
+	}
+	return hostVars, nil
+}
+
+// hostTemplateContext returns the template context of a host: its connection fields overridden
+// by each of the variable maps in turn. The password is never exposed.
+func hostTemplateContext(host ssh.ClientInfo, vars ...map[string]any) map[string]any {
+	context := map[string]any{
+		"Name":  host.Name,
+		"Group": host.Group,
+		"Host":  host.Host,
+		"Port":  host.Port,
+		"User":  host.User,
+	}
+	for _, layer := range vars {
+		maps.Copy(context, layer)
+	}
+	return context
+}
+
+// hostCommandRenderer parses the command template once and returns a function rendering it for a
+// host against its template context, the shared vars and the host's own vars. String values are
+// shell-quoted like in renderCommandTemplate.
+func hostCommandRenderer(name string, text string, shared map[string]any, hostVars map[string]map[string]any) (func(host ssh.ClientInfo) (string, error), error) {
+	t, err := parseCommandTemplate(name, text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command template %s: %w", name, err)
+	}
+	return func(host ssh.ClientInfo) (string, error) {
+		var sb strings.Builder
+		if err := t.Execute(&sb, shellQuoteVars(hostTemplateContext(host, shared, hostVars[host.Group+":"+host.Name]))); err != nil {
+			return "", err
+		}
+		return sb.String(), nil
+	}, nil
+}
@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+// Tests for per-host template variables
+
+func TestHostVarsFromRequest(t *testing.T) {
+	hosts := []ssh.ClientInfo{{Group: "prod", Name: "web1"}, {Group: "prod", Name: "web2"}}
+
+	hostVars, err := hostVarsFromRequest(mcp.CallToolRequest{}, hosts)
+	require.NoError(t, err)
+	require.Nil(t, hostVars)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"host_vars": map[string]any{"prod:web1": map[string]any{"port": float64(8080)}}}
+	hostVars, err = hostVarsFromRequest(request, hosts)
+	require.NoError(t, err)
+	require.Equal(t, float64(8080), hostVars["prod:web1"]["port"])
+
+	for name, invalid := range map[string]any{
+		"not an object":     "port=8080",
+		"untargeted host":   map[string]any{"prod:db1": map[string]any{"port": float64(5432)}},
+		"missing group":     map[string]any{"web1": map[string]any{"port": float64(8080)}},
+		"vars not a object": map[string]any{"prod:web1": "8080"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			request.Params.Arguments = map[string]any{"host_vars": invalid}
+			_, err := hostVarsFromRequest(request, hosts)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestHostCommandRenderer(t *testing.T) {
+	hostVars := map[string]map[string]any{
+		"prod:web1": {"port": float64(8080), "unit": "api"},
+	}
+	render, err := hostCommandRenderer("restart", "systemctl restart {{.unit}} && curl {{.Host}}:{{.port}}", map[string]any{"unit": "app"}, hostVars)
+	require.NoError(t, err)
+
+	command, err := render(ssh.ClientInfo{Group: "prod", Name: "web1", Host: "10.0.1.1", Pass: "secret"})
+	require.NoError(t, err)
+	require.Equal(t, "systemctl restart 'api' && curl '10.0.1.1':8080", command)
+
+	// web2 has no port, only it fails
+	_, err = render(ssh.ClientInfo{Group: "prod", Name: "web2", Host: "10.0.1.2"})
+	require.Error(t, err)
+
+	// the password is never part of the context
+	render, err = hostCommandRenderer("leak", "echo {{.Pass}}", nil, nil)
+	require.NoError(t, err)
+	_, err = render(ssh.ClientInfo{Group: "prod", Name: "web1", Pass: "secret"})
+	require.Error(t, err)
+
+	_, err = hostCommandRenderer("broken", "echo {{.port", nil, nil)
+	require.Error(t, err)
+}
+
+func TestPerformCommand_HostVars(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	addTestHost(t, engine, "production", "server2", "10.0.1.2")
+
+	var created *commands.Command
+	mock := commands.NewMockRunner()
+	mock.CreateCommandFunc = func(commandStr string, hosts []ssh.ClientInfo, opts ...commands.CommandOption) *commands.Command {
+		// return a finished command so nothing is dialed
+		created = commands.NewMockRunner().CreateCommand(commandStr, hosts, opts...)
+		created.SetStatusForTest(commands.CommandStatusCompleted)
+		return created
+	}
+	tool := &PerformCommand{commandRunner: mock}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"group":     "production",
+				"command":   "echo {{.Name}} {{.port}}",
+				"host_vars": map[string]interface{}{"production:server1": map[string]interface{}{"port": float64(8080)}},
+			},
+		},
+	}
+	_, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.NotNil(t, created)
+	require.Equal(t, "echo {{.Name}} {{.port}}", created.ToState().Command)
+
+	request.Params.Arguments = map[string]interface{}{
+		"group":     "production",
+		"command":   "echo {{.Name}}",
+		"host_vars": map[string]interface{}{"staging:server9": map[string]interface{}{}},
+	}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
+
+func TestRunCommandTemplate_HostVarsInvalidTemplate(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	require.NoError(t, engine.SetTemplate(storage.CommandTemplate{Name: "broken", Command: "echo {{.port"}))
+
+	tool := &RunCommandTemplate{commandRunner: commands.NewMockRunner()}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"template":  "broken",
+				"group":     "production",
+				"host_vars": map[string]interface{}{"production:server1": map[string]interface{}{"port": float64(8080)}},
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+// getHostsFromRequest resolves the target hosts from the 'group', 'groups' or 'name_of_hosts'
+// arguments. Groups outside of the --allowed-groups of the server are rejected.
+func getHostsFromRequest(storageEngine storage.Store, request mcp.CallToolRequest) ([]ssh.ClientInfo, error) {
+	var found []ssh.ClientInfo
+	var err error
+	group := request.GetString("group", "")
+	groups := request.GetStringSlice("groups", []string{})
+	sshNameOfHosts := request.GetStringSlice("name_of_hosts", []string{})
+	cidr := request.GetString("cidr", "")
+
+	if group != "" && len(sshNameOfHosts) > 0 {
+		return nil, errors.New("cannot specify both 'group' and 'name_of_hosts'")
+	}
+	if len(groups) > 0 && (group != "" || len(sshNameOfHosts) > 0) {
+		return nil, errors.New("cannot specify 'groups' with 'group' or 'name_of_hosts'")
+	}
+	if cidr != "" && (group != "" || len(groups) > 0 || len(sshNameOfHosts) > 0) {
+		return nil, errors.New("cannot specify 'cidr' with 'group', 'groups' or 'name_of_hosts'")
+	}
+
+	if cidr != "" {
+		found, err = getHostsInCIDR(storageEngine, cidr)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(groups) > 0 {
+		found, err = getHostsFromGroups(storageEngine, groups)
+		if err != nil {
+			return nil, err
+		}
+	} else if group != "" {
+		if err := checkGroupAllowed(group); err != nil {
+			return nil, err
+		}
+		found, err = utils.GetHostsFromGroup(storageEngine, group)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(sshNameOfHosts) > 0 {
+		identifiers, err := utils.ParseHostIdentifiers(sshNameOfHosts)
+		if err != nil {
+			return nil, err
+		}
+		for _, identifier := range identifiers {
+			if err := checkGroupAllowed(identifier.Group); err != nil {
+				return nil, err
+			}
+		}
+		found, err = utils.GetHostsFromStorage(storageEngine, identifiers)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, errors.New("must specify either 'group' or 'name_of_hosts'")
+	}
+
+	if len(found) == 0 {
+		return nil, errors.New("no matching hosts found")
+	}
+	return applyDefaultUsers(storageEngine, found), nil
+}
+
+// getHostsFromGroups returns the union of the hosts in every group, each host once. Results are
+// keyed by host name, so hosts with the same name in different groups are rejected.
+func getHostsFromGroups(storageEngine storage.Store, groups []string) ([]ssh.ClientInfo, error) {
+	var found []ssh.ClientInfo
+	seen := make(map[string]bool)
+	names := make(map[string]string)
+	for _, group := range groups {
+		if group == "" {
+			return nil, errors.New("'groups' cannot contain an empty group name")
+		}
+		if err := checkGroupAllowed(group); err != nil {
+			return nil, err
+		}
+		hosts, err := utils.GetHostsFromGroup(storageEngine, group)
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range hosts {
+			id := host.Group + ":" + host.Name
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if other, ok := names[host.Name]; ok {
+				return nil, fmt.Errorf("host name %s is in both group %s and group %s, target them with 'name_of_hosts' instead", host.Name, other, host.Group)
+			}
+			names[host.Name] = host.Group
+			found = append(found, host)
+		}
+	}
+	return found, nil
+}
+
+// getHostsInCIDR returns the stored hosts in the allowed groups whose address is an IP within the
+// CIDR range. Results are keyed by host name, so hosts with the same name in different groups are
+// rejected.
+func getHostsInCIDR(storageEngine storage.Store, cidr string) ([]ssh.ClientInfo, error) {
+	hosts, skipped, err := utils.GetHostsInCIDR(storageEngine, cidr)
+	if err != nil {
+		return nil, err
+	}
+	var found []ssh.ClientInfo
+	names := make(map[string]string)
+	for _, host := range hosts {
+		if !groupAllowed(host.Group) {
+			continue
+		}
+		if other, ok := names[host.Name]; ok {
+			return nil, fmt.Errorf("host name %s is in both group %s and group %s, target them with 'name_of_hosts' instead", host.Name, other, host.Group)
+		}
+		names[host.Name] = host.Group
+		found = append(found, host)
+	}
+	if len(found) == 0 {
+		if len(skipped) > 0 {
+			return nil, fmt.Errorf("no hosts with an address in %s (%d hosts addressed by a DNS name were not matched)", cidr, len(skipped))
+		}
+		return nil, fmt.Errorf("no hosts with an address in %s", cidr)
+	}
+	return found, nil
+}
+
+// adHocGroup is the group assigned to ad-hoc hosts, which are never stored.
+const adHocGroup = "ad-hoc"
+
+// getHostsWithAdHocFromRequest resolves the target hosts like getHostsFromRequest, plus the
+// 'ad_hoc_hosts' connection strings which are used directly without being stored. Ad-hoc hosts
+// cannot be combined with 'group' but can be mixed with 'name_of_hosts'.
+func getHostsWithAdHocFromRequest(storageEngine storage.Store, request mcp.CallToolRequest) ([]ssh.ClientInfo, error) {
+	connStrs := request.GetStringSlice("ad_hoc_hosts", []string{})
+	if len(connStrs) == 0 {
+		return getHostsFromRequest(storageEngine, request)
+	}
+	if request.GetString("group", "") != "" {
+		return nil, errors.New("cannot specify both 'group' and 'ad_hoc_hosts'")
+	}
+	if len(request.GetStringSlice("groups", []string{})) > 0 {
+		return nil, errors.New("cannot specify both 'groups' and 'ad_hoc_hosts'")
+	}
+	if request.GetString("cidr", "") != "" {
+		return nil, errors.New("cannot specify both 'cidr' and 'ad_hoc_hosts'")
+	}
+	if err := checkGroupAllowed(adHocGroup); err != nil {
+		return nil, err
+	}
+
+	var found []ssh.ClientInfo
+	names := make(map[string]bool)
+	if len(request.GetStringSlice("name_of_hosts", []string{})) > 0 {
+		stored, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range stored {
+			names[host.Name] = true
+		}
+		found = stored
+	}
+
+	var adHoc []ssh.ClientInfo
+	for _, connStr := range connStrs {
+		clientInfo, err := ssh.NewClientInfo("", connStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ad-hoc host '%s': %w", connStr, err)
+		}
+		// results are keyed by name, so the name includes the port to tell hosts apart
+		clientInfo.Name = net.JoinHostPort(clientInfo.Host, clientInfo.Port)
+		clientInfo.Group = adHocGroup
+		if names[clientInfo.Name] {
+			return nil, fmt.Errorf("ad-hoc host %s is specified more than once or conflicts with a stored host name", clientInfo.Name)
+		}
+		names[clientInfo.Name] = true
+		adHoc = append(adHoc, *clientInfo)
+	}
+	return append(found, applyDefaultUsers(storageEngine, adHoc)...), nil
+}
+
+// applyDefaultUsers fills in the user for hosts that do not specify one from the stored group
+// default, then the stored global default. Hosts still without a user fall back to the
+// --default-user flag and then the OS user when connecting.
+func applyDefaultUsers(storageEngine storage.Store, hosts []ssh.ClientInfo) []ssh.ClientInfo {
+	for i, host := range hosts {
+		if host.User != "" {
+			continue
+		}
+		if user, ok := storageEngine.GetDefaultUser(host.Group); ok {
+			hosts[i].User = user
+		} else if user, ok := storageEngine.GetDefaultUser(""); ok {
+			hosts[i].User = user
+		}
+	}
+	return hosts
+}
+
+// JumpHostResolver returns the function resolving 'group:name' jump host references to stored hosts
+// with their default users applied, see ssh.SetJumpHostResolver.
+func JumpHostResolver(storageEngine storage.Store) func(ref string) (ssh.ClientInfo, bool) {
+	return func(ref string) (ssh.ClientInfo, bool) {
+		identifiers, err := utils.ParseHostIdentifiers([]string{ref})
+		if err != nil {
+			return ssh.ClientInfo{}, false
+		}
+		host, ok := storageEngine.Get(identifiers[0].Group, identifiers[0].Name)
+		if !ok {
+			return ssh.ClientInfo{}, false
+		}
+		return applyDefaultUsers(storageEngine, []ssh.ClientInfo{host})[0], true
+	}
+}
+
+// validateJumpHost checks that a jump_host reference is a stored host in an allowed group or a
+// valid connection string.
+func validateJumpHost(storageEngine storage.Store, ref string) error {
+	if host, ok := JumpHostResolver(storageEngine)(ref); ok {
+		return checkGroupAllowed(host.Group)
+	}
+	if _, err := ssh.NewClientInfo("", ref); err != nil {
+		return fmt.Errorf("invalid jump_host %q, it is neither a stored 'group:name' host nor a connection string: %w", ref, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&ImportSSHConfig{})
+}
+
+// ImportSSHConfig is a tool that imports the hosts from an OpenSSH client config file.
+type ImportSSHConfig struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *ImportSSHConfig) Definition() mcp.Tool {
+	return mcp.NewTool("import_ssh_config",
+		mcp.WithDescription("Imports the Host entries from an OpenSSH client config file (~/.ssh/config) into a group. HostName, Port, User and IdentityFile are used for each host. Wildcard host patterns and hosts that already exist in the group are skipped. OS information is not gathered, use update_os_info after importing."),
+		mcp.WithString("group",
+			mcp.Required(),
+			mcp.Description("Group to import the hosts into"),
+		),
+		mcp.WithString("path",
+			mcp.Description("Path to the OpenSSH config file (optional, defaults to ~/.ssh/config)"),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *ImportSSHConfig) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		group, err := request.RequireString("group")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// Validate that group is not empty
+		if group == "" {
+			return mcp.NewToolResultError("group cannot be empty"), nil
+		}
+		if err := checkGroupAllowed(group); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		configPath := request.GetString("path", "")
+		if configPath == "" {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Errorf("failed to get user home directory: %w", err).Error()), nil
+			}
+			configPath = filepath.Join(homeDir, ".ssh", "config")
+		}
+
+		f, err := os.Open(configPath)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Errorf("failed to open ssh config: %w", err).Error()), nil
+		}
+		defer f.Close()
+
+		configHosts, skipped, err := ssh.ParseConfig(f)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		imported := make([]string, 0, len(configHosts))
+		infos := make([]ssh.ClientInfo, 0, len(configHosts))
+		for _, configHost := range configHosts {
+			if _, exists := storageEngine.Get(group, configHost.Alias); exists {
+				skipped = append(skipped, ssh.SkippedConfigHost{Pattern: configHost.Alias, Reason: "host already exists in group"})
+				continue
+			}
+			info := configHost.ToClientInfo()
+			info.Group = group
+			infos = append(infos, info)
+			imported = append(imported, info.Name)
+		}
+		// store all the hosts at once so a failure imports nothing
+		err = storageEngine.SetBatch(infos)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Errorf("failed to add hosts to storage: %w", err).Error()), nil
+		}
+
+		return mcp.NewToolResultStructured(map[string]any{
+			"imported": imported,
+			"skipped":  skipped,
+		}, fmt.Sprintf("imported %d hosts into group %s, skipped %d", len(imported), group, len(skipped))), nil
+	}
+}
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for ImportSSHConfig tool
+
+func TestImportSSHConfig_ImportsAndSkips(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "imported", "existing", "10.0.0.1")
+
+	configPath := filepath.Join(t.TempDir(), "config")
+	config := "Host web01\n  HostName 10.0.1.5\n  User deploy\n\nHost existing\n  HostName 10.0.0.2\n\nHost *\n  User root\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0600))
+
+	tool := &ImportSSHConfig{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"group": "imported",
+				"path":  configPath,
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	host, ok := engine.Get("imported", "web01")
+	require.True(t, ok)
+	require.Equal(t, "10.0.1.5", host.Host)
+	require.Equal(t, "22", host.Port)
+	require.Equal(t, "deploy", host.User)
+
+	// existing host must not be overwritten
+	existing, ok := engine.Get("imported", "existing")
+	require.True(t, ok)
+	require.Equal(t, "10.0.0.1", existing.Host)
+}
+
+func TestImportSSHConfig_MissingFile(t *testing.T) {
+	engine := setupTestStorage(t)
+
+	tool := &ImportSSHConfig{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"group": "imported",
+				"path":  filepath.Join(t.TempDir(), "missing"),
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// initiatedByMetaKey is the _meta field of a tool call a client sets to identify its user or agent.
+const initiatedByMetaKey = "initiated_by"
+
+// defaultInitiator identifies the caller of tool calls that do not identify themselves.
+var defaultInitiator string
+
+// SetDefaultInitiator sets the identity recorded on commands whose tool call does not carry an
+// initiated_by _meta field, e.g. the OS user running a single-user stdio server.
+func SetDefaultInitiator(initiator string) {
+	defaultInitiator = initiator
+}
+
+// initiatedBy returns the identity of the caller of a tool call: the initiated_by _meta field of
+// the request or else the default initiator, followed by the MCP client name and version reported
+// when the session was initialized.
+func initiatedBy(ctx context.Context, request mcp.CallToolRequest) string {
+	caller := defaultInitiator
+	if meta := request.Params.Meta; meta != nil {
+		if value, ok := meta.AdditionalFields[initiatedByMetaKey].(string); ok && strings.TrimSpace(value) != "" {
+			caller = strings.TrimSpace(value)
+		}
+	}
+
+	var client string
+	if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+		info := session.GetClientInfo()
+		client = strings.TrimSpace(info.Name + " " + info.Version)
+	}
+	switch {
+	case caller == "":
+		return client
+	case client == "":
+		return caller
+	}
+	return caller + " via " + client
+}
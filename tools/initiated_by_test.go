@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/require"
+)
+
+// clientInfoSession is a session that reports the client it was initialized by.
+type clientInfoSession struct {
+	info mcp.Implementation
+}
+
+func (s *clientInfoSession) Initialize()       {}
+func (s *clientInfoSession) Initialized() bool { return true }
+func (s *clientInfoSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification, 1)
+}
+func (s *clientInfoSession) SessionID() string                     { return "test" }
+func (s *clientInfoSession) GetClientInfo() mcp.Implementation     { return s.info }
+func (s *clientInfoSession) SetClientInfo(info mcp.Implementation) { s.info = info }
+func (s *clientInfoSession) GetClientCapabilities() mcp.ClientCapabilities {
+	return mcp.ClientCapabilities{}
+}
+func (s *clientInfoSession) SetClientCapabilities(mcp.ClientCapabilities) {}
+
+func TestInitiatedBy(t *testing.T) {
+	SetDefaultInitiator("alice")
+	t.Cleanup(func() { SetDefaultInitiator("") })
+
+	withClient := server.NewMCPServer("test", "1.0").WithContext(context.Background(),
+		&clientInfoSession{info: mcp.Implementation{Name: "claude-code", Version: "1.0"}})
+	withMeta := mcp.CallToolRequest{}
+	withMeta.Params.Meta = &mcp.Meta{AdditionalFields: map[string]any{"initiated_by": "deploy-bot"}}
+
+	require.Equal(t, "alice", initiatedBy(context.Background(), mcp.CallToolRequest{}))
+	require.Equal(t, "alice via claude-code 1.0", initiatedBy(withClient, mcp.CallToolRequest{}))
+	require.Equal(t, "deploy-bot", initiatedBy(context.Background(), withMeta))
+	require.Equal(t, "deploy-bot via claude-code 1.0", initiatedBy(withClient, withMeta))
+
+	SetDefaultInitiator("")
+	require.Equal(t, "claude-code 1.0", initiatedBy(withClient, mcp.CallToolRequest{}))
+	require.Empty(t, initiatedBy(context.Background(), mcp.CallToolRequest{}))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/sessions"
 	"github.com/blakerouse/ssh-mcp/storage"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -12,7 +13,7 @@ import (
 // Tool defines the interface that provides both the definition and the handler for a tool.
 type Tool interface {
 	Definition() mcp.Tool
-	Handler(ctx context.Context, engine *storage.Engine) server.ToolHandlerFunc
+	Handler(ctx context.Context, engine storage.Store) server.ToolHandlerFunc
 }
 
 // CommandRunnerAware is an optional interface that tools can implement to support background command execution.
@@ -22,3 +23,20 @@ type CommandRunnerAware interface {
 	// SetCommandRunner sets the command runner for background execution.
 	SetCommandRunner(runner commands.Runner)
 }
+
+// SessionManagerAware is an optional interface that tools can implement to use interactive shell sessions.
+type SessionManagerAware interface {
+	Tool
+
+	// SetSessionManager sets the manager keeping the open shell sessions.
+	SetSessionManager(manager *sessions.Manager)
+}
+
+// Mutating is an optional interface implemented by tools that change the state of remote hosts.
+// Mutating tools refuse to run while the server is in maintenance mode.
+type Mutating interface {
+	Tool
+
+	// IsMutating returns true when the tool changes the state of remote hosts.
+	IsMutating() bool
+}
@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&KillProcess{})
+}
+
+// killPatternScript reads the pattern from stdin, so it is not part of the remote command line, and
+// sets pids to the matching processes that still exist, except the shell running the script and its
+// subshells (e.g. the one running pgrep).
+const killPatternScript = `IFS= read -r pattern; matches=$(pgrep -f -- "$pattern"); pids=; ` +
+	`for pid in $matches; do ` +
+	`[ "$pid" = "$$" ] && continue; ` +
+	`[ -d "/proc/$pid" ] || continue; ` +
+	`[ "$(ps -o ppid= -p "$pid" 2>/dev/null | tr -d ' ')" = "$$" ] && continue; ` +
+	`pids="$pids $pid"; done; `
+
+// KillRequest is the requested signal and target processes.
+type KillRequest struct {
+	PID     int
+	Pattern string
+	Signal  string
+	Sudo    bool
+}
+
+// KillProcessResult is the outcome of signaling processes on a single host.
+type KillProcessResult struct {
+	Host     string `json:"host"`
+	Signal   string `json:"signal"`
+	Signaled []int  `json:"signaled"`
+	Failed   []int  `json:"failed,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// KillProcess is a tool that signals processes on remote machines.
+type KillProcess struct{}
+
+// IsMutating returns true as the tool changes remote hosts.
+func (c *KillProcess) IsMutating() bool {
+	return true
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *KillProcess) Definition() mcp.Tool {
+	return mcp.NewTool("kill_process",
+		mcp.WithDescription("Sends a signal (TERM by default, or KILL) to a process by pid, or to every process whose full command line matches a pattern (pgrep -f), and returns the pids that were signaled per host. Killing by pattern requires confirm=true; use list_processes with a filter first to check what matches. On Windows processes are stopped with Stop-Process -Force, the pattern matches the process name and the signal is ignored. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to kill the process on all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithNumber("pid", mcp.Description("Process ID to signal (mutually exclusive with pattern)")),
+		mcp.WithString("pattern", mcp.Description("Regular expression matched against the full command line of the processes to signal (mutually exclusive with pid, requires confirm)")),
+		mcp.WithString("signal",
+			mcp.Description("Signal to send (default: TERM)"),
+			mcp.Enum("TERM", "KILL"),
+		),
+		mcp.WithBoolean("sudo", mcp.Description("Send the signal through non-interactive sudo, required for processes of other users (default: false, not supported on Windows)")),
+		mcp.WithBoolean("confirm", mcp.Description("Confirm signaling every process matching pattern (default: false)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *KillProcess) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kill := KillRequest{
+			PID:     request.GetInt("pid", 0),
+			Pattern: request.GetString("pattern", ""),
+			Signal:  request.GetString("signal", "TERM"),
+			Sudo:    request.GetBool("sudo", false),
+		}
+		if err := kill.validate(request.GetBool("confirm", false)); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			command, err := killProcessCommand(kill, utils.IsWindows(host))
+			if err != nil {
+				return "", err
+			}
+			if kill.Pattern != "" && !utils.IsWindows(host) {
+				output, err := sshClient.ExecWithStdin(command, kill.Pattern+"\n")
+				return string(output), err
+			}
+			return execWithOutput(sshClient, command)
+		})
+
+		kills := make([]KillProcessResult, 0, len(results))
+		for name, result := range results {
+			hostKill := KillProcessResult{Host: name, Signal: kill.Signal, Signaled: []int{}}
+			if result.Err != nil {
+				hostKill.Error = result.Err.Error()
+			} else {
+				hostKill.Signaled, hostKill.Failed = parseKillOutput(result.Result)
+			}
+			kills = append(kills, hostKill)
+		}
+		sort.Slice(kills, func(i, j int) bool {
+			return kills[i].Host < kills[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": kills}), nil
+	}
+}
+
+// validate checks that exactly one target was given and that a pattern kill was confirmed.
+func (k KillRequest) validate(confirm bool) error {
+	if (k.PID != 0) == (k.Pattern != "") {
+		return errors.New("must specify exactly one of 'pid' or 'pattern'")
+	}
+	if k.PID < 0 || k.PID == 1 {
+		return fmt.Errorf("refusing to signal pid %d", k.PID)
+	}
+	if k.Pattern != "" && !confirm {
+		return errors.New("killing by pattern signals every matching process, set confirm=true to proceed (use list_processes with a filter to check what matches)")
+	}
+	switch k.Signal {
+	case "TERM", "KILL":
+	default:
+		return fmt.Errorf("invalid signal %q: must be TERM or KILL", k.Signal)
+	}
+	return nil
+}
+
+// killProcessCommand returns the command that signals the target processes and prints
+// "ok <pid>" or "fail <pid>" for each of them.
+func killProcessCommand(k KillRequest, windows bool) (string, error) {
+	if windows {
+		if k.Sudo {
+			return "", errors.New("sudo is not supported on Windows hosts")
+		}
+		selector := fmt.Sprintf("Get-Process -Id %d -ErrorAction SilentlyContinue", k.PID)
+		if k.Pattern != "" {
+			selector = fmt.Sprintf("Get-Process | Where-Object { $_.ProcessName -match %s -and $_.Id -ne $PID }", utils.PowerShellQuote(k.Pattern))
+		}
+		// the process is saved first, inside catch $_ is the error record
+		return utils.PowerShellCommand(fmt.Sprintf(
+			"%s | ForEach-Object { $p = $_; try { Stop-Process -Id $p.Id -Force -ErrorAction Stop; 'ok ' + $p.Id } catch { 'fail ' + $p.Id } }",
+			selector)), nil
+	}
+
+	sudo := ""
+	if k.Sudo {
+		sudo = "sudo -n "
+	}
+	selector := fmt.Sprintf("pids=%d; ", k.PID)
+	if k.Pattern != "" {
+		selector = killPatternScript
+	}
+	return fmt.Sprintf(`%sfor pid in $pids; do if %skill -%s "$pid" 2>/dev/null; then echo "ok $pid"; else echo "fail $pid"; fi; done`,
+		selector, sudo, k.Signal), nil
+}
+
+// parseKillOutput parses the "ok <pid>" and "fail <pid>" lines into the signaled and failed pids.
+func parseKillOutput(output string) ([]int, []int) {
+	signaled := []int{}
+	var failed []int
+	for _, line := range strings.Split(output, "\n") {
+		status, value, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		pid, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		switch status {
+		case "ok":
+			signaled = append(signaled, pid)
+		case "fail":
+			failed = append(failed, pid)
+		}
+	}
+	return signaled, failed
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for KillProcess tool
+
+func TestKillRequest_Validate(t *testing.T) {
+	require.NoError(t, KillRequest{PID: 4242, Signal: "TERM"}.validate(false))
+	require.NoError(t, KillRequest{Pattern: "php-fpm", Signal: "KILL"}.validate(true))
+
+	require.ErrorContains(t, KillRequest{Signal: "TERM"}.validate(false), "exactly one")
+	require.ErrorContains(t, KillRequest{PID: 1, Pattern: "x", Signal: "TERM"}.validate(true), "exactly one")
+	require.ErrorContains(t, KillRequest{PID: 1, Signal: "TERM"}.validate(false), "refusing")
+	require.ErrorContains(t, KillRequest{Pattern: "php-fpm", Signal: "TERM"}.validate(false), "confirm=true")
+	require.ErrorContains(t, KillRequest{PID: 4242, Signal: "HUP"}.validate(false), "invalid signal")
+}
+
+func TestKillProcessCommand(t *testing.T) {
+	command, err := killProcessCommand(KillRequest{PID: 4242, Signal: "KILL", Sudo: true}, false)
+	require.NoError(t, err)
+	require.Equal(t, `pids=4242; for pid in $pids; do if sudo -n kill -KILL "$pid" 2>/dev/null; then echo "ok $pid"; else echo "fail $pid"; fi; done`, command)
+
+	// the pattern is read from stdin and never part of the command line
+	command, err = killProcessCommand(KillRequest{Pattern: "secret-worker", Signal: "TERM"}, false)
+	require.NoError(t, err)
+	require.NotContains(t, command, "secret-worker")
+	require.Contains(t, command, `pgrep -f -- "$pattern"`)
+
+	_, err = killProcessCommand(KillRequest{PID: 4242, Signal: "TERM", Sudo: true}, true)
+	require.Error(t, err)
+
+	command, err = killProcessCommand(KillRequest{Pattern: "notepad", Signal: "TERM"}, true)
+	require.NoError(t, err)
+	require.Contains(t, powerShellScript(t, command), "$_.ProcessName -match 'notepad' -and $_.Id -ne $PID")
+
+	command, err = killProcessCommand(KillRequest{PID: 4242, Signal: "TERM"}, true)
+	require.NoError(t, err)
+	require.Equal(t, "Get-Process -Id 4242 -ErrorAction SilentlyContinue | ForEach-Object { $p = $_; try { Stop-Process -Id $p.Id -Force -ErrorAction Stop; 'ok ' + $p.Id } catch { 'fail ' + $p.Id } }",
+		powerShellScript(t, command))
+}
+
+func TestParseKillOutput(t *testing.T) {
+	signaled, failed := parseKillOutput("ok 10\nfail 11\r\nok 12\n")
+	require.Equal(t, []int{10, 12}, signaled)
+	require.Equal(t, []int{11}, failed)
+
+	// PowerShell output of a process that could not be stopped
+	signaled, failed = parseKillOutput("ok 10\r\nfail 4242\r\n")
+	require.Equal(t, []int{10}, signaled)
+	require.Equal(t, []int{4242}, failed)
+
+	signaled, failed = parseKillOutput("")
+	require.Empty(t, signaled)
+	require.NotNil(t, signaled)
+	require.Nil(t, failed)
+}
+
+func TestKillProcess_PatternRequiresConfirm(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "prod", "web1", "10.0.0.1")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"group": "prod", "pattern": "php-fpm"}
+	result, err := (&KillProcess{}).Handler(context.Background(), engine)(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "confirm=true")
+}
@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&ListCommandTemplates{})
+}
+
+// ListCommandTemplates is a tool that lists the saved command templates.
+type ListCommandTemplates struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *ListCommandTemplates) Definition() mcp.Tool {
+	return mcp.NewTool("list_command_templates",
+		mcp.WithDescription("Lists all saved command templates with their command and description, sorted by name."),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *ListCommandTemplates) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		templates, err := storageEngine.ListTemplates()
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Errorf("failed to list templates: %w", err).Error()), nil
+		}
+		if templates == nil {
+			templates = []storage.CommandTemplate{}
+		}
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"templates": templates}), nil
+	}
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+// Tests for ListCommandTemplates tool
+
+func TestListCommandTemplates(t *testing.T) {
+	engine := setupTestStorage(t)
+	require.NoError(t, engine.SetTemplate(storage.CommandTemplate{Name: "uptime", Command: "uptime"}))
+	require.NoError(t, engine.SetTemplate(storage.CommandTemplate{Name: "failed-units", Command: "systemctl --failed"}))
+
+	tool := &ListCommandTemplates{}
+	handler := tool.Handler(context.Background(), engine)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	structured, ok := result.StructuredContent.(map[string]any)
+	require.True(t, ok)
+	templates, ok := structured["templates"].([]storage.CommandTemplate)
+	require.True(t, ok)
+	require.Len(t, templates, 2)
+	require.Equal(t, "failed-units", templates[0].Name)
+}
+
+func TestListCommandTemplates_Empty(t *testing.T) {
+	engine := setupTestStorage(t)
+	tool := &ListCommandTemplates{}
+	handler := tool.Handler(context.Background(), engine)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	structured, ok := result.StructuredContent.(map[string]any)
+	require.True(t, ok)
+	require.Empty(t, structured["templates"])
+}
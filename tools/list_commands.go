@@ -29,13 +29,17 @@ func (l *ListCommands) SetCommandRunner(runner commands.Runner) {
 // Definition returns the mcp.Tool definition.
 func (l *ListCommands) Definition() mcp.Tool {
 	return mcp.NewTool("list_commands",
-		mcp.WithDescription("Lists all background commands with their current status (id, status, command, hosts, created_at, started_at, ended_at). Use get_command_status to see detailed results for a specific command."),
-		mcp.WithString("status", mcp.Description("Optional filter by command status (pending, running, completed, failed, cancelled)")),
+		mcp.WithDescription("Lists all background commands with their current status (id, status, command, hosts, created_at, started_at, ended_at). Finished commands from previous runs of the server are included, those it stopped before they finished as interrupted. Use get_command_status to see detailed results for a specific command."),
+		mcp.WithString("status", mcp.Description("Optional filter by command status (pending, running, completed, failed, cancelled, connect_failed, interrupted)")),
+		mcp.WithString("sort",
+			mcp.Description("Order by creation time: 'newest' first (default) or 'oldest' first to read a chronological history"),
+			mcp.Enum("newest", "oldest"),
+		),
 	)
 }
 
 // Handler is the function that is called when the tool is invoked.
-func (l *ListCommands) Handler(ctx context.Context, storageEngine *storage.Engine) server.ToolHandlerFunc {
+func (l *ListCommands) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
 	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if l.commandRunner == nil {
 			panic("command runner not available")
@@ -50,13 +54,19 @@ func (l *ListCommands) Handler(ctx context.Context, storageEngine *storage.Engin
 			switch filterStatus {
 			case commands.CommandStatusPending, commands.CommandStatusRunning,
 				commands.CommandStatusCompleted, commands.CommandStatusFailed,
-				commands.CommandStatusCancelled:
+				commands.CommandStatusCancelled, commands.CommandStatusConnectFailed,
+				commands.CommandStatusInterrupted:
 				// Valid status
 			default:
-				return mcp.NewToolResultError("invalid status filter: must be one of pending, running, completed, failed, cancelled"), nil
+				return mcp.NewToolResultError("invalid status filter: must be one of pending, running, completed, failed, cancelled, connect_failed, interrupted"), nil
 			}
 		}
 
+		order := request.GetString("sort", "newest")
+		if order != "newest" && order != "oldest" {
+			return mcp.NewToolResultError("invalid sort: must be one of newest, oldest"), nil
+		}
+
 		allCommands := l.commandRunner.ListCommands()
 		if len(allCommands) == 0 {
 			return mcp.NewToolResultText("No commands found"), nil
@@ -65,6 +75,9 @@ func (l *ListCommands) Handler(ctx context.Context, storageEngine *storage.Engin
 		// Convert to list items (without results) and apply filter
 		commandList := make([]*commands.CommandListItem, 0, len(allCommands))
 		for _, cmd := range allCommands {
+			if !commandAllowed(cmd) {
+				continue
+			}
 			listItem := cmd.ToListItem()
 
 			// Apply status filter if provided
@@ -81,8 +94,11 @@ func (l *ListCommands) Handler(ctx context.Context, storageEngine *storage.Engin
 			return mcp.NewToolResultText("No commands found"), nil
 		}
 
-		// Sort commands by creation time (newest first)
+		// Sort commands by creation time (newest first unless oldest is requested)
 		sort.Slice(commandList, func(i, j int) bool {
+			if order == "oldest" {
+				return commandList[i].CreatedAt.Before(commandList[j].CreatedAt)
+			}
 			return commandList[i].CreatedAt.After(commandList[j].CreatedAt)
 		})
 
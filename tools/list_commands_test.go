@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -293,7 +294,7 @@ func TestListCommands_FilterByStatus_Invalid(t *testing.T) {
 		t.Fatal("expected text content for error")
 	}
 
-	expectedMsg := "invalid status filter: must be one of pending, running, completed, failed, cancelled"
+	expectedMsg := "invalid status filter: must be one of pending, running, completed, failed, cancelled, connect_failed, interrupted"
 	if textContent.Text != expectedMsg {
 		t.Errorf("expected error message about invalid status, got '%s'", textContent.Text)
 	}
@@ -348,6 +349,67 @@ func TestListCommands_SortedByCreationTime(t *testing.T) {
 	// We've confirmed it returns successfully
 }
 
+// TestListCommands_SortOldest tests that sort=oldest returns the commands in chronological order
+func TestListCommands_SortOldest(t *testing.T) {
+	mock := commands.NewMockRunner()
+
+	hosts := []ssh.ClientInfo{
+		{Name: "host1", Host: "example.com", Port: "22", Group: "prod"},
+	}
+
+	var ids []string
+	for _, command := range []string{"echo first", "echo second", "echo third"} {
+		cmd := mock.CreateCommand(command, hosts)
+		cmd.SetStatusForTest(commands.CommandStatusCompleted)
+		ids = append(ids, cmd.ID())
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tool := &ListCommands{
+		commandRunner: mock,
+	}
+
+	storageEngine := createTestStorage(t)
+	defer storageEngine.Close()
+
+	handler := tool.Handler(context.Background(), storageEngine)
+	for order, want := range map[string][]string{
+		"oldest": ids,
+		"newest": {ids[2], ids[1], ids[0]},
+	} {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Arguments: map[string]interface{}{"sort": order},
+			},
+		}
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		list := result.StructuredContent.(map[string]any)["commands"].([]*commands.CommandListItem)
+		var got []string
+		for _, item := range list {
+			got = append(got, item.ID)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("sort=%s: expected %v, got %v", order, want, got)
+		}
+	}
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{"sort": "random"},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("expected an error for an invalid sort")
+	}
+}
+
 // TestListCommands_NilRunner tests panic when runner is not set
 func TestListCommands_NilRunner(t *testing.T) {
 	tool := &ListCommands{
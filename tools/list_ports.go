@@ -0,0 +1,231 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+// listPortsCommand lists the listening TCP and UDP sockets with ss, falling back to netstat,
+// printing the tool used on the first line.
+const listPortsCommand = `if command -v ss >/dev/null 2>&1; then echo TOOL=ss; ss -tulnp; else echo TOOL=netstat; netstat -tulnp 2>/dev/null; fi`
+
+// listPortsWindowsScript lists the listening sockets as "<proto>\t<address>\t<port>\t<pid>\t<process>" lines.
+const listPortsWindowsScript = `'TOOL=powershell'; ` +
+	`Get-NetTCPConnection -State Listen | ForEach-Object { 'tcp' + [char]9 + $_.LocalAddress + [char]9 + $_.LocalPort + [char]9 + $_.OwningProcess + [char]9 + (Get-Process -Id $_.OwningProcess -ErrorAction SilentlyContinue).ProcessName }; ` +
+	`Get-NetUDPEndpoint | ForEach-Object { 'udp' + [char]9 + $_.LocalAddress + [char]9 + $_.LocalPort + [char]9 + $_.OwningProcess + [char]9 + (Get-Process -Id $_.OwningProcess -ErrorAction SilentlyContinue).ProcessName }`
+
+// ssProcessRegexp matches the first process in the ss users:(("name",pid=123,fd=3)) column
+var ssProcessRegexp = regexp.MustCompile(`\(\("([^"]*)",pid=(\d+)`)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&ListPorts{})
+}
+
+// ListeningPort is a listening socket and the process that owns it.
+type ListeningPort struct {
+	Proto   string `json:"proto"`
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+	PID     int    `json:"pid,omitempty"`
+	Process string `json:"process,omitempty"`
+}
+
+// ListPortsResult is the listening ports of a single host.
+type ListPortsResult struct {
+	Host  string          `json:"host"`
+	Ports []ListeningPort `json:"ports"`
+	Error string          `json:"error,omitempty"`
+}
+
+// ListPorts is a tool that lists the listening ports on remote machines.
+type ListPorts struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *ListPorts) Definition() mcp.Tool {
+	return mcp.NewTool("list_ports",
+		mcp.WithDescription("Lists the listening TCP and UDP ports on each host with the owning process, using ss (or netstat) on Linux and Get-NetTCPConnection/Get-NetUDPEndpoint on Windows. Process details of sockets owned by other users are only visible when connecting as root. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to list ports for all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("proto",
+			mcp.Description("Only list ports of this protocol (default: both)"),
+			mcp.Enum("tcp", "udp"),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *ListPorts) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		proto := request.GetString("proto", "")
+		if proto != "" && proto != "tcp" && proto != "udp" {
+			return mcp.NewToolResultError("invalid proto: must be tcp or udp"), nil
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			cmd := listPortsCommand
+			if utils.IsWindows(host) {
+				cmd = utils.PowerShellCommand(listPortsWindowsScript)
+			}
+			output, err := sshClient.Exec(cmd)
+			if err != nil {
+				return "", fmt.Errorf("failed to list ports: %w", err)
+			}
+			return string(output), nil
+		})
+
+		hosts := make([]ListPortsResult, 0, len(results))
+		for name, result := range results {
+			hostPorts := ListPortsResult{Host: name, Ports: []ListeningPort{}}
+			if result.Err != nil {
+				hostPorts.Error = result.Err.Error()
+			} else {
+				ports, err := parseListPorts(result.Result)
+				if err != nil {
+					hostPorts.Error = err.Error()
+				}
+				for _, port := range ports {
+					if proto == "" || port.Proto == proto {
+						hostPorts.Ports = append(hostPorts.Ports, port)
+					}
+				}
+			}
+			hosts = append(hosts, hostPorts)
+		}
+		sort.Slice(hosts, func(i, j int) bool {
+			return hosts[i].Host < hosts[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": hosts}), nil
+	}
+}
+
+// parseListPorts parses the output of the list ports commands, sorted by protocol and port.
+func parseListPorts(output string) ([]ListeningPort, error) {
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	firstLine, rest, _ := strings.Cut(strings.TrimLeft(output, "\n"), "\n")
+
+	var ports []ListeningPort
+	switch strings.TrimSpace(firstLine) {
+	case "TOOL=ss":
+		ports = parseSS(rest)
+	case "TOOL=netstat":
+		ports = parseNetstat(rest)
+	case "TOOL=powershell":
+		ports = parseWindowsPorts(rest)
+	default:
+		return nil, fmt.Errorf("unexpected output while listing ports")
+	}
+	sort.SliceStable(ports, func(i, j int) bool {
+		if ports[i].Proto != ports[j].Proto {
+			return ports[i].Proto < ports[j].Proto
+		}
+		return ports[i].Port < ports[j].Port
+	})
+	return ports, nil
+}
+
+// splitAddressPort splits "addr:port" where addr may be an IPv6 address, in brackets or not.
+func splitAddressPort(local string) (string, int, bool) {
+	i := strings.LastIndex(local, ":")
+	if i < 0 {
+		return "", 0, false
+	}
+	port, err := strconv.Atoi(local[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	address := strings.TrimSuffix(strings.TrimPrefix(local[:i], "["), "]")
+	return address, port, true
+}
+
+// parseSS parses "Netid State Recv-Q Send-Q Local:Port Peer:Port Process" lines from ss -tulnp.
+func parseSS(output string) []ListeningPort {
+	var ports []ListeningPort
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] == "Netid" {
+			continue
+		}
+		address, port, ok := splitAddressPort(fields[4])
+		if !ok {
+			continue
+		}
+		listening := ListeningPort{Proto: fields[0], Address: address, Port: port}
+		if len(fields) > 6 {
+			if m := ssProcessRegexp.FindStringSubmatch(strings.Join(fields[6:], " ")); m != nil {
+				listening.Process = m[1]
+				listening.PID, _ = strconv.Atoi(m[2])
+			}
+		}
+		ports = append(ports, listening)
+	}
+	return ports
+}
+
+// parseNetstat parses "Proto Recv-Q Send-Q Local Foreign [State] PID/Program" lines from netstat -tulnp.
+func parseNetstat(output string) []ListeningPort {
+	var ports []ListeningPort
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "tcp") && !strings.HasPrefix(fields[0], "udp") {
+			continue
+		}
+		address, port, ok := splitAddressPort(fields[3])
+		if !ok {
+			continue
+		}
+		listening := ListeningPort{Proto: strings.TrimSuffix(fields[0], "6"), Address: address, Port: port}
+		if pid, program, ok := strings.Cut(fields[len(fields)-1], "/"); ok {
+			listening.PID, _ = strconv.Atoi(pid)
+			listening.Process = program
+		}
+		ports = append(ports, listening)
+	}
+	return ports
+}
+
+// parseWindowsPorts parses "<proto>\t<address>\t<port>\t<pid>\t<process>" lines.
+func parseWindowsPorts(output string) []ListeningPort {
+	var ports []ListeningPort
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 4 {
+			continue
+		}
+		port, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+		listening := ListeningPort{Proto: fields[0], Address: fields[1], Port: port}
+		listening.PID, _ = strconv.Atoi(fields[3])
+		if len(fields) > 4 {
+			listening.Process = fields[4]
+		}
+		ports = append(ports, listening)
+	}
+	return ports
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for ListPorts tool
+
+func TestParseListPorts_SS(t *testing.T) {
+	output := "TOOL=ss\n" +
+		"Netid State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process\n" +
+		"udp   UNCONN 0      0      127.0.0.53%lo:53     0.0.0.0:*     users:((\"systemd-resolve\",pid=640,fd=13))\n" +
+		"tcp   LISTEN 0      4096   0.0.0.0:22          0.0.0.0:*     users:((\"sshd\",pid=900,fd=3))\n" +
+		"tcp   LISTEN 0      511    [::]:80             [::]:*\n"
+
+	ports, err := parseListPorts(output)
+	require.NoError(t, err)
+	require.Equal(t, []ListeningPort{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 22, PID: 900, Process: "sshd"},
+		{Proto: "tcp", Address: "::", Port: 80},
+		{Proto: "udp", Address: "127.0.0.53%lo", Port: 53, PID: 640, Process: "systemd-resolve"},
+	}, ports)
+}
+
+func TestParseListPorts_Netstat(t *testing.T) {
+	output := "TOOL=netstat\n" +
+		"Active Internet connections (only servers)\n" +
+		"Proto Recv-Q Send-Q Local Address           Foreign Address         State       PID/Program name\n" +
+		"tcp        0      0 0.0.0.0:22              0.0.0.0:*               LISTEN      900/sshd\n" +
+		"tcp6       0      0 :::443                  :::*                    LISTEN      -\n" +
+		"udp        0      0 0.0.0.0:68              0.0.0.0:*                           700/dhclient\n"
+
+	ports, err := parseListPorts(output)
+	require.NoError(t, err)
+	require.Equal(t, []ListeningPort{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 22, PID: 900, Process: "sshd"},
+		{Proto: "tcp", Address: "::", Port: 443},
+		{Proto: "udp", Address: "0.0.0.0", Port: 68, PID: 700, Process: "dhclient"},
+	}, ports)
+}
+
+func TestParseListPorts_Windows(t *testing.T) {
+	output := "TOOL=powershell\r\n" +
+		"tcp\t0.0.0.0\t3389\t1044\tsvchost\r\n" +
+		"udp\t::\t5353\t2020\t\r\n"
+
+	ports, err := parseListPorts(output)
+	require.NoError(t, err)
+	require.Equal(t, []ListeningPort{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 3389, PID: 1044, Process: "svchost"},
+		{Proto: "udp", Address: "::", Port: 5353, PID: 2020},
+	}, ports)
+}
+
+func TestParseListPorts_Unexpected(t *testing.T) {
+	_, err := parseListPorts("command not found\n")
+	require.Error(t, err)
+}
@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&ListProcesses{})
+}
+
+// listProcessesCommand lists every process with the BSD-style columns of 'ps aux'.
+const listProcessesCommand = "ps aux"
+
+// listProcessesWindowsScript prints a tab separated line of pid, name, cpu seconds and working set bytes per process.
+const listProcessesWindowsScript = `Get-Process | ForEach-Object { @($_.Id, $_.ProcessName, [math]::Round($_.CPU, 2), $_.WorkingSet64) -join [char]9 }`
+
+// ProcessInfo is a single process running on a host.
+type ProcessInfo struct {
+	PID        int     `json:"pid"`
+	User       string  `json:"user,omitempty"`
+	CPUPercent float64 `json:"cpu_percent,omitempty"`
+	MemPercent float64 `json:"mem_percent,omitempty"`
+	CPUSeconds float64 `json:"cpu_seconds,omitempty"`
+	RSSKB      int64   `json:"rss_kb"`
+	State      string  `json:"state,omitempty"`
+	Started    string  `json:"started,omitempty"`
+	Command    string  `json:"command"`
+}
+
+// ListProcessesResult is the list of processes for a single host.
+type ListProcessesResult struct {
+	Host      string        `json:"host"`
+	Processes []ProcessInfo `json:"processes"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// ListProcesses is a tool that lists the processes running on remote machines.
+type ListProcesses struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *ListProcesses) Definition() mcp.Tool {
+	return mcp.NewTool("list_processes",
+		mcp.WithDescription("Lists the running processes per host from 'ps aux' (pid, user, cpu and memory percent, rss, state, start time and command line) or Get-Process on Windows (pid, name, cpu seconds and working set), sorted by CPU usage. Use filter to find processes before killing them with kill_process. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to list processes for all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("filter",
+			mcp.Description("Only return processes whose command contains this text, case-insensitive (optional)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of processes returned per host, highest CPU first (default: 0, all)"),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *ListProcesses) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filter := strings.ToLower(request.GetString("filter", ""))
+		limit := request.GetInt("limit", 0)
+		if limit < 0 {
+			return mcp.NewToolResultError("limit cannot be negative"), nil
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		windows := make(map[string]bool, len(found))
+		for _, host := range found {
+			windows[host.Name] = utils.IsWindows(host)
+		}
+
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			if utils.IsWindows(host) {
+				return execWithOutput(sshClient, utils.PowerShellCommand(listProcessesWindowsScript))
+			}
+			return execWithOutput(sshClient, listProcessesCommand)
+		})
+
+		lists := make([]ListProcessesResult, 0, len(results))
+		for name, result := range results {
+			list := ListProcessesResult{Host: name, Processes: []ProcessInfo{}}
+			if result.Err != nil {
+				list.Error = result.Err.Error()
+			} else {
+				var processes []ProcessInfo
+				if windows[name] {
+					processes = parseGetProcess(result.Result)
+				} else {
+					processes = parsePsAux(result.Result)
+				}
+				list.Processes = filterProcesses(processes, filter, limit)
+			}
+			lists = append(lists, list)
+		}
+		sort.Slice(lists, func(i, j int) bool {
+			return lists[i].Host < lists[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": lists}), nil
+	}
+}
+
+// filterProcesses keeps the processes whose command contains filter (already lower case), sorted
+// by CPU usage descending and then pid, and truncated to limit when it is greater than 0.
+func filterProcesses(processes []ProcessInfo, filter string, limit int) []ProcessInfo {
+	filtered := make([]ProcessInfo, 0, len(processes))
+	for _, process := range processes {
+		if filter == "" || strings.Contains(strings.ToLower(process.Command), filter) {
+			filtered = append(filtered, process)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := filtered[i], filtered[j]
+		if a.CPUPercent+a.CPUSeconds != b.CPUPercent+b.CPUSeconds {
+			return a.CPUPercent+a.CPUSeconds > b.CPUPercent+b.CPUSeconds
+		}
+		return a.PID < b.PID
+	})
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
+	return filtered
+}
+
+// parsePsAux parses the output of 'ps aux'. The command is everything after the ten fixed columns.
+func parsePsAux(output string) []ProcessInfo {
+	var processes []ProcessInfo
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 11 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			// header line
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(fields[2], 64)
+		mem, _ := strconv.ParseFloat(fields[3], 64)
+		rss, _ := strconv.ParseInt(fields[5], 10, 64)
+		processes = append(processes, ProcessInfo{
+			PID:        pid,
+			User:       fields[0],
+			CPUPercent: cpu,
+			MemPercent: mem,
+			RSSKB:      rss,
+			State:      fields[7],
+			Started:    fields[8],
+			Command:    strings.Join(fields[10:], " "),
+		})
+	}
+	return processes
+}
+
+// parseGetProcess parses the tab separated pid, name, cpu seconds and working set lines printed on Windows.
+func parseGetProcess(output string) []ProcessInfo {
+	var processes []ProcessInfo
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(fields[2], 64)
+		workingSet, _ := strconv.ParseInt(fields[3], 10, 64)
+		processes = append(processes, ProcessInfo{
+			PID:        pid,
+			CPUSeconds: cpu,
+			RSSKB:      workingSet / 1024,
+			Command:    fields[1],
+		})
+	}
+	return processes
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for ListProcesses tool
+
+func TestParsePsAux(t *testing.T) {
+	output := "USER         PID %CPU %MEM    VSZ   RSS TTY      STAT START   TIME COMMAND\n" +
+		"root           1  0.0  0.1 167000 11000 ?        Ss   Apr30   0:05 /sbin/init splash\n" +
+		"www-data    4242 85.5  2.0 500000 80000 ?        R    10:01  12:00 php-fpm: pool www\n"
+
+	require.Equal(t, []ProcessInfo{
+		{PID: 1, User: "root", CPUPercent: 0, MemPercent: 0.1, RSSKB: 11000, State: "Ss", Started: "Apr30", Command: "/sbin/init splash"},
+		{PID: 4242, User: "www-data", CPUPercent: 85.5, MemPercent: 2, RSSKB: 80000, State: "R", Started: "10:01", Command: "php-fpm: pool www"},
+	}, parsePsAux(output))
+}
+
+func TestParseGetProcess(t *testing.T) {
+	output := "4\tSystem\t1200.5\t40960\r\n" +
+		"812\tsvchost\t\t2048000\r\n"
+
+	require.Equal(t, []ProcessInfo{
+		{PID: 4, CPUSeconds: 1200.5, RSSKB: 40, Command: "System"},
+		{PID: 812, RSSKB: 2000, Command: "svchost"},
+	}, parseGetProcess(output))
+}
+
+func TestFilterProcesses(t *testing.T) {
+	processes := []ProcessInfo{
+		{PID: 3, CPUPercent: 1, Command: "nginx: worker process"},
+		{PID: 2, CPUPercent: 50, Command: "java -jar app.jar"},
+		{PID: 1, CPUPercent: 1, Command: "nginx: master process"},
+	}
+
+	filtered := filterProcesses(processes, "nginx", 0)
+	require.Len(t, filtered, 2)
+	require.Equal(t, 1, filtered[0].PID)
+	require.Equal(t, 3, filtered[1].PID)
+
+	limited := filterProcesses(processes, "", 1)
+	require.Len(t, limited, 1)
+	require.Equal(t, 2, limited[0].PID)
+}
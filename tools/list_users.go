@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+// nobodyUID is the overflow uid used by the "nobody" account, treated as a system account
+const nobodyUID = 65534
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&ListUsers{})
+}
+
+// UserAccount is a local user account on a host.
+type UserAccount struct {
+	Username string `json:"username"`
+	UID      int    `json:"uid"`
+	GID      int    `json:"gid,omitempty"`
+	Home     string `json:"home,omitempty"`
+	Shell    string `json:"shell,omitempty"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+}
+
+// ListUsersResult is the list of local user accounts for a single host.
+type ListUsersResult struct {
+	Host  string        `json:"host"`
+	Users []UserAccount `json:"users"`
+	Error string        `json:"error,omitempty"`
+}
+
+// ListUsers is a tool that lists the local user accounts on remote machines.
+type ListUsers struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *ListUsers) Definition() mcp.Tool {
+	return mcp.NewTool("list_users",
+		mcp.WithDescription("Lists the local user accounts (username, uid, shell, home) on Linux and Windows hosts from /etc/passwd or Get-LocalUser. System accounts below min_uid are excluded unless include_system is set. On Windows the uid is the relative identifier (RID) of the account's SID. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to list users for all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("include_system",
+			mcp.Description("Include system accounts (default: false)"),
+		),
+		mcp.WithNumber("min_uid",
+			mcp.Description("Accounts with a uid below this are treated as system accounts (default: 1000)"),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *ListUsers) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		includeSystem := request.GetBool("include_system", false)
+		minUID := request.GetInt("min_uid", 1000)
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		windows := make(map[string]bool, len(found))
+		for _, host := range found {
+			windows[host.Name] = utils.IsWindows(host)
+		}
+
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			cmd := "cat /etc/passwd"
+			if windows[host.Name] {
+				cmd = utils.PowerShellCommand("Get-LocalUser | ForEach-Object { $_.Name + [char]9 + $_.SID.Value + [char]9 + $_.Enabled }")
+			}
+			output, err := sshClient.Exec(cmd)
+			if err != nil {
+				return "", fmt.Errorf("failed to list users: %w", err)
+			}
+			return string(output), nil
+		})
+
+		users := make([]ListUsersResult, 0, len(results))
+		for name, result := range results {
+			hostUsers := ListUsersResult{Host: name, Users: []UserAccount{}}
+			if result.Err != nil {
+				hostUsers.Error = result.Err.Error()
+			} else {
+				var accounts []UserAccount
+				if windows[name] {
+					accounts = parseLocalUsers(result.Result)
+				} else {
+					accounts = parsePasswd(result.Result)
+				}
+				for _, account := range accounts {
+					if includeSystem || (account.UID >= minUID && account.UID != nobodyUID) {
+						hostUsers.Users = append(hostUsers.Users, account)
+					}
+				}
+			}
+			users = append(users, hostUsers)
+		}
+		sort.Slice(users, func(i, j int) bool {
+			return users[i].Host < users[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": users}), nil
+	}
+}
+
+// parsePasswd parses the contents of /etc/passwd.
+func parsePasswd(output string) []UserAccount {
+	var accounts []UserAccount
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// name:password:uid:gid:gecos:home:shell
+		fields := strings.Split(line, ":")
+		if len(fields) < 7 {
+			continue
+		}
+		uid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+		gid, _ := strconv.Atoi(fields[3])
+		accounts = append(accounts, UserAccount{
+			Username: fields[0],
+			UID:      uid,
+			GID:      gid,
+			Home:     fields[5],
+			Shell:    fields[6],
+		})
+	}
+	return accounts
+}
+
+// parseLocalUsers parses "<name>\t<sid>\t<enabled>" lines from Get-LocalUser.
+func parseLocalUsers(output string) []UserAccount {
+	var accounts []UserAccount
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		sidParts := strings.Split(fields[1], "-")
+		rid, err := strconv.Atoi(sidParts[len(sidParts)-1])
+		if err != nil {
+			continue
+		}
+		enabled := strings.EqualFold(fields[2], "true")
+		accounts = append(accounts, UserAccount{
+			Username: fields[0],
+			UID:      rid,
+			Enabled:  &enabled,
+		})
+	}
+	return accounts
+}
@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for ListUsers tool
+
+func TestParsePasswd(t *testing.T) {
+	output := "root:x:0:0:root:/root:/bin/bash\n" +
+		"# comment\n" +
+		"daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin\n" +
+		"alice:x:1000:1000:Alice,,,:/home/alice:/bin/zsh\n" +
+		"broken-line\n"
+
+	accounts := parsePasswd(output)
+	require.Len(t, accounts, 3)
+	require.Equal(t, UserAccount{Username: "alice", UID: 1000, GID: 1000, Home: "/home/alice", Shell: "/bin/zsh"}, accounts[2])
+}
+
+func TestParseLocalUsers(t *testing.T) {
+	output := "Administrator\tS-1-5-21-1-2-3-500\tFalse\r\n" +
+		"alice\tS-1-5-21-1-2-3-1001\tTrue\r\n"
+
+	accounts := parseLocalUsers(output)
+	require.Len(t, accounts, 2)
+	require.Equal(t, "alice", accounts[1].Username)
+	require.Equal(t, 1001, accounts[1].UID)
+	require.NotNil(t, accounts[1].Enabled)
+	require.True(t, *accounts[1].Enabled)
+	require.False(t, *accounts[0].Enabled)
+}
@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+// MaintenanceGuard wraps a mutating tool's handler so it refuses to run while the server is in
+// maintenance mode. The mode is read on every call so toggling it takes effect immediately.
+func MaintenanceGuard(storageEngine storage.Store, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		mode, err := storageEngine.GetMaintenanceMode()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if mode.Enabled {
+			msg := "server in maintenance mode"
+			if mode.Reason != "" {
+				msg = fmt.Sprintf("%s: %s", msg, mode.Reason)
+			}
+			return mcp.NewToolResultError(msg), nil
+		}
+		return handler(ctx, request)
+	}
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func TestMaintenanceGuard(t *testing.T) {
+	engine := setupTestStorage(t)
+
+	called := false
+	handler := MaintenanceGuard(engine, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.True(t, called)
+
+	called = false
+	require.NoError(t, engine.SetMaintenanceMode(storage.MaintenanceMode{Enabled: true, Reason: "change freeze"}))
+	result, err = handler(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.False(t, called)
+	require.Equal(t, "server in maintenance mode: change freeze", result.Content[0].(mcp.TextContent).Text)
+}
+
+func TestMutatingTools(t *testing.T) {
+	mutating := map[string]bool{}
+	for _, tool := range Registry.Tools() {
+		if m, ok := tool.(Mutating); ok && m.IsMutating() {
+			mutating[tool.Definition().Name] = true
+		}
+	}
+	require.True(t, mutating["perform_command"])
+	require.True(t, mutating["set_file_mode"])
+	require.False(t, mutating["get_hosts"])
+	require.False(t, mutating["set_maintenance_mode"])
+}
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/sessions"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&OpenShell{})
+}
+
+// OpenShell is a tool that opens an interactive shell on a single host.
+type OpenShell struct {
+	sessionManager *sessions.Manager
+}
+
+// SetSessionManager sets the session manager
+func (o *OpenShell) SetSessionManager(manager *sessions.Manager) {
+	o.sessionManager = manager
+}
+
+// IsMutating returns true as the shell can change remote hosts.
+func (o *OpenShell) IsMutating() bool {
+	return true
+}
+
+// Definition returns the mcp.Tool definition.
+func (o *OpenShell) Definition() mcp.Tool {
+	opts := []mcp.ToolOption{
+		mcp.WithDescription("Opens an interactive shell in a pseudo-terminal on a single host and keeps it open across tool calls, for workflows one-shot commands cannot handle such as REPLs, installers and password prompts. Returns a session_id with the initial output (e.g. the banner and prompt). Type into the shell with send_input, read what it prints with read_output and close it with close_shell. Sessions idle for 30 minutes are closed."),
+		mcp.WithString("group",
+			mcp.Description("Group name containing the single host to open the shell on (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array with the single host identifier in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("term", mcp.Description("Terminal type ($TERM) to request (default: xterm-256color)")),
+		mcp.WithNumber("cols", mcp.Description("Terminal width in columns (default: 200)")),
+		mcp.WithNumber("rows", mcp.Description("Terminal height in rows (default: 50)")),
+	}
+	opts = append(opts, shellReadOptions(2)...)
+	return mcp.NewTool("open_shell", opts...)
+}
+
+// Handler is the function that is called when the tool is invoked.
+func (o *OpenShell) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if o.sessionManager == nil {
+			panic("session manager not available")
+		}
+		pty, err := ptyOptionsFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		wait, until, err := shellReadFromRequest(request, 2)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(found) != 1 {
+			return mcp.NewToolResultError(fmt.Sprintf("open_shell opens a shell on a single host, %d hosts were targeted", len(found))), nil
+		}
+
+		session, err := o.sessionManager.Open(reqCtx, found[0], pty)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultStructuredOnly(session.Read(reqCtx, wait, until)), nil
+	}
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/sessions"
+)
+
+// Tests for OpenShell tool
+
+func TestOpenShell_Invalid(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	addTestHost(t, engine, "production", "server2", "10.0.1.2")
+
+	manager := sessions.NewManager()
+	t.Cleanup(manager.CloseAll)
+	tool := &OpenShell{sessionManager: manager}
+	handler := tool.Handler(context.Background(), engine)
+
+	testCases := map[string]map[string]interface{}{
+		"several hosts": {
+			"group": "production",
+		},
+		"no hosts": {},
+		"invalid until": {
+			"name_of_hosts": []interface{}{"production:server1"},
+			"until":         "(",
+		},
+		"negative wait": {
+			"name_of_hosts": []interface{}{"production:server1"},
+			"wait_seconds":  -1,
+		},
+		"empty term": {
+			"name_of_hosts": []interface{}{"production:server1"},
+			"term":          "",
+		},
+	}
+	for name, args := range testCases {
+		t.Run(name, func(t *testing.T) {
+			request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}}
+			result, err := handler(context.Background(), request)
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+		})
+	}
+}
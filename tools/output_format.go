@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+// outputFormatOption is the shared output_format argument of the command tools.
+func outputFormatOption() mcp.ToolOption {
+	return mcp.WithString("output_format",
+		mcp.Description("How to post-process ANSI escape codes (e.g. colors from pty output) in each host's output: 'raw' (default) leaves them as is, 'plain' strips them and 'html' converts colors and styles to HTML spans"),
+		mcp.Enum("raw", "plain", "html"),
+	)
+}
+
+// outputFormatFromRequest reads and validates the output_format argument.
+func outputFormatFromRequest(request mcp.CallToolRequest) (string, error) {
+	format := request.GetString("output_format", "raw")
+	switch format {
+	case "raw", "plain", "html":
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid output_format: must be one of raw, plain, html")
+}
+
+// applyOutputFormat converts the output of every host result in the state to the format. Encoded
+// (binary) output is left untouched.
+func applyOutputFormat(state *commands.CommandState, format string) {
+	var convert func(string) string
+	switch format {
+	case "plain":
+		convert = utils.StripANSI
+	case "html":
+		convert = utils.ANSIToHTML
+	default:
+		return
+	}
+	for host, result := range state.Results {
+		if result.Encoding != "" {
+			continue
+		}
+		result.Result = convert(result.Result)
+		state.Results[host] = result
+	}
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&PerformAndCache{})
+}
+
+// PerformAndCache is a tool that executes a command and caches each host's output as a fact.
+type PerformAndCache struct{}
+
+// IsMutating returns true as the tool changes remote hosts.
+func (c *PerformAndCache) IsMutating() bool {
+	return true
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *PerformAndCache) Definition() mcp.Tool {
+	return mcp.NewTool("perform_and_cache",
+		mcp.WithDescription("SSH into remote machines, executes a command and stores each host's latest output under a fact key so it can be read back later with get_cached_fact without re-running the command. Useful for periodic state capture (e.g. uptime, installed packages). A host where the command fails keeps its previously cached fact. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to execute command on all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("command", mcp.Required(), mcp.Description("The command to execute")),
+		mcp.WithString("fact_key", mcp.Required(), mcp.Description("Key to cache the output under (e.g. 'uptime'), cannot contain ':'")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *PerformAndCache) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		commandStr, err := request.RequireString("command")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		factKey, err := request.RequireString("fact_key")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if factKey == "" {
+			return mcp.NewToolResultError("fact_key cannot be empty"), nil
+		}
+		// checked before running so a bad key is not only found once the command ran everywhere
+		if strings.Contains(factKey, ":") {
+			return mcp.NewToolResultError("fact_key cannot contain ':'"), nil
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		result := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			output, err := sshClient.Exec(commandStr)
+			if err != nil {
+				// the output is lost on failure, keep the previous fact rather than an empty one
+				return "", err
+			}
+			fact := storage.Fact{
+				Key:        factKey,
+				Group:      host.Group,
+				Name:       host.Name,
+				Command:    commandStr,
+				Output:     string(output),
+				CapturedAt: time.Now().UTC(),
+			}
+			if err := storageEngine.SetFact(fact); err != nil {
+				return string(output), fmt.Errorf("failed to cache fact: %w", err)
+			}
+			return string(output), nil
+		})
+
+		return mcp.NewToolResultStructuredOnly(result), nil
+	}
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerformAndCache_InvalidFactKey(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "127.0.0.1")
+
+	tool := &PerformAndCache{}
+	handler := tool.Handler(context.Background(), engine)
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+		"name_of_hosts": []interface{}{"production:server1"},
+		"command":       "uptime",
+		"fact_key":      "up:time",
+	}}}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Equal(t, "fact_key cannot contain ':'", result.Content[0].(mcp.TextContent).Text)
+}
+
+func TestPerformAndCache_KeepsFactOnFailure(t *testing.T) {
+	engine := setupTestStorage(t)
+	host := startCopyTestServer(t, 5)
+	require.NoError(t, engine.Set(host))
+
+	tool := &PerformAndCache{}
+	handler := tool.Handler(context.Background(), engine)
+	perform := func(command string) {
+		request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"name_of_hosts": []interface{}{host.Group + ":" + host.Name},
+			"command":       command,
+			"fact_key":      "state",
+		}}}
+		result, err := handler(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+	}
+
+	perform("uptime")
+	fact, ok := engine.GetFact("state", host.Group, host.Name)
+	require.True(t, ok)
+	require.Equal(t, "xxxxx", fact.Output)
+
+	// the test server fails writes, which must not replace the fact
+	perform("cat > /dev/full")
+	fact, ok = engine.GetFact("state", host.Group, host.Name)
+	require.True(t, ok)
+	require.Equal(t, "uptime", fact.Command)
+	require.Equal(t, "xxxxx", fact.Output)
+}
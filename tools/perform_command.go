@@ -9,9 +9,7 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 
 	"github.com/blakerouse/ssh-mcp/commands"
-	"github.com/blakerouse/ssh-mcp/ssh"
 	"github.com/blakerouse/ssh-mcp/storage"
-	"github.com/blakerouse/ssh-mcp/utils"
 )
 
 func init() {
@@ -29,26 +27,82 @@ func (c *PerformCommand) SetCommandRunner(runner commands.Runner) {
 	c.commandRunner = runner
 }
 
+// IsMutating returns true as the tool changes remote hosts.
+func (c *PerformCommand) IsMutating() bool {
+	return true
+}
+
 // Definition returns the mcp.Tool definition.
 func (c *PerformCommand) Definition() mcp.Tool {
 	return mcp.NewTool("perform_command",
-		mcp.WithDescription("SSH into a remote machine and executes a command. You can specify individual hosts or an entire group. Commands that take longer than 30 seconds are automatically moved to background. Use background=true to run immediately in background. For background commands, use get_command_status to poll for progress and see partial output snapshots."),
+		mcp.WithDescription("SSH into a remote machine and executes a command. You can specify individual hosts or an entire group. Commands that take longer than background_after_seconds (default 30 seconds) are automatically moved to background. Use background=true to run immediately in background. For background commands, use get_command_status to poll for progress and see partial output snapshots."),
 		mcp.WithString("group",
-			mcp.Description("Group name to execute command on all hosts in that group (mutually exclusive with name_of_hosts)"),
+			mcp.Description("Group name to execute command on all hosts in that group (mutually exclusive with name_of_hosts and groups)"),
+		),
+		mcp.WithArray("groups",
+			mcp.Description("Array of group names to execute command on every host in all of them, each host once, e.g. ['prod-web', 'prod-api'] (mutually exclusive with group, name_of_hosts and ad_hoc_hosts)"),
+			mcp.WithStringItems(),
 		),
 		mcp.WithArray("name_of_hosts",
-			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group and groups)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("cidr",
+			mcp.Description("CIDR range, e.g. '10.0.1.0/24', to execute command on every stored host whose address is an IP in it. Hosts stored with a DNS name are not matched (mutually exclusive with group, groups, name_of_hosts and ad_hoc_hosts)"),
+		),
+		mcp.WithArray("ad_hoc_hosts",
+			mcp.Description("Array of connection strings (e.g. 'ssh://user@host:port') for hosts that are not in storage. They are used for this request only and never stored, and are reported by 'host:port' in the results. Mutually exclusive with group, can be combined with name_of_hosts"),
 			mcp.WithStringItems(),
 		),
 		mcp.WithString("command", mcp.Required(), mcp.Description("The command to execute")),
+		mcp.WithString("command_id",
+			mcp.Description("Optional ID to assign to the command for correlation with external logs, must be unique (default: a generated UUID)"),
+		),
 		mcp.WithBoolean("background",
-			mcp.Description("Run the command in the background immediately and return a command ID (default: false, waits up to 30s before auto-backgrounding)"),
+			mcp.Description("Run the command in the background immediately and return a command ID (default: false, waits up to background_after_seconds before auto-backgrounding)"),
+		),
+		mcp.WithBoolean("skip_unreachable",
+			mcp.Description("Skip hosts that failed to connect within the unreachable cooldown instead of waiting for them to time out again, they are reported as recently unreachable (default: false)"),
+		),
+		mcp.WithNumber("idle_timeout_seconds",
+			mcp.Description("Stop a host's execution if it produces no output for this many seconds, e.g. when stuck waiting on a prompt (default: 0, disabled)"),
+		),
+		mcp.WithNumber("hard_timeout_seconds",
+			mcp.Description("Stop a host's execution if it is still running after this many seconds, even when it keeps producing output (default: 0, disabled)"),
+		),
+		mcp.WithNumber("expect_exit_code",
+			mcp.Description("Exit code each host's command is expected to exit with (0-255). When set, a host exiting with any other code is marked failed with 'unexpected exit code' even though the command ran, and a matching non-zero code (e.g. 1 for grep finding nothing) counts as success (default: not checked)"),
+		),
+		mcp.WithBoolean("auto_sudo",
+			mcp.Description("Run the command as the stored user and, on each host where it fails with a permission denied error (exit code 126 or a message such as 'Permission denied' or 'must be run as root'), run it once more through non-interactive sudo. Detection is best-effort and the command runs twice on those hosts, so only use it for commands that are safe to repeat. Escalated hosts are marked 'escalated' and their output holds both attempts. Not supported on Windows hosts, which are never escalated (default: false)"),
+		),
+		mcp.WithBoolean("parse_json",
+			mcp.Description("Parse each host's output as JSON (e.g. for 'docker inspect' or 'kubectl get -o json') and return it in the 'json' field alongside the raw output. Output that is not valid JSON gets a 'json_note' instead (default: false)"),
+		),
+		mcp.WithBoolean("binary",
+			mcp.Description("Capture binary standard output (e.g. 'tar -czf - dir') byte for byte and return it base64 encoded in 'result', with 'encoding' set to 'base64' and standard error as text in 'stderr'. Output is only returned once each host finishes. Cannot be combined with parse_json, pty, auto_sudo or an output_format other than raw (default: false)"),
+		),
+		mcp.WithBoolean("pty",
+			mcp.Description("Run the command in a pseudo-terminal, for programs that require a TTY (default: false). Stdout and stderr are merged when enabled."),
+		),
+		hostVarsOption(),
+		outputFormatOption(),
+		resultFormatOption(),
+		autoBackgroundOption(),
+		mcp.WithString("term",
+			mcp.Description("Terminal type ($TERM) to request when pty is true (default: xterm-256color)"),
+		),
+		mcp.WithNumber("cols",
+			mcp.Description("Terminal width in columns when pty is true (default: 200)"),
+		),
+		mcp.WithNumber("rows",
+			mcp.Description("Terminal height in rows when pty is true (default: 50)"),
 		),
 	)
 }
 
 // Handle is the function that is called when the tool is invoked.
-func (c *PerformCommand) Handler(ctx context.Context, storageEngine *storage.Engine) server.ToolHandlerFunc {
+func (c *PerformCommand) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
 	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		if c.commandRunner == nil {
 			panic("command runner not available")
@@ -59,38 +113,87 @@ func (c *PerformCommand) Handler(ctx context.Context, storageEngine *storage.Eng
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		// Get hosts either by group or by individual host identifiers
-		var found []ssh.ClientInfo
-		group := request.GetString("group", "")
-		sshNameOfHosts := request.GetStringSlice("name_of_hosts", []string{})
-		if group != "" && len(sshNameOfHosts) > 0 {
-			return mcp.NewToolResultError("cannot specify both 'group' and 'name_of_hosts'"), nil
+		// Get hosts by group, groups or individual host identifiers, plus any ad-hoc hosts
+		found, err := getHostsWithAdHocFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		if group != "" {
-			found, err = utils.GetHostsFromGroup(storageEngine, group)
+		outputFormat, err := outputFormatFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		resultFormat, err := resultFormatFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		backgroundAfter, err := autoBackgroundFromRequest(request, c.commandRunner)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		opts := []commands.CommandOption{commands.WithInitiatedBy(initiatedBy(reqCtx, request))}
+		expectExitCode, err := expectExitCodeFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if expectExitCode != nil {
+			opts = append(opts, commands.WithExpectExitCode(*expectExitCode))
+		}
+		if request.GetBool("parse_json", false) {
+			opts = append(opts, commands.WithParseJSON())
+		}
+		if request.GetBool("auto_sudo", false) {
+			opts = append(opts, commands.WithAutoSudo())
+		}
+		if request.GetBool("skip_unreachable", false) {
+			opts = append(opts, commands.WithSkipUnreachable())
+		}
+		if idleTimeout := request.GetInt("idle_timeout_seconds", 0); idleTimeout > 0 {
+			opts = append(opts, commands.WithIdleTimeout(time.Duration(idleTimeout)*time.Second))
+		} else if idleTimeout < 0 {
+			return mcp.NewToolResultError("idle_timeout_seconds cannot be negative"), nil
+		}
+		if hardTimeout := request.GetInt("hard_timeout_seconds", 0); hardTimeout > 0 {
+			opts = append(opts, commands.WithHardTimeout(time.Duration(hardTimeout)*time.Second))
+		} else if hardTimeout < 0 {
+			return mcp.NewToolResultError("hard_timeout_seconds cannot be negative"), nil
+		}
+		if request.GetBool("pty", false) {
+			pty, err := ptyOptionsFromRequest(request)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
-		} else if len(sshNameOfHosts) > 0 {
-			identifiers, err := utils.ParseHostIdentifiers(sshNameOfHosts)
+			opts = append(opts, commands.WithPTY(pty))
+		}
+		if request.GetBool("binary", false) {
+			if request.GetBool("parse_json", false) || request.GetBool("pty", false) || request.GetBool("auto_sudo", false) || outputFormat != "raw" {
+				return mcp.NewToolResultError("binary cannot be combined with parse_json, pty, auto_sudo or an output_format other than raw"), nil
+			}
+			opts = append(opts, commands.WithBinaryOutput())
+		}
+		hostVars, err := hostVarsFromRequest(request, found)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if hostVars != nil {
+			render, err := hostCommandRenderer("command", commandStr, nil, hostVars)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
-			found, err = utils.GetHostsFromStorage(storageEngine, identifiers)
+			opts = append(opts, commands.WithHostCommand(render))
+		}
+
+		// Create and start the command
+		var cmd *commands.Command
+		if commandID := request.GetString("command_id", ""); commandID != "" {
+			cmd, err = c.commandRunner.CreateCommandWithID(commandID, commandStr, found, opts...)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 		} else {
-			return mcp.NewToolResultError("must specify either 'group' or 'name_of_hosts'"), nil
-		}
-
-		if len(found) == 0 {
-			return mcp.NewToolResultError("no matching hosts found"), nil
+			cmd = c.commandRunner.CreateCommand(commandStr, found, opts...)
 		}
-
-		// Create and start the command
-		cmd := c.commandRunner.CreateCommand(commandStr, found)
 		err = cmd.Start()
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to start command: %v", err)), nil
@@ -101,16 +204,67 @@ func (c *PerformCommand) Handler(ctx context.Context, storageEngine *storage.Eng
 			return mcp.NewToolResultStructured(cmd.ToState(), fmt.Sprintf("Command started in background with ID: %s\nUse get_command_status tool to check progress.", cmd.ID())), nil
 		}
 
-		// Wait for command completion with 30 second timeout
-		return c.waitForCommandOrBackground(reqCtx, cmd)
+		// Wait for command completion until the auto-background threshold
+		return waitForCommandOrBackground(reqCtx, cmd, backgroundAfter, outputFormat, resultFormat)
+	}
+}
+
+// expectExitCodeFromRequest reads the optional expect_exit_code argument, nil when not provided.
+func expectExitCodeFromRequest(request mcp.CallToolRequest) (*int, error) {
+	raw, ok := request.GetArguments()["expect_exit_code"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	value, ok := raw.(float64)
+	if !ok || value != float64(int(value)) || value < 0 || value > 255 {
+		return nil, fmt.Errorf("expect_exit_code must be an integer between 0 and 255")
+	}
+	code := int(value)
+	return &code, nil
+}
+
+// ptyOptionsFromRequest reads the terminal type and size, defaulting to a wide terminal so
+// tabular output is not wrapped at 80 columns.
+func ptyOptionsFromRequest(request mcp.CallToolRequest) (commands.PTYOptions, error) {
+	pty := commands.PTYOptions{
+		Term: request.GetString("term", "xterm-256color"),
+		Cols: request.GetInt("cols", 200),
+		Rows: request.GetInt("rows", 50),
+	}
+	if pty.Term == "" {
+		return pty, fmt.Errorf("term cannot be empty")
+	}
+	if pty.Cols <= 0 || pty.Rows <= 0 {
+		return pty, fmt.Errorf("cols and rows must be greater than 0")
+	}
+	return pty, nil
+}
+
+// autoBackgroundOption is the shared argument of the command tools overriding the auto-background threshold.
+func autoBackgroundOption() mcp.ToolOption {
+	return mcp.WithNumber("background_after_seconds",
+		mcp.Description("Seconds to wait for the command to complete before moving it to the background and returning its command ID (default: the server's --auto-background-after, 30s unless changed)"),
+	)
+}
+
+// autoBackgroundFromRequest returns how long to wait before backgrounding the command, using the
+// runner's default unless background_after_seconds is provided.
+func autoBackgroundFromRequest(request mcp.CallToolRequest, runner commands.Runner) (time.Duration, error) {
+	seconds := request.GetFloat("background_after_seconds", 0)
+	if seconds < 0 {
+		return 0, fmt.Errorf("background_after_seconds cannot be negative")
+	}
+	if seconds == 0 {
+		return runner.AutoBackgroundAfter(), nil
 	}
+	return time.Duration(seconds * float64(time.Second)), nil
 }
 
-// waitForCommandOrBackground waits up to 30 seconds for a command to complete.
+// waitForCommandOrBackground waits up to backgroundAfter for a command to complete.
 // If it completes in time, returns the results. Otherwise, returns the command ID for background tracking.
 // If the context is cancelled, returns the command ID immediately.
-func (c *PerformCommand) waitForCommandOrBackground(ctx context.Context, cmd *commands.Command) (*mcp.CallToolResult, error) {
-	const timeout = 30
+// The output of the returned results is converted to outputFormat and returned in resultFormat.
+func waitForCommandOrBackground(ctx context.Context, cmd *commands.Command, backgroundAfter time.Duration, outputFormat string, resultFormat string) (*mcp.CallToolResult, error) {
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -120,11 +274,10 @@ func (c *PerformCommand) waitForCommandOrBackground(ctx context.Context, cmd *co
 		case <-ctx.Done():
 			return mcp.NewToolResultError("request cancelled"), nil
 		case <-ticker.C:
-			if cmd.Status() == commands.CommandStatusCompleted ||
-				cmd.Status() == commands.CommandStatusFailed ||
-				cmd.Status() == commands.CommandStatusCancelled ||
-				time.Since(startTime) >= timeout*time.Second {
-				return mcp.NewToolResultStructuredOnly(cmd.ToState()), nil
+			if cmd.Status().IsTerminal() || time.Since(startTime) >= backgroundAfter {
+				state := cmd.ToState()
+				applyOutputFormat(state, outputFormat)
+				return commandStateResult(state, resultFormat), nil
 			}
 		}
 	}
@@ -0,0 +1,250 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+// fakePlaybookRun returns a run function that fails the commands in failing and records the
+// commands that were run.
+func fakePlaybookRun(failing map[string]bool, ran *[]string) func(context.Context, string, []ssh.ClientInfo) (*commands.CommandState, error) {
+	return func(ctx context.Context, command string, hosts []ssh.ClientInfo) (*commands.CommandState, error) {
+		*ran = append(*ran, command)
+		state := &commands.CommandState{ID: "cmd-" + command, Status: commands.CommandStatusCompleted, Results: map[string]commands.CommandResult{}}
+		for _, host := range hosts {
+			result := commands.CommandResult{Host: host.Name, Result: "ok"}
+			if failing[command] {
+				result.Err = errors.New("command failed: exit status 1")
+				state.Status = commands.CommandStatusFailed
+			}
+			state.Results[host.Name] = result
+		}
+		return state, nil
+	}
+}
+
+func resolveTestHosts(step storage.PlaybookStep) ([]ssh.ClientInfo, error) {
+	return []ssh.ClientInfo{{Group: "web", Name: "web2"}, {Group: "web", Name: "web1"}}, nil
+}
+
+func TestExecutePlaybook_Completed(t *testing.T) {
+	var ran []string
+	playbook := storage.Playbook{Name: "deploy", Steps: []storage.PlaybookStep{
+		{Name: "pull", Command: "pull"},
+		{Command: "restart"},
+	}}
+
+	result := executePlaybook(context.Background(), playbook, resolveTestHosts, fakePlaybookRun(nil, &ran))
+	require.Equal(t, PlaybookStatusCompleted, result.Status)
+	require.Equal(t, []string{"pull", "restart"}, ran)
+	require.Len(t, result.Steps, 2)
+	require.Equal(t, 1, result.Steps[0].Step)
+	require.Equal(t, "pull", result.Steps[0].Name)
+	require.Equal(t, "cmd-pull", result.Steps[0].CommandID)
+	require.Equal(t, "web1", result.Steps[0].Results[0].Host)
+	require.Equal(t, "web2", result.Steps[0].Results[1].Host)
+}
+
+func TestExecutePlaybook_AbortOnFailure(t *testing.T) {
+	var ran []string
+	playbook := storage.Playbook{Name: "deploy", Steps: []storage.PlaybookStep{
+		{Command: "pull"},
+		{Command: "migrate"},
+		{Command: "restart"},
+	}}
+
+	result := executePlaybook(context.Background(), playbook, resolveTestHosts, fakePlaybookRun(map[string]bool{"migrate": true}, &ran))
+	require.Equal(t, PlaybookStatusAborted, result.Status)
+	require.Equal(t, []string{"pull", "migrate"}, ran)
+	require.Equal(t, string(commands.CommandStatusFailed), result.Steps[1].Status)
+	require.Equal(t, PlaybookStepSkipped, result.Steps[2].Status)
+}
+
+func TestExecutePlaybook_ContinueOnFailure(t *testing.T) {
+	var ran []string
+	playbook := storage.Playbook{Name: "cleanup", Steps: []storage.PlaybookStep{
+		{Command: "rm-cache", OnFailure: storage.OnFailureContinue},
+		{Command: "restart"},
+	}}
+
+	result := executePlaybook(context.Background(), playbook, resolveTestHosts, fakePlaybookRun(map[string]bool{"rm-cache": true}, &ran))
+	require.Equal(t, PlaybookStatusFailed, result.Status)
+	require.Equal(t, []string{"rm-cache", "restart"}, ran)
+	require.Equal(t, string(commands.CommandStatusCompleted), result.Steps[1].Status)
+}
+
+func TestExecutePlaybook_HostResolutionFails(t *testing.T) {
+	var ran []string
+	playbook := storage.Playbook{Name: "deploy", Steps: []storage.PlaybookStep{
+		{Command: "pull", Group: "missing"},
+		{Command: "restart"},
+	}}
+	resolve := func(step storage.PlaybookStep) ([]ssh.ClientInfo, error) {
+		return nil, errors.New("no hosts found in group: missing")
+	}
+
+	result := executePlaybook(context.Background(), playbook, resolve, fakePlaybookRun(nil, &ran))
+	require.Equal(t, PlaybookStatusAborted, result.Status)
+	require.Empty(t, ran)
+	require.Equal(t, PlaybookStatusFailed, result.Steps[0].Status)
+	require.Contains(t, result.Steps[0].Error, "no hosts found")
+	require.Equal(t, PlaybookStepSkipped, result.Steps[1].Status)
+}
+
+func TestSavePlaybook(t *testing.T) {
+	engine := setupTestStorage(t)
+	tool := &SavePlaybook{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"name": "deploy",
+		"steps": []any{
+			map[string]any{"name": "pull", "command": "git pull", "group": "web"},
+			map[string]any{"command": "systemctl restart app", "name_of_hosts": []any{"web:web1"}, "on_failure": "continue"},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	playbook, ok := engine.GetPlaybook("deploy")
+	require.True(t, ok)
+	require.Len(t, playbook.Steps, 2)
+	require.Equal(t, "web", playbook.Steps[0].Group)
+	require.Equal(t, []string{"web:web1"}, playbook.Steps[1].NameOfHosts)
+	require.Equal(t, storage.OnFailureContinue, playbook.Steps[1].OnFailure)
+}
+
+func TestSavePlaybook_Invalid(t *testing.T) {
+	engine := setupTestStorage(t)
+	tool := &SavePlaybook{}
+	handler := tool.Handler(context.Background(), engine)
+
+	testCases := map[string]map[string]any{
+		"no steps":        {"name": "deploy", "steps": []any{}},
+		"bad on_failure":  {"name": "deploy", "steps": []any{map[string]any{"command": "uptime", "on_failure": "retry"}}},
+		"bad identifier":  {"name": "deploy", "steps": []any{map[string]any{"command": "uptime", "name_of_hosts": []any{"web1"}}}},
+		"steps not array": {"name": "deploy", "steps": "uptime"},
+	}
+	for name, args := range testCases {
+		t.Run(name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			result, err := handler(context.Background(), request)
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+		})
+	}
+}
+
+func TestRunPlaybook_NotFound(t *testing.T) {
+	engine := setupTestStorage(t)
+	tool := &RunPlaybook{commandRunner: commands.NewMockRunner()}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"playbook": "missing"}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
+
+func TestRunPlaybook_StepWithoutHosts(t *testing.T) {
+	engine := setupTestStorage(t)
+	require.NoError(t, engine.SetPlaybook(storage.Playbook{Name: "deploy", Steps: []storage.PlaybookStep{{Command: "uptime"}}}))
+
+	tool := &RunPlaybook{commandRunner: commands.NewMockRunner()}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"playbook": "deploy"}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	playbook := result.StructuredContent.(PlaybookResult)
+	require.Equal(t, PlaybookStatusAborted, playbook.Status)
+	require.Contains(t, playbook.Steps[0].Error, "must specify either 'group' or 'name_of_hosts'")
+}
+
+func TestValidatePlaybook(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "web", "web1", "10.0.0.1")
+	addTestHost(t, engine, "web", "web2", "10.0.0.2")
+	require.NoError(t, engine.SetPlaybook(storage.Playbook{Name: "deploy", Steps: []storage.PlaybookStep{
+		{Name: "pull", Command: "git pull", Group: "web"},
+		{Command: "systemctl restart app", NameOfHosts: []string{"web:web1", "web:gone"}},
+		{Command: "docker ps --format '{{.Names}}'", Group: "db"},
+		{Command: "uptime"},
+	}}))
+
+	tool := &ValidatePlaybook{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"playbook": "deploy"}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	validation := result.StructuredContent.(PlaybookValidation)
+	require.False(t, validation.Valid)
+	require.Len(t, validation.Steps, 4)
+
+	require.True(t, validation.Steps[0].Valid)
+	require.Equal(t, 2, validation.Steps[0].HostCount)
+	require.Equal(t, []string{"web:web1", "web:web2"}, validation.Steps[0].Hosts)
+
+	require.True(t, validation.Steps[1].Valid)
+	require.Equal(t, 1, validation.Steps[1].HostCount)
+	require.Len(t, validation.Steps[1].Warnings, 1)
+	require.Contains(t, validation.Steps[1].Warnings[0], "web:gone")
+
+	require.False(t, validation.Steps[2].Valid)
+	require.Equal(t, 0, validation.Steps[2].HostCount)
+	require.Contains(t, validation.Steps[2].Problems[0], "no hosts found in group: db")
+	require.Contains(t, validation.Steps[2].Warnings[0], "not rendered as templates")
+
+	require.False(t, validation.Steps[3].Valid)
+	require.Contains(t, validation.Steps[3].Problems[0], "must specify either 'group' or 'name_of_hosts'")
+}
+
+func TestValidatePlaybook_DefaultHosts(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "web", "web1", "10.0.0.1")
+	require.NoError(t, engine.SetPlaybook(storage.Playbook{Name: "deploy", Steps: []storage.PlaybookStep{{Command: "uptime"}}}))
+
+	tool := &ValidatePlaybook{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"playbook": "deploy", "group": "web"}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	validation := result.StructuredContent.(PlaybookValidation)
+	require.True(t, validation.Valid)
+	require.Equal(t, []string{"web:web1"}, validation.Steps[0].Hosts)
+}
+
+func TestValidatePlaybook_NotFound(t *testing.T) {
+	engine := setupTestStorage(t)
+	tool := &ValidatePlaybook{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"playbook": "missing"}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
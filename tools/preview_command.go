@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&PreviewCommand{})
+}
+
+// CommandPreview is the exact command that would run on a single host.
+type CommandPreview struct {
+	Host string `json:"host"`
+	// Windows is true when the cached OS information reports Windows, OSKnown is false when the
+	// OS of the host was never detected
+	Windows bool   `json:"windows"`
+	OSKnown bool   `json:"os_known"`
+	Command string `json:"command,omitempty"`
+	// EscalatedCommand is what auto_sudo runs when Command fails with permission denied, only set
+	// when auto_sudo is requested and the host would be escalated
+	EscalatedCommand string `json:"escalated_command,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// PreviewCommand is a tool that shows the command each host would run without running it.
+type PreviewCommand struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *PreviewCommand) Definition() mcp.Tool {
+	return mcp.NewTool("preview_command",
+		mcp.WithDescription("Shows the exact command string perform_command or run_command_template would send to each host, without connecting to any host: the command or saved template rendered with vars and host_vars, and with auto_sudo the sudo-wrapped command that would run after a permission denied error. Uses the cached OS of each host. Use it to catch quoting mistakes or a missing variable on some hosts before running."),
+		mcp.WithString("command", mcp.Description("Command to preview as given to perform_command (mutually exclusive with template)")),
+		mcp.WithString("template", mcp.Description("Name of a saved template to preview as run by run_command_template (mutually exclusive with command)")),
+		mcp.WithObject("vars",
+			mcp.Description("Values for the template placeholders, as given to run_command_template (only with template)"),
+		),
+		mcp.WithString("group",
+			mcp.Description("Group name to preview the command on all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		hostVarsOption(),
+		mcp.WithBoolean("auto_sudo", mcp.Description("Also show the command auto_sudo would run on each host (default: false)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *PreviewCommand) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		commandStr := request.GetString("command", "")
+		templateName := request.GetString("template", "")
+		if (commandStr == "") == (templateName == "") {
+			return mcp.NewToolResultError("must specify exactly one of 'command' or 'template'"), nil
+		}
+		vars := map[string]any{}
+		if raw, ok := request.GetArguments()["vars"]; ok && raw != nil {
+			if templateName == "" {
+				return mcp.NewToolResultError("vars can only be used with template, use host_vars with command"), nil
+			}
+			vars, ok = raw.(map[string]any)
+			if !ok {
+				return mcp.NewToolResultError("vars must be an object"), nil
+			}
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		hostVars, err := hostVarsFromRequest(request, found)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// resolve the command the same way the tools that run it do
+		var render func(host ssh.ClientInfo) (string, error)
+		switch {
+		case templateName != "":
+			tmpl, ok := storageEngine.GetTemplate(templateName)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("template %s not found", templateName)), nil
+			}
+			if hostVars != nil {
+				render, err = hostCommandRenderer(tmpl.Name, tmpl.Command, vars, hostVars)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				break
+			}
+			rendered, err := renderCommandTemplate(tmpl, vars)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			render = func(ssh.ClientInfo) (string, error) { return rendered, nil }
+		case hostVars != nil:
+			render, err = hostCommandRenderer("command", commandStr, nil, hostVars)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		default:
+			render = func(ssh.ClientInfo) (string, error) { return commandStr, nil }
+		}
+
+		previews := previewCommand(found, render, request.GetBool("auto_sudo", false))
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": previews}), nil
+	}
+}
+
+// previewCommand resolves the command of each host with render, sorted by host.
+func previewCommand(hosts []ssh.ClientInfo, render func(host ssh.ClientInfo) (string, error), autoSudo bool) []CommandPreview {
+	previews := make([]CommandPreview, 0, len(hosts))
+	for _, host := range hosts {
+		preview := CommandPreview{
+			Host:    host.Name,
+			Windows: utils.IsWindows(host),
+			OSKnown: host.OS.Uname != "",
+		}
+		command, err := render(host)
+		if err != nil {
+			preview.Error = fmt.Sprintf("failed to render command: %v", err)
+			previews = append(previews, preview)
+			continue
+		}
+		preview.Command = command
+		if autoSudo {
+			preview.EscalatedCommand, _ = commands.EscalatedCommand(host, command)
+		}
+		previews = append(previews, preview)
+	}
+	sort.Slice(previews, func(i, j int) bool {
+		return previews[i].Host < previews[j].Host
+	})
+	return previews
+}
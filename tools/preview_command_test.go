@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func TestPreviewCommand_TemplateWithHostVars(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "web", "web1", "10.0.0.1")
+	addTestHost(t, engine, "web", "web2", "10.0.0.2")
+	require.NoError(t, engine.SetTemplate(storage.CommandTemplate{Name: "probe", Command: "curl -s '{{raw .scheme}}://localhost:{{.port}}'"}))
+
+	tool := &PreviewCommand{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"template":  "probe",
+		"group":     "web",
+		"vars":      map[string]any{"scheme": "http"},
+		"host_vars": map[string]any{"web:web1": map[string]any{"port": 8080}},
+		"auto_sudo": true,
+	}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	previews := result.StructuredContent.(map[string]any)["hosts"].([]CommandPreview)
+	require.Len(t, previews, 2)
+	require.Equal(t, "web1", previews[0].Host)
+	require.Equal(t, "curl -s 'http://localhost:8080'", previews[0].Command)
+	require.Equal(t, `sudo -n sh -c 'curl -s '\''http://localhost:8080'\'''`, previews[0].EscalatedCommand)
+	require.Equal(t, "web2", previews[1].Host)
+	require.Empty(t, previews[1].Command)
+	require.Contains(t, previews[1].Error, "port")
+}
+
+func TestPreviewCommand_CommandOrTemplate(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "web", "web1", "10.0.0.1")
+
+	tool := &PreviewCommand{}
+	handler := tool.Handler(context.Background(), engine)
+
+	testCases := map[string]map[string]any{
+		"neither":            {"group": "web"},
+		"both":               {"group": "web", "command": "uptime", "template": "probe"},
+		"vars with command":  {"group": "web", "command": "uptime", "vars": map[string]any{"a": 1}},
+		"template not found": {"group": "web", "template": "missing"},
+	}
+	for name, args := range testCases {
+		t.Run(name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			result, err := handler(context.Background(), request)
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+		})
+	}
+}
+
+func TestPreviewCommand_SudoSkipped(t *testing.T) {
+	hosts := []ssh.ClientInfo{
+		{Group: "win", Name: "dc1", OS: ssh.OSInfo{Uname: "Windows 10.0.17763"}},
+		{Group: "web", Name: "web1", OS: ssh.OSInfo{Uname: "Linux 6.1.0"}},
+		{Group: "web", Name: "web2"},
+	}
+	render := func(host ssh.ClientInfo) (string, error) {
+		if host.Name == "web1" {
+			return "sudo systemctl restart nginx", nil
+		}
+		return "whoami", nil
+	}
+
+	previews := previewCommand(hosts, render, true)
+	require.Equal(t, "dc1", previews[0].Host)
+	require.True(t, previews[0].Windows)
+	require.Empty(t, previews[0].EscalatedCommand)
+	// already running through sudo
+	require.Equal(t, "web1", previews[1].Host)
+	require.True(t, previews[1].OSKnown)
+	require.Empty(t, previews[1].EscalatedCommand)
+	require.False(t, previews[2].OSKnown)
+	require.Equal(t, "sudo -n sh -c 'whoami'", previews[2].EscalatedCommand)
+}
@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&ProbeAuth{})
+}
+
+// ProbeAuthResult is the authentication offered by a server to a user.
+type ProbeAuthResult struct {
+	*ssh.AuthProbe
+
+	Host string `json:"host"`
+	Port string `json:"port"`
+	User string `json:"user,omitempty"`
+	// Advice explains what to supply to add_host for the advertised methods
+	Advice string `json:"advice"`
+	// Agent is the status of the local SSH agent, omitted when SSH_AUTH_SOCK is not set
+	Agent *ssh.AgentStatus `json:"agent,omitempty"`
+}
+
+// ProbeAuth is a tool that reports which authentication methods an SSH server offers.
+type ProbeAuth struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *ProbeAuth) Definition() mcp.Tool {
+	return mcp.NewTool("probe_auth",
+		mcp.WithDescription("Connects to an SSH server without sending any credentials and reports the authentication methods it advertises for the user (publickey, password, keyboard-interactive), its host key fingerprint and login banner. Use it before add_host to find out whether a password is needed. The host key is not verified or remembered."),
+		mcp.WithString("ssh_connection_string",
+			mcp.Required(),
+			mcp.Description("SSH connection string in format: [user@]host[:port], like add_host. Any password is ignored. The user defaults to the default user"),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *ProbeAuth) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sshConnectionString, err := request.RequireString("ssh_connection_string")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		clientInfo, err := ssh.NewClientInfo("", sshConnectionString)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		// probe as the user add_host would connect as
+		if clientInfo.User == "" {
+			if user, ok := storageEngine.GetDefaultUser(""); ok {
+				clientInfo.User = user
+			}
+		}
+
+		probe, err := ssh.ProbeAuth(reqCtx, clientInfo.Host, clientInfo.Port, clientInfo.User)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		agent := ssh.CheckAgent()
+		return mcp.NewToolResultStructuredOnly(ProbeAuthResult{
+			AuthProbe: probe,
+			Host:      clientInfo.Host,
+			Port:      clientInfo.Port,
+			User:      clientInfo.User,
+			Advice:    agentAdvice(authAdvice(probe), probe, agent),
+			Agent:     agent,
+		}), nil
+	}
+}
+
+// agentAdvice adds the reason the SSH agent cannot be used to the advice when the server accepts
+// keys, as keys held by the agent would not be offered.
+func agentAdvice(advice string, probe *ssh.AuthProbe, agent *ssh.AgentStatus) string {
+	if agent == nil || agent.Reachable || !slices.Contains(probe.Methods, "publickey") {
+		return advice
+	}
+	return fmt.Sprintf("%s; note that %s, so its keys will not be offered", advice, agent.Problem)
+}
+
+// authAdvice explains what add_host needs for the advertised authentication methods.
+func authAdvice(probe *ssh.AuthProbe) string {
+	publicKey := slices.Contains(probe.Methods, "publickey")
+	password := slices.Contains(probe.Methods, "password")
+	switch {
+	case probe.NoAuthRequired:
+		return "the server accepts the user without credentials"
+	case publicKey && password:
+		return "use an SSH key (agent or ~/.ssh) or include a password in the connection string"
+	case publicKey:
+		return "password authentication is disabled, an SSH key (agent or ~/.ssh) is required"
+	case password:
+		return "key authentication is disabled, include a password in the connection string or use password_ref"
+	case slices.Contains(probe.Methods, "keyboard-interactive"):
+		return "only keyboard-interactive authentication is offered, which is not supported"
+	}
+	return fmt.Sprintf("no supported authentication method is offered (advertised: %v)", probe.Methods)
+}
@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// Tests for ProbeAuth tool
+
+func TestAuthAdvice(t *testing.T) {
+	require.Contains(t, authAdvice(&ssh.AuthProbe{Methods: []string{"password", "publickey"}}), "SSH key")
+	require.Contains(t, authAdvice(&ssh.AuthProbe{Methods: []string{"publickey"}}), "password authentication is disabled")
+	require.Contains(t, authAdvice(&ssh.AuthProbe{Methods: []string{"keyboard-interactive", "password"}}), "include a password")
+	require.Contains(t, authAdvice(&ssh.AuthProbe{Methods: []string{"keyboard-interactive"}}), "not supported")
+	require.Contains(t, authAdvice(&ssh.AuthProbe{Methods: []string{}, NoAuthRequired: true}), "without credentials")
+	require.Contains(t, authAdvice(&ssh.AuthProbe{Methods: []string{"gssapi-with-mic"}}), "no supported")
+}
+
+func TestAgentAdvice(t *testing.T) {
+	probe := &ssh.AuthProbe{Methods: []string{"publickey"}}
+	dead := &ssh.AgentStatus{Socket: "/tmp/dead.sock", Problem: "SSH_AUTH_SOCK is set to /tmp/dead.sock but the agent is unreachable"}
+
+	require.Contains(t, agentAdvice("use a key", probe, dead), "agent is unreachable")
+	require.Equal(t, "use a key", agentAdvice("use a key", probe, nil))
+	require.Equal(t, "use a key", agentAdvice("use a key", probe, &ssh.AgentStatus{Socket: "/tmp/agent.sock", Reachable: true}))
+	require.Equal(t, "use a password", agentAdvice("use a password", &ssh.AuthProbe{Methods: []string{"password"}}, dead))
+}
+
+func TestProbeAuth_InvalidConnectionString(t *testing.T) {
+	engine := setupTestStorage(t)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"ssh_connection_string": "ftp://example.com"}
+	result, err := (&ProbeAuth{}).Handler(context.Background(), engine)(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
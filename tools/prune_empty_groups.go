@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&PruneEmptyGroups{})
+}
+
+// PruneEmptyGroups is a tool that removes the configuration of groups that no longer have hosts.
+type PruneEmptyGroups struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *PruneEmptyGroups) Definition() mcp.Tool {
+	return mcp.NewTool("prune_empty_groups",
+		mcp.WithDescription("Removes the stored configuration (the group default user set with set_default_user) of every group that no longer has any hosts, so it does not apply to hosts later added under a recycled group name. Returns the pruned groups. The global default user is never removed."),
+		mcp.WithBoolean("dry_run", mcp.Description("Only return the groups that would be pruned without removing anything (default: false)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *PruneEmptyGroups) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if allowedGroups != nil {
+			return mcp.NewToolResultError("not authorized: empty groups cannot be pruned when the server is restricted with --allowed-groups"), nil
+		}
+
+		dryRun := request.GetBool("dry_run", false)
+		pruned, err := storageEngine.PruneEmptyGroups(dryRun)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if pruned == nil {
+			pruned = []string{}
+		}
+
+		text := "No empty groups to prune"
+		if len(pruned) > 0 {
+			verb := "Pruned"
+			if dryRun {
+				verb = "Would prune"
+			}
+			text = fmt.Sprintf("%s %d empty groups: %s", verb, len(pruned), strings.Join(pruned, ", "))
+		}
+		return mcp.NewToolResultStructured(map[string]any{
+			"pruned":  pruned,
+			"dry_run": dryRun,
+		}, text), nil
+	}
+}
@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for PruneEmptyGroups tool
+
+func TestPruneEmptyGroups(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	require.NoError(t, engine.SetDefaultUser("production", "ops"))
+	require.NoError(t, engine.SetDefaultUser("staging", "qa"))
+
+	tool := &PruneEmptyGroups{}
+	handler := tool.Handler(context.Background(), engine)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{}}})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	structured, ok := result.StructuredContent.(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, []string{"staging"}, structured["pruned"])
+
+	_, ok = engine.GetDefaultUser("staging")
+	require.False(t, ok)
+	_, ok = engine.GetDefaultUser("production")
+	require.True(t, ok)
+}
+
+func TestPruneEmptyGroups_AllowedGroups(t *testing.T) {
+	SetAllowedGroups([]string{"production"})
+	defer SetAllowedGroups(nil)
+
+	engine := setupTestStorage(t)
+	require.NoError(t, engine.SetDefaultUser("staging", "qa"))
+
+	tool := &PruneEmptyGroups{}
+	handler := tool.Handler(context.Background(), engine)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{}}})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	_, ok := engine.GetDefaultUser("staging")
+	require.True(t, ok)
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/sessions"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&ReadOutput{})
+}
+
+// ReadOutput is a tool that reads the output of an open shell session.
+type ReadOutput struct {
+	sessionManager *sessions.Manager
+}
+
+// SetSessionManager sets the session manager
+func (r *ReadOutput) SetSessionManager(manager *sessions.Manager) {
+	r.sessionManager = manager
+}
+
+// Definition returns the mcp.Tool definition.
+func (r *ReadOutput) Definition() mcp.Tool {
+	opts := []mcp.ToolOption{
+		mcp.WithDescription("Reads the output a shell opened with open_shell printed since it was last read, e.g. to follow a long running installer. 'closed' is set once the shell has exited, with its exit_code. Up to 1 MiB of unread output is kept, older output is dropped and counted in dropped_bytes."),
+		mcp.WithString("session_id", mcp.Required(), mcp.Description("The session ID returned by open_shell")),
+	}
+	opts = append(opts, shellReadOptions(0)...)
+	return mcp.NewTool("read_output", opts...)
+}
+
+// Handler is the function that is called when the tool is invoked.
+func (r *ReadOutput) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if r.sessionManager == nil {
+			panic("session manager not available")
+		}
+		sessionID, err := request.RequireString("session_id")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		wait, until, err := shellReadFromRequest(request, 0)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		session, err := r.sessionManager.Get(sessionID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultStructuredOnly(session.Read(reqCtx, wait, until)), nil
+	}
+}
@@ -1,5 +1,12 @@
 package tools
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // Registry holds all of the defined tools.
 var Registry = newRegistry()
 
@@ -20,3 +27,47 @@ func (r *registry) Register(tool Tool) {
 func (r *registry) Tools() []Tool {
 	return r.tools
 }
+
+// Filter returns the registered tools allowed by the enabled and disabled tool name lists.
+// When enabled is non-empty only those tools are returned, and any tool in disabled is removed.
+// An error is returned for names that do not match a registered tool.
+func (r *registry) Filter(enabled []string, disabled []string) ([]Tool, error) {
+	known := make(map[string]bool, len(r.tools))
+	for _, tool := range r.tools {
+		known[tool.Definition().Name] = true
+	}
+
+	var unknown []string
+	toSet := func(names []string) map[string]bool {
+		set := make(map[string]bool, len(names))
+		for _, name := range names {
+			if !known[name] {
+				unknown = append(unknown, name)
+			}
+			set[name] = true
+		}
+		return set
+	}
+	enabledSet := toSet(enabled)
+	disabledSet := toSet(disabled)
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown tools: %s", strings.Join(unknown, ", "))
+	}
+
+	var filtered []Tool
+	for _, tool := range r.tools {
+		name := tool.Definition().Name
+		if len(enabledSet) > 0 && !enabledSet[name] {
+			continue
+		}
+		if disabledSet[name] {
+			continue
+		}
+		filtered = append(filtered, tool)
+	}
+	if len(filtered) == 0 {
+		return nil, errors.New("no tools are enabled")
+	}
+	return filtered, nil
+}
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func toolNames(tools []Tool) []string {
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Definition().Name)
+	}
+	return names
+}
+
+func TestRegistry_Filter(t *testing.T) {
+	r := newRegistry()
+	r.Register(&GetHosts{})
+	r.Register(&GetOSInfo{})
+	r.Register(&PerformCommand{})
+
+	all, err := r.Filter(nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"get_hosts", "get_os_info", "perform_command"}, toolNames(all))
+
+	readOnly, err := r.Filter([]string{"get_hosts", "get_os_info"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"get_hosts", "get_os_info"}, toolNames(readOnly))
+
+	withoutPerform, err := r.Filter(nil, []string{"perform_command"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"get_hosts", "get_os_info"}, toolNames(withoutPerform))
+
+	both, err := r.Filter([]string{"get_hosts", "perform_command"}, []string{"perform_command"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"get_hosts"}, toolNames(both))
+}
+
+func TestRegistry_Filter_Invalid(t *testing.T) {
+	r := newRegistry()
+	r.Register(&GetHosts{})
+
+	_, err := r.Filter([]string{"get_hosts", "perfrom_command"}, []string{"nope"})
+	require.EqualError(t, err, "unknown tools: nope, perfrom_command")
+
+	_, err = r.Filter(nil, []string{"get_hosts"})
+	require.Error(t, err)
+}
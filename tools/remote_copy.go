@@ -0,0 +1,226 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/sftp"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&RemoteCopy{})
+}
+
+// remoteCopyPartialSuffix is appended to the destination path while the file is being written,
+// it is only renamed into place once the transfer has been verified.
+const remoteCopyPartialSuffix = ".ssh-mcp-partial"
+
+// RemoteCopyResult is the outcome of copying a file from one host to another.
+type RemoteCopyResult struct {
+	Source          string `json:"source"`
+	SourcePath      string `json:"source_path"`
+	Destination     string `json:"destination"`
+	DestinationPath string `json:"destination_path"`
+	// BytesTransferred is the number of bytes streamed from the source to the destination
+	BytesTransferred int64 `json:"bytes_transferred"`
+	// SHA256 is the checksum of the bytes read from the source
+	SHA256 string `json:"sha256,omitempty"`
+	// DestinationSHA256 is the checksum of the file written on the destination, only set when verified
+	DestinationSHA256 string `json:"destination_sha256,omitempty"`
+	Verified          bool   `json:"verified"`
+	Error             string `json:"error,omitempty"`
+}
+
+// RemoteCopy is a tool that copies a file from one remote host to another through the server.
+type RemoteCopy struct{}
+
+// IsMutating returns true as the tool changes remote hosts.
+func (c *RemoteCopy) IsMutating() bool {
+	return true
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *RemoteCopy) Definition() mcp.Tool {
+	return mcp.NewTool("remote_copy",
+		mcp.WithDescription("Copies a file from a source host to a destination host without going through your machine. The file is streamed through the server (the hosts do not need to reach each other) and never held in memory as a whole, so large files are fine. It is read and written over SFTP, next to the destination path first, and only moved into place once its SHA256 checksum on the destination matches the bytes read from the source. The copy keeps the permissions of the source file. Returns the bytes transferred and the checksums. Not supported on Windows hosts."),
+		mcp.WithString("source", mcp.Required(), mcp.Description("Source host identifier in format 'group:name'")),
+		mcp.WithString("source_path", mcp.Required(), mcp.Description("Path of the file to copy on the source host")),
+		mcp.WithString("destination", mcp.Required(), mcp.Description("Destination host identifier in format 'group:name'")),
+		mcp.WithString("destination_path", mcp.Required(), mcp.Description("Path to write the file to on the destination host, its directory must exist")),
+		mcp.WithBoolean("overwrite", mcp.Description("Replace the destination file when it already exists (default: false)")),
+		mcp.WithBoolean("verify", mcp.Description("Compare the SHA256 checksum of the written file with the bytes read from the source before moving it into place, skip it for very large files where the extra read is costly (default: true)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *RemoteCopy) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var args [4]string
+		for i, name := range []string{"source", "source_path", "destination", "destination_path"} {
+			value, err := request.RequireString(name)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if value == "" {
+				return mcp.NewToolResultError(fmt.Sprintf("%s cannot be empty", name)), nil
+			}
+			args[i] = value
+		}
+		source, err := resolveSingleHost(storageEngine, args[0])
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		destination, err := resolveSingleHost(storageEngine, args[2])
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if source.Group == destination.Group && source.Name == destination.Name && args[1] == args[3] {
+			return mcp.NewToolResultError("the source and destination are the same file"), nil
+		}
+		for _, host := range []ssh.ClientInfo{source, destination} {
+			if utils.IsWindows(host) {
+				return mcp.NewToolResultError(fmt.Sprintf("remote_copy is not supported on Windows host %s:%s", host.Group, host.Name)), nil
+			}
+		}
+
+		result := RemoteCopyResult{
+			Source:          args[0],
+			SourcePath:      args[1],
+			Destination:     args[2],
+			DestinationPath: args[3],
+		}
+		if err := copyBetweenHosts(reqCtx, source, destination, &result, request.GetBool("overwrite", false), request.GetBool("verify", true)); err != nil {
+			result.Error = err.Error()
+		}
+		return mcp.NewToolResultStructuredOnly(result), nil
+	}
+}
+
+// resolveSingleHost looks up the host with the 'group:name' identifier.
+func resolveSingleHost(storageEngine storage.Store, id string) (ssh.ClientInfo, error) {
+	identifiers, err := utils.ParseHostIdentifiers([]string{id})
+	if err != nil {
+		return ssh.ClientInfo{}, err
+	}
+	if err := checkGroupAllowed(identifiers[0].Group); err != nil {
+		return ssh.ClientInfo{}, err
+	}
+	found, err := utils.GetHostsFromStorage(storageEngine, identifiers)
+	if err != nil {
+		return ssh.ClientInfo{}, err
+	}
+	return applyDefaultUsers(storageEngine, found)[0], nil
+}
+
+// copyBetweenHosts reads the source file over SFTP and writes it to a partial file on the
+// destination, verifies it and moves it into place, filling in the transfer details of result.
+func copyBetweenHosts(ctx context.Context, source ssh.ClientInfo, destination ssh.ClientInfo, result *RemoteCopyResult, overwrite bool, verify bool) error {
+	// both connections are made together, as holding one while waiting for a free slot for the
+	// other could wait forever under --max-total-connections
+	srcClient := ssh.NewClient(&source)
+	dstClient := ssh.NewClient(&destination)
+	if err := ssh.ConnectAll(ctx, srcClient, dstClient); err != nil {
+		return err
+	}
+	defer srcClient.Close()
+	defer dstClient.Close()
+
+	// closing the connections aborts a transfer in progress when the request is cancelled
+	stop := context.AfterFunc(ctx, func() {
+		_ = srcClient.Close()
+		_ = dstClient.Close()
+	})
+	defer stop()
+
+	if !overwrite {
+		// checked up front so a large file is not transferred only for the rename to fail
+		if _, err := execWithOutput(dstClient, fmt.Sprintf("test ! -e %s", utils.ShellQuote(result.DestinationPath))); err != nil {
+			return fmt.Errorf("destination %s already exists, set overwrite to replace it", result.DestinationPath)
+		}
+	}
+
+	srcSFTP, err := sftp.NewClient(srcClient)
+	if err != nil {
+		return fmt.Errorf("failed to start sftp on the source: %w", err)
+	}
+	defer srcSFTP.Close()
+	dstSFTP, err := sftp.NewClient(dstClient)
+	if err != nil {
+		return fmt.Errorf("failed to start sftp on the destination: %w", err)
+	}
+	defer dstSFTP.Close()
+
+	src, err := srcSFTP.Open(result.SourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to read source: %w", err)
+	}
+	defer src.Close()
+	// the copy keeps the permissions of the source, e.g. of an executable
+	mode, err := src.Mode()
+	if err != nil {
+		return fmt.Errorf("failed to read source mode: %w", err)
+	}
+
+	partialPath := result.DestinationPath + remoteCopyPartialSuffix
+	hash := sha256.New()
+	result.BytesTransferred, err = dstSFTP.WriteFile(partialPath, io.TeeReader(src, hash), mode, true)
+	result.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	if err == nil && verify {
+		result.DestinationSHA256, err = remoteSHA256(dstClient, destination, partialPath)
+		if err == nil && result.DestinationSHA256 != result.SHA256 {
+			err = fmt.Errorf("checksum mismatch: read %s from the source but the destination has %s", result.SHA256, result.DestinationSHA256)
+		}
+		result.Verified = err == nil
+	}
+	if err != nil {
+		// best-effort cleanup, the partial file is useless
+		_ = dstSFTP.Remove(partialPath)
+		return err
+	}
+	if err := dstSFTP.Rename(partialPath, result.DestinationPath, overwrite); err != nil {
+		return fmt.Errorf("failed to move the copied file into place: %w", err)
+	}
+	return nil
+}
+
+// remoteSHA256 returns the SHA256 checksum of the file on the host, with sha256sum where it exists
+// (GNU coreutils), shasum otherwise (macOS and the BSDs) and Get-FileHash on Windows.
+func remoteSHA256(sshClient *ssh.Client, host ssh.ClientInfo, path string) (string, error) {
+	quoted := utils.ShellQuote(path)
+	command := fmt.Sprintf("sha256sum -- %s 2>/dev/null || shasum -a 256 -- %s", quoted, quoted)
+	if utils.IsWindows(host) {
+		command = utils.PowerShellCommand(fmt.Sprintf("(Get-FileHash -Algorithm SHA256 -LiteralPath %s).Hash", utils.PowerShellQuote(path)))
+	}
+	output, err := execWithOutput(sshClient, command)
+	if err != nil {
+		return "", fmt.Errorf("failed to checksum %s: %w", path, err)
+	}
+	return parseSHA256Sum(output)
+}
+
+// parseSHA256Sum returns the checksum from the output of sha256sum or shasum -a 256, which both
+// print the checksum followed by the file name, or of Get-FileHash, which prints it alone.
+// sha256sum prefixes the line with a backslash when the file name contains one or a newline.
+func parseSHA256Sum(output string) (string, error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return "", errors.New("unexpected checksum output: " + strings.TrimSpace(output))
+	}
+	sum := strings.TrimPrefix(fields[0], "\\")
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+		return "", errors.New("unexpected checksum output: " + strings.TrimSpace(output))
+	}
+	return strings.ToLower(sum), nil
+}
@@ -0,0 +1,253 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// Tests for RemoteCopy tool
+
+func TestParseSHA256Sum(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+
+	parsed, err := parseSHA256Sum(sum + "  /tmp/file.ssh-mcp-partial\n")
+	require.NoError(t, err)
+	require.Equal(t, sum, parsed)
+
+	// shasum -a 256 on macOS and the BSDs
+	parsed, err = parseSHA256Sum(strings.ToUpper(sum) + "  /tmp/file.ssh-mcp-partial\n")
+	require.NoError(t, err)
+	require.Equal(t, sum, parsed)
+
+	// Get-FileHash on Windows
+	parsed, err = parseSHA256Sum(strings.ToUpper(sum) + "\r\n")
+	require.NoError(t, err)
+	require.Equal(t, sum, parsed)
+
+	// sha256sum escapes file names containing a backslash or newline
+	parsed, err = parseSHA256Sum("\\" + sum + "  /tmp/a\\nb.ssh-mcp-partial\n")
+	require.NoError(t, err)
+	require.Equal(t, sum, parsed)
+
+	_, err = parseSHA256Sum("sha256sum: /tmp/file: No such file or directory")
+	require.Error(t, err)
+	_, err = parseSHA256Sum("shasum: /tmp/file: No such file or directory")
+	require.Error(t, err)
+	_, err = parseSHA256Sum("")
+	require.Error(t, err)
+}
+
+func TestRemoteCopy_InvalidArguments(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+
+	tool := &RemoteCopy{}
+	handler := tool.Handler(context.Background(), engine)
+
+	for name, args := range map[string]map[string]interface{}{
+		"missing destination path": {"source": "production:server1", "source_path": "/tmp/a", "destination": "production:server1"},
+		"unknown source":           {"source": "production:missing", "source_path": "/tmp/a", "destination": "production:server1", "destination_path": "/tmp/b"},
+		"invalid identifier":       {"source": "server1", "source_path": "/tmp/a", "destination": "production:server1", "destination_path": "/tmp/b"},
+		"same file":                {"source": "production:server1", "source_path": "/tmp/a", "destination": "production:server1", "destination_path": "/tmp/a"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+		})
+	}
+}
+
+func TestCopyBetweenHosts_KeepsMode(t *testing.T) {
+	sftpServer := ""
+	for _, path := range []string{"/usr/lib/openssh/sftp-server", "/usr/libexec/openssh/sftp-server", "/usr/libexec/sftp-server", "/usr/lib/ssh/sftp-server"} {
+		if _, err := os.Stat(path); err == nil {
+			sftpServer = path
+		}
+	}
+	if sftpServer == "" {
+		t.Skip("OpenSSH sftp-server is not installed")
+	}
+	host := startLocalTestServer(t, sftpServer)
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "deploy.sh")
+	require.NoError(t, os.WriteFile(source, []byte("#!/bin/sh\necho deployed\n"), 0o750))
+	require.NoError(t, os.Chmod(source, 0o750))
+
+	result := RemoteCopyResult{SourcePath: source, DestinationPath: filepath.Join(dir, "deploy-copy.sh")}
+	require.NoError(t, copyBetweenHosts(context.Background(), host, host, &result, false, false))
+	info, err := os.Stat(result.DestinationPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o750), info.Mode().Perm())
+	require.NoFileExists(t, result.DestinationPath+remoteCopyPartialSuffix)
+}
+
+// startLocalTestServer starts an SSH server that runs exec requests with sh on the local machine
+// and the sftp subsystem with the OpenSSH sftp-server at sftpServer.
+func startLocalTestServer(t *testing.T, sftpServer string) ssh.ClientInfo {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := gossh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	config := &gossh.ServerConfig{
+		PasswordCallback: func(conn gossh.ConnMetadata, pass []byte) (*gossh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, chans, reqs, err := gossh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go gossh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					channel, channelReqs, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go serveLocalCommand(channel, channelReqs, sftpServer)
+				}
+			}()
+		}
+	}()
+
+	// trust the new host key in memory only
+	require.NoError(t, ssh.SetKnownHostsData("unrelated.invalid "+string(gossh.MarshalAuthorizedKey(signer.PublicKey()))))
+	t.Cleanup(func() { _ = ssh.SetKnownHostsData("") })
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return ssh.ClientInfo{Group: "test", Name: "local1", Host: host, Port: port, User: "tester", Pass: "secret"}
+}
+
+// serveLocalCommand runs the exec or sftp subsystem request of the channel for
+// startLocalTestServer.
+func serveLocalCommand(channel gossh.Channel, reqs <-chan *gossh.Request, sftpServer string) {
+	defer channel.Close()
+	for req := range reqs {
+		if len(req.Payload) < 4 || (req.Type != "exec" && req.Type != "subsystem") {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+		go gossh.DiscardRequests(reqs)
+
+		cmd := exec.Command(sftpServer)
+		if req.Type == "exec" {
+			cmd = exec.Command("sh", "-c", string(req.Payload[4:]))
+		}
+		cmd.Stdin = channel
+		cmd.Stdout = channel
+		cmd.Stderr = channel.Stderr()
+		status := make([]byte, 4)
+		if err := cmd.Run(); err != nil {
+			binary.BigEndian.PutUint32(status, 1)
+		}
+		_, _ = channel.SendRequest("exit-status", false, status)
+		return
+	}
+}
+
+// startCopyTestServer starts an SSH server whose "cat --" source command writes size bytes and
+// whose "cat >" destination command fails after reading the first KiB, like a full disk.
+func startCopyTestServer(t *testing.T, size int) ssh.ClientInfo {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := gossh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	config := &gossh.ServerConfig{
+		PasswordCallback: func(conn gossh.ConnMetadata, pass []byte) (*gossh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, chans, reqs, err := gossh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go gossh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					channel, channelReqs, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go serveCopyCommand(channel, channelReqs, size)
+				}
+			}()
+		}
+	}()
+
+	// trust the new host key in memory only
+	require.NoError(t, ssh.SetKnownHostsData("unrelated.invalid "+string(gossh.MarshalAuthorizedKey(signer.PublicKey()))))
+	t.Cleanup(func() { _ = ssh.SetKnownHostsData("") })
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return ssh.ClientInfo{Group: "test", Name: "copy1", Host: host, Port: port, User: "tester", Pass: "secret"}
+}
+
+// serveCopyCommand runs the exec request of the channel for startCopyTestServer.
+func serveCopyCommand(channel gossh.Channel, reqs <-chan *gossh.Request, size int) {
+	defer channel.Close()
+	for req := range reqs {
+		if req.Type != "exec" || len(req.Payload) < 4 {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+		status := make([]byte, 4)
+		if command := string(req.Payload[4:]); strings.HasPrefix(command, "cat > ") {
+			_, _ = io.CopyN(io.Discard, channel, 1024)
+			_, _ = channel.Stderr().Write([]byte("No space left on device"))
+			binary.BigEndian.PutUint32(status, 1)
+		} else {
+			// blocks once the client stops reading and the window is full
+			_, _ = channel.Write(bytes.Repeat([]byte("x"), size))
+		}
+		_, _ = channel.SendRequest("exit-status", false, status)
+		return
+	}
+}
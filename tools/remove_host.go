@@ -3,10 +3,13 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
 	"github.com/blakerouse/ssh-mcp/storage"
 )
 
@@ -15,13 +18,20 @@ func init() {
 	Registry.Register(&RemoveHost{})
 }
 
+// RemoveHostVerification is the identity check of a host made before removing it.
+type RemoveHostVerification struct {
+	Reachable      bool   `json:"reachable"`
+	RemoteHostname string `json:"remote_hostname,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
 // RemoveHost is a tool that removes a host from the SSH configuration.
 type RemoveHost struct{}
 
 // Definition returns the mcp.Tool definition.
 func (c *RemoveHost) Definition() mcp.Tool {
 	return mcp.NewTool("remove_host",
-		mcp.WithDescription("Removes a host from the SSH configuration."),
+		mcp.WithDescription("Removes a host from the SSH configuration. Set expected_hostname to first connect and only remove the host when it reports that hostname, so the wrong machine cannot be removed. Set verify=true to only connect and return the remote hostname along with the removal."),
 		mcp.WithString("group",
 			mcp.Required(),
 			mcp.Description("Group that the host belongs to"),
@@ -30,11 +40,20 @@ func (c *RemoveHost) Definition() mcp.Tool {
 			mcp.Required(),
 			mcp.Description("Name of the host"),
 		),
+		mcp.WithBoolean("verify",
+			mcp.Description("Connect to the host before removing it and return its remote hostname (default: false)"),
+		),
+		mcp.WithString("expected_hostname",
+			mcp.Description("Hostname the host must report (case-insensitive) before it is removed. The host is connected to first and kept when it reports another hostname or cannot be connected to (optional)"),
+		),
+		mcp.WithBoolean("require_reachable",
+			mcp.Description("With verify, abort the removal when the host cannot be connected to (default: false, the host is removed anyway)"),
+		),
 	)
 }
 
 // Handle is the function that is called when the tool is invoked.
-func (c *RemoveHost) Handler(ctx context.Context, storageEngine *storage.Engine) server.ToolHandlerFunc {
+func (c *RemoveHost) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
 	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		group, err := request.RequireString("group")
 		if err != nil {
@@ -45,6 +64,9 @@ func (c *RemoveHost) Handler(ctx context.Context, storageEngine *storage.Engine)
 		if group == "" {
 			return mcp.NewToolResultError("group cannot be empty"), nil
 		}
+		if err := checkGroupAllowed(group); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
 
 		sshNameOfHost, err := request.RequireString("name_of_host")
 		if err != nil {
@@ -58,15 +80,67 @@ func (c *RemoveHost) Handler(ctx context.Context, storageEngine *storage.Engine)
 
 		// check if its existed first so we change change the resulting output depending
 		// on its existance
-		_, ok := storageEngine.Get(group, sshNameOfHost)
+		info, ok := storageEngine.Get(group, sshNameOfHost)
+
+		expectedHostname := strings.TrimSpace(request.GetString("expected_hostname", ""))
+		var verification *RemoveHostVerification
+		if ok && (request.GetBool("verify", false) || expectedHostname != "") {
+			verification = verifyHostBeforeRemoval(storageEngine, info)
+			if expectedHostname != "" {
+				if err := checkExpectedHostname(verification, expectedHostname); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("host %s in group %s was not removed, %v", sshNameOfHost, group, err)), nil
+				}
+			} else if verification.Error != "" && request.GetBool("require_reachable", false) {
+				return mcp.NewToolResultError(fmt.Sprintf("host %s in group %s was not removed, verification failed: %s", sshNameOfHost, group, verification.Error)), nil
+			}
+		}
+
 		err = storageEngine.Delete(group, sshNameOfHost)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Errorf("failed to remove host from storage: %w", err).Error()), nil
 		}
 
+		if verification != nil {
+			return mcp.NewToolResultStructured(map[string]any{
+				"removed":      true,
+				"verification": verification,
+			}, fmt.Sprintf("successfully removed %s from group %s", sshNameOfHost, group)), nil
+		}
 		if ok {
 			return mcp.NewToolResultText(fmt.Sprintf("successfully removed %s from group %s", sshNameOfHost, group)), nil
 		}
 		return mcp.NewToolResultText(fmt.Sprintf("host %s in group %s not found", sshNameOfHost, group)), nil
 	}
 }
+
+// verifyHostBeforeRemoval connects to the host and reads its hostname.
+func verifyHostBeforeRemoval(storageEngine storage.Store, info ssh.ClientInfo) *RemoveHostVerification {
+	hosts := applyDefaultUsers(storageEngine, []ssh.ClientInfo{info})
+	results := commands.PerformOnHosts(hosts, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+		output, err := sshClient.Exec("hostname")
+		if err != nil {
+			return "", fmt.Errorf("failed to get hostname: %w", err)
+		}
+		return string(output), nil
+	})
+
+	result := results[info.Name]
+	verification := &RemoveHostVerification{Reachable: !result.ConnectFailed}
+	if result.Err != nil {
+		verification.Error = result.Err.Error()
+		return verification
+	}
+	verification.RemoteHostname = strings.TrimSpace(result.Result)
+	return verification
+}
+
+// checkExpectedHostname returns an error unless the verified host reported the expected hostname.
+func checkExpectedHostname(verification *RemoveHostVerification, expected string) error {
+	if verification.Error != "" {
+		return fmt.Errorf("verification failed: %s", verification.Error)
+	}
+	if !strings.EqualFold(verification.RemoteHostname, expected) {
+		return fmt.Errorf("it reports hostname %q instead of the expected %q", verification.RemoteHostname, expected)
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
 )
 
 // Tests for RemoveHost tool
@@ -231,3 +233,81 @@ func TestRemoveHost_MultipleRemoves(t *testing.T) {
 	_, ok = engine.Get("production", "server3")
 	require.True(t, ok)
 }
+
+func TestRemoveHost_VerifyUnreachable(t *testing.T) {
+	engine := setupTestStorage(t)
+	require.NoError(t, engine.Set(ssh.ClientInfo{Name: "server1", Group: "production", Host: "127.0.0.1", Port: "1", User: "testuser"}))
+
+	tool := &RemoveHost{}
+	handler := tool.Handler(context.Background(), engine)
+
+	// require_reachable aborts the removal
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"group":             "production",
+				"name_of_host":      "server1",
+				"verify":            true,
+				"require_reachable": true,
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	_, ok := engine.Get("production", "server1")
+	require.True(t, ok)
+
+	// without it the host is removed and the failed verification reported
+	request.Params.Arguments = map[string]interface{}{
+		"group":        "production",
+		"name_of_host": "server1",
+		"verify":       true,
+	}
+	result, err = handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	structured, ok := result.StructuredContent.(map[string]any)
+	require.True(t, ok)
+	verification, ok := structured["verification"].(*RemoveHostVerification)
+	require.True(t, ok)
+	require.False(t, verification.Reachable)
+	require.NotEmpty(t, verification.Error)
+	_, ok = engine.Get("production", "server1")
+	require.False(t, ok)
+}
+
+func TestRemoveHost_ExpectedHostnameUnreachable(t *testing.T) {
+	engine := setupTestStorage(t)
+	require.NoError(t, engine.Set(ssh.ClientInfo{Name: "server1", Group: "production", Host: "127.0.0.1", Port: "1", User: "testuser"}))
+
+	tool := &RemoveHost{}
+	handler := tool.Handler(context.Background(), engine)
+
+	// the hostname cannot be checked, so the host is kept
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"group":             "production",
+				"name_of_host":      "server1",
+				"expected_hostname": "server1",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "verification failed")
+	_, ok := engine.Get("production", "server1")
+	require.True(t, ok)
+}
+
+func TestCheckExpectedHostname(t *testing.T) {
+	require.NoError(t, checkExpectedHostname(&RemoveHostVerification{Reachable: true, RemoteHostname: "Web01"}, "web01"))
+
+	err := checkExpectedHostname(&RemoveHostVerification{Reachable: true, RemoteHostname: "db01"}, "web01")
+	require.EqualError(t, err, `it reports hostname "db01" instead of the expected "web01"`)
+
+	err = checkExpectedHostname(&RemoveHostVerification{Error: "failed to connect: connection refused"}, "web01")
+	require.EqualError(t, err, "verification failed: failed to connect: connection refused")
+}
@@ -0,0 +1,373 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&RollingReboot{})
+}
+
+const (
+	// rebootPollInterval is how often a rebooting host is checked for having come back
+	rebootPollInterval = 5 * time.Second
+	// defaultRecoverTimeout is how long a wave is given to come back and pass the healthcheck
+	defaultRecoverTimeout = 10 * time.Minute
+
+	// bootIDCommand prints an identifier that changes on every boot
+	bootIDCommand = "cat /proc/sys/kernel/random/boot_id"
+	// bootIDWindowsScript prints the last boot time, which changes on every boot
+	bootIDWindowsScript = "(Get-CimInstance Win32_OperatingSystem).LastBootUpTime.ToString('o')"
+
+	// rebootSudoCommand checks sudo first so a missing permission is reported, then reboots in the
+	// background after the session has been closed
+	rebootSudoCommand = `sudo -n true && { nohup sh -c 'sleep 2; sudo -n reboot' >/dev/null 2>&1 & }`
+	// rebootCommand reboots in the background after the session has been closed
+	rebootCommand = `nohup sh -c 'sleep 2; reboot' >/dev/null 2>&1 &`
+	// rebootWindowsCommand reboots after a short delay so the session can be closed
+	rebootWindowsCommand = "shutdown /r /t 5 /f"
+)
+
+// Rolling reboot host statuses.
+const (
+	RebootStatusRecovered  = "recovered"
+	RebootStatusFailed     = "failed"
+	RebootStatusNotStarted = "not_started"
+)
+
+// RollingRebootHost is the outcome of rebooting a single host.
+type RollingRebootHost struct {
+	Host string `json:"host"`
+	// Wave is the 1-based wave the host was rebooted in
+	Wave   int    `json:"wave"`
+	Status string `json:"status"`
+	// DowntimeSeconds is the time from the reboot to the host accepting connections again
+	DowntimeSeconds float64 `json:"downtime_seconds,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// rebootSteps are the operations a rolling reboot performs on a host.
+type rebootSteps struct {
+	// bootID returns an identifier of the current boot of the host
+	bootID func(host ssh.ClientInfo) (string, error)
+	// reboot starts rebooting the host
+	reboot func(host ssh.ClientInfo) error
+	// healthcheck returns nil when the host is healthy, nil func skips the healthcheck
+	healthcheck func(host ssh.ClientInfo) error
+	// pollInterval is how often the host is checked while waiting for it
+	pollInterval time.Duration
+	// report is called with the outcome of each host once its wave is over, nil when not needed
+	report func(host RollingRebootHost)
+}
+
+// RollingReboot is a tool that reboots the hosts of a group in waves.
+type RollingReboot struct {
+	commandRunner commands.Runner
+}
+
+// SetCommandRunner sets the command runner the rollout runs in the background with.
+func (c *RollingReboot) SetCommandRunner(runner commands.Runner) {
+	c.commandRunner = runner
+}
+
+// IsMutating returns true as the tool changes remote hosts.
+func (c *RollingReboot) IsMutating() bool {
+	return true
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *RollingReboot) Definition() mcp.Tool {
+	return mcp.NewTool("rolling_reboot",
+		mcp.WithDescription("Reboots hosts in waves of wave_size hosts (in host name order), waiting for every host in a wave to come back with a new boot and, when given, pass the healthcheck command before rebooting the next wave, so a cluster is never down at once. The rollout stops when a host of a wave fails to reboot or recover within recover_timeout_seconds; the remaining hosts are reported as not_started. The rollout runs in the background and its command ID is returned right away: poll it with get_command_status, where each host reports its wave, status and downtime as it finishes, or stop it with cancel_command. Only Linux and Windows hosts are supported, as a new boot is detected from the Linux boot_id or the Windows last boot time. Requires confirm=true."),
+		mcp.WithString("group",
+			mcp.Description("Group name to reboot all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithNumber("wave_size", mcp.Description("Number of hosts rebooted at the same time (default: 1)")),
+		mcp.WithString("healthcheck", mcp.Description("Command that must exit 0 on a host after it comes back before the next wave starts, e.g. 'systemctl is-active nginx' (optional)")),
+		mcp.WithNumber("recover_timeout_seconds", mcp.Description("How long each wave is given to come back and pass the healthcheck before the rollout is stopped (default: 600)")),
+		mcp.WithBoolean("sudo", mcp.Description("Reboot through non-interactive sudo, not needed when connecting as root (default: true, ignored on Windows)")),
+		mcp.WithBoolean("confirm", mcp.Description("Confirm rebooting every targeted host (default: false)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *RollingReboot) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if c.commandRunner == nil {
+			panic("command runner not available")
+		}
+		if !request.GetBool("confirm", false) {
+			return mcp.NewToolResultError("rolling_reboot reboots every targeted host, set confirm=true to proceed"), nil
+		}
+		waveSize := request.GetInt("wave_size", 1)
+		if waveSize < 1 {
+			return mcp.NewToolResultError("wave_size must be at least 1"), nil
+		}
+		recoverTimeout := defaultRecoverTimeout
+		if seconds := request.GetInt("recover_timeout_seconds", 0); seconds > 0 {
+			recoverTimeout = time.Duration(seconds) * time.Second
+		} else if seconds < 0 {
+			return mcp.NewToolResultError("recover_timeout_seconds cannot be negative"), nil
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if unsupported := unsupportedRebootHosts(found); len(unsupported) > 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("rolling_reboot only supports Linux and Windows hosts, cannot detect a new boot on: %s", strings.Join(unsupported, ", "))), nil
+		}
+
+		steps := rebootSteps{
+			bootID:       readBootID,
+			reboot:       rebootHostCommand(request.GetBool("sudo", true)),
+			pollInterval: rebootPollInterval,
+		}
+		if healthcheck := request.GetString("healthcheck", ""); healthcheck != "" {
+			steps.healthcheck = func(host ssh.ClientInfo) error {
+				_, err := runOnHost(host, healthcheck)
+				return err
+			}
+		}
+
+		waves := rebootWaves(found, waveSize)
+		description := fmt.Sprintf("rolling_reboot wave_size=%d", waveSize)
+		cmd := c.commandRunner.CreateCommand(description, found,
+			commands.WithInitiatedBy(initiatedBy(reqCtx, request)),
+			commands.WithTask(func(ctx context.Context, report func(commands.CommandResult)) {
+				steps.report = func(host RollingRebootHost) {
+					report(rebootCommandResult(host))
+				}
+				steps.rollingReboot(ctx, waves, recoverTimeout)
+			}),
+		)
+		if err := cmd.Start(); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to start rolling reboot: %v", err)), nil
+		}
+		return mcp.NewToolResultStructured(cmd.ToState(), fmt.Sprintf("Rolling reboot of %d hosts in %d waves started in background with ID: %s\nUse get_command_status tool to check progress.", len(found), len(waves), cmd.ID())), nil
+	}
+}
+
+// unsupportedRebootHosts returns the names of the hosts whose OS is known and a new boot cannot be
+// detected on. A host whose OS is unknown fails on its own when its boot cannot be read, before it
+// is rebooted.
+func unsupportedRebootHosts(hosts []ssh.ClientInfo) []string {
+	var unsupported []string
+	for _, host := range hosts {
+		if host.OS.Uname == "" || utils.IsWindows(host) || strings.HasPrefix(host.OS.Uname, "Linux") {
+			continue
+		}
+		unsupported = append(unsupported, host.Name)
+	}
+	return unsupported
+}
+
+// rebootCommandResult converts the outcome of a host to its result in the rollout command, with the
+// outcome itself as the JSON of the result.
+func rebootCommandResult(host RollingRebootHost) commands.CommandResult {
+	result := commands.CommandResult{Host: host.Host, JSON: host}
+	switch host.Status {
+	case RebootStatusRecovered:
+		result.Result = fmt.Sprintf("recovered in wave %d after %gs of downtime", host.Wave, host.DowntimeSeconds)
+	case RebootStatusNotStarted:
+		result.Err = fmt.Errorf("not started: the rollout stopped before wave %d", host.Wave)
+	default:
+		result.Err = errors.New(host.Error)
+	}
+	return result
+}
+
+// rebootWaves splits the hosts, sorted by name, into waves of at most size hosts.
+func rebootWaves(hosts []ssh.ClientInfo, size int) [][]ssh.ClientInfo {
+	sorted := make([]ssh.ClientInfo, len(hosts))
+	copy(sorted, hosts)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	var waves [][]ssh.ClientInfo
+	for len(sorted) > 0 {
+		n := min(size, len(sorted))
+		waves = append(waves, sorted[:n])
+		sorted = sorted[n:]
+	}
+	return waves
+}
+
+// rollingReboot reboots the waves one after the other, stopping after the first wave with a host
+// that did not recover or once ctx is cancelled. It returns the outcome of every host and the
+// 1-based wave the rollout stopped at, 0 when every wave recovered.
+func (s rebootSteps) rollingReboot(ctx context.Context, waves [][]ssh.ClientInfo, recoverTimeout time.Duration) ([]RollingRebootHost, int) {
+	var hosts []RollingRebootHost
+	stoppedAt := 0
+	for i, wave := range waves {
+		number := i + 1
+		if stoppedAt == 0 && ctx.Err() != nil {
+			stoppedAt = number
+		}
+		if stoppedAt != 0 {
+			for _, host := range wave {
+				hosts = append(hosts, s.reported(RollingRebootHost{Host: host.Name, Wave: number, Status: RebootStatusNotStarted}))
+			}
+			continue
+		}
+
+		results := make([]RollingRebootHost, len(wave))
+		var wg sync.WaitGroup
+		for j, host := range wave {
+			wg.Add(1)
+			go func(j int, host ssh.ClientInfo) {
+				defer wg.Done()
+				results[j] = s.rebootHost(ctx, host, recoverTimeout)
+				results[j].Wave = number
+			}(j, host)
+		}
+		wg.Wait()
+
+		for _, result := range results {
+			if result.Status != RebootStatusRecovered {
+				stoppedAt = number
+			}
+			s.reported(result)
+		}
+		hosts = append(hosts, results...)
+	}
+	return hosts, stoppedAt
+}
+
+// reported passes the outcome of a host to report, when set, and returns it.
+func (s rebootSteps) reported(host RollingRebootHost) RollingRebootHost {
+	if s.report != nil {
+		s.report(host)
+	}
+	return host
+}
+
+// rebootHost reboots the host and waits until it is back with a new boot and healthy.
+func (s rebootSteps) rebootHost(ctx context.Context, host ssh.ClientInfo, recoverTimeout time.Duration) RollingRebootHost {
+	result := RollingRebootHost{Host: host.Name, Status: RebootStatusFailed}
+	before, err := s.bootID(host)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to read boot before reboot: %v", err)
+		return result
+	}
+	// the rollout may have been cancelled while the boot was read
+	if err := ctx.Err(); err != nil {
+		result.Status = RebootStatusNotStarted
+		result.Error = fmt.Sprintf("not rebooted: %v", err)
+		return result
+	}
+	start := time.Now()
+	if err := s.reboot(host); err != nil {
+		result.Error = fmt.Sprintf("failed to reboot: %v", err)
+		return result
+	}
+	deadline := start.Add(recoverTimeout)
+
+	// the host is back once it accepts connections again with a different boot
+	err = s.waitUntil(ctx, deadline, func() error {
+		after, err := s.bootID(host)
+		if err != nil {
+			return err
+		}
+		if after == before {
+			return errors.New("host has not rebooted yet")
+		}
+		return nil
+	})
+	if err != nil {
+		result.Error = fmt.Sprintf("did not come back within %s: %v", recoverTimeout, err)
+		return result
+	}
+	result.DowntimeSeconds = time.Since(start).Round(time.Second).Seconds()
+
+	if s.healthcheck != nil {
+		err = s.waitUntil(ctx, deadline, func() error {
+			return s.healthcheck(host)
+		})
+		if err != nil {
+			result.Error = fmt.Sprintf("healthcheck did not pass within %s: %v", recoverTimeout, err)
+			return result
+		}
+	}
+	result.Status = RebootStatusRecovered
+	return result
+}
+
+// waitUntil calls check every poll interval until it returns nil, returning the last error of
+// check when the deadline passes or the context is done first.
+func (s rebootSteps) waitUntil(ctx context.Context, deadline time.Time, check func() error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.pollInterval):
+		}
+		err := check()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+	}
+}
+
+// readBootID returns the identifier of the current boot of the host.
+func readBootID(host ssh.ClientInfo) (string, error) {
+	command := bootIDCommand
+	if utils.IsWindows(host) {
+		command = utils.PowerShellCommand(bootIDWindowsScript)
+	}
+	output, err := runOnHost(host, command)
+	if err != nil {
+		return "", err
+	}
+	bootID := strings.TrimSpace(output)
+	if bootID == "" {
+		return "", errors.New("empty boot identifier")
+	}
+	return bootID, nil
+}
+
+// rebootHostCommand returns the step that starts rebooting a host.
+func rebootHostCommand(sudo bool) func(host ssh.ClientInfo) error {
+	return func(host ssh.ClientInfo) error {
+		command := rebootCommand
+		if utils.IsWindows(host) {
+			command = rebootWindowsCommand
+		} else if sudo {
+			command = rebootSudoCommand
+		}
+		_, err := runOnHost(host, command)
+		return err
+	}
+}
+
+// runOnHost connects to a single host and runs the command, returning its output.
+func runOnHost(host ssh.ClientInfo, command string) (string, error) {
+	results := commands.PerformOnHosts([]ssh.ClientInfo{host}, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+		return execWithOutput(sshClient, command)
+	})
+	result := results[host.Name]
+	return result.Result, result.Err
+}
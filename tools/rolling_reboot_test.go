@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// fakeReboots simulates hosts that come back with a new boot after their first check.
+type fakeReboots struct {
+	mu      sync.Mutex
+	boots   map[string]int
+	pending map[string]bool
+	order   []string
+	// down are hosts that never come back
+	down map[string]bool
+}
+
+func newFakeReboots() *fakeReboots {
+	return &fakeReboots{boots: map[string]int{}, pending: map[string]bool{}, down: map[string]bool{}}
+}
+
+func (f *fakeReboots) steps() rebootSteps {
+	return rebootSteps{
+		bootID: func(host ssh.ClientInfo) (string, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if f.pending[host.Name] {
+				if f.down[host.Name] {
+					return "", errors.New("connection refused")
+				}
+				f.pending[host.Name] = false
+				f.boots[host.Name]++
+			}
+			return string(rune('a' + f.boots[host.Name])), nil
+		},
+		reboot: func(host ssh.ClientInfo) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.pending[host.Name] = true
+			f.order = append(f.order, host.Name)
+			return nil
+		},
+		pollInterval: time.Millisecond,
+	}
+}
+
+func testRebootHosts(names ...string) []ssh.ClientInfo {
+	hosts := make([]ssh.ClientInfo, 0, len(names))
+	for _, name := range names {
+		hosts = append(hosts, ssh.ClientInfo{Group: "prod", Name: name})
+	}
+	return hosts
+}
+
+func TestRebootWaves(t *testing.T) {
+	waves := rebootWaves(testRebootHosts("web3", "web1", "web5", "web2", "web4"), 2)
+	require.Len(t, waves, 3)
+	require.Equal(t, "web1", waves[0][0].Name)
+	require.Equal(t, "web2", waves[0][1].Name)
+	require.Equal(t, "web3", waves[1][0].Name)
+	require.Len(t, waves[2], 1)
+	require.Equal(t, "web5", waves[2][0].Name)
+}
+
+func TestRollingReboot_AllRecover(t *testing.T) {
+	fake := newFakeReboots()
+	waves := rebootWaves(testRebootHosts("web1", "web2", "web3"), 2)
+
+	hosts, stoppedAt := fake.steps().rollingReboot(context.Background(), waves, time.Second)
+	require.Equal(t, 0, stoppedAt)
+	require.Len(t, hosts, 3)
+	for _, host := range hosts {
+		require.Equal(t, RebootStatusRecovered, host.Status, host.Host)
+	}
+	require.Equal(t, 1, hosts[0].Wave)
+	require.Equal(t, 1, hosts[1].Wave)
+	require.Equal(t, 2, hosts[2].Wave)
+	// the second wave only starts once the first is back
+	require.Equal(t, "web3", fake.order[2])
+}
+
+func TestRollingReboot_StopsWhenWaveDoesNotRecover(t *testing.T) {
+	fake := newFakeReboots()
+	fake.down["web2"] = true
+	waves := rebootWaves(testRebootHosts("web1", "web2", "web3", "web4"), 1)
+	steps := fake.steps()
+	var reported []string
+	steps.report = func(host RollingRebootHost) {
+		reported = append(reported, host.Host+":"+host.Status)
+	}
+
+	hosts, stoppedAt := steps.rollingReboot(context.Background(), waves, 20*time.Millisecond)
+	require.Equal(t, 2, stoppedAt)
+	require.Equal(t, RebootStatusRecovered, hosts[0].Status)
+	require.Equal(t, RebootStatusFailed, hosts[1].Status)
+	require.Contains(t, hosts[1].Error, "did not come back")
+	require.Equal(t, RebootStatusNotStarted, hosts[2].Status)
+	require.Equal(t, RebootStatusNotStarted, hosts[3].Status)
+	require.Equal(t, 4, hosts[3].Wave)
+	require.Equal(t, []string{"web1", "web2"}, fake.order)
+	require.Equal(t, []string{"web1:recovered", "web2:failed", "web3:not_started", "web4:not_started"}, reported)
+}
+
+func TestRollingReboot_CancelledBetweenWaves(t *testing.T) {
+	fake := newFakeReboots()
+	waves := rebootWaves(testRebootHosts("web1", "web2", "web3"), 1)
+	steps := fake.steps()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	steps.report = func(host RollingRebootHost) {
+		if host.Host == "web1" {
+			cancel()
+		}
+	}
+
+	hosts, stoppedAt := steps.rollingReboot(ctx, waves, time.Second)
+	require.Equal(t, 2, stoppedAt)
+	require.Equal(t, RebootStatusRecovered, hosts[0].Status)
+	require.Equal(t, RebootStatusNotStarted, hosts[1].Status)
+	require.Equal(t, RebootStatusNotStarted, hosts[2].Status)
+	require.Equal(t, []string{"web1"}, fake.order)
+}
+
+func TestRollingReboot_CancelledBeforeReboot(t *testing.T) {
+	fake := newFakeReboots()
+	steps := fake.steps()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bootID := steps.bootID
+	steps.bootID = func(host ssh.ClientInfo) (string, error) {
+		cancel()
+		return bootID(host)
+	}
+
+	hosts, stoppedAt := steps.rollingReboot(ctx, rebootWaves(testRebootHosts("web1"), 1), time.Second)
+	require.Equal(t, 1, stoppedAt)
+	require.Equal(t, RebootStatusNotStarted, hosts[0].Status)
+	require.Contains(t, hosts[0].Error, "not rebooted")
+	require.Empty(t, fake.order)
+}
+
+func TestRollingReboot_Healthcheck(t *testing.T) {
+	fake := newFakeReboots()
+	steps := fake.steps()
+	checks := 0
+	steps.healthcheck = func(host ssh.ClientInfo) error {
+		checks++
+		if checks < 3 {
+			return errors.New("nginx is not active")
+		}
+		return nil
+	}
+
+	hosts, stoppedAt := steps.rollingReboot(context.Background(), rebootWaves(testRebootHosts("web1"), 1), time.Second)
+	require.Equal(t, 0, stoppedAt)
+	require.Equal(t, RebootStatusRecovered, hosts[0].Status)
+	require.Equal(t, 3, checks)
+
+	steps.healthcheck = func(host ssh.ClientInfo) error {
+		return errors.New("nginx is not active")
+	}
+	hosts, stoppedAt = steps.rollingReboot(context.Background(), rebootWaves(testRebootHosts("web1"), 1), 20*time.Millisecond)
+	require.Equal(t, 1, stoppedAt)
+	require.Contains(t, hosts[0].Error, "healthcheck did not pass")
+	require.Contains(t, hosts[0].Error, "nginx is not active")
+}
+
+func TestRollingReboot_RebootFails(t *testing.T) {
+	fake := newFakeReboots()
+	steps := fake.steps()
+	steps.reboot = func(host ssh.ClientInfo) error {
+		return errors.New("sudo: a password is required")
+	}
+
+	hosts, stoppedAt := steps.rollingReboot(context.Background(), rebootWaves(testRebootHosts("web1", "web2"), 1), time.Second)
+	require.Equal(t, 1, stoppedAt)
+	require.Contains(t, hosts[0].Error, "failed to reboot")
+	require.Equal(t, RebootStatusNotStarted, hosts[1].Status)
+}
+
+func TestRollingReboot_RequiresConfirm(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "prod", "web1", "10.0.0.1")
+
+	tool := &RollingReboot{}
+	tool.SetCommandRunner(commands.NewRunner())
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"group": "prod"}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	request.Params.Arguments = map[string]any{"group": "prod", "confirm": true, "wave_size": 0}
+	result, err = handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
+
+func TestRollingReboot_RejectsUnsupportedHosts(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "prod", "web1", "10.0.0.1")
+	require.NoError(t, engine.Set(ssh.ClientInfo{Group: "prod", Name: "bsd1", Host: "10.0.0.2", Port: "22", User: "root",
+		OS: ssh.OSInfo{Uname: "FreeBSD bsd1 14.0-RELEASE"}}))
+
+	runner := commands.NewRunner()
+	tool := &RollingReboot{}
+	tool.SetCommandRunner(runner)
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"group": "prod", "confirm": true}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "bsd1")
+	require.Empty(t, runner.ListCommands())
+}
+
+func TestRebootCommandResult(t *testing.T) {
+	recovered := rebootCommandResult(RollingRebootHost{Host: "web1", Wave: 1, Status: RebootStatusRecovered, DowntimeSeconds: 42})
+	require.NoError(t, recovered.Err)
+	require.Equal(t, "recovered in wave 1 after 42s of downtime", recovered.Result)
+	require.Equal(t, RollingRebootHost{Host: "web1", Wave: 1, Status: RebootStatusRecovered, DowntimeSeconds: 42}, recovered.JSON)
+
+	failed := rebootCommandResult(RollingRebootHost{Host: "web2", Wave: 2, Status: RebootStatusFailed, Error: "failed to reboot: denied"})
+	require.EqualError(t, failed.Err, "failed to reboot: denied")
+
+	notStarted := rebootCommandResult(RollingRebootHost{Host: "web3", Wave: 3, Status: RebootStatusNotStarted})
+	require.EqualError(t, notStarted.Err, "not started: the rollout stopped before wave 3")
+}
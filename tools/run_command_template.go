@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&RunCommandTemplate{})
+}
+
+// RunCommandTemplate is a tool that resolves a saved command template and executes it.
+type RunCommandTemplate struct {
+	commandRunner commands.Runner
+}
+
+// SetCommandRunner sets the command runner for background execution
+func (c *RunCommandTemplate) SetCommandRunner(runner commands.Runner) {
+	c.commandRunner = runner
+}
+
+// IsMutating returns true as the tool changes remote hosts.
+func (c *RunCommandTemplate) IsMutating() bool {
+	return true
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *RunCommandTemplate) Definition() mcp.Tool {
+	return mcp.NewTool("run_command_template",
+		mcp.WithDescription("Resolves a command template saved with save_command_template and executes it like perform_command. You can specify individual hosts or an entire group. Commands that take longer than 30 seconds are automatically moved to background."),
+		mcp.WithString("template", mcp.Required(), mcp.Description("Name of the saved template to run")),
+		mcp.WithObject("vars",
+			mcp.Description("Values for the template placeholders, e.g. {\"unit\": \"nginx\"} for {{.unit}}. Every placeholder must be provided. String values are inserted shell-quoted, so they are always a single argument; use {{raw .name}} to insert one as is."),
+		),
+		mcp.WithString("group",
+			mcp.Description("Group name to execute command on all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("background",
+			mcp.Description("Run the command in the background immediately and return a command ID (default: false, waits up to 30s before auto-backgrounding)"),
+		),
+		hostVarsOption(),
+		outputFormatOption(),
+		resultFormatOption(),
+		autoBackgroundOption(),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *RunCommandTemplate) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if c.commandRunner == nil {
+			panic("command runner not available")
+		}
+
+		name, err := request.RequireString("template")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		tmpl, ok := storageEngine.GetTemplate(name)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("template %s not found", name)), nil
+		}
+
+		vars := map[string]any{}
+		if raw, ok := request.GetArguments()["vars"]; ok && raw != nil {
+			vars, ok = raw.(map[string]any)
+			if !ok {
+				return mcp.NewToolResultError("vars must be an object"), nil
+			}
+		}
+
+		outputFormat, err := outputFormatFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		resultFormat, err := resultFormatFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		backgroundAfter, err := autoBackgroundFromRequest(request, c.commandRunner)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// With host_vars the template is rendered for each host, so a missing variable only fails
+		// the hosts missing it
+		opts := []commands.CommandOption{commands.WithInitiatedBy(initiatedBy(reqCtx, request))}
+		hostVars, err := hostVarsFromRequest(request, found)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		commandStr := tmpl.Command
+		if hostVars != nil {
+			render, err := hostCommandRenderer(tmpl.Name, tmpl.Command, vars, hostVars)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			opts = append(opts, commands.WithHostCommand(render))
+		} else {
+			commandStr, err = renderCommandTemplate(tmpl, vars)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		// Create and start the command
+		cmd := c.commandRunner.CreateCommand(commandStr, found, opts...)
+		err = cmd.Start()
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to start command: %v", err)), nil
+		}
+
+		// If background execution is requested, return immediately
+		if request.GetBool("background", false) {
+			return mcp.NewToolResultStructured(cmd.ToState(), fmt.Sprintf("Command started in background with ID: %s\nUse get_command_status tool to check progress.", cmd.ID())), nil
+		}
+
+		// Wait for command completion with 30 second timeout
+		return waitForCommandOrBackground(reqCtx, cmd, backgroundAfter, outputFormat, resultFormat)
+	}
+}
+
+// renderCommandTemplate fills in the template placeholders, failing on any placeholder without a value.
+// String values are shell-quoted, see shellQuoteVars.
+func renderCommandTemplate(tmpl storage.CommandTemplate, vars map[string]any) (string, error) {
+	t, err := parseCommandTemplate(tmpl.Name, tmpl.Command)
+	if err != nil {
+		return "", fmt.Errorf("invalid command template %s: %w", tmpl.Name, err)
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, shellQuoteVars(vars)); err != nil {
+		return "", fmt.Errorf("failed to render command template %s: %w", tmpl.Name, err)
+	}
+	return sb.String(), nil
+}
+
+// commandTemplateFuncs are the functions available in command templates.
+var commandTemplateFuncs = template.FuncMap{
+	"raw": rawTemplateValue,
+}
+
+// parseCommandTemplate parses a command template with the command template functions, failing on
+// any placeholder without a value when executed.
+func parseCommandTemplate(name string, text string) (*template.Template, error) {
+	return template.New(name).Funcs(commandTemplateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// shellValue is a string template variable, printed quoted for a POSIX shell so a value such as
+// "nginx; rm -rf /" stays a single argument. It still compares and tests like the plain string.
+type shellValue string
+
+// String returns the value quoted for a POSIX shell.
+func (v shellValue) String() string {
+	return utils.ShellQuote(string(v))
+}
+
+// shellQuoteVars returns a copy of vars with every string, including those nested in objects and
+// arrays, printed shell-quoted by templates. Numbers and booleans cannot hold shell syntax and are
+// printed as is.
+func shellQuoteVars(vars map[string]any) map[string]any {
+	quoted := make(map[string]any, len(vars))
+	for key, value := range vars {
+		quoted[key] = shellQuoteValue(value)
+	}
+	return quoted
+}
+
+// shellQuoteValue wraps the strings of value in shellValue.
+func shellQuoteValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return shellValue(v)
+	case map[string]any:
+		return shellQuoteVars(v)
+	case []any:
+		quoted := make([]any, len(v))
+		for i, item := range v {
+			quoted[i] = shellQuoteValue(item)
+		}
+		return quoted
+	}
+	return value
+}
+
+// rawTemplateValue is the raw template function, returning a value without shell quoting for
+// templates that quote it themselves or run on hosts without a POSIX shell.
+func rawTemplateValue(value any) string {
+	if v, ok := value.(shellValue); ok {
+		return string(v)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+// Tests for RunCommandTemplate tool
+
+func TestRenderCommandTemplate(t *testing.T) {
+	tmpl := storage.CommandTemplate{Name: "unit-logs", Command: "journalctl -u {{.unit}} -n {{.lines}}"}
+
+	rendered, err := renderCommandTemplate(tmpl, map[string]any{"unit": "nginx", "lines": float64(50)})
+	require.NoError(t, err)
+	require.Equal(t, "journalctl -u 'nginx' -n 50", rendered)
+
+	_, err = renderCommandTemplate(tmpl, map[string]any{"unit": "nginx"})
+	require.Error(t, err)
+}
+
+func TestRenderCommandTemplate_QuotesMetacharacters(t *testing.T) {
+	tmpl := storage.CommandTemplate{Name: "unit-logs", Command: "journalctl -u {{.unit}}"}
+	for value, expected := range map[string]string{
+		"nginx; rm -rf /":      `journalctl -u 'nginx; rm -rf /'`,
+		"$(reboot)":            `journalctl -u '$(reboot)'`,
+		"`id` && echo | cat &": "journalctl -u '`id` && echo | cat &'",
+		"it's":                 `journalctl -u 'it'\''s'`,
+		"a\nb > /etc/passwd":   "journalctl -u 'a\nb > /etc/passwd'",
+	} {
+		rendered, err := renderCommandTemplate(tmpl, map[string]any{"unit": value})
+		require.NoError(t, err)
+		require.Equal(t, expected, rendered, value)
+	}
+
+	// nested strings are quoted too, numbers and booleans cannot hold shell syntax
+	rendered, err := renderCommandTemplate(storage.CommandTemplate{Name: "nested", Command: "{{range .units}}{{.}} {{end}}{{.opts.user}} {{.lines}} {{.follow}}"},
+		map[string]any{"units": []any{"a;b", "c"}, "opts": map[string]any{"user": "$USER"}, "lines": float64(5), "follow": true})
+	require.NoError(t, err)
+	require.Equal(t, `'a;b' 'c' '$USER' 5 true`, rendered)
+}
+
+func TestRenderCommandTemplate_Raw(t *testing.T) {
+	tmpl := storage.CommandTemplate{Name: "probe", Command: `curl -s "{{raw .scheme}}://localhost"{{if eq .scheme "https"}} -k{{end}}`}
+	rendered, err := renderCommandTemplate(tmpl, map[string]any{"scheme": "https"})
+	require.NoError(t, err)
+	require.Equal(t, `curl -s "https://localhost" -k`, rendered)
+}
+
+func TestRenderCommandTemplate_NoPlaceholders(t *testing.T) {
+	rendered, err := renderCommandTemplate(storage.CommandTemplate{Name: "uptime", Command: "uptime"}, map[string]any{})
+	require.NoError(t, err)
+	require.Equal(t, "uptime", rendered)
+}
+
+func TestRunCommandTemplate_NotFound(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+
+	tool := &RunCommandTemplate{commandRunner: commands.NewMockRunner()}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"template": "missing",
+				"group":    "production",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
+
+func TestRunCommandTemplate_MissingVar(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	require.NoError(t, engine.SetTemplate(storage.CommandTemplate{Name: "unit-logs", Command: "journalctl -u {{.unit}}"}))
+
+	tool := &RunCommandTemplate{commandRunner: commands.NewMockRunner()}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"template": "unit-logs",
+				"group":    "production",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&RunPlaybook{})
+}
+
+// Playbook statuses, a step is skipped when an earlier step aborted the playbook.
+const (
+	PlaybookStatusCompleted = "completed"
+	PlaybookStatusFailed    = "failed"
+	PlaybookStatusAborted   = "aborted"
+	PlaybookStatusCancelled = "cancelled"
+	PlaybookStepSkipped     = "skipped"
+)
+
+// PlaybookStepResult is the outcome of a single playbook step.
+type PlaybookStepResult struct {
+	// Step is the 1-based position of the step in the playbook
+	Step      int                      `json:"step"`
+	Name      string                   `json:"name,omitempty"`
+	Command   string                   `json:"command"`
+	CommandID string                   `json:"command_id,omitempty"`
+	Status    string                   `json:"status"`
+	Error     string                   `json:"error,omitempty"`
+	Results   []commands.CommandResult `json:"results,omitempty"`
+}
+
+// PlaybookResult is the outcome of running a playbook.
+type PlaybookResult struct {
+	Playbook string               `json:"playbook"`
+	Status   string               `json:"status"`
+	Steps    []PlaybookStepResult `json:"steps"`
+}
+
+// RunPlaybook is a tool that runs the steps of a saved playbook one after the other.
+type RunPlaybook struct {
+	commandRunner commands.Runner
+}
+
+// SetCommandRunner sets the command runner for background execution
+func (c *RunPlaybook) SetCommandRunner(runner commands.Runner) {
+	c.commandRunner = runner
+}
+
+// IsMutating returns true as the tool changes remote hosts.
+func (c *RunPlaybook) IsMutating() bool {
+	return true
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *RunPlaybook) Definition() mcp.Tool {
+	return mcp.NewTool("run_playbook",
+		mcp.WithDescription("Runs a playbook saved with save_playbook: each step's command is executed on its hosts and must finish before the next step starts. A step that fails on any host stops the playbook unless its on_failure is continue; the remaining steps are then reported as skipped. Returns the status of the playbook and each step with its command ID and per-host results. Steps without their own hosts run on the group or name_of_hosts given here."),
+		mcp.WithString("playbook", mcp.Required(), mcp.Description("Name of the saved playbook to run")),
+		mcp.WithString("group",
+			mcp.Description("Group name to run the steps without their own hosts on (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' to run the steps without their own hosts on (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *RunPlaybook) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if c.commandRunner == nil {
+			panic("command runner not available")
+		}
+
+		name, err := request.RequireString("playbook")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		playbook, ok := storageEngine.GetPlaybook(name)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("playbook %s not found", name)), nil
+		}
+		defaultGroup := request.GetString("group", "")
+		defaultHosts := request.GetStringSlice("name_of_hosts", []string{})
+
+		resolve := playbookStepResolver(storageEngine, defaultGroup, defaultHosts)
+		caller := initiatedBy(reqCtx, request)
+		run := func(ctx context.Context, command string, hosts []ssh.ClientInfo) (*commands.CommandState, error) {
+			cmd := c.commandRunner.CreateCommand(command, hosts, commands.WithInitiatedBy(caller))
+			if err := cmd.Start(); err != nil {
+				return nil, fmt.Errorf("failed to start command: %w", err)
+			}
+			return waitForCommand(ctx, cmd), nil
+		}
+
+		return mcp.NewToolResultStructuredOnly(executePlaybook(reqCtx, playbook, resolve, run)), nil
+	}
+}
+
+// playbookStepSelector returns the group and name_of_hosts a step runs on, falling back to the
+// defaults when the step has neither.
+func playbookStepSelector(step storage.PlaybookStep, defaultGroup string, defaultHosts []string) (string, []string) {
+	if step.Group == "" && len(step.NameOfHosts) == 0 {
+		return defaultGroup, defaultHosts
+	}
+	return step.Group, step.NameOfHosts
+}
+
+// playbookStepResolver returns the function resolving the hosts of a step, steps without their own
+// hosts resolve to the defaults.
+func playbookStepResolver(storageEngine storage.Store, defaultGroup string, defaultHosts []string) func(step storage.PlaybookStep) ([]ssh.ClientInfo, error) {
+	return func(step storage.PlaybookStep) ([]ssh.ClientInfo, error) {
+		group, hosts := playbookStepSelector(step, defaultGroup, defaultHosts)
+		stepRequest := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{"group": group, "name_of_hosts": hosts}}}
+		return getHostsFromRequest(storageEngine, stepRequest)
+	}
+}
+
+// executePlaybook runs the steps in order, resolving each step's hosts with resolve and running its
+// command with run.
+func executePlaybook(
+	ctx context.Context,
+	playbook storage.Playbook,
+	resolve func(step storage.PlaybookStep) ([]ssh.ClientInfo, error),
+	run func(ctx context.Context, command string, hosts []ssh.ClientInfo) (*commands.CommandState, error),
+) PlaybookResult {
+	result := PlaybookResult{Playbook: playbook.Name, Status: PlaybookStatusCompleted, Steps: []PlaybookStepResult{}}
+	for i, step := range playbook.Steps {
+		stepResult := PlaybookStepResult{Step: i + 1, Name: step.Name, Command: step.Command}
+		if result.Status == PlaybookStatusAborted || result.Status == PlaybookStatusCancelled {
+			stepResult.Status = PlaybookStepSkipped
+			result.Steps = append(result.Steps, stepResult)
+			continue
+		}
+
+		hosts, err := resolve(step)
+		var state *commands.CommandState
+		if err == nil {
+			state, err = run(ctx, step.Command, hosts)
+		}
+		switch {
+		case err != nil:
+			stepResult.Status = PlaybookStatusFailed
+			stepResult.Error = err.Error()
+		default:
+			stepResult.CommandID = state.ID
+			stepResult.Status = string(state.Status)
+			stepResult.Error = state.Error
+			for _, hostResult := range state.Results {
+				stepResult.Results = append(stepResult.Results, hostResult)
+			}
+			sort.Slice(stepResult.Results, func(i, j int) bool {
+				return stepResult.Results[i].Host < stepResult.Results[j].Host
+			})
+		}
+		result.Steps = append(result.Steps, stepResult)
+
+		switch {
+		case stepResult.Status == string(commands.CommandStatusCompleted):
+		case stepResult.Status == string(commands.CommandStatusCancelled) || ctx.Err() != nil:
+			result.Status = PlaybookStatusCancelled
+		case step.OnFailure == storage.OnFailureContinue:
+			result.Status = PlaybookStatusFailed
+		default:
+			result.Status = PlaybookStatusAborted
+		}
+	}
+	return result
+}
+
+// waitForCommand waits until the command reaches a terminal status, cancelling it when the
+// context is done first, and returns its final state.
+func waitForCommand(ctx context.Context, cmd *commands.Command) *commands.CommandState {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !cmd.Status().IsTerminal() {
+		select {
+		case <-ctx.Done():
+			_ = cmd.Cancel()
+			for !cmd.Status().IsTerminal() {
+				time.Sleep(50 * time.Millisecond)
+			}
+		case <-ticker.C:
+		}
+	}
+	return cmd.ToState()
+}
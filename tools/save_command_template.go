@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&SaveCommandTemplate{})
+}
+
+// SaveCommandTemplate is a tool that saves a named command template for later reuse.
+type SaveCommandTemplate struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *SaveCommandTemplate) Definition() mcp.Tool {
+	return mcp.NewTool("save_command_template",
+		mcp.WithDescription("Saves a named command template that can be run later with run_command_template, to avoid repeating long command strings. The command may contain Go template placeholders (e.g. 'journalctl -u {{.unit}} -n 50') that are filled in from vars when run. String values are inserted shell-quoted, so a value is always a single argument; use {{raw .name}} where a value must be inserted as is, e.g. when the template quotes it itself. Saving with an existing name replaces the template."),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Name of the template (e.g. 'failed-units')")),
+		mcp.WithString("command", mcp.Required(), mcp.Description("The command to store, optionally with Go template placeholders such as {{.unit}}")),
+		mcp.WithString("description", mcp.Description("Optional description of what the command does")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *SaveCommandTemplate) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		commandStr, err := request.RequireString("command")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// reject templates that would never render
+		if _, err := parseCommandTemplate(name, commandStr); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid command template: %v", err)), nil
+		}
+
+		tmpl := storage.CommandTemplate{
+			Name:        name,
+			Command:     commandStr,
+			Description: request.GetString("description", ""),
+			UpdatedAt:   time.Now().UTC(),
+		}
+		if err := storageEngine.SetTemplate(tmpl); err != nil {
+			return mcp.NewToolResultError(fmt.Errorf("failed to save template: %w", err).Error()), nil
+		}
+
+		return mcp.NewToolResultStructuredOnly(tmpl), nil
+	}
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for SaveCommandTemplate tool
+
+func TestSaveCommandTemplate_Success(t *testing.T) {
+	engine := setupTestStorage(t)
+	tool := &SaveCommandTemplate{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"name":        "unit-logs",
+				"command":     "journalctl -u {{.unit}} -n 50",
+				"description": "Recent logs for a unit",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	tmpl, ok := engine.GetTemplate("unit-logs")
+	require.True(t, ok)
+	require.Equal(t, "journalctl -u {{.unit}} -n 50", tmpl.Command)
+	require.Equal(t, "Recent logs for a unit", tmpl.Description)
+}
+
+func TestSaveCommandTemplate_InvalidTemplate(t *testing.T) {
+	engine := setupTestStorage(t)
+	tool := &SaveCommandTemplate{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"name":    "broken",
+				"command": "echo {{.unit",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	_, ok := engine.GetTemplate("broken")
+	require.False(t, ok)
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&SavePlaybook{})
+}
+
+// playbookStepSchema is the JSON schema of a single playbook step.
+var playbookStepSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"name":    map[string]any{"type": "string", "description": "Optional name of the step, shown in the results"},
+		"command": map[string]any{"type": "string", "description": "The command to execute"},
+		"group":   map[string]any{"type": "string", "description": "Group to run the step on (mutually exclusive with name_of_hosts)"},
+		"name_of_hosts": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "Host identifiers in format 'group:name' to run the step on (mutually exclusive with group)",
+		},
+		"on_failure": map[string]any{
+			"type":        "string",
+			"enum":        []string{storage.OnFailureAbort, storage.OnFailureContinue},
+			"description": "Whether a failure of the step stops the playbook (default: abort)",
+		},
+	},
+	"required": []string{"command"},
+}
+
+// SavePlaybook is a tool that saves a named sequence of commands for later reuse.
+type SavePlaybook struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *SavePlaybook) Definition() mcp.Tool {
+	return mcp.NewTool("save_playbook",
+		mcp.WithDescription("Saves a playbook: a named, ordered list of command steps run one after the other with run_playbook. Each step can target its own group or name_of_hosts, otherwise it runs on the hosts given to run_playbook, and sets on_failure to abort (default) or continue the playbook when the step fails on any host. Saving with an existing name replaces the playbook."),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Name of the playbook (e.g. 'deploy-web')")),
+		mcp.WithString("description", mcp.Description("Optional description of what the playbook does")),
+		mcp.WithArray("steps",
+			mcp.Required(),
+			mcp.Description("The steps in the order they run"),
+			mcp.Items(playbookStepSchema),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *SavePlaybook) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		steps, err := playbookStepsFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		playbook := storage.Playbook{
+			Name:        name,
+			Description: request.GetString("description", ""),
+			Steps:       steps,
+			UpdatedAt:   time.Now().UTC(),
+		}
+		if err := playbook.Validate(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		for i, step := range playbook.Steps {
+			if err := validatePlaybookStepHosts(step); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("step %d: %v", i+1, err)), nil
+			}
+		}
+		if err := storageEngine.SetPlaybook(playbook); err != nil {
+			return mcp.NewToolResultError(fmt.Errorf("failed to save playbook: %w", err).Error()), nil
+		}
+
+		return mcp.NewToolResultStructuredOnly(playbook), nil
+	}
+}
+
+// playbookStepsFromRequest decodes the steps argument.
+func playbookStepsFromRequest(request mcp.CallToolRequest) ([]storage.PlaybookStep, error) {
+	raw, ok := request.GetArguments()["steps"]
+	if !ok || raw == nil {
+		return nil, fmt.Errorf("required argument \"steps\" not found")
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid steps: %w", err)
+	}
+	var steps []storage.PlaybookStep
+	if err := json.Unmarshal(encoded, &steps); err != nil {
+		return nil, fmt.Errorf("invalid steps: %w", err)
+	}
+	return steps, nil
+}
+
+// validatePlaybookStepHosts checks the host identifiers of the step and that its groups are allowed.
+func validatePlaybookStepHosts(step storage.PlaybookStep) error {
+	if step.Group != "" {
+		return checkGroupAllowed(step.Group)
+	}
+	identifiers, err := utils.ParseHostIdentifiers(step.NameOfHosts)
+	if err != nil {
+		return err
+	}
+	for _, identifier := range identifiers {
+		if err := checkGroupAllowed(identifier.Group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
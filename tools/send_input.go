@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/sessions"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&SendInput{})
+}
+
+// SendInput is a tool that types input into an open shell session.
+type SendInput struct {
+	sessionManager *sessions.Manager
+}
+
+// SetSessionManager sets the session manager
+func (s *SendInput) SetSessionManager(manager *sessions.Manager) {
+	s.sessionManager = manager
+}
+
+// IsMutating returns true as the input can change remote hosts.
+func (s *SendInput) IsMutating() bool {
+	return true
+}
+
+// Definition returns the mcp.Tool definition.
+func (s *SendInput) Definition() mcp.Tool {
+	opts := []mcp.ToolOption{
+		mcp.WithDescription("Types input into a shell opened with open_shell, followed by Enter unless newline is false, and returns the output printed in response. Control characters can be sent with JSON escapes, e.g. '\\u0003' for Ctrl-C or '\\u0004' for Ctrl-D with newline false."),
+		mcp.WithString("session_id", mcp.Required(), mcp.Description("The session ID returned by open_shell")),
+		mcp.WithString("input", mcp.Required(), mcp.Description("The text to type, e.g. a command, an answer to a prompt or a password")),
+		mcp.WithBoolean("newline", mcp.Description("Press Enter after the input (default: true)")),
+	}
+	opts = append(opts, shellReadOptions(2)...)
+	return mcp.NewTool("send_input", opts...)
+}
+
+// Handler is the function that is called when the tool is invoked.
+func (s *SendInput) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if s.sessionManager == nil {
+			panic("session manager not available")
+		}
+		sessionID, err := request.RequireString("session_id")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		input, err := request.RequireString("input")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if request.GetBool("newline", true) {
+			// a terminal sends a carriage return for Enter
+			input += "\r"
+		}
+		wait, until, err := shellReadFromRequest(request, 2)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		session, err := s.sessionManager.Get(sessionID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if err := session.Write(input); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultStructuredOnly(session.Read(reqCtx, wait, until)), nil
+	}
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&ServerStatus{})
+}
+
+// ConnectionStatus is the server-wide SSH connection usage.
+type ConnectionStatus struct {
+	InUse int `json:"in_use"`
+	// Max is the --max-total-connections limit, 0 when unlimited
+	Max int `json:"max"`
+}
+
+// ServerStatusResult is the current state of the server.
+type ServerStatusResult struct {
+	Connections     ConnectionStatus        `json:"connections"`
+	RunningCommands int                     `json:"running_commands"`
+	TotalCommands   int                     `json:"total_commands"`
+	Maintenance     storage.MaintenanceMode `json:"maintenance"`
+}
+
+// ServerStatus is a tool that reports the current state of the server.
+type ServerStatus struct {
+	commandRunner commands.Runner
+}
+
+// SetCommandRunner sets the command runner
+func (c *ServerStatus) SetCommandRunner(runner commands.Runner) {
+	c.commandRunner = runner
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *ServerStatus) Definition() mcp.Tool {
+	return mcp.NewTool("server_status",
+		mcp.WithDescription("Reports the current state of the server: open SSH connections against the --max-total-connections limit, running and tracked background commands, and maintenance mode."),
+	)
+}
+
+// Handler is the function that is called when the tool is invoked.
+func (c *ServerStatus) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if c.commandRunner == nil {
+			panic("command runner not available")
+		}
+
+		var status ServerStatusResult
+		status.Connections.InUse, status.Connections.Max = ssh.ConnectionUsage()
+
+		cmds := c.commandRunner.ListCommands()
+		status.TotalCommands = len(cmds)
+		for _, cmd := range cmds {
+			if cmd.Status() == commands.CommandStatusRunning {
+				status.RunningCommands++
+			}
+		}
+
+		maintenance, err := storageEngine.GetMaintenanceMode()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		status.Maintenance = maintenance
+
+		return mcp.NewToolResultStructuredOnly(status), nil
+	}
+}
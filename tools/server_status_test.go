@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+)
+
+// Tests for ServerStatus tool
+
+func TestServerStatus(t *testing.T) {
+	engine := setupTestStorage(t)
+	mock := commands.NewMockRunner()
+	mock.CreateCommand("uptime", nil).SetStatusForTest(commands.CommandStatusRunning)
+	mock.CreateCommand("uptime", nil).SetStatusForTest(commands.CommandStatusCompleted)
+
+	tool := &ServerStatus{commandRunner: mock}
+	handler := tool.Handler(context.Background(), engine)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	status, ok := result.StructuredContent.(ServerStatusResult)
+	require.True(t, ok)
+	require.Equal(t, 1, status.RunningCommands)
+	require.Equal(t, 2, status.TotalCommands)
+	require.Equal(t, 0, status.Connections.InUse)
+	require.False(t, status.Maintenance.Enabled)
+}
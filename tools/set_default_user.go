@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&SetDefaultUser{})
+}
+
+// SetDefaultUser is a tool that sets the user to connect as for hosts that do not specify one.
+type SetDefaultUser struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *SetDefaultUser) Definition() mcp.Tool {
+	return mcp.NewTool("set_default_user",
+		mcp.WithDescription("Sets the user to connect as for hosts that were added without a user. Precedence when connecting is: host user, group default, global default (this tool without a group, then the --default-user flag), then the user running the server. An empty user removes the default."),
+		mcp.WithString("user", mcp.Required(), mcp.Description("The user to connect as, or empty to remove the default")),
+		mcp.WithString("group", mcp.Description("Group to set the default for (default: set the global default)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *SetDefaultUser) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		user, err := request.RequireString("user")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		group := request.GetString("group", "")
+		if group == "" && allowedGroups != nil {
+			return mcp.NewToolResultError("not authorized: the global default user cannot be set when the server is restricted with --allowed-groups, set it for a group instead"), nil
+		}
+		if err := checkGroupAllowed(group); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if err := storageEngine.SetDefaultUser(group, user); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		scope := "global"
+		if group != "" {
+			scope = fmt.Sprintf("group %s", group)
+		}
+		if user == "" {
+			return mcp.NewToolResultText(fmt.Sprintf("Removed the %s default user", scope)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Set the %s default user to %s", scope, user)), nil
+	}
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for SetDefaultUser tool
+
+func TestSetDefaultUser_GroupAndGlobal(t *testing.T) {
+	engine := setupTestStorage(t)
+	tool := &SetDefaultUser{}
+	handler := tool.Handler(context.Background(), engine)
+
+	for _, args := range []map[string]interface{}{
+		{"user": "deploy"},
+		{"user": "ops", "group": "production"},
+	} {
+		result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+	}
+
+	user, ok := engine.GetDefaultUser("")
+	require.True(t, ok)
+	require.Equal(t, "deploy", user)
+	user, ok = engine.GetDefaultUser("production")
+	require.True(t, ok)
+	require.Equal(t, "ops", user)
+}
+
+func TestApplyDefaultUsers_Precedence(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "with-user", "10.0.1.1")
+	addTestHostWithoutUser(t, engine, "production", "prod-no-user", "10.0.1.2")
+	addTestHostWithoutUser(t, engine, "staging", "staging-no-user", "10.0.2.1")
+	addTestHostWithoutUser(t, engine, "development", "dev-no-user", "10.0.3.1")
+
+	require.NoError(t, engine.SetDefaultUser("production", "ops"))
+	require.NoError(t, engine.SetDefaultUser("", "deploy"))
+
+	hosts, err := engine.List()
+	require.NoError(t, err)
+	users := map[string]string{}
+	for _, host := range applyDefaultUsers(engine, hosts) {
+		users[host.Name] = host.User
+	}
+
+	require.Equal(t, "testuser", users["with-user"])
+	require.Equal(t, "ops", users["prod-no-user"])
+	require.Equal(t, "deploy", users["staging-no-user"])
+	require.Equal(t, "deploy", users["dev-no-user"])
+
+	// without a global default the user is left empty for the --default-user flag / OS user
+	require.NoError(t, engine.SetDefaultUser("", ""))
+	hosts, err = engine.List()
+	require.NoError(t, err)
+	for _, host := range applyDefaultUsers(engine, hosts) {
+		if host.Name == "staging-no-user" {
+			require.Empty(t, host.User)
+		}
+	}
+}
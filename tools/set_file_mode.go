@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&SetFileMode{})
+}
+
+// octalModeRegexp matches a chmod octal mode such as 644 or 0755.
+var octalModeRegexp = regexp.MustCompile(`^[0-7]{3,4}$`)
+
+// FileModeChange is the requested permission and ownership change.
+type FileModeChange struct {
+	Path  string
+	Mode  string
+	Owner string
+	Group string
+	Sudo  bool
+}
+
+// FileModeResult is the outcome of a permission and ownership change on a single host.
+type FileModeResult struct {
+	Host    string `json:"host"`
+	Path    string `json:"path"`
+	Listing string `json:"listing,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SetFileMode is a tool that changes the permissions and ownership of a remote file.
+type SetFileMode struct{}
+
+// IsMutating returns true as the tool changes remote hosts.
+func (c *SetFileMode) IsMutating() bool {
+	return true
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *SetFileMode) Definition() mcp.Tool {
+	return mcp.NewTool("set_file_mode",
+		mcp.WithDescription("Changes the permissions (chmod) and/or ownership (chown) of a remote file or directory and returns the resulting 'ls -ld' line per host to confirm the change. On Windows hosts only owner is supported (via icacls) and the resulting icacls listing is returned. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to change the file on all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("path", mcp.Required(), mcp.Description("The remote file or directory to change")),
+		mcp.WithString("mode", mcp.Description("Octal permission mode, e.g. '644' or '0755'")),
+		mcp.WithString("owner", mcp.Description("User to set as the owner")),
+		mcp.WithString("owner_group", mcp.Description("Group to set as the group owner (not supported on Windows)")),
+		mcp.WithBoolean("sudo", mcp.Description("Run chmod/chown through non-interactive sudo, required when changing ownership or files not owned by the connecting user (default: false)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *SetFileMode) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, err := request.RequireString("path")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		change := FileModeChange{
+			Path:  path,
+			Mode:  request.GetString("mode", ""),
+			Owner: request.GetString("owner", ""),
+			Group: request.GetString("owner_group", ""),
+			Sudo:  request.GetBool("sudo", false),
+		}
+		if err := change.validate(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			command, err := setFileModeCommand(change, utils.IsWindows(host))
+			if err != nil {
+				return "", err
+			}
+			return execWithOutput(sshClient, command)
+		})
+
+		changes := make([]FileModeResult, 0, len(results))
+		for name, result := range results {
+			hostChange := FileModeResult{Host: name, Path: path}
+			if result.Err != nil {
+				hostChange.Error = result.Err.Error()
+			} else {
+				hostChange.Listing = strings.TrimSpace(result.Result)
+			}
+			changes = append(changes, hostChange)
+		}
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].Host < changes[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": changes}), nil
+	}
+}
+
+// validate checks that a change was requested, the mode is octal and the owner and group cannot be
+// taken as options.
+func (f FileModeChange) validate() error {
+	if f.Path == "" {
+		return errors.New("path cannot be empty")
+	}
+	if f.Mode == "" && f.Owner == "" && f.Group == "" {
+		return errors.New("at least one of mode, owner or owner_group must be provided")
+	}
+	if f.Mode != "" && !octalModeRegexp.MatchString(f.Mode) {
+		return fmt.Errorf("invalid mode %q: must be an octal mode such as 644 or 0755", f.Mode)
+	}
+	if strings.HasPrefix(f.Owner, "-") {
+		return fmt.Errorf("invalid owner %q: cannot start with '-'", f.Owner)
+	}
+	if strings.HasPrefix(f.Group, "-") {
+		return fmt.Errorf("invalid owner_group %q: cannot start with '-'", f.Group)
+	}
+	return nil
+}
+
+// setFileModeCommand returns the command that applies the change and then prints the resulting listing.
+func setFileModeCommand(f FileModeChange, windows bool) (string, error) {
+	if windows {
+		if f.Mode != "" || f.Group != "" {
+			return "", errors.New("mode and owner_group are not supported on Windows hosts, only owner can be changed")
+		}
+		path := utils.PowerShellQuote(f.Path)
+		return utils.PowerShellCommand(fmt.Sprintf(
+			"$ErrorActionPreference = 'Stop'; icacls %s /setowner %s | Out-Null; if ($LASTEXITCODE -ne 0) { exit $LASTEXITCODE }; icacls %s",
+			path, utils.PowerShellQuote(f.Owner), path)), nil
+	}
+
+	sudo := ""
+	if f.Sudo {
+		sudo = "sudo -n "
+	}
+	// '--' ends the options, so a path starting with '-' is not taken as one
+	path := utils.ShellQuote(f.Path)
+	var steps []string
+	if f.Mode != "" {
+		steps = append(steps, fmt.Sprintf("%schmod -- %s %s", sudo, f.Mode, path))
+	}
+	if f.Owner != "" || f.Group != "" {
+		owner := f.Owner
+		if f.Group != "" {
+			owner += ":" + f.Group
+		}
+		steps = append(steps, fmt.Sprintf("%schown -- %s %s", sudo, utils.ShellQuote(owner), path))
+	}
+	steps = append(steps, fmt.Sprintf("ls -ld -- %s", path))
+	return strings.Join(steps, " && "), nil
+}
+
+// execWithOutput runs a command and includes its output in the error when it fails, so the
+// reason (e.g. "Operation not permitted") is not lost.
+func execWithOutput(sshClient *ssh.Client, command string) (string, error) {
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	output, err := session.CombinedOutput(command)
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return string(output), nil
+}
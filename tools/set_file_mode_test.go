@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for SetFileMode tool
+
+func TestSetFileModeCommand_Linux(t *testing.T) {
+	command, err := setFileModeCommand(FileModeChange{Path: "/etc/app.conf", Mode: "640", Owner: "app", Group: "www-data", Sudo: true}, false)
+	require.NoError(t, err)
+	require.Equal(t, "sudo -n chmod -- 640 '/etc/app.conf' && sudo -n chown -- 'app:www-data' '/etc/app.conf' && ls -ld -- '/etc/app.conf'", command)
+
+	command, err = setFileModeCommand(FileModeChange{Path: "/tmp/x", Group: "staff"}, false)
+	require.NoError(t, err)
+	require.Equal(t, "chown -- ':staff' '/tmp/x' && ls -ld -- '/tmp/x'", command)
+
+	// a path starting with '-' is not an option
+	command, err = setFileModeCommand(FileModeChange{Path: "-R", Mode: "777"}, false)
+	require.NoError(t, err)
+	require.Equal(t, "chmod -- 777 '-R' && ls -ld -- '-R'", command)
+}
+
+func TestSetFileModeCommand_Windows(t *testing.T) {
+	command, err := setFileModeCommand(FileModeChange{Path: `C:\app\config.json`, Owner: "Administrators"}, true)
+	require.NoError(t, err)
+	require.Contains(t, powerShellScript(t, command), "icacls 'C:\\app\\config.json' /setowner 'Administrators'")
+
+	_, err = setFileModeCommand(FileModeChange{Path: `C:\app\config.json`, Mode: "644"}, true)
+	require.Error(t, err)
+
+	// double quotes in the path or owner must not end the command line and run what follows
+	command, err = setFileModeCommand(FileModeChange{Path: `C:\x" & whoami & "`, Owner: `a" & net user & "`}, true)
+	require.NoError(t, err)
+	require.NotContains(t, command, "whoami")
+	require.NotContains(t, command, "net user")
+	require.Contains(t, powerShellScript(t, command), `icacls 'C:\x" & whoami & "' /setowner 'a" & net user & "'`)
+}
+
+func TestFileModeChange_Validate(t *testing.T) {
+	require.NoError(t, FileModeChange{Path: "/tmp/x", Mode: "0755"}.validate())
+	require.Error(t, FileModeChange{Path: "/tmp/x"}.validate())
+	require.Error(t, FileModeChange{Path: "/tmp/x", Mode: "u+x"}.validate())
+	require.Error(t, FileModeChange{Path: "/tmp/x", Mode: "999"}.validate())
+	require.Error(t, FileModeChange{Path: "/tmp/x", Owner: "--reference=/etc/shadow"}.validate())
+	require.Error(t, FileModeChange{Path: "/tmp/x", Group: "-R"}.validate())
+}
+
+func TestSetFileMode_InvalidMode(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+
+	tool := &SetFileMode{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"group": "production",
+				"path":  "/tmp/x",
+				"mode":  "rwxr-xr-x",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
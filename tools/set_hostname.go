@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&SetHostname{})
+}
+
+// hostnameRegexp matches a hostname made of RFC 1123 labels separated by dots.
+var hostnameRegexp = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`)
+
+// windowsHostnameMaxLength is the longest computer name Windows accepts without truncating its
+// NetBIOS name.
+const windowsHostnameMaxLength = 15
+
+// SetHostnameResult is the outcome of changing the hostname of a host.
+type SetHostnameResult struct {
+	Host string `json:"host"`
+	// PreviousHostname is the hostname before the change
+	PreviousHostname string `json:"previous_hostname,omitempty"`
+	// Hostname is the hostname read back after the change
+	Hostname string `json:"hostname,omitempty"`
+	// RebootRequired is true when the new hostname only applies after a reboot (Windows)
+	RebootRequired bool `json:"reboot_required,omitempty"`
+	// StoredName is the name the host is stored under after updating it to match
+	StoredName string `json:"stored_name,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SetHostname is a tool that changes the hostname of a remote host.
+type SetHostname struct{}
+
+// IsMutating returns true as the tool changes remote hosts.
+func (c *SetHostname) IsMutating() bool {
+	return true
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *SetHostname) Definition() mcp.Tool {
+	return mcp.NewTool("set_hostname",
+		mcp.WithDescription("Sets the hostname of a single host (hostnamectl set-hostname on Linux, Rename-Computer on Windows) and reads it back to confirm, returning the previous and current hostname. On Windows the new name only applies after a reboot, which is reported with reboot_required. Set update_stored_name to also rename the stored host to the new hostname."),
+		mcp.WithString("group",
+			mcp.Description("Group name containing the single host to rename (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array with the single host identifier in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("hostname", mcp.Required(), mcp.Description("The new hostname, e.g. 'web01' or 'web01.example.com' (at most 15 characters without dots on Windows)")),
+		mcp.WithBoolean("sudo", mcp.Description("Run hostnamectl through non-interactive sudo, not needed when connecting as root (default: true, ignored on Windows)")),
+		mcp.WithBoolean("update_stored_name", mcp.Description("Rename the stored host to the new hostname once it has been set (default: false)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *SetHostname) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		hostname, err := request.RequireString("hostname")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(found) != 1 {
+			return mcp.NewToolResultError(fmt.Sprintf("set_hostname changes a single host, %d hosts were targeted", len(found))), nil
+		}
+		host := found[0]
+		windows := utils.IsWindows(host)
+		if err := validateHostname(hostname, windows); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if request.GetBool("update_stored_name", false) && hostname != host.Name {
+			if _, exists := storageEngine.Get(host.Group, hostname); exists {
+				return mcp.NewToolResultError(fmt.Sprintf("host %s already exists in group %s, cannot update the stored name", hostname, host.Group)), nil
+			}
+		}
+
+		result := SetHostnameResult{Host: host.Name, RebootRequired: windows}
+		output, err := runOnHost(host, setHostnameCommand(hostname, request.GetBool("sudo", true), windows))
+		if err != nil {
+			result.RebootRequired = false
+			result.Error = err.Error()
+			return mcp.NewToolResultStructuredOnly(result), nil
+		}
+		result.PreviousHostname, result.Hostname = parseHostnameOutput(output)
+
+		if request.GetBool("update_stored_name", false) && hostname != host.Name {
+			if err := storageEngine.Rename(host.Group, host.Name, hostname); err != nil {
+				result.Error = fmt.Sprintf("hostname was set but the stored name was not updated: %v", err)
+			} else {
+				result.StoredName = hostname
+			}
+		}
+		return mcp.NewToolResultStructuredOnly(result), nil
+	}
+}
+
+// validateHostname checks the hostname is valid for the operating system of the host.
+func validateHostname(hostname string, windows bool) error {
+	if len(hostname) > 253 || !hostnameRegexp.MatchString(hostname) {
+		return fmt.Errorf("invalid hostname %q: must be letters, digits and hyphens in dot separated labels of at most 63 characters", hostname)
+	}
+	if windows {
+		if strings.Contains(hostname, ".") {
+			return errors.New("a Windows computer name cannot contain dots")
+		}
+		if len(hostname) > windowsHostnameMaxLength {
+			return fmt.Errorf("a Windows computer name cannot be longer than %d characters", windowsHostnameMaxLength)
+		}
+	}
+	return nil
+}
+
+// setHostnameCommand returns the command that prints the current hostname, sets the new one and
+// prints the hostname again.
+func setHostnameCommand(hostname string, sudo bool, windows bool) string {
+	if windows {
+		return utils.PowerShellCommand(fmt.Sprintf(
+			"$ErrorActionPreference = 'Stop'; hostname; Rename-Computer -NewName %s -Force -WarningAction SilentlyContinue; hostname",
+			utils.PowerShellQuote(hostname)))
+	}
+	prefix := ""
+	if sudo {
+		prefix = "sudo -n "
+	}
+	return fmt.Sprintf("hostname && %shostnamectl set-hostname %s && hostname", prefix, utils.ShellQuote(hostname))
+}
+
+// parseHostnameOutput returns the hostnames printed before and after the change.
+func parseHostnameOutput(output string) (previous string, current string) {
+	lines := strings.Fields(output)
+	if len(lines) == 0 {
+		return "", ""
+	}
+	return lines[0], lines[len(lines)-1]
+}
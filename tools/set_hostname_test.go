@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for SetHostname tool
+
+func TestValidateHostname(t *testing.T) {
+	require.NoError(t, validateHostname("web01", false))
+	require.NoError(t, validateHostname("web01.example.com", false))
+	require.NoError(t, validateHostname("WEB-01", true))
+
+	require.Error(t, validateHostname("", false))
+	require.Error(t, validateHostname("-web01", false))
+	require.Error(t, validateHostname("web_01", false))
+	require.Error(t, validateHostname("web01; reboot", false))
+	require.Error(t, validateHostname("web01.example.com", true))
+	require.Error(t, validateHostname("a-very-long-computer-name", true))
+}
+
+func TestSetHostnameCommand(t *testing.T) {
+	require.Equal(t, "hostname && sudo -n hostnamectl set-hostname 'web01' && hostname", setHostnameCommand("web01", true, false))
+	require.Equal(t, "hostname && hostnamectl set-hostname 'web01' && hostname", setHostnameCommand("web01", false, false))
+	require.Contains(t, powerShellScript(t, setHostnameCommand("WEB01", true, true)), "Rename-Computer -NewName 'WEB01' -Force")
+}
+
+func TestParseHostnameOutput(t *testing.T) {
+	previous, current := parseHostnameOutput("old-name\nweb01\n")
+	require.Equal(t, "old-name", previous)
+	require.Equal(t, "web01", current)
+
+	previous, current = parseHostnameOutput("WIN-ABC123\r\nWIN-ABC123\r\n")
+	require.Equal(t, "WIN-ABC123", previous)
+	require.Equal(t, "WIN-ABC123", current)
+}
+
+func TestSetHostname_Invalid(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	addTestHost(t, engine, "production", "server2", "10.0.1.2")
+
+	tool := &SetHostname{}
+	handler := tool.Handler(context.Background(), engine)
+
+	testCases := map[string]map[string]interface{}{
+		"several hosts": {
+			"group":    "production",
+			"hostname": "web01",
+		},
+		"invalid hostname": {
+			"name_of_hosts": []interface{}{"production:server1"},
+			"hostname":      "web 01",
+		},
+		"stored name taken": {
+			"name_of_hosts":      []interface{}{"production:server1"},
+			"hostname":           "server2",
+			"update_stored_name": true,
+		},
+	}
+	for name, args := range testCases {
+		t.Run(name, func(t *testing.T) {
+			request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}}
+			result, err := handler(context.Background(), request)
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&SetMaintenanceMode{})
+}
+
+// SetMaintenanceMode is a tool that toggles the persisted maintenance mode.
+type SetMaintenanceMode struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *SetMaintenanceMode) Definition() mcp.Tool {
+	return mcp.NewTool("set_maintenance_mode",
+		mcp.WithDescription("Enables or disables maintenance mode, e.g. for a change freeze. While enabled, tools that change remote hosts (perform_command, run_command_template, set_file_mode, etc.) refuse to run, read-only tools keep working. The mode is persisted and survives restarts."),
+		mcp.WithBoolean("enabled", mcp.Required(), mcp.Description("True to enter maintenance mode, false to leave it")),
+		mcp.WithString("reason", mcp.Description("Optional reason reported by the refused tools, e.g. 'change freeze until Monday'")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *SetMaintenanceMode) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// the mode applies to every group, not only the ones this server is scoped to
+		if allowedGroups != nil {
+			return mcp.NewToolResultError("not authorized: maintenance mode cannot be changed when the server is restricted with --allowed-groups"), nil
+		}
+
+		enabled, err := request.RequireBool("enabled")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		mode := storage.MaintenanceMode{Enabled: enabled}
+		if enabled {
+			mode.Reason = request.GetString("reason", "")
+			mode.Since = time.Now().UTC()
+		}
+		if err := storageEngine.SetMaintenanceMode(mode); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultStructuredOnly(mode), nil
+	}
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for SetMaintenanceMode tool
+
+func TestSetMaintenanceMode_Toggle(t *testing.T) {
+	engine := setupTestStorage(t)
+
+	tool := &SetMaintenanceMode{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"enabled": true,
+				"reason":  "change freeze",
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	mode, err := engine.GetMaintenanceMode()
+	require.NoError(t, err)
+	require.True(t, mode.Enabled)
+	require.Equal(t, "change freeze", mode.Reason)
+
+	request.Params.Arguments = map[string]interface{}{"enabled": false}
+	result, err = handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	mode, err = engine.GetMaintenanceMode()
+	require.NoError(t, err)
+	require.False(t, mode.Enabled)
+	require.Empty(t, mode.Reason)
+}
+
+func TestSetMaintenanceMode_MissingEnabled(t *testing.T) {
+	engine := setupTestStorage(t)
+
+	tool := &SetMaintenanceMode{}
+	handler := tool.Handler(context.Background(), engine)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
+
+func TestSetMaintenanceMode_AllowedGroups(t *testing.T) {
+	restrictGroups(t, "production")
+	engine := setupTestStorage(t)
+
+	tool := &SetMaintenanceMode{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{"enabled": true}}}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "not authorized")
+
+	mode, err := engine.GetMaintenanceMode()
+	require.NoError(t, err)
+	require.False(t, mode.Enabled)
+}
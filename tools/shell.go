@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// shellReadOptions are the shared arguments of the shell tools controlling how output is read.
+func shellReadOptions(defaultWait float64) []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithNumber("wait_seconds",
+			mcp.Description(fmt.Sprintf("Seconds to wait for output to arrive and settle before returning, 0 returns what is buffered right away (default: %g)", defaultWait)),
+		),
+		mcp.WithString("until",
+			mcp.Description("Regular expression to keep waiting for, up to wait_seconds, instead of returning once output settles, e.g. a prompt like '\\$ $' or 'Password:'. 'matched' reports whether it was seen"),
+		),
+	}
+}
+
+// shellReadFromRequest reads the wait and the optional pattern to wait for.
+func shellReadFromRequest(request mcp.CallToolRequest, defaultWait float64) (time.Duration, *regexp.Regexp, error) {
+	seconds := request.GetFloat("wait_seconds", defaultWait)
+	if seconds < 0 {
+		return 0, nil, fmt.Errorf("wait_seconds cannot be negative")
+	}
+	var until *regexp.Regexp
+	if pattern := request.GetString("until", ""); pattern != "" {
+		var err error
+		until, err = regexp.Compile(pattern)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid until pattern: %w", err)
+		}
+	}
+	return time.Duration(seconds * float64(time.Second)), until, nil
+}
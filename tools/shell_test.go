@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/sessions"
+)
+
+func TestShellReadFromRequest(t *testing.T) {
+	wait, until, err := shellReadFromRequest(mcp.CallToolRequest{}, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Second, wait)
+	require.Nil(t, until)
+
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+		"wait_seconds": 0.5,
+		"until":        `Password:\s*$`,
+	}}}
+	wait, until, err = shellReadFromRequest(request, 2)
+	require.NoError(t, err)
+	require.Equal(t, 500*time.Millisecond, wait)
+	require.True(t, until.MatchString("[sudo] Password: "))
+}
+
+func TestShellTools_UnknownSession(t *testing.T) {
+	engine := setupTestStorage(t)
+	manager := sessions.NewManager()
+	t.Cleanup(manager.CloseAll)
+	shellTools := map[string]Tool{
+		"send_input":  &SendInput{sessionManager: manager},
+		"read_output": &ReadOutput{sessionManager: manager},
+		"close_shell": &CloseShell{sessionManager: manager},
+	}
+	for name, tool := range shellTools {
+		t.Run(name, func(t *testing.T) {
+			handler := tool.Handler(context.Background(), engine)
+			request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+				"session_id": "missing",
+				"input":      "ls",
+			}}}
+			result, err := handler(context.Background(), request)
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+			require.Contains(t, result.Content[0].(mcp.TextContent).Text, "shell session missing not found")
+		})
+	}
+}
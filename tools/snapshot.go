@@ -0,0 +1,284 @@
+package tools
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&Snapshot{})
+}
+
+// snapshotSectionPrefix starts the marker line printed before each section of the snapshot script.
+const snapshotSectionPrefix = "@@SNAPSHOT:"
+
+// snapshotScript gathers every section of the snapshot in a single round trip on Linux.
+const snapshotScript = `echo '@@SNAPSHOT:os@@'; cat /etc/os-release 2>/dev/null; ` +
+	`echo '@@SNAPSHOT:kernel@@'; uname -srm; ` +
+	`echo '@@SNAPSHOT:uptime@@'; cat /proc/uptime 2>/dev/null; ` +
+	`echo '@@SNAPSHOT:load@@'; cat /proc/loadavg 2>/dev/null; ` +
+	`echo '@@SNAPSHOT:memory@@'; cat /proc/meminfo 2>/dev/null; ` +
+	`echo '@@SNAPSHOT:disk@@'; df -P -k 2>/dev/null; ` +
+	`echo '@@SNAPSHOT:users@@'; who 2>/dev/null; ` +
+	`echo '@@SNAPSHOT:env@@'; env -0 2>/dev/null || env`
+
+// snapshotWindowsScript gathers the snapshot on Windows, printing the sections in the same
+// format as the Linux script.
+const snapshotWindowsScript = `$os = Get-CimInstance Win32_OperatingSystem; ` +
+	`'@@SNAPSHOT:os@@'; 'PRETTY_NAME=' + $os.Caption; ` +
+	`'@@SNAPSHOT:kernel@@'; 'Windows ' + $os.Version + ' ' + $os.OSArchitecture; ` +
+	`'@@SNAPSHOT:uptime@@'; [string][math]::Floor(((Get-Date) - $os.LastBootUpTime).TotalSeconds); ` +
+	`'@@SNAPSHOT:memory@@'; 'MemTotal: ' + $os.TotalVisibleMemorySize + ' kB'; 'MemAvailable: ' + $os.FreePhysicalMemory + ' kB'; ` +
+	`'@@SNAPSHOT:disk@@'; 'Filesystem 1024-blocks Used Available Capacity Mounted'; ` +
+	`Get-CimInstance Win32_LogicalDisk -Filter 'DriveType=3' | ForEach-Object { $size = [math]::Floor($_.Size / 1024); $free = [math]::Floor($_.FreeSpace / 1024); $pct = if ($size -gt 0) { [math]::Round(($size - $free) * 100 / $size) } else { 0 }; '{0} {1} {2} {3} {4}% {0}' -f $_.DeviceID, $size, ($size - $free), $free, $pct }; ` +
+	`'@@SNAPSHOT:users@@'; (Get-CimInstance Win32_ComputerSystem).UserName; ` +
+	`'@@SNAPSHOT:env@@'; Get-ChildItem env: | ForEach-Object { $_.Name + '=' + $_.Value }`
+
+// SnapshotMemory is the memory usage of a host in kilobytes.
+type SnapshotMemory struct {
+	TotalKB     int64 `json:"total_kb"`
+	AvailableKB int64 `json:"available_kb"`
+	SwapTotalKB int64 `json:"swap_total_kb,omitempty"`
+	SwapFreeKB  int64 `json:"swap_free_kb,omitempty"`
+}
+
+// SnapshotDisk is the usage of a single mounted filesystem in kilobytes.
+type SnapshotDisk struct {
+	Filesystem  string `json:"filesystem"`
+	Mount       string `json:"mount"`
+	SizeKB      int64  `json:"size_kb"`
+	UsedKB      int64  `json:"used_kb"`
+	AvailableKB int64  `json:"available_kb"`
+	UsePercent  int    `json:"use_percent"`
+}
+
+// SnapshotResult is the environment snapshot of a single host.
+type SnapshotResult struct {
+	Host          string            `json:"host"`
+	OS            string            `json:"os,omitempty"`
+	Kernel        string            `json:"kernel,omitempty"`
+	UptimeSeconds int64             `json:"uptime_seconds,omitempty"`
+	Load          []float64         `json:"load,omitempty"`
+	Memory        *SnapshotMemory   `json:"memory,omitempty"`
+	Disks         []SnapshotDisk    `json:"disks,omitempty"`
+	Users         []string          `json:"users,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// Snapshot is a tool that gathers a bundle of troubleshooting context from hosts.
+type Snapshot struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *Snapshot) Definition() mcp.Tool {
+	return mcp.NewTool("snapshot",
+		mcp.WithDescription("Gathers a snapshot of each host's environment in a single round trip for troubleshooting: OS, kernel, uptime, load averages (1, 5 and 15 minutes), memory, disk usage per filesystem, logged-in users and the environment variables commands run with. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to snapshot all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *Snapshot) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			if utils.IsWindows(host) {
+				return execWithOutput(sshClient, utils.PowerShellCommand(snapshotWindowsScript))
+			}
+			return execWithOutput(sshClient, snapshotScript)
+		})
+
+		snapshots := make([]SnapshotResult, 0, len(results))
+		for name, result := range results {
+			snapshot := SnapshotResult{Host: name}
+			if result.Err != nil {
+				snapshot.Error = result.Err.Error()
+			} else {
+				parseSnapshot(result.Result, &snapshot)
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+		sort.Slice(snapshots, func(i, j int) bool {
+			return snapshots[i].Host < snapshots[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": snapshots}), nil
+	}
+}
+
+// splitSnapshotSections splits the output of the snapshot script into its sections by name.
+func splitSnapshotSections(output string) map[string]string {
+	sections := make(map[string]string)
+	var name string
+	var body strings.Builder
+	flush := func() {
+		if name != "" {
+			sections[name] = body.String()
+		}
+		body.Reset()
+	}
+	for _, line := range strings.SplitAfter(output, "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if section, ok := strings.CutPrefix(trimmed, snapshotSectionPrefix); ok && strings.HasSuffix(section, "@@") {
+			flush()
+			name = strings.TrimSuffix(section, "@@")
+			continue
+		}
+		body.WriteString(line)
+	}
+	flush()
+	return sections
+}
+
+// parseSnapshot parses the output of the snapshot script into the snapshot.
+func parseSnapshot(output string, snapshot *SnapshotResult) {
+	sections := splitSnapshotSections(output)
+
+	osRelease := utils.ParseOSRelease(sections["os"])
+	snapshot.OS = osRelease["PRETTY_NAME"]
+	if snapshot.OS == "" {
+		snapshot.OS = strings.TrimSpace(osRelease["NAME"] + " " + osRelease["VERSION"])
+	}
+	snapshot.Kernel = strings.TrimSpace(sections["kernel"])
+
+	if fields := strings.Fields(sections["uptime"]); len(fields) > 0 {
+		if uptime, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			snapshot.UptimeSeconds = int64(uptime)
+		}
+	}
+
+	if fields := strings.Fields(sections["load"]); len(fields) >= 3 {
+		load := make([]float64, 0, 3)
+		for _, field := range fields[:3] {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				load = nil
+				break
+			}
+			load = append(load, value)
+		}
+		snapshot.Load = load
+	}
+
+	snapshot.Memory = parseMemInfo(sections["memory"])
+	snapshot.Disks = parseDfOutput(sections["disk"])
+	snapshot.Users = nonEmptyLines(sections["users"])
+	snapshot.Env = parseEnvOutput(sections["env"])
+}
+
+// nonEmptyLines returns the trimmed non-empty lines of the output.
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseMemInfo parses /proc/meminfo, returning nil when the totals are missing.
+func parseMemInfo(output string) *SnapshotMemory {
+	values := make(map[string]int64)
+	for _, line := range nonEmptyLines(output) {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		if n, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			values[key] = n
+		}
+	}
+	total, ok := values["MemTotal"]
+	if !ok {
+		return nil
+	}
+	available, ok := values["MemAvailable"]
+	if !ok {
+		// kernels before 3.14 do not report MemAvailable
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+	return &SnapshotMemory{
+		TotalKB:     total,
+		AvailableKB: available,
+		SwapTotalKB: values["SwapTotal"],
+		SwapFreeKB:  values["SwapFree"],
+	}
+}
+
+// parseDfOutput parses the output of 'df -P -k', skipping the header and malformed lines.
+func parseDfOutput(output string) []SnapshotDisk {
+	var disks []SnapshotDisk
+	for i, line := range nonEmptyLines(output) {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) < 6 {
+			continue
+		}
+		size, err1 := strconv.ParseInt(fields[1], 10, 64)
+		used, err2 := strconv.ParseInt(fields[2], 10, 64)
+		available, err3 := strconv.ParseInt(fields[3], 10, 64)
+		percent, err4 := strconv.Atoi(strings.TrimSuffix(fields[4], "%"))
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			continue
+		}
+		disks = append(disks, SnapshotDisk{
+			Filesystem:  fields[0],
+			Mount:       strings.Join(fields[5:], " "),
+			SizeKB:      size,
+			UsedKB:      used,
+			AvailableKB: available,
+			UsePercent:  percent,
+		})
+	}
+	return disks
+}
+
+// parseEnvOutput parses the output of 'env -0', or of 'env' when NUL separation is not supported.
+// Values spanning multiple lines are only kept intact with 'env -0'.
+func parseEnvOutput(output string) map[string]string {
+	separator := "\n"
+	if strings.Contains(output, "\x00") {
+		separator = "\x00"
+	}
+	env := make(map[string]string)
+	for _, entry := range strings.Split(output, separator) {
+		if separator == "\n" {
+			entry = strings.TrimRight(entry, "\r")
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || strings.ContainsAny(name, " \t\n") {
+			continue
+		}
+		env[name] = value
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
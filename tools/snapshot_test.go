@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Snapshot tool
+
+func TestParseSnapshot_Linux(t *testing.T) {
+	output := "@@SNAPSHOT:os@@\n" +
+		"NAME=\"Ubuntu\"\n" +
+		"PRETTY_NAME=\"Ubuntu 22.04.4 LTS\"\n" +
+		"@@SNAPSHOT:kernel@@\n" +
+		"Linux 5.15.0-105-generic x86_64\n" +
+		"@@SNAPSHOT:uptime@@\n" +
+		"86400.52 170000.10\n" +
+		"@@SNAPSHOT:load@@\n" +
+		"0.50 0.25 0.10 1/234 5678\n" +
+		"@@SNAPSHOT:memory@@\n" +
+		"MemTotal:        2000000 kB\n" +
+		"MemFree:          500000 kB\n" +
+		"MemAvailable:    1500000 kB\n" +
+		"SwapTotal:       1000000 kB\n" +
+		"SwapFree:         900000 kB\n" +
+		"@@SNAPSHOT:disk@@\n" +
+		"Filesystem     1024-blocks    Used Available Capacity Mounted on\n" +
+		"/dev/sda1         10000000 4000000   6000000      40% /\n" +
+		"/dev/sdb1          2000000 1000000   1000000      50% /mnt/my data\n" +
+		"@@SNAPSHOT:users@@\n" +
+		"deploy   pts/0        2024-05-01 10:00 (10.0.0.5)\n" +
+		"@@SNAPSHOT:env@@\n" +
+		"HOME=/home/deploy\x00MOTD=line one\nline two\x00PATH=/usr/bin:/bin\x00"
+
+	snapshot := SnapshotResult{Host: "web01"}
+	parseSnapshot(output, &snapshot)
+
+	require.Equal(t, SnapshotResult{
+		Host:          "web01",
+		OS:            "Ubuntu 22.04.4 LTS",
+		Kernel:        "Linux 5.15.0-105-generic x86_64",
+		UptimeSeconds: 86400,
+		Load:          []float64{0.5, 0.25, 0.1},
+		Memory: &SnapshotMemory{
+			TotalKB:     2000000,
+			AvailableKB: 1500000,
+			SwapTotalKB: 1000000,
+			SwapFreeKB:  900000,
+		},
+		Disks: []SnapshotDisk{
+			{Filesystem: "/dev/sda1", Mount: "/", SizeKB: 10000000, UsedKB: 4000000, AvailableKB: 6000000, UsePercent: 40},
+			{Filesystem: "/dev/sdb1", Mount: "/mnt/my data", SizeKB: 2000000, UsedKB: 1000000, AvailableKB: 1000000, UsePercent: 50},
+		},
+		Users: []string{"deploy   pts/0        2024-05-01 10:00 (10.0.0.5)"},
+		Env: map[string]string{
+			"HOME": "/home/deploy",
+			"MOTD": "line one\nline two",
+			"PATH": "/usr/bin:/bin",
+		},
+	}, snapshot)
+}
+
+func TestParseSnapshot_WindowsWithoutLoad(t *testing.T) {
+	output := "@@SNAPSHOT:os@@\r\n" +
+		"PRETTY_NAME=Microsoft Windows Server 2022 Standard\r\n" +
+		"@@SNAPSHOT:kernel@@\r\n" +
+		"Windows 10.0.20348 64-bit\r\n" +
+		"@@SNAPSHOT:uptime@@\r\n" +
+		"3600\r\n" +
+		"@@SNAPSHOT:memory@@\r\n" +
+		"MemTotal: 8000000 kB\r\n" +
+		"MemAvailable: 4000000 kB\r\n" +
+		"@@SNAPSHOT:users@@\r\n" +
+		"@@SNAPSHOT:env@@\r\n" +
+		"USERNAME=Administrator\r\n"
+
+	snapshot := SnapshotResult{Host: "win01"}
+	parseSnapshot(output, &snapshot)
+
+	require.Equal(t, "Microsoft Windows Server 2022 Standard", snapshot.OS)
+	require.Equal(t, int64(3600), snapshot.UptimeSeconds)
+	require.Nil(t, snapshot.Load)
+	require.Equal(t, &SnapshotMemory{TotalKB: 8000000, AvailableKB: 4000000}, snapshot.Memory)
+	require.Empty(t, snapshot.Users)
+	require.Equal(t, map[string]string{"USERNAME": "Administrator"}, snapshot.Env)
+}
+
+func TestParseMemInfo_WithoutMemAvailable(t *testing.T) {
+	memory := parseMemInfo("MemTotal: 1000 kB\nMemFree: 100 kB\nBuffers: 50 kB\nCached: 200 kB\n")
+	require.Equal(t, int64(350), memory.AvailableKB)
+	require.Nil(t, parseMemInfo(""))
+}
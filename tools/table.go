@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+)
+
+// maxTableCellLength is the longest output line shown in a table cell before it is truncated.
+const maxTableCellLength = 80
+
+// resultFormatOption is the shared format argument of the command tools.
+func resultFormatOption() mcp.ToolOption {
+	return mcp.WithString("format",
+		mcp.Description("How to return the results: 'structured' (default) for the full result object, 'markdown' for a table of host, status, exit code and first line of output, 'csv' for the same columns as CSV text to paste into a spreadsheet, or 'both' for the structured result with the Markdown table as text"),
+		mcp.Enum("structured", "markdown", "csv", "both"),
+	)
+}
+
+// resultFormatFromRequest reads and validates the format argument.
+func resultFormatFromRequest(request mcp.CallToolRequest) (string, error) {
+	format := request.GetString("format", "structured")
+	switch format {
+	case "structured", "markdown", "csv", "both":
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid format: must be one of structured, markdown, csv, both")
+}
+
+// commandStateResult returns the command state in the requested result format.
+func commandStateResult(state *commands.CommandState, format string) *mcp.CallToolResult {
+	switch format {
+	case "markdown":
+		return mcp.NewToolResultText(renderMarkdownTable(state))
+	case "csv":
+		return mcp.NewToolResultText(renderCSV(state))
+	case "both":
+		return mcp.NewToolResultStructured(state, renderMarkdownTable(state))
+	}
+	return mcp.NewToolResultStructuredOnly(state)
+}
+
+// renderCSV renders the per-host results of a command as CSV with a header row and the host,
+// status, exit code (empty when unknown) and full first line of output.
+func renderCSV(state *commands.CommandState) string {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	_ = w.Write([]string{"host", "status", "exit_code", "first_line"})
+	for _, host := range state.Hosts {
+		result, ok := state.Results[host.Name]
+		status, exitCode := hostResultStatus(state.Status, result, ok)
+		if exitCode == "-" {
+			exitCode = ""
+		}
+		_ = w.Write([]string{host.Group + ":" + host.Name, status, exitCode, firstOutputLine(result.Result)})
+	}
+	// writing to a strings.Builder cannot fail
+	w.Flush()
+	return sb.String()
+}
+
+// renderMarkdownTable renders the per-host results of a command as a Markdown table with the
+// host, status, exit code and first line of output, for quick scanning by a human operator.
+func renderMarkdownTable(state *commands.CommandState) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Command `%s` (%s): %s\n\n", state.ID, state.Command, state.Status)
+	sb.WriteString("| Host | Status | Exit Code | Output |\n")
+	sb.WriteString("| --- | --- | --- | --- |\n")
+	for _, host := range state.Hosts {
+		result, ok := state.Results[host.Name]
+		status, exitCode := hostResultStatus(state.Status, result, ok)
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n",
+			markdownCell(host.Group+":"+host.Name),
+			markdownCell(status),
+			exitCode,
+			markdownCell(outputCell(result)))
+	}
+	return sb.String()
+}
+
+// hostResultStatus summarizes a single host's result as a short status and exit code ("-" when unknown).
+func hostResultStatus(status commands.CommandStatus, result commands.CommandResult, ok bool) (string, string) {
+	if !ok {
+		if status.IsTerminal() {
+			return "no result", "-"
+		}
+		return "pending", "-"
+	}
+	if result.Err == nil && !status.IsTerminal() {
+		return "running", "-"
+	}
+
+	exitCode := "-"
+	if code, ok := result.ExitCode(); ok {
+		exitCode = strconv.Itoa(code)
+	}
+	var unexpected *commands.UnexpectedExitCodeError
+	switch {
+	case errors.As(result.Err, &unexpected):
+		return "unexpected exit", exitCode
+	case result.Skipped:
+		return "skipped", exitCode
+	case result.AuthFailed:
+		return "auth failed", exitCode
+	case result.ConnectFailed:
+		return "connect failed", exitCode
+	case result.TimedOut && result.TimeoutReason != "":
+		return result.TimeoutReason + " timeout", exitCode
+	case result.TimedOut:
+		return "timed out", exitCode
+	case result.Err != nil:
+		return "error", exitCode
+	}
+	return "ok", exitCode
+}
+
+// outputCell returns the first line of the output of a host, or a marker when the command
+// succeeded without output so it is not mistaken for a missing result.
+func outputCell(result commands.CommandResult) string {
+	if result.Empty {
+		return "(no output)"
+	}
+	return firstLine(result.Result)
+}
+
+// firstLine returns the first non-empty line of the output, truncated to fit in a table cell.
+func firstLine(output string) string {
+	line := firstOutputLine(output)
+	if len(line) > maxTableCellLength {
+		line = line[:maxTableCellLength] + "…"
+	}
+	return line
+}
+
+// firstOutputLine returns the first non-empty line of the output.
+func firstOutputLine(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// markdownCell escapes a value so it can be placed in a Markdown table cell.
+func markdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+)
+
+func TestRenderMarkdownTable(t *testing.T) {
+	state := &commands.CommandState{
+		ID:      "cmd-1",
+		Command: "cat /etc/hostname",
+		Status:  commands.CommandStatusFailed,
+		Hosts: []commands.CommandHost{
+			{Group: "prod", Name: "web1"},
+			{Group: "prod", Name: "web2"},
+			{Group: "prod", Name: "db1"},
+		},
+		Results: map[string]commands.CommandResult{
+			"web1": {Host: "web1", Result: "\nweb1 | primary\nsecond line\n"},
+			"web2": {Host: "web2", Err: errors.New("failed to connect: refused"), ConnectFailed: true},
+		},
+	}
+
+	table := renderMarkdownTable(state)
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	require.Equal(t, "Command `cmd-1` (cat /etc/hostname): failed", lines[0])
+	require.Equal(t, "| Host | Status | Exit Code | Output |", lines[2])
+	require.Equal(t, `| prod:web1 | ok | 0 | web1 \| primary |`, lines[4])
+	require.Equal(t, "| prod:web2 | connect failed | - |  |", lines[5])
+	require.Equal(t, "| prod:db1 | no result | - |  |", lines[6])
+}
+
+func TestRenderMarkdownTable_EmptyOutput(t *testing.T) {
+	state := &commands.CommandState{
+		ID:      "cmd-1",
+		Command: "grep error /var/log/app.log",
+		Status:  commands.CommandStatusCompleted,
+		Hosts:   []commands.CommandHost{{Group: "prod", Name: "web1"}},
+		Results: map[string]commands.CommandResult{
+			"web1": {Host: "web1", Empty: true},
+		},
+	}
+
+	lines := strings.Split(strings.TrimSpace(renderMarkdownTable(state)), "\n")
+	require.Equal(t, "| prod:web1 | ok | 0 | (no output) |", lines[4])
+}
+
+func TestHostResultStatus_Running(t *testing.T) {
+	status, exitCode := hostResultStatus(commands.CommandStatusRunning, commands.CommandResult{Result: "partial"}, true)
+	require.Equal(t, "running", status)
+	require.Equal(t, "-", exitCode)
+
+	status, _ = hostResultStatus(commands.CommandStatusRunning, commands.CommandResult{}, false)
+	require.Equal(t, "pending", status)
+}
+
+func TestHostResultStatus_TimedOut(t *testing.T) {
+	result := commands.CommandResult{Err: errors.New("command timed out: no output for 30s"), TimedOut: true}
+	status, exitCode := hostResultStatus(commands.CommandStatusFailed, result, true)
+	require.Equal(t, "timed out", status)
+	require.Equal(t, "-", exitCode)
+
+	for reason, expected := range map[string]string{
+		commands.TimeoutReasonIdle: "idle timeout",
+		commands.TimeoutReasonHard: "hard timeout",
+	} {
+		result := commands.CommandResult{Err: &commands.TimeoutError{Reason: reason, After: time.Minute}, TimedOut: true, TimeoutReason: reason}
+		status, _ := hostResultStatus(commands.CommandStatusFailed, result, true)
+		require.Equal(t, expected, status)
+	}
+}
+
+func TestFirstLine_Truncates(t *testing.T) {
+	line := firstLine(strings.Repeat("x", maxTableCellLength+10))
+	require.Equal(t, strings.Repeat("x", maxTableCellLength)+"…", line)
+}
+
+func TestRenderCSV(t *testing.T) {
+	state := &commands.CommandState{
+		ID:      "cmd-1",
+		Command: "cat /etc/motd",
+		Status:  commands.CommandStatusFailed,
+		Hosts: []commands.CommandHost{
+			{Group: "prod", Name: "web1"},
+			{Group: "prod", Name: "web2"},
+		},
+		Results: map[string]commands.CommandResult{
+			"web1": {Host: "web1", Result: `hello, "world"` + "\nsecond line\n"},
+			"web2": {Host: "web2", Err: errors.New("failed to connect: refused"), ConnectFailed: true},
+		},
+	}
+
+	require.Equal(t, "host,status,exit_code,first_line\n"+
+		`prod:web1,ok,0,"hello, ""world"""`+"\n"+
+		"prod:web2,connect failed,,\n", renderCSV(state))
+}
+
+func TestResultFormatFromRequest_Invalid(t *testing.T) {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"format": "xml"}
+	_, err := resultFormatFromRequest(request)
+	require.Error(t, err)
+}
+
+func TestHostResultStatus_AuthFailed(t *testing.T) {
+	result := commands.CommandResult{Err: errors.New("failed to connect: unable to authenticate"), ConnectFailed: true, AuthFailed: true}
+	status, exitCode := hostResultStatus(commands.CommandStatusConnectFailed, result, true)
+	require.Equal(t, "auth failed", status)
+	require.Equal(t, "-", exitCode)
+}
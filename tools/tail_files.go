@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+// defaultTailLines is the number of lines read from each file without an n.
+const defaultTailLines = 50
+
+// maxTailLines is the most lines that can be read from each file.
+const maxTailLines = 10000
+
+// tailErrorsMarker separates the output of tail from its error messages.
+const tailErrorsMarker = "__SSH_MCP_TAIL_ERRORS__"
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&TailFiles{})
+}
+
+// TailedFile is the end of a single file, or why it could not be read.
+type TailedFile struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// TailFilesResult is the end of each requested file on a single host, keyed by path.
+type TailFilesResult struct {
+	Host  string                `json:"host"`
+	Files map[string]TailedFile `json:"files"`
+	Error string                `json:"error,omitempty"`
+}
+
+// TailFiles is a tool that reads the last lines of several files in one round trip.
+type TailFiles struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *TailFiles) Definition() mcp.Tool {
+	return mcp.NewTool("tail_files",
+		mcp.WithDescription("Reads the last n lines of several files on each host with a single 'tail' (Get-Content -Tail on Windows) and returns the output keyed by file per host, e.g. to grab all relevant logs during an incident in one call. A file that cannot be read gets an error without failing the others. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to read the files on all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("paths",
+			mcp.Required(),
+			mcp.Description("Remote paths of the files to read, e.g. ['/var/log/syslog', '/var/log/nginx/error.log']"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithNumber("n", mcp.Description(fmt.Sprintf("Number of lines to read from the end of each file (default: %d, at most %d)", defaultTailLines, maxTailLines))),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *TailFiles) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		paths, err := tailPathsFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		n := request.GetInt("n", defaultTailLines)
+		if n < 1 || n > maxTailLines {
+			return mcp.NewToolResultError(fmt.Sprintf("n must be between 1 and %d", maxTailLines)), nil
+		}
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			output, err := execWithOutput(sshClient, tailFilesCommand(paths, n, utils.IsWindows(host)))
+			if err != nil {
+				return "", fmt.Errorf("failed to tail files: %w", err)
+			}
+			return output, nil
+		})
+
+		hosts := make([]TailFilesResult, 0, len(results))
+		for name, result := range results {
+			hostFiles := TailFilesResult{Host: name, Files: map[string]TailedFile{}}
+			if result.Err != nil {
+				hostFiles.Error = result.Err.Error()
+			} else {
+				hostFiles.Files = parseTailOutput(result.Result, paths)
+			}
+			hosts = append(hosts, hostFiles)
+		}
+		sort.Slice(hosts, func(i, j int) bool {
+			return hosts[i].Host < hosts[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": hosts}), nil
+	}
+}
+
+// tailPathsFromRequest reads the paths argument, rejecting empty and duplicate paths.
+func tailPathsFromRequest(request mcp.CallToolRequest) ([]string, error) {
+	paths := request.GetStringSlice("paths", nil)
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("paths must contain at least one path")
+	}
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if path == "" {
+			return nil, fmt.Errorf("paths cannot contain an empty path")
+		}
+		if seen[path] {
+			return nil, fmt.Errorf("path %s is listed more than once", path)
+		}
+		seen[path] = true
+	}
+	return paths, nil
+}
+
+// tailFilesCommand returns the command printing the last n lines of each file under a
+// "==> path <==" header, followed by the marker and the error messages of unreadable files.
+func tailFilesCommand(paths []string, n int, windows bool) string {
+	if windows {
+		quoted := make([]string, len(paths))
+		for i, path := range paths {
+			quoted[i] = utils.PowerShellQuote(path)
+		}
+		return utils.PowerShellCommand(fmt.Sprintf(
+			"$errs = @(); foreach ($f in @(%s)) { '==> ' + $f + ' <=='; try { Get-Content -LiteralPath $f -Tail %d -ErrorAction Stop } catch { $errs += $f + ': ' + $_.Exception.Message } }; '%s'; $errs",
+			strings.Join(quoted, ", "), n, tailErrorsMarker))
+	}
+	quoted := make([]string, len(paths))
+	for i, path := range paths {
+		quoted[i] = utils.ShellQuote(path)
+	}
+	// -v is not portable, so with a single file tail prints no header and it is added instead.
+	// stderr is captured separately as it is not ordered with the buffered stdout of tail.
+	header := ""
+	if len(paths) == 1 {
+		header = fmt.Sprintf("printf '==> %%s <==\\n' %s; ", quoted[0])
+	}
+	return fmt.Sprintf("%s{ errs=$(tail -n %d -- %s 2>&1 >&3); } 3>&1; echo %s; printf '%%s\\n' \"$errs\"",
+		header, n, strings.Join(quoted, " "), tailErrorsMarker)
+}
+
+// parseTailOutput splits the output of tailFilesCommand into the output of each path. Error
+// messages are attributed to the path they mention.
+func parseTailOutput(output string, paths []string) map[string]TailedFile {
+	requested := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		requested[path] = true
+	}
+
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	body, errs, _ := strings.Cut(output, tailErrorsMarker+"\n")
+
+	sections := make(map[string][]string, len(paths))
+	var current string
+	for _, line := range strings.Split(body, "\n") {
+		if path, ok := parseTailHeader(line); ok && requested[path] {
+			current = path
+			sections[path] = []string{}
+			continue
+		}
+		if current != "" {
+			sections[current] = append(sections[current], line)
+		}
+	}
+
+	files := make(map[string]TailedFile, len(paths))
+	for _, path := range paths {
+		lines := sections[path]
+		// tail separates the sections with a blank line, and the output ends with a newline
+		for len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		file := TailedFile{Output: strings.Join(lines, "\n")}
+		if len(lines) > 0 {
+			file.Output += "\n"
+		}
+		files[path] = file
+	}
+
+	// attribute each error to the longest path it mentions, so /var/log/a.log is not blamed
+	// for an error of /var/log/a.log.1
+	byLength := append([]string(nil), paths...)
+	sort.SliceStable(byLength, func(i, j int) bool {
+		return len(byLength[i]) > len(byLength[j])
+	})
+	for _, line := range strings.Split(strings.TrimSpace(errs), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		for _, path := range byLength {
+			if strings.Contains(line, path) {
+				file := files[path]
+				if file.Error != "" {
+					file.Error += "; "
+				}
+				file.Error += line
+				files[path] = file
+				break
+			}
+		}
+	}
+	return files
+}
+
+// parseTailHeader returns the path of a "==> path <==" header line.
+func parseTailHeader(line string) (string, bool) {
+	if !strings.HasPrefix(line, "==> ") || !strings.HasSuffix(line, " <==") || len(line) < 8 {
+		return "", false
+	}
+	return line[4 : len(line)-4], true
+}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for TailFiles tool
+
+func TestParseTailOutput_MultipleFiles(t *testing.T) {
+	output := "==> /var/log/syslog <==\n" +
+		"Oct 16 10:00:01 web1 CRON[1]: job\n" +
+		"Oct 16 10:00:02 web1 CRON[1]: done\n" +
+		"\n" +
+		"==> /var/log/nginx/error.log <==\n" +
+		"2026/10/16 10:00:03 [error] upstream timed out\n" +
+		tailErrorsMarker + "\n" +
+		"tail: cannot open '/var/log/missing.log' for reading: No such file or directory\n"
+
+	files := parseTailOutput(output, []string{"/var/log/syslog", "/var/log/nginx/error.log", "/var/log/missing.log"})
+	require.Equal(t, map[string]TailedFile{
+		"/var/log/syslog": {
+			Output: "Oct 16 10:00:01 web1 CRON[1]: job\nOct 16 10:00:02 web1 CRON[1]: done\n",
+		},
+		"/var/log/nginx/error.log": {
+			Output: "2026/10/16 10:00:03 [error] upstream timed out\n",
+		},
+		"/var/log/missing.log": {
+			Error: "tail: cannot open '/var/log/missing.log' for reading: No such file or directory",
+		},
+	}, files)
+}
+
+func TestParseTailOutput_ErrorAttributedToLongestPath(t *testing.T) {
+	output := "==> /var/log/app.log <==\nready\n" +
+		tailErrorsMarker + "\n" +
+		"tail: /var/log/app.log.1: Permission denied\n"
+
+	files := parseTailOutput(output, []string{"/var/log/app.log", "/var/log/app.log.1"})
+	require.Equal(t, "ready\n", files["/var/log/app.log"].Output)
+	require.Empty(t, files["/var/log/app.log"].Error)
+	require.Equal(t, "tail: /var/log/app.log.1: Permission denied", files["/var/log/app.log.1"].Error)
+}
+
+func TestParseTailOutput_Windows(t *testing.T) {
+	output := "==> C:\\logs\\a.log <==\r\nline 1\r\nline 2\r\n" +
+		"==> C:\\logs\\b.log <==\r\n" +
+		tailErrorsMarker + "\r\n" +
+		"C:\\logs\\b.log: Cannot find path 'C:\\logs\\b.log' because it does not exist.\r\n"
+
+	files := parseTailOutput(output, []string{`C:\logs\a.log`, `C:\logs\b.log`})
+	require.Equal(t, "line 1\nline 2\n", files[`C:\logs\a.log`].Output)
+	require.Empty(t, files[`C:\logs\b.log`].Output)
+	require.Contains(t, files[`C:\logs\b.log`].Error, "does not exist")
+}
+
+func TestTailFilesCommand(t *testing.T) {
+	require.Equal(t,
+		"{ errs=$(tail -n 20 -- '/var/log/a.log' '/tmp/it'\\''s.log' 2>&1 >&3); } 3>&1; echo "+tailErrorsMarker+"; printf '%s\\n' \"$errs\"",
+		tailFilesCommand([]string{"/var/log/a.log", "/tmp/it's.log"}, 20, false))
+
+	// a single file gets its header added as tail prints none
+	require.Contains(t, tailFilesCommand([]string{"/var/log/a.log"}, 5, false), "printf '==> %s <==\\n' '/var/log/a.log'; ")
+}
+
+func TestTailFilesCommand_WindowsQuotedPath(t *testing.T) {
+	// a double quote in a path must not end the command line and run what follows it
+	command := tailFilesCommand([]string{`C:\logs\app.log`, `C:\x" & whoami & "`}, 20, true)
+	require.NotContains(t, command, "whoami")
+	require.Contains(t, powerShellScript(t, command), `foreach ($f in @('C:\logs\app.log', 'C:\x" & whoami & "'))`)
+}
+
+func TestTailFiles_InvalidArguments(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+
+	tool := &TailFiles{}
+	handler := tool.Handler(context.Background(), engine)
+
+	for name, args := range map[string]map[string]interface{}{
+		"no paths":       {"group": "production", "paths": []interface{}{}},
+		"empty path":     {"group": "production", "paths": []interface{}{""}},
+		"duplicate path": {"group": "production", "paths": []interface{}{"/var/log/a", "/var/log/a"}},
+		"n too large":    {"group": "production", "paths": []interface{}{"/var/log/a"}, "n": float64(maxTailLines + 1)},
+		"n zero":         {"group": "production", "paths": []interface{}{"/var/log/a"}, "n": float64(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+)
+
+// TextFallback wraps a tool handler so results with structured content also include a
+// human-readable text rendering, for clients that do not display structured content.
+func TextFallback(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := handler(ctx, request)
+		if err != nil || result == nil || result.StructuredContent == nil {
+			return result, err
+		}
+		result.Content = append(result.Content, mcp.NewTextContent(renderText(result.StructuredContent)))
+		return result, nil
+	}
+}
+
+// renderText renders structured content as human-readable text.
+func renderText(structured any) string {
+	switch v := structured.(type) {
+	case *commands.CommandState:
+		return renderCommandState(v)
+	case map[string]commands.CommandResult:
+		return renderCommandResults(v)
+	}
+
+	// Render anything else generically from its JSON form
+	data, err := json.Marshal(structured)
+	if err != nil {
+		return fmt.Sprintf("unable to render result: %v", err)
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return fmt.Sprintf("unable to render result: %v", err)
+	}
+	var sb strings.Builder
+	renderValue(&sb, generic, 0)
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// renderCommandState renders the command header followed by each host's output.
+func renderCommandState(state *commands.CommandState) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Command %s [%s]: %s\n", state.ID, state.Status, state.Command)
+	if state.StartedAt != nil {
+		fmt.Fprintf(&sb, "Started: %s\n", state.StartedAt.Format("2006-01-02 15:04:05"))
+	}
+	if state.EndedAt != nil {
+		fmt.Fprintf(&sb, "Ended: %s\n", state.EndedAt.Format("2006-01-02 15:04:05"))
+	}
+	if state.Error != "" {
+		fmt.Fprintf(&sb, "Error: %s\n", state.Error)
+	}
+	sb.WriteString("\n")
+	sb.WriteString(renderCommandResults(state.Results))
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// renderCommandResults renders each host's status and output, sorted by host.
+func renderCommandResults(results map[string]commands.CommandResult) string {
+	hosts := make([]string, 0, len(results))
+	for host := range results {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var sb strings.Builder
+	for _, host := range hosts {
+		result := results[host]
+		status := "ok"
+		if result.Err != nil {
+			status = "error: " + result.Err.Error()
+		} else if result.Empty {
+			status = "ok, no output"
+		}
+		fmt.Fprintf(&sb, "== %s [%s]\n", host, status)
+		if output := strings.TrimRight(result.Result, "\n"); output != "" {
+			sb.WriteString(output)
+			sb.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// renderValue renders a decoded JSON value as indented "key: value" lines.
+func renderValue(sb *strings.Builder, value any, indent int) {
+	prefix := strings.Repeat("  ", indent)
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if isScalar(v[key]) {
+				fmt.Fprintf(sb, "%s%s: %s\n", prefix, key, scalarString(v[key]))
+				continue
+			}
+			fmt.Fprintf(sb, "%s%s:\n", prefix, key)
+			renderValue(sb, v[key], indent+1)
+		}
+	case []any:
+		for _, item := range v {
+			if isScalar(item) {
+				fmt.Fprintf(sb, "%s- %s\n", prefix, scalarString(item))
+				continue
+			}
+			fmt.Fprintf(sb, "%s-\n", prefix)
+			renderValue(sb, item, indent+1)
+		}
+	default:
+		fmt.Fprintf(sb, "%s%s\n", prefix, scalarString(v))
+	}
+}
+
+func isScalar(value any) bool {
+	switch value.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return true
+}
+
+func scalarString(value any) string {
+	if value == nil {
+		return "-"
+	}
+	if s, ok := value.(string); ok && strings.Contains(s, "\n") {
+		// keep multi-line values readable on a single line
+		return strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", " | ")
+	}
+	return fmt.Sprint(value)
+}
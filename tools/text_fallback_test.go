@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+)
+
+// Tests for the text fallback rendering
+
+func TestTextFallback_AppendsRendering(t *testing.T) {
+	handler := TextFallback(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultStructuredOnly(map[string]commands.CommandResult{
+			"web01": {Host: "web01", Result: "up 3 days\n"},
+			"web02": {Host: "web02", Err: errors.New("failed to connect")},
+			"web03": {Host: "web03", Empty: true},
+		}), nil
+	})
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.Len(t, result.Content, 2)
+
+	text, ok := result.Content[1].(mcp.TextContent)
+	require.True(t, ok)
+	require.Equal(t, "== web01 [ok]\nup 3 days\n== web02 [error: failed to connect]\n== web03 [ok, no output]", text.Text)
+}
+
+func TestTextFallback_LeavesPlainResults(t *testing.T) {
+	handler := TextFallback(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("done"), nil
+	})
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+}
+
+func TestRenderText_Generic(t *testing.T) {
+	text := renderText(map[string]any{
+		"groups": []string{"production", "staging"},
+		"total":  2,
+	})
+	require.Equal(t, "groups:\n  - production\n  - staging\ntotal: 2", text)
+}
@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&UpdateHost{})
+}
+
+// UpdateHostResult is the outcome of updating a stored host.
+type UpdateHostResult struct {
+	Group string `json:"group"`
+	Name  string `json:"name"`
+	// Updated are the arguments of the fields that were changed, secrets are only listed by name
+	Updated []string `json:"updated"`
+	// Verified is true when the host was connected to with the changes before saving them
+	Verified bool `json:"verified,omitempty"`
+	// AuthMethod is how the verifying connection authenticated
+	AuthMethod string `json:"auth_method,omitempty"`
+	// JumpHostsUpdated are the 'group:name' of the hosts whose jump_host now references the moved host
+	JumpHostsUpdated []string `json:"jump_hosts_updated,omitempty"`
+}
+
+// UpdateHost is a tool that changes the fields of a stored host.
+type UpdateHost struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *UpdateHost) Definition() mcp.Tool {
+	return mcp.NewTool("update_host",
+		mcp.WithDescription("Changes selected fields of a stored host, e.g. its port, user, password or group, leaving the others as they are. Only the given arguments are changed; pass an empty string to clear an optional field such as password or jump_host. Set verify=true to connect with the changes first and only save them when that succeeds. Moving or renaming a host also updates the jump_host of the hosts connecting through it, which are listed in jump_hosts_updated."),
+		mcp.WithString("group",
+			mcp.Required(),
+			mcp.Description("Group that the host belongs to"),
+		),
+		mcp.WithString("name_of_host",
+			mcp.Required(),
+			mcp.Description("Name of the host"),
+		),
+		mcp.WithString("new_group", mcp.Description("Move the host to this group (optional)")),
+		mcp.WithString("new_name", mcp.Description("Rename the host within its group, or new_group (optional)")),
+		mcp.WithString("host", mcp.Description("New address, hostname or IP, to connect to (optional)")),
+		mcp.WithString("port", mcp.Description("New SSH port (optional)")),
+		mcp.WithString("user", mcp.Description("New user to connect as, empty to use the default user (optional)")),
+		mcp.WithString("password", mcp.Description("New password, empty to remove it and authenticate with keys only (optional, mutually exclusive with password_ref)")),
+		mcp.WithString("password_ref", mcp.Description("Reference to the password resolved at connect time instead of storing it, e.g. 'env:DB_PASS' or 'file:/run/secrets/db', when allowed by the server's --secret-env or --secrets-dir (optional, mutually exclusive with password)")),
+		mcp.WithString("key_path", mcp.Description("New path to the private key to authenticate with, empty to remove it together with its passphrase (optional)")),
+		mcp.WithString("key_passphrase", mcp.Description("New passphrase of the key_path (optional, mutually exclusive with key_passphrase_ref)")),
+		mcp.WithString("key_passphrase_ref", mcp.Description("Reference to the passphrase of the key_path resolved at connect time, e.g. 'env:KEY_PASS', when allowed by the server's --secret-env or --secrets-dir (optional, mutually exclusive with key_passphrase)")),
+		mcp.WithString("jump_host", mcp.Description("New bastion to connect through, a stored host as 'group:name' or a connection string, empty to connect directly (optional)")),
+		mcp.WithString("description", mcp.Description("New free-form notes about the host, empty to remove them (optional)")),
+		mcp.WithBoolean("legacy_ssh_rsa", mcp.Description("Whether to also accept the SHA-1 'ssh-rsa' host key algorithm for the host (optional)")),
+		mcp.WithBoolean("verify", mcp.Description("Connect to the host with the changes before saving them, leaving the host unchanged when that fails (default: false)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *UpdateHost) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		group, err := request.RequireString("group")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if err := checkGroupAllowed(group); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		name, err := request.RequireString("name_of_host")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		info, ok := storageEngine.Get(group, name)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("host %s not found in group %s", name, group)), nil
+		}
+
+		updated, err := patchHost(storageEngine, &info, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(updated) == 0 {
+			return mcp.NewToolResultError("nothing to update, specify at least one field to change"), nil
+		}
+		if info.Group != group || info.Name != name {
+			if _, exists := storageEngine.Get(info.Group, info.Name); exists {
+				return mcp.NewToolResultError(fmt.Sprintf("host %s already exists in group %s", info.Name, info.Group)), nil
+			}
+		}
+
+		result := UpdateHostResult{Group: info.Group, Name: info.Name, Updated: updated}
+		if info.Group != group || info.Name != name {
+			result.JumpHostsUpdated, err = jumpHostDependents(storageEngine, group+":"+name)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		if request.GetBool("verify", false) {
+			// connect as it would be connected to once saved, with the default user of its group
+			connectInfo := applyDefaultUsers(storageEngine, []ssh.ClientInfo{info})[0]
+			sshClient := ssh.NewClient(&connectInfo)
+			if err := sshClient.ConnectContext(reqCtx); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("verification failed, the host was not updated: %v", err)), nil
+			}
+			result.Verified = true
+			result.AuthMethod = sshClient.AuthMethod()
+			sshClient.Close()
+		}
+
+		if err := storageEngine.Update(group, name, info); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultStructuredOnly(result), nil
+	}
+}
+
+// patchHost applies the arguments given in the request to the host, returning the names of the
+// arguments that changed it.
+func patchHost(storageEngine storage.Store, info *ssh.ClientInfo, request mcp.CallToolRequest) ([]string, error) {
+	var updated []string
+	set := func(arg string, field *string, value string) {
+		if *field != value {
+			*field = value
+			updated = append(updated, arg)
+		}
+	}
+	// setSecret also records whether the secret is a reference, a change of which alone is an update
+	setSecret := func(arg string, field *string, isRef *bool, value string, ref bool) {
+		if *field != value || *isRef != ref {
+			*field = value
+			*isRef = ref
+			updated = append(updated, arg)
+		}
+	}
+
+	if newGroup, ok := stringArgument(request, "new_group"); ok {
+		if newGroup == "" {
+			return nil, fmt.Errorf("new_group cannot be empty")
+		}
+		if err := checkGroupAllowed(newGroup); err != nil {
+			return nil, err
+		}
+		set("new_group", &info.Group, newGroup)
+	}
+	if newName, ok := stringArgument(request, "new_name"); ok {
+		if newName == "" {
+			return nil, fmt.Errorf("new_name cannot be empty")
+		}
+		set("new_name", &info.Name, newName)
+	}
+	if host, ok := stringArgument(request, "host"); ok {
+		if host == "" {
+			return nil, fmt.Errorf("host cannot be empty")
+		}
+		set("host", &info.Host, host)
+	}
+	if port, ok := stringArgument(request, "port"); ok {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid port %q: must be a number between 1 and 65535", port)
+		}
+		set("port", &info.Port, port)
+	}
+	if user, ok := stringArgument(request, "user"); ok {
+		set("user", &info.User, user)
+	}
+
+	password, hasPassword := stringArgument(request, "password")
+	passwordRef, hasPasswordRef := stringArgument(request, "password_ref")
+	if hasPassword && hasPasswordRef {
+		return nil, fmt.Errorf("cannot specify both 'password' and 'password_ref'")
+	}
+	if hasPassword {
+		setSecret("password", &info.Pass, &info.PassRef, password, false)
+	}
+	if hasPasswordRef {
+		if err := ssh.CheckSecretReference(passwordRef); err != nil {
+			return nil, fmt.Errorf("invalid password_ref: %w", err)
+		}
+		setSecret("password_ref", &info.Pass, &info.PassRef, passwordRef, true)
+	}
+
+	keyPath, hasKeyPath := stringArgument(request, "key_path")
+	keyPassphrase, hasKeyPassphrase := stringArgument(request, "key_passphrase")
+	keyPassphraseRef, hasKeyPassphraseRef := stringArgument(request, "key_passphrase_ref")
+	if hasKeyPassphrase && hasKeyPassphraseRef {
+		return nil, fmt.Errorf("cannot specify both 'key_passphrase' and 'key_passphrase_ref'")
+	}
+	if hasKeyPath {
+		if keyPath == "" {
+			// the passphrase belongs to the key
+			setSecret("key_passphrase", &info.KeyPassphrase, &info.KeyPassphraseRef, "", false)
+		}
+		set("key_path", &info.KeyPath, ssh.ExpandHome(keyPath))
+	}
+	if hasKeyPassphrase || hasKeyPassphraseRef {
+		name, value := "key_passphrase", keyPassphrase
+		if hasKeyPassphraseRef {
+			name, value = "key_passphrase_ref", keyPassphraseRef
+		}
+		if info.KeyPath == "" && value != "" {
+			return nil, fmt.Errorf("'%s' requires 'key_path'", name)
+		}
+		setSecret(name, &info.KeyPassphrase, &info.KeyPassphraseRef, value, hasKeyPassphraseRef && value != "")
+	}
+	if (hasKeyPath || hasKeyPassphrase || hasKeyPassphraseRef) && info.KeyPath != "" {
+		if err := validatePrivateKey(info.KeyPath, info.KeyPassphrase, info.KeyPassphraseRef); err != nil {
+			return nil, err
+		}
+	}
+
+	if jumpHost, ok := stringArgument(request, "jump_host"); ok {
+		if jumpHost != "" {
+			if err := validateJumpHost(storageEngine, jumpHost); err != nil {
+				return nil, err
+			}
+		}
+		set("jump_host", &info.JumpHost, jumpHost)
+	}
+	if description, ok := stringArgument(request, "description"); ok {
+		set("description", &info.Description, description)
+	}
+	if _, ok := request.GetArguments()["legacy_ssh_rsa"]; ok {
+		if legacy := request.GetBool("legacy_ssh_rsa", false); legacy != info.LegacySSHRSA {
+			info.LegacySSHRSA = legacy
+			updated = append(updated, "legacy_ssh_rsa")
+		}
+	}
+	return updated, nil
+}
+
+// jumpHostDependents returns the 'group:name' of the hosts using the host ref as their jump host.
+func jumpHostDependents(storageEngine storage.Store, ref string) ([]string, error) {
+	hosts, err := storageEngine.List()
+	if err != nil {
+		return nil, err
+	}
+	var dependents []string
+	for _, host := range hosts {
+		if host.JumpHost == ref {
+			dependents = append(dependents, host.Group+":"+host.Name)
+		}
+	}
+	return dependents, nil
+}
+
+// stringArgument returns a string argument and whether it was given at all, so an empty string
+// can clear a field while a missing argument leaves it unchanged.
+func stringArgument(request mcp.CallToolRequest, name string) (string, bool) {
+	if _, ok := request.GetArguments()[name]; !ok {
+		return "", false
+	}
+	return request.GetString(name, ""), true
+}
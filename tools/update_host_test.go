@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// Tests for UpdateHost tool
+
+func TestUpdateHost_PatchesFields(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+
+	tool := &UpdateHost{}
+	handler := tool.Handler(context.Background(), engine)
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+		"group":        "production",
+		"name_of_host": "server1",
+		"port":         "2222",
+		"user":         "deploy",
+		"password":     "",
+		"description":  "primary DB",
+	}}}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	updated := result.StructuredContent.(UpdateHostResult)
+	require.Equal(t, []string{"port", "user", "password", "description"}, updated.Updated)
+	require.False(t, updated.Verified)
+
+	stored, ok := engine.Get("production", "server1")
+	require.True(t, ok)
+	require.Equal(t, "10.0.1.1", stored.Host)
+	require.Equal(t, "2222", stored.Port)
+	require.Equal(t, "deploy", stored.User)
+	require.Empty(t, stored.Pass)
+	require.Equal(t, "primary DB", stored.Description)
+	// fields that were not given are kept
+	require.Equal(t, "Ubuntu 22.04", stored.OS.OSRelease)
+}
+
+func TestUpdateHost_PasswordRef(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	allowSecrets(t, ssh.SecretPolicy{EnvNames: []string{"DB_PASS"}})
+
+	tool := &UpdateHost{}
+	handler := tool.Handler(context.Background(), engine)
+	update := func(args map[string]interface{}) UpdateHostResult {
+		args["group"] = "production"
+		args["name_of_host"] = "server1"
+		result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.StructuredContent.(UpdateHostResult)
+	}
+
+	require.Equal(t, []string{"password_ref"}, update(map[string]interface{}{"password_ref": "env:DB_PASS"}).Updated)
+	stored, ok := engine.Get("production", "server1")
+	require.True(t, ok)
+	require.Equal(t, "env:DB_PASS", stored.Pass)
+	require.True(t, stored.PassRef)
+
+	// the same text given as a password is the password itself
+	require.Equal(t, []string{"password"}, update(map[string]interface{}{"password": "env:DB_PASS"}).Updated)
+	stored, ok = engine.Get("production", "server1")
+	require.True(t, ok)
+	require.Equal(t, "env:DB_PASS", stored.Pass)
+	require.False(t, stored.PassRef)
+}
+
+func TestUpdateHost_MovesHost(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	behind := ssh.ClientInfo{Group: "production", Name: "db1", Host: "10.0.1.2", Port: "22", JumpHost: "production:server1"}
+	require.NoError(t, engine.Set(behind))
+
+	tool := &UpdateHost{}
+	handler := tool.Handler(context.Background(), engine)
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+		"group":        "production",
+		"name_of_host": "server1",
+		"new_group":    "staging",
+		"new_name":     "web1",
+	}}}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	updated := result.StructuredContent.(UpdateHostResult)
+	require.Equal(t, "staging", updated.Group)
+	require.Equal(t, "web1", updated.Name)
+
+	_, ok := engine.Get("production", "server1")
+	require.False(t, ok)
+	stored, ok := engine.Get("staging", "web1")
+	require.True(t, ok)
+	require.Equal(t, "10.0.1.1", stored.Host)
+	require.Equal(t, "testpass", stored.Pass)
+
+	// hosts connecting through it follow the move
+	require.Equal(t, []string{"production:db1"}, updated.JumpHostsUpdated)
+	stored, ok = engine.Get("production", "db1")
+	require.True(t, ok)
+	require.Equal(t, "staging:web1", stored.JumpHost)
+}
+
+func TestUpdateHost_VerifyFailureKeepsHost(t *testing.T) {
+	// a port nothing listens on refuses the connection right away
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+
+	tool := &UpdateHost{}
+	handler := tool.Handler(context.Background(), engine)
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+		"group":        "production",
+		"name_of_host": "server1",
+		"host":         "127.0.0.1",
+		"port":         port,
+		"verify":       true,
+	}}}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "verification failed, the host was not updated")
+
+	stored, ok := engine.Get("production", "server1")
+	require.True(t, ok)
+	require.Equal(t, "10.0.1.1", stored.Host)
+	require.Equal(t, "22", stored.Port)
+}
+
+func TestUpdateHost_Invalid(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	addTestHost(t, engine, "production", "server2", "10.0.1.2")
+
+	tool := &UpdateHost{}
+	handler := tool.Handler(context.Background(), engine)
+
+	testCases := map[string]map[string]interface{}{
+		"not found":          {"group": "production", "name_of_host": "missing", "port": "2222"},
+		"nothing to update":  {"group": "production", "name_of_host": "server1"},
+		"unchanged":          {"group": "production", "name_of_host": "server1", "port": "22"},
+		"invalid port":       {"group": "production", "name_of_host": "server1", "port": "ssh"},
+		"empty host":         {"group": "production", "name_of_host": "server1", "host": ""},
+		"both passwords":     {"group": "production", "name_of_host": "server1", "password": "a", "password_ref": "env:PASS"},
+		"invalid ref":        {"group": "production", "name_of_host": "server1", "password_ref": "plain"},
+		"ref not allowed":    {"group": "production", "name_of_host": "server1", "password_ref": "env:HOME"},
+		"both passphrases":   {"group": "production", "name_of_host": "server1", "key_passphrase": "a", "key_passphrase_ref": "env:PASS"},
+		"passphrase alone":   {"group": "production", "name_of_host": "server1", "key_passphrase": "secret"},
+		"missing key":        {"group": "production", "name_of_host": "server1", "key_path": "/nonexistent/key"},
+		"name taken":         {"group": "production", "name_of_host": "server1", "new_name": "server2"},
+		"invalid jump host":  {"group": "production", "name_of_host": "server1", "jump_host": "ftp://bastion"},
+		"empty target group": {"group": "production", "name_of_host": "server1", "new_group": ""},
+	}
+	for name, args := range testCases {
+		t.Run(name, func(t *testing.T) {
+			request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}}
+			result, err := handler(context.Background(), request)
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+		})
+	}
+
+	// nothing was changed by the rejected updates
+	stored, ok := engine.Get("production", "server1")
+	require.True(t, ok)
+	require.Equal(t, "22", stored.Port)
+	require.Equal(t, "testpass", stored.Pass)
+}
+
+func TestUpdateHost_RestrictedGroups(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+	restrictGroups(t, "production")
+
+	tool := &UpdateHost{}
+	handler := tool.Handler(context.Background(), engine)
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+		"group":        "production",
+		"name_of_host": "server1",
+		"new_group":    "staging",
+	}}}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	_, ok := engine.Get("production", "server1")
+	require.True(t, ok)
+}
@@ -18,13 +18,27 @@ func init() {
 	Registry.Register(&UpdateOSInfo{})
 }
 
+// DefaultOSInfoParallelism is the default number of hosts update_os_info gathers from at once. OS
+// gathering runs several probes per host, so it is kept lower than the command tools.
+const DefaultOSInfoParallelism = 8
+
+// osInfoParallelism is the number of hosts update_os_info gathers from at once unless overridden
+// by max_parallel.
+var osInfoParallelism = DefaultOSInfoParallelism
+
+// SetOSInfoParallelism sets the default number of hosts update_os_info gathers from at once. 0 or
+// less gathers from every host at once.
+func SetOSInfoParallelism(max int) {
+	osInfoParallelism = max
+}
+
 // UpdateOSInfo is a tool that updates the operating system information on a remote machine.
 type UpdateOSInfo struct{}
 
 // Definition returns the mcp.Tool definition.
 func (c *UpdateOSInfo) Definition() mcp.Tool {
 	return mcp.NewTool("update_os_info",
-		mcp.WithDescription("Updates the cached operating system information for Linux and Windows hosts. You can specify individual hosts or an entire group."),
+		mcp.WithDescription("Updates the cached operating system information for Linux and Windows hosts. You can specify individual hosts or an entire group. At most max_parallel hosts are connected to at once."),
 		mcp.WithString("group",
 			mcp.Description("Group name to update OS info for all hosts in that group (mutually exclusive with name_of_hosts)"),
 		),
@@ -32,55 +46,40 @@ func (c *UpdateOSInfo) Definition() mcp.Tool {
 			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
 			mcp.WithStringItems(),
 		),
+		mcp.WithNumber("max_parallel", mcp.Description("Maximum number of hosts to gather from at once (default: the server's --os-info-parallelism, 8 unless changed)")),
 	)
 }
 
 // Handle is the function that is called when the tool is invoked.
-func (c *UpdateOSInfo) Handler(ctx context.Context, storageEngine *storage.Engine) server.ToolHandlerFunc {
+func (c *UpdateOSInfo) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
 	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Get hosts either by group or by individual host identifiers
-		var found []ssh.ClientInfo
-		var err error
-		group := request.GetString("group", "")
-		sshNameOfHosts := request.GetStringSlice("name_of_hosts", []string{})
-
-		if group != "" && len(sshNameOfHosts) > 0 {
-			return mcp.NewToolResultError("cannot specify both 'group' and 'name_of_hosts'"), nil
-		}
-
-		if group != "" {
-			found, err = utils.GetHostsFromGroup(storageEngine, group)
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
-			}
-		} else if len(sshNameOfHosts) > 0 {
-			identifiers, err := utils.ParseHostIdentifiers(sshNameOfHosts)
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
-			}
-			found, err = utils.GetHostsFromStorage(storageEngine, identifiers)
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
-			}
-		} else {
-			return mcp.NewToolResultError("must specify either 'group' or 'name_of_hosts'"), nil
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		if len(found) == 0 {
-			return mcp.NewToolResultError("no matching hosts found"), nil
+		maxParallel := request.GetInt("max_parallel", osInfoParallelism)
+		if maxParallel < 1 && request.GetArguments()["max_parallel"] != nil {
+			return mcp.NewToolResultError("max_parallel must be at least 1"), nil
 		}
 
 		// Detect OS and gather system information (supports Linux and Windows)
-		result := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+		result := commands.PerformOnHostsLimited(found, maxParallel, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
 			osRelease, uname, err := utils.GatherOSInfo(sshClient)
 			if err != nil {
 				return "", fmt.Errorf("failed to gather OS information: %w", err)
 			}
 
-			// set the OS info and store it for usage later
-			host.OS.OSRelease = osRelease
-			host.OS.Uname = uname
-			err = storageEngine.Set(host)
+			// set the OS info on the latest stored copy, so fields updated while connecting
+			// (such as last seen) and default users applied to host are kept as stored
+			stored, ok := storageEngine.Get(host.Group, host.Name)
+			if !ok {
+				return "", fmt.Errorf("host %s no longer exists in group %s", host.Name, host.Group)
+			}
+			stored.OS.OSRelease = osRelease
+			stored.OS.Uname = uname
+			err = storageEngine.Set(stored)
 			if err != nil {
 				return "", fmt.Errorf("failed to add host to storage: %w", err)
 			}
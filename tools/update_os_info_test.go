@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// Tests for UpdateOSInfo tool
+
+func TestUpdateOSInfo_InvalidMaxParallel(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "production", "server1", "10.0.1.1")
+
+	tool := &UpdateOSInfo{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"group":        "production",
+				"max_parallel": float64(0),
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
+
+func TestUpdateOSInfo_MaxParallelKeepsEveryHost(t *testing.T) {
+	engine := setupTestStorage(t)
+	for _, name := range []string{"server1", "server2", "server3"} {
+		// nothing listens on port 1, so every connection fails right away
+		require.NoError(t, engine.Set(ssh.ClientInfo{Group: "production", Name: name, Host: "127.0.0.1", Port: "1", User: "testuser"}))
+	}
+
+	tool := &UpdateOSInfo{}
+	handler := tool.Handler(context.Background(), engine)
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"group":        "production",
+				"max_parallel": float64(1),
+			},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	results, ok := result.StructuredContent.(map[string]commands.CommandResult)
+	require.True(t, ok)
+	require.Len(t, results, 3)
+	for _, name := range []string{"server1", "server2", "server3"} {
+		require.Equal(t, name, results[name].Host)
+		require.True(t, results[name].ConnectFailed)
+	}
+}
@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/sftp"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&UploadFile{})
+}
+
+// defaultUploadMode is the mode of uploaded files without a mode.
+const defaultUploadMode = 0o644
+
+// uploadDir is the directory the local_path of upload_file may read from, empty allows no local file.
+var uploadDir string
+
+// SetUploadDir sets the directory the local_path of upload_file may read from. The path is chosen
+// by the tool caller, so only files the operator placed there can be uploaded. An empty directory
+// allows only inline content.
+func SetUploadDir(dir string) {
+	uploadDir = dir
+}
+
+// UploadResult is the outcome of uploading the file to a single host.
+type UploadResult struct {
+	Host         string `json:"host"`
+	Path         string `json:"path"`
+	BytesWritten int64  `json:"bytes_written"`
+	// SHA256 is the checksum of the bytes uploaded
+	SHA256 string `json:"sha256,omitempty"`
+	// RemoteSHA256 is the checksum of the file written on the host, only set when verified
+	RemoteSHA256 string `json:"remote_sha256,omitempty"`
+	Verified     bool   `json:"verified"`
+	Error        string `json:"error,omitempty"`
+}
+
+// UploadFile is a tool that writes a local file or inline content to a path on remote hosts.
+type UploadFile struct{}
+
+// IsMutating returns true as the tool changes remote hosts.
+func (c *UploadFile) IsMutating() bool {
+	return true
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *UploadFile) Definition() mcp.Tool {
+	return mcp.NewTool("upload_file",
+		mcp.WithDescription("Uploads a file to a path on each host over SFTP. The file is either a local_path inside the upload directory configured on the machine running this server, streamed to each host, or inline content. The remote directory must exist. Returns the bytes written per host and, unless verify is false, the SHA256 checksums of the uploaded bytes and of the written file, failing the host when they differ. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to upload the file to all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("path", mcp.Required(), mcp.Description("Remote path to write the file to on each host")),
+		mcp.WithString("local_path", mcp.Description("Absolute path of the file to upload on the machine running this server, it must be inside the directory set with --upload-dir (mutually exclusive with content)")),
+		mcp.WithString("content", mcp.Description("Text content of the file to upload (mutually exclusive with local_path)")),
+		mcp.WithString("mode", mcp.Description("Octal permissions of the remote file, e.g. '0600' (default: '0644')")),
+		mcp.WithBoolean("overwrite", mcp.Description("Replace the remote file when it already exists (default: false)")),
+		mcp.WithBoolean("verify", mcp.Description("Compare the SHA256 checksum of the written file on each host with the uploaded bytes, skip it for very large files where the extra read is costly (default: true)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *UploadFile) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, err := request.RequireString("path")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if path == "" {
+			return mcp.NewToolResultError("path cannot be empty"), nil
+		}
+		mode, err := uploadModeFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		open, err := uploadSourceFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		overwrite := request.GetBool("overwrite", false)
+		verify := request.GetBool("verify", true)
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var uploadsMx sync.Mutex
+		uploaded := make(map[string]UploadResult, len(found))
+		results := commands.PerformOnHosts(found, func(host ssh.ClientInfo, sshClient *ssh.Client) (string, error) {
+			upload := UploadResult{Host: host.Name, Path: path}
+			var err error
+			upload.BytesWritten, upload.SHA256, err = uploadToHost(sshClient, open, path, mode, overwrite)
+			if err == nil && verify {
+				upload.RemoteSHA256, err = remoteSHA256(sshClient, host, path)
+				if err == nil && upload.RemoteSHA256 != upload.SHA256 {
+					err = fmt.Errorf("checksum mismatch: uploaded %s but the host has %s", upload.SHA256, upload.RemoteSHA256)
+				}
+				upload.Verified = err == nil
+			}
+			uploadsMx.Lock()
+			uploaded[host.Name] = upload
+			uploadsMx.Unlock()
+			return "", err
+		})
+
+		uploads := make([]UploadResult, 0, len(results))
+		for name, result := range results {
+			upload, ok := uploaded[name]
+			if !ok {
+				upload = UploadResult{Host: name, Path: path}
+			}
+			if result.Err != nil {
+				upload.Error = result.Err.Error()
+			}
+			uploads = append(uploads, upload)
+		}
+		sort.Slice(uploads, func(i, j int) bool {
+			return uploads[i].Host < uploads[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": uploads}), nil
+	}
+}
+
+// uploadModeFromRequest parses the optional octal mode argument.
+func uploadModeFromRequest(request mcp.CallToolRequest) (os.FileMode, error) {
+	raw := request.GetString("mode", "")
+	if raw == "" {
+		return defaultUploadMode, nil
+	}
+	mode, err := strconv.ParseUint(raw, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid mode '%s': must be octal permissions from '0000' to '0777', e.g. '0644'", raw)
+	}
+	return os.FileMode(mode), nil
+}
+
+// uploadSourceFromRequest returns the function opening the file to upload for each host, from
+// either the local_path or the content argument.
+func uploadSourceFromRequest(request mcp.CallToolRequest) (func() (io.ReadCloser, error), error) {
+	localPath := request.GetString("local_path", "")
+	args := request.GetArguments()
+	content, hasContent := args["content"].(string)
+	switch {
+	case localPath != "" && hasContent:
+		return nil, errors.New("cannot specify both 'local_path' and 'content'")
+	case hasContent:
+		return func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(content)), nil
+		}, nil
+	case localPath != "":
+		realPath, err := uploadDirPath(localPath)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(realPath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read local_path: %w", err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("local_path %s is not a regular file", localPath)
+		}
+		return func() (io.ReadCloser, error) {
+			return os.Open(realPath)
+		}, nil
+	}
+	return nil, errors.New("must specify either 'local_path' or 'content'")
+}
+
+// uploadDirPath returns the path of localPath with symlinks followed, failing when it is not inside
+// the upload directory.
+func uploadDirPath(localPath string) (string, error) {
+	if uploadDir == "" {
+		return "", errors.New("local_path is not allowed, the server was started without --upload-dir; upload inline content instead")
+	}
+	if !filepath.IsAbs(localPath) {
+		return "", fmt.Errorf("local_path %s must be an absolute path", localPath)
+	}
+	realDir, err := filepath.EvalSymlinks(uploadDir)
+	if err != nil {
+		return "", fmt.Errorf("cannot read the upload directory: %w", err)
+	}
+	realPath, err := filepath.EvalSymlinks(localPath)
+	if err != nil {
+		return "", fmt.Errorf("cannot read local_path: %w", err)
+	}
+	rel, err := filepath.Rel(realDir, realPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("local_path %s is not inside the upload directory %s", localPath, uploadDir)
+	}
+	return realPath, nil
+}
+
+// uploadToHost writes the file opened by open to path on the host over SFTP, returning the bytes
+// written and the SHA256 checksum of the bytes read from the file.
+func uploadToHost(sshClient *ssh.Client, open func() (io.ReadCloser, error), path string, mode os.FileMode, overwrite bool) (int64, string, error) {
+	src, err := open()
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to open the file to upload: %w", err)
+	}
+	defer src.Close()
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return 0, "", err
+	}
+	defer client.Close()
+	hash := sha256.New()
+	written, err := client.WriteFile(path, io.TeeReader(src, hash), mode, overwrite)
+	return written, hex.EncodeToString(hash.Sum(nil)), err
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadFile_InvalidArguments(t *testing.T) {
+	engine := setupTestStorage(t)
+	addTestHost(t, engine, "web", "web1", "10.0.0.1")
+
+	dir := t.TempDir()
+	SetUploadDir(dir)
+	t.Cleanup(func() { SetUploadDir("") })
+
+	tool := &UploadFile{}
+	handler := tool.Handler(context.Background(), engine)
+
+	testCases := map[string]map[string]any{
+		"no source":          {"group": "web", "path": "/tmp/a"},
+		"both sources":       {"group": "web", "path": "/tmp/a", "content": "x", "local_path": "/etc/hostname"},
+		"missing local":      {"group": "web", "path": "/tmp/a", "local_path": filepath.Join(dir, "missing")},
+		"directory":          {"group": "web", "path": "/tmp/a", "local_path": dir},
+		"outside upload dir": {"group": "web", "path": "/tmp/a", "local_path": "/etc/hostname"},
+		"empty path":         {"group": "web", "path": "", "content": "x"},
+		"bad mode":           {"group": "web", "path": "/tmp/a", "content": "x", "mode": "rw-r--r--"},
+		"mode out of range":  {"group": "web", "path": "/tmp/a", "content": "x", "mode": "4755"},
+		"unknown group":      {"group": "missing", "path": "/tmp/a", "content": "x"},
+	}
+	for name, args := range testCases {
+		t.Run(name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			result, err := handler(context.Background(), request)
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+		})
+	}
+}
+
+func TestUploadModeFromRequest(t *testing.T) {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{}
+	mode, err := uploadModeFromRequest(request)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o644), mode)
+
+	request.Params.Arguments = map[string]any{"mode": "0600"}
+	mode, err = uploadModeFromRequest(request)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), mode)
+}
+
+func TestUploadSourceFromRequest(t *testing.T) {
+	dir := t.TempDir()
+	SetUploadDir(dir)
+	t.Cleanup(func() { SetUploadDir("") })
+	local := filepath.Join(dir, "app.conf")
+	require.NoError(t, os.WriteFile(local, []byte("listen 8080\n"), 0o600))
+
+	for name, args := range map[string]map[string]any{
+		"local_path": {"local_path": local},
+		"content":    {"content": "listen 8080\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			open, err := uploadSourceFromRequest(request)
+			require.NoError(t, err)
+
+			// every host gets its own reader
+			for range 2 {
+				src, err := open()
+				require.NoError(t, err)
+				data, err := io.ReadAll(src)
+				require.NoError(t, err)
+				require.NoError(t, src.Close())
+				require.Equal(t, "listen 8080\n", string(data))
+			}
+		})
+	}
+
+	// empty content is a valid empty file
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"content": ""}
+	_, err := uploadSourceFromRequest(request)
+	require.NoError(t, err)
+}
+
+func TestUploadSourceFromRequest_UploadDir(t *testing.T) {
+	dir := t.TempDir()
+	local := filepath.Join(dir, "app.conf")
+	require.NoError(t, os.WriteFile(local, []byte("listen 8080\n"), 0o600))
+	outside := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(outside, []byte("private key"), 0o600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "escape")))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"local_path": local}
+
+	// no local file may be read unless the operator configured a directory
+	_, err := uploadSourceFromRequest(request)
+	require.ErrorContains(t, err, "--upload-dir")
+
+	SetUploadDir(dir)
+	t.Cleanup(func() { SetUploadDir("") })
+	_, err = uploadSourceFromRequest(request)
+	require.NoError(t, err)
+
+	for name, localPath := range map[string]string{
+		"outside":  outside,
+		"relative": "app.conf",
+		"dotdot":   filepath.Join(dir, "..", filepath.Base(filepath.Dir(outside)), "id_ed25519"),
+		"symlink":  filepath.Join(dir, "escape"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			request.Params.Arguments = map[string]any{"local_path": localPath}
+			_, err := uploadSourceFromRequest(request)
+			require.Error(t, err)
+		})
+	}
+}
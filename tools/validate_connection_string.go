@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&ValidateConnectionString{})
+}
+
+// ConnectionStringValidation is the outcome of parsing a connection string. The password is
+// never returned, only whether one was supplied.
+type ConnectionStringValidation struct {
+	Valid            bool     `json:"valid"`
+	Error            string   `json:"error,omitempty"`
+	Name             string   `json:"name,omitempty"`
+	Host             string   `json:"host,omitempty"`
+	Port             string   `json:"port,omitempty"`
+	User             string   `json:"user,omitempty"`
+	PasswordSupplied bool     `json:"password_supplied"`
+	Warnings         []string `json:"warnings,omitempty"`
+}
+
+// ValidateConnectionString is a tool that parses a connection string without connecting or
+// storing anything.
+type ValidateConnectionString struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *ValidateConnectionString) Definition() mcp.Tool {
+	return mcp.NewTool("validate_connection_string",
+		mcp.WithDescription("Parses an SSH connection string the same way add_host does and returns its host, port, user and whether a password was supplied (the password itself is never returned), plus warnings about anything add_host would silently default or ignore. Nothing is connected to or stored, use it to check a connection string before calling add_host."),
+		mcp.WithString("ssh_connection_string",
+			mcp.Required(),
+			mcp.Description("SSH connection string in format: [user[:password]@]host[:port]. The 'ssh://' prefix is optional."),
+		),
+		mcp.WithString("name_of_host",
+			mcp.Description("Name of the host as it would be passed to add_host (optional, defaults to hostname)"),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *ValidateConnectionString) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		connStr, err := request.RequireString("ssh_connection_string")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultStructuredOnly(validateConnectionString(request.GetString("name_of_host", ""), connStr)), nil
+	}
+}
+
+// validateConnectionString parses the connection string with ssh.NewClientInfo and collects
+// warnings about the parts that are defaulted, ignored or likely to fail when connecting.
+func validateConnectionString(name string, connStr string) ConnectionStringValidation {
+	info, err := ssh.NewClientInfo(name, connStr)
+	if err != nil {
+		return ConnectionStringValidation{Error: err.Error()}
+	}
+	validation := ConnectionStringValidation{
+		Valid:            true,
+		Name:             info.Name,
+		Host:             info.Host,
+		Port:             info.Port,
+		User:             info.User,
+		PasswordSupplied: info.Pass != "",
+		Warnings:         []string{},
+	}
+
+	if port, err := strconv.Atoi(info.Port); err != nil || port < 1 || port > 65535 {
+		validation.Valid = false
+		validation.Error = fmt.Sprintf("invalid port %s: must be between 1 and 65535", info.Port)
+	}
+	if info.User == "" {
+		validation.Warnings = append(validation.Warnings, "no user given, the stored default user, the --default-user flag or the user running the server is used")
+	}
+	if validation.PasswordSupplied {
+		validation.Warnings = append(validation.Warnings, "the password would be stored in plain text, consider add_host's password_ref instead")
+	}
+	// NewClientInfo parsed the string with the ssh:// prefix added the same way
+	if parsed, err := url.Parse(withSSHScheme(connStr)); err == nil {
+		if parsed.Port() == "" {
+			validation.Warnings = append(validation.Warnings, "no port given, port 22 is used")
+		}
+		if strings.Trim(parsed.Path, "/") != "" || parsed.RawQuery != "" || parsed.Fragment != "" {
+			validation.Warnings = append(validation.Warnings, "the path, query or fragment of the connection string is ignored")
+		}
+	}
+	return validation
+}
+
+// withSSHScheme prefixes the connection string with ssh:// when it has no scheme.
+func withSSHScheme(connStr string) string {
+	if strings.Contains(connStr, "://") {
+		return connStr
+	}
+	return "ssh://" + connStr
+}
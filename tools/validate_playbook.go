@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&ValidatePlaybook{})
+}
+
+// PlaybookStepValidation is the outcome of checking a single playbook step.
+type PlaybookStepValidation struct {
+	// Step is the 1-based position of the step in the playbook
+	Step    int    `json:"step"`
+	Name    string `json:"name,omitempty"`
+	Command string `json:"command"`
+	// Hosts are the 'group:name' identifiers the step would run on
+	Hosts     []string `json:"hosts"`
+	HostCount int      `json:"host_count"`
+	Valid     bool     `json:"valid"`
+	// Problems are the reasons the step cannot run, Warnings do not stop it from running
+	Problems []string `json:"problems,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// PlaybookValidation is the outcome of checking every step of a playbook.
+type PlaybookValidation struct {
+	Playbook string                   `json:"playbook"`
+	Valid    bool                     `json:"valid"`
+	Steps    []PlaybookStepValidation `json:"steps"`
+}
+
+// ValidatePlaybook is a tool that checks a saved playbook can run without running anything.
+type ValidatePlaybook struct{}
+
+// Definition returns the mcp.Tool definition.
+func (c *ValidatePlaybook) Definition() mcp.Tool {
+	return mcp.NewTool("validate_playbook",
+		mcp.WithDescription("Checks a playbook saved with save_playbook without running anything or connecting to the hosts: every step must be well formed and its selector must resolve to at least one host. Returns per step the hosts it would run on and their count, the problems that would make run_playbook fail the step, and warnings such as hosts in name_of_hosts that no longer exist. Pass the same group or name_of_hosts you would give run_playbook to check the steps without their own hosts."),
+		mcp.WithString("playbook", mcp.Required(), mcp.Description("Name of the saved playbook to check")),
+		mcp.WithString("group",
+			mcp.Description("Group name the steps without their own hosts would run on (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' the steps without their own hosts would run on (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *ValidatePlaybook) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("playbook")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		playbook, ok := storageEngine.GetPlaybook(name)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("playbook %s not found", name)), nil
+		}
+		defaultGroup := request.GetString("group", "")
+		defaultHosts := request.GetStringSlice("name_of_hosts", []string{})
+		if defaultGroup != "" && len(defaultHosts) > 0 {
+			return mcp.NewToolResultError("cannot specify both 'group' and 'name_of_hosts'"), nil
+		}
+
+		resolve := playbookStepResolver(storageEngine, defaultGroup, defaultHosts)
+		return mcp.NewToolResultStructuredOnly(validatePlaybook(playbook, defaultGroup, defaultHosts, resolve)), nil
+	}
+}
+
+// validatePlaybook checks every step of the playbook, resolving each step's hosts with resolve.
+func validatePlaybook(
+	playbook storage.Playbook,
+	defaultGroup string,
+	defaultHosts []string,
+	resolve func(step storage.PlaybookStep) ([]ssh.ClientInfo, error),
+) PlaybookValidation {
+	result := PlaybookValidation{Playbook: playbook.Name, Valid: true, Steps: []PlaybookStepValidation{}}
+	for i, step := range playbook.Steps {
+		stepResult := PlaybookStepValidation{Step: i + 1, Name: step.Name, Command: step.Command, Hosts: []string{}}
+
+		// check the step alone, as Validate reports the first problem of the whole playbook
+		single := storage.Playbook{Name: playbook.Name, Steps: []storage.PlaybookStep{step}}
+		if err := single.Validate(); err != nil {
+			_, msg, _ := strings.Cut(err.Error(), ": ")
+			stepResult.Problems = append(stepResult.Problems, msg)
+		}
+
+		hosts, err := resolve(step)
+		if err != nil {
+			stepResult.Problems = append(stepResult.Problems, err.Error())
+		}
+		resolved := make(map[string]bool, len(hosts))
+		for _, host := range hosts {
+			id := host.Group + ":" + host.Name
+			resolved[id] = true
+			stepResult.Hosts = append(stepResult.Hosts, id)
+		}
+		sort.Strings(stepResult.Hosts)
+		stepResult.HostCount = len(stepResult.Hosts)
+
+		// unknown hosts are skipped by run_playbook as long as one of them exists
+		if _, names := playbookStepSelector(step, defaultGroup, defaultHosts); err == nil {
+			for _, id := range names {
+				if !resolved[id] {
+					stepResult.Warnings = append(stepResult.Warnings, fmt.Sprintf("host %s not found, the step will not run on it", id))
+				}
+			}
+		}
+		if strings.Contains(step.Command, "{{") {
+			stepResult.Warnings = append(stepResult.Warnings, "the command contains '{{', playbook commands are run as written and not rendered as templates")
+		}
+
+		stepResult.Valid = len(stepResult.Problems) == 0
+		if !stepResult.Valid {
+			result.Valid = false
+		}
+		result.Steps = append(result.Steps, stepResult)
+	}
+	return result
+}
@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+	"github.com/blakerouse/ssh-mcp/storage"
+	"github.com/blakerouse/ssh-mcp/utils"
+)
+
+func init() {
+	// register the tool in the registry
+	Registry.Register(&WhoAmI{})
+}
+
+// whoAmIEnvVars are the environment variables reported by whoami.
+var whoAmIEnvVars = []string{"HOME", "SHELL", "PATH", "LANG", "TERM", "SUDO_USER"}
+
+// whoAmIWindowsEnvVars are the environment variables reported by whoami on Windows.
+var whoAmIWindowsEnvVars = []string{"USERPROFILE", "USERDOMAIN", "COMPUTERNAME", "PATH"}
+
+// WhoAmIResult is the execution context of commands on a single host.
+type WhoAmIResult struct {
+	Host     string            `json:"host"`
+	User     string            `json:"user,omitempty"`
+	UID      string            `json:"uid,omitempty"`
+	GID      string            `json:"gid,omitempty"`
+	Groups   []string          `json:"groups,omitempty"`
+	Hostname string            `json:"hostname,omitempty"`
+	Cwd      string            `json:"cwd,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+	Sudo     bool              `json:"sudo"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// WhoAmI is a tool that reports which user and environment commands run as.
+type WhoAmI struct {
+	commandRunner commands.Runner
+}
+
+// SetCommandRunner sets the command runner the report is run with.
+func (c *WhoAmI) SetCommandRunner(runner commands.Runner) {
+	c.commandRunner = runner
+}
+
+// Definition returns the mcp.Tool definition.
+func (c *WhoAmI) Definition() mcp.Tool {
+	return mcp.NewTool("whoami",
+		mcp.WithDescription("Reports the user, uid/gid, groups, hostname, working directory and key environment variables that commands run with on each host. Set sudo=true to check that non-interactive sudo works and which user it runs as. You can specify individual hosts or an entire group."),
+		mcp.WithString("group",
+			mcp.Description("Group name to report on all hosts in that group (mutually exclusive with name_of_hosts)"),
+		),
+		mcp.WithArray("name_of_hosts",
+			mcp.Description("Array of host identifiers in format 'group:name' (mutually exclusive with group)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("sudo", mcp.Description("Run the report through non-interactive sudo (default: false, not supported on Windows)")),
+	)
+}
+
+// Handle is the function that is called when the tool is invoked.
+func (c *WhoAmI) Handler(ctx context.Context, storageEngine storage.Store) server.ToolHandlerFunc {
+	return func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if c.commandRunner == nil {
+			panic("command runner not available")
+		}
+		sudo := request.GetBool("sudo", false)
+
+		// Get hosts either by group or by individual host identifiers
+		found, err := getHostsFromRequest(storageEngine, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// run through the runner like any command, the report differs between Linux and Windows hosts
+		cmd := c.commandRunner.CreateCommand("whoami", found,
+			commands.WithInitiatedBy(initiatedBy(reqCtx, request)),
+			commands.WithHostCommand(func(host ssh.ClientInfo) (string, error) {
+				return whoAmICommand(utils.IsWindows(host), sudo)
+			}),
+		)
+		if err := cmd.Start(); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to start command: %v", err)), nil
+		}
+		state := waitForCommand(reqCtx, cmd)
+
+		reports := make([]WhoAmIResult, 0, len(found))
+		for _, host := range found {
+			reports = append(reports, whoAmIReport(host.Name, state.Results[host.Name], sudo))
+		}
+		sort.Slice(reports, func(i, j int) bool {
+			return reports[i].Host < reports[j].Host
+		})
+
+		return mcp.NewToolResultStructuredOnly(map[string]any{"hosts": reports}), nil
+	}
+}
+
+// whoAmIReport converts the result of the whoami command on a host to its report.
+func whoAmIReport(name string, result commands.CommandResult, sudo bool) WhoAmIResult {
+	report := WhoAmIResult{Host: name, Sudo: sudo}
+	switch {
+	case result.Err != nil:
+		report.Error = result.Err.Error()
+		if output := strings.TrimSpace(result.Result); output != "" {
+			report.Error += ": " + output
+		}
+	case result.Host == "":
+		report.Error = "no result"
+	default:
+		parseWhoAmI(result.Result, &report)
+	}
+	return report
+}
+
+// whoAmICommand returns the command that prints the context as "key=value" lines.
+func whoAmICommand(windows bool, sudo bool) (string, error) {
+	if windows {
+		if sudo {
+			return "", fmt.Errorf("sudo is not supported on Windows hosts")
+		}
+		script := []string{
+			"$id = [Security.Principal.WindowsIdentity]::GetCurrent()",
+			"'user=' + $id.Name",
+			"'uid=' + $id.User.Value",
+			"'groups=' + (($id.Groups | ForEach-Object { $sid = $_; try { $sid.Translate([Security.Principal.NTAccount]).Value } catch { $sid.Value } }) -join ' ')",
+			"'hostname=' + [Environment]::MachineName",
+			"'cwd=' + (Get-Location).Path",
+		}
+		for _, name := range whoAmIWindowsEnvVars {
+			script = append(script, fmt.Sprintf("'env.%s=' + $env:%s", name, name))
+		}
+		return utils.PowerShellCommand(strings.Join(script, "; ")), nil
+	}
+
+	script := []string{
+		`echo "user=$(id -un)"`,
+		`echo "uid=$(id -u)"`,
+		`echo "gid=$(id -g)"`,
+		`echo "groups=$(id -Gn)"`,
+		`echo "hostname=$(hostname)"`,
+		`echo "cwd=$(pwd)"`,
+	}
+	for _, name := range whoAmIEnvVars {
+		script = append(script, fmt.Sprintf(`echo "env.%s=$%s"`, name, name))
+	}
+	command := strings.Join(script, "; ")
+	if sudo {
+		command = "sudo -n sh -c " + utils.ShellQuote(command)
+	}
+	return command, nil
+}
+
+// parseWhoAmI parses the "key=value" lines printed by the whoami command into the report.
+func parseWhoAmI(output string, report *WhoAmIResult) {
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimRight(line, "\r"), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "user":
+			report.User = value
+		case "uid":
+			report.UID = value
+		case "gid":
+			report.GID = value
+		case "groups":
+			report.Groups = strings.Fields(value)
+		case "hostname":
+			report.Hostname = value
+		case "cwd":
+			report.Cwd = value
+		default:
+			if name, ok := strings.CutPrefix(key, "env."); ok && value != "" {
+				if report.Env == nil {
+					report.Env = make(map[string]string)
+				}
+				report.Env[name] = value
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakerouse/ssh-mcp/commands"
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// Tests for WhoAmI tool
+
+func TestParseWhoAmI(t *testing.T) {
+	output := "user=root\n" +
+		"uid=0\n" +
+		"gid=0\n" +
+		"groups=root adm\n" +
+		"hostname=web01\n" +
+		"cwd=/root\n" +
+		"env.HOME=/root\n" +
+		"env.PATH=/usr/bin:/bin\n" +
+		"env.SUDO_USER=deploy\n" +
+		"env.TERM=\n"
+
+	report := WhoAmIResult{Host: "web01", Sudo: true}
+	parseWhoAmI(output, &report)
+
+	require.Equal(t, WhoAmIResult{
+		Host:     "web01",
+		User:     "root",
+		UID:      "0",
+		GID:      "0",
+		Groups:   []string{"root", "adm"},
+		Hostname: "web01",
+		Cwd:      "/root",
+		Env: map[string]string{
+			"HOME":      "/root",
+			"PATH":      "/usr/bin:/bin",
+			"SUDO_USER": "deploy",
+		},
+		Sudo: true,
+	}, report)
+}
+
+func TestWhoAmICommand(t *testing.T) {
+	command, err := whoAmICommand(false, false)
+	require.NoError(t, err)
+	require.Contains(t, command, `echo "user=$(id -un)"`)
+
+	command, err = whoAmICommand(false, true)
+	require.NoError(t, err)
+	require.Contains(t, command, "sudo -n sh -c '")
+
+	command, err = whoAmICommand(true, false)
+	require.NoError(t, err)
+	require.Contains(t, command, "powershell")
+	// groups that cannot be translated fall back to their SID, not to the error record
+	require.Contains(t, powerShellScript(t, command), "ForEach-Object { $sid = $_; try { $sid.Translate([Security.Principal.NTAccount]).Value } catch { $sid.Value } }")
+
+	_, err = whoAmICommand(true, true)
+	require.Error(t, err)
+}
+
+func TestWhoAmI_RunsThroughRunner(t *testing.T) {
+	engine := setupTestStorage(t)
+	require.NoError(t, engine.Set(ssh.ClientInfo{Group: "win", Name: "dc1", Host: "10.0.0.5", Port: "22", User: "admin",
+		OS: ssh.OSInfo{Uname: "Windows_NT"}}))
+
+	runner := commands.NewRunner()
+	tool := &WhoAmI{}
+	tool.SetCommandRunner(runner)
+	handler := tool.Handler(context.Background(), engine)
+
+	// sudo cannot be rendered for Windows, so the host fails without being connected to
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"group": "win", "sudo": true}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	reports := result.StructuredContent.(map[string]any)["hosts"].([]WhoAmIResult)
+	require.Len(t, reports, 1)
+	require.Equal(t, "dc1", reports[0].Host)
+	require.Contains(t, reports[0].Error, "sudo is not supported on Windows hosts")
+
+	listed := runner.ListCommands()
+	require.Len(t, listed, 1)
+	require.Equal(t, "whoami", listed[0].ToState().Command)
+}
+
+func TestWhoAmIReport(t *testing.T) {
+	report := whoAmIReport("web1", commands.CommandResult{Host: "web1", Result: "sudo: a password is required\n", Err: errors.New("exit status 1")}, true)
+	require.Equal(t, "exit status 1: sudo: a password is required", report.Error)
+
+	report = whoAmIReport("web1", commands.CommandResult{Host: "web1", Result: "user=deploy\n"}, false)
+	require.Empty(t, report.Error)
+	require.Equal(t, "deploy", report.User)
+
+	report = whoAmIReport("web2", commands.CommandResult{}, false)
+	require.Equal(t, "no result", report.Error)
+}
@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// ansiPalette is the xterm palette for the 16 standard and bright colors
+var ansiPalette = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// ansiToken is either a run of text or the parameters of an SGR (color/style) escape sequence
+type ansiToken struct {
+	text  string
+	sgr   []int
+	isSGR bool
+}
+
+// tokenizeANSI splits s into text and SGR sequences, dropping every other escape sequence
+// (cursor movement, OSC titles, etc.).
+func tokenizeANSI(s string) []ansiToken {
+	var tokens []ansiToken
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			tokens = append(tokens, ansiToken{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != 0x1b {
+			text.WriteByte(s[i])
+			continue
+		}
+		if i+1 >= len(s) {
+			break
+		}
+		switch s[i+1] {
+		case '[':
+			// CSI: parameters followed by a final byte in 0x40-0x7e
+			j := i + 2
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			if j >= len(s) {
+				i = len(s)
+				continue
+			}
+			if s[j] == 'm' {
+				flush()
+				tokens = append(tokens, ansiToken{sgr: parseSGR(s[i+2 : j]), isSGR: true})
+			}
+			i = j
+		case ']':
+			// OSC: terminated by BEL or ESC \
+			j := i + 2
+			for j < len(s) && s[j] != 0x07 && !(s[j] == 0x1b && j+1 < len(s) && s[j+1] == '\\') {
+				j++
+			}
+			if j < len(s) && s[j] == 0x1b {
+				j++
+			}
+			i = j
+		default:
+			// two byte escape sequence
+			i++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// parseSGR parses the semicolon separated SGR parameters, an empty parameter means 0
+func parseSGR(params string) []int {
+	if params == "" {
+		return []int{0}
+	}
+	parts := strings.Split(params, ";")
+	codes := make([]int, 0, len(parts))
+	for _, part := range parts {
+		code, err := strconv.Atoi(part)
+		if err != nil {
+			code = 0
+		}
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// StripANSI removes all ANSI escape sequences from s, leaving the plain text
+func StripANSI(s string) string {
+	var b strings.Builder
+	for _, token := range tokenizeANSI(s) {
+		b.WriteString(token.text)
+	}
+	return b.String()
+}
+
+// ansiStyle is the current SGR state while converting to HTML
+type ansiStyle struct {
+	bold      bool
+	italic    bool
+	underline bool
+	fg        string
+	bg        string
+}
+
+// css returns the inline style for the state, empty when it is the default style
+func (st ansiStyle) css() string {
+	var rules []string
+	if st.fg != "" {
+		rules = append(rules, "color:"+st.fg)
+	}
+	if st.bg != "" {
+		rules = append(rules, "background-color:"+st.bg)
+	}
+	if st.bold {
+		rules = append(rules, "font-weight:bold")
+	}
+	if st.italic {
+		rules = append(rules, "font-style:italic")
+	}
+	if st.underline {
+		rules = append(rules, "text-decoration:underline")
+	}
+	return strings.Join(rules, ";")
+}
+
+// apply updates the style with the SGR codes
+func (st *ansiStyle) apply(codes []int) {
+	for i := 0; i < len(codes); i++ {
+		code := codes[i]
+		switch {
+		case code == 0:
+			*st = ansiStyle{}
+		case code == 1:
+			st.bold = true
+		case code == 3:
+			st.italic = true
+		case code == 4:
+			st.underline = true
+		case code == 22:
+			st.bold = false
+		case code == 23:
+			st.italic = false
+		case code == 24:
+			st.underline = false
+		case code >= 30 && code <= 37:
+			st.fg = ansiPalette[code-30]
+		case code >= 90 && code <= 97:
+			st.fg = ansiPalette[code-90+8]
+		case code == 39:
+			st.fg = ""
+		case code >= 40 && code <= 47:
+			st.bg = ansiPalette[code-40]
+		case code >= 100 && code <= 107:
+			st.bg = ansiPalette[code-100+8]
+		case code == 49:
+			st.bg = ""
+		case code == 38 || code == 48:
+			color, consumed := extendedColor(codes[i+1:])
+			i += consumed
+			if color != "" {
+				if code == 38 {
+					st.fg = color
+				} else {
+					st.bg = color
+				}
+			}
+		}
+	}
+}
+
+// extendedColor parses a 256 color (5;n) or true color (2;r;g;b) parameter list, returning the
+// color and the number of parameters consumed.
+func extendedColor(params []int) (string, int) {
+	if len(params) >= 2 && params[0] == 5 {
+		n := params[1]
+		switch {
+		case n >= 0 && n < 16:
+			return ansiPalette[n], 2
+		case n >= 16 && n < 232:
+			n -= 16
+			level := func(v int) int {
+				if v == 0 {
+					return 0
+				}
+				return 55 + v*40
+			}
+			return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6)), 2
+		case n >= 232 && n < 256:
+			gray := 8 + (n-232)*10
+			return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray), 2
+		}
+		return "", 2
+	}
+	if len(params) >= 4 && params[0] == 2 {
+		return fmt.Sprintf("#%02x%02x%02x", params[1]&0xff, params[2]&0xff, params[3]&0xff), 4
+	}
+	return "", len(params)
+}
+
+// ANSIToHTML converts ANSI colored text to HTML, escaping the text and wrapping styled runs in
+// spans with inline styles. Escape sequences other than colors and styles are dropped.
+func ANSIToHTML(s string) string {
+	var b strings.Builder
+	var style ansiStyle
+	for _, token := range tokenizeANSI(s) {
+		if token.isSGR {
+			style.apply(token.sgr)
+			continue
+		}
+		css := style.css()
+		if css == "" {
+			b.WriteString(html.EscapeString(token.text))
+			continue
+		}
+		b.WriteString(`<span style="` + css + `">` + html.EscapeString(token.text) + "</span>")
+	}
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package utils
+
+import "testing"
+
+func TestStripANSI(t *testing.T) {
+	testCases := map[string]string{
+		"plain text":                         "plain text",
+		"\x1b[1;31merror\x1b[0m: failed":     "error: failed",
+		"\x1b[38;5;208morange\x1b[m":         "orange",
+		"\x1b]0;window title\x07prompt$ ":    "prompt$ ",
+		"\x1b[2K\x1b[1Gprogress 50%":         "progress 50%",
+		"\x1b[38;2;10;20;30mtrue color\x1b[": "true color",
+	}
+	for input, expected := range testCases {
+		if got := StripANSI(input); got != expected {
+			t.Errorf("StripANSI(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestANSIToHTML(t *testing.T) {
+	testCases := map[string]string{
+		"a < b":                               "a &lt; b",
+		"\x1b[31mred\x1b[0m plain":            `<span style="color:#cd0000">red</span> plain`,
+		"\x1b[1;42mbold\x1b[22m not":          `<span style="background-color:#00cd00;font-weight:bold">bold</span><span style="background-color:#00cd00"> not</span>`,
+		"\x1b[38;5;196mx\x1b[39my":            `<span style="color:#ff0000">x</span>y`,
+		"\x1b[38;2;1;2;3m<tag>":               `<span style="color:#010203">&lt;tag&gt;</span>`,
+		"\x1b[4;93mwarn\x1b[m\x1b[2Kdone\r\n": `<span style="color:#ffff00;text-decoration:underline">warn</span>done` + "\r\n",
+	}
+	for input, expected := range testCases {
+		if got := ANSIToHTML(input); got != expected {
+			t.Errorf("ANSIToHTML(%q): expected %s, got %s", input, expected, got)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"net/netip"
 	"strings"
 
 	"github.com/blakerouse/ssh-mcp/ssh"
@@ -31,7 +32,7 @@ func ParseHostIdentifiers(hostStrings []string) ([]HostIdentifier, error) {
 }
 
 // GetHostsFromStorage takes a list of host identifiers and finds the hosts for those identifiers
-func GetHostsFromStorage(storageEngine *storage.Engine, identifiers []HostIdentifier) ([]ssh.ClientInfo, error) {
+func GetHostsFromStorage(storageEngine storage.Store, identifiers []HostIdentifier) ([]ssh.ClientInfo, error) {
 	hosts := make([]ssh.ClientInfo, 0, len(identifiers))
 	var notFound []string
 	for _, id := range identifiers {
@@ -49,7 +50,7 @@ func GetHostsFromStorage(storageEngine *storage.Engine, identifiers []HostIdenti
 }
 
 // GetHostsFromGroup gets all hosts from a specific group
-func GetHostsFromGroup(storageEngine *storage.Engine, group string) ([]ssh.ClientInfo, error) {
+func GetHostsFromGroup(storageEngine storage.Store, group string) ([]ssh.ClientInfo, error) {
 	hosts, err := storageEngine.ListGroup(group)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get hosts from group %s: %w", group, err)
@@ -59,3 +60,33 @@ func GetHostsFromGroup(storageEngine *storage.Engine, group string) ([]ssh.Clien
 	}
 	return hosts, nil
 }
+
+// GetHostsInCIDR gets all stored hosts whose address is an IP within the CIDR range, e.g.
+// 10.0.1.0/24. Hosts addressed by a DNS name cannot be matched and are returned as skipped by
+// their 'group:name' identifier.
+func GetHostsInCIDR(storageEngine storage.Store, cidr string) ([]ssh.ClientInfo, []string, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid cidr '%s': %w", cidr, err)
+	}
+	prefix = prefix.Masked()
+
+	all, err := storageEngine.List()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list hosts: %w", err)
+	}
+	var hosts []ssh.ClientInfo
+	var skipped []string
+	for _, host := range all {
+		// IPv6 addresses may be stored in brackets or with a zone
+		addr, err := netip.ParseAddr(strings.Trim(host.Host, "[]"))
+		if err != nil {
+			skipped = append(skipped, host.Group+":"+host.Name)
+			continue
+		}
+		if prefix.Contains(addr.WithZone("").Unmap()) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, skipped, nil
+}
@@ -297,3 +297,43 @@ func TestGetHostsFromGroup_NonexistentGroup(t *testing.T) {
 		t.Error("expected error for nonexistent group, got nil")
 	}
 }
+
+func TestGetHostsInCIDR(t *testing.T) {
+	engine, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	addTestHost(t, engine, "prod", "web01", "10.0.1.1")
+	addTestHost(t, engine, "prod", "web02", "10.0.1.254")
+	addTestHost(t, engine, "prod", "db01", "10.0.2.1")
+	addTestHost(t, engine, "prod", "v6", "[fd00::1]")
+	addTestHost(t, engine, "prod", "dns", "web.example.com")
+
+	// the host bits of the range are ignored
+	hosts, skipped, err := GetHostsInCIDR(engine, "10.0.1.7/24")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(hosts) != 2 {
+		t.Errorf("expected 2 hosts, got %d", len(hosts))
+	}
+	for _, host := range hosts {
+		if host.Name != "web01" && host.Name != "web02" {
+			t.Errorf("unexpected host %s in 10.0.1.0/24", host.Name)
+		}
+	}
+	if len(skipped) != 1 || skipped[0] != "prod:dns" {
+		t.Errorf("expected prod:dns to be skipped, got %v", skipped)
+	}
+
+	hosts, _, err = GetHostsInCIDR(engine, "fd00::/64")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(hosts) != 1 || hosts[0].Name != "v6" {
+		t.Errorf("expected only v6 in fd00::/64, got %v", hosts)
+	}
+
+	if _, _, err := GetHostsInCIDR(engine, "10.0.1.0"); err == nil {
+		t.Error("expected an error for a range without a prefix length")
+	}
+}
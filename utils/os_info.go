@@ -7,58 +7,45 @@ import (
 	"github.com/blakerouse/ssh-mcp/ssh"
 )
 
-// GatherOSInfo detects the operating system and gathers relevant system information
-func GatherOSInfo(sshClient *ssh.Client) (osRelease string, uname string, err error) {
-	// Try to detect the OS by checking if common commands exist
-	// First, try Linux/Unix commands
-	osReleaseOutput, err := sshClient.Exec("cat /etc/os-release 2>/dev/null || echo ''")
-	if err != nil {
-		return "", "", fmt.Errorf("failed to check for Linux OS: %w", err)
-	}
+// linuxProbeMarker separates the /etc/os-release content from the uname output in the output of
+// linuxProbeScript.
+const linuxProbeMarker = "---ssh-mcp-uname---"
 
-	// If we got os-release content, it's Linux
-	if strings.TrimSpace(string(osReleaseOutput)) != "" {
-		unameOutput, err := sshClient.Exec("uname -a")
-		if err != nil {
-			return "", "", fmt.Errorf("failed to get uname output: %w", err)
-		}
-		return string(osReleaseOutput), string(unameOutput), nil
-	}
+// linuxProbeScript gathers /etc/os-release and uname in a single session. Shells other than a
+// POSIX shell (cmd.exe, PowerShell) fail it or do not print the marker.
+const linuxProbeScript = "cat /etc/os-release 2>/dev/null; echo " + linuxProbeMarker + "; uname -a"
 
-	// Try Windows detection with 'ver' command
-	verOutput, err := sshClient.Exec("ver 2>nul || echo ''")
-	if err == nil && strings.TrimSpace(string(verOutput)) != "" {
-		// It's Windows - gather Windows system information
-		return gatherWindowsInfo(sshClient)
-	}
+// GatherOSInfo detects the operating system and gathers relevant system information. Each probe
+// needs its own session, so Linux is detected and gathered in one script and Windows with a
+// single systeminfo call.
+func GatherOSInfo(sshClient *ssh.Client) (osRelease string, uname string, err error) {
+	return gatherOSInfo(sshClient.Exec)
+}
 
-	// Try PowerShell-based detection as fallback
-	psVersion, err := sshClient.Exec("powershell -Command \"$PSVersionTable.PSVersion.ToString()\" 2>nul || echo ''")
-	if err == nil && strings.TrimSpace(string(psVersion)) != "" {
-		return gatherWindowsInfo(sshClient)
+// gatherOSInfo gathers the system information with exec, which runs a command in a new session.
+func gatherOSInfo(exec func(cmd string) ([]byte, error)) (osRelease string, uname string, err error) {
+	// First, try Linux/Unix with a single script
+	probeOutput, probeErr := exec(linuxProbeScript)
+	if probeErr == nil {
+		if osRelease, uname, ok := parseLinuxProbe(string(probeOutput)); ok {
+			return osRelease, uname, nil
+		}
 	}
 
-	// Try systeminfo command (works in cmd.exe on Windows)
-	systemInfoOutput, err := sshClient.Exec("systeminfo 2>nul | findstr /B /C:\"OS Name\" /C:\"OS Version\" || echo ''")
-	if err == nil && strings.TrimSpace(string(systemInfoOutput)) != "" {
-		return gatherWindowsInfo(sshClient)
+	// systeminfo both detects and describes Windows (it works in cmd.exe and PowerShell)
+	systemInfo, err := exec("systeminfo")
+	if err == nil {
+		if osRelease, uname, ok := parseSystemInfo(string(systemInfo)); ok {
+			return osRelease, uname, nil
+		}
 	}
 
-	// If we couldn't detect the OS, return an error
-	return "", "", fmt.Errorf("unable to detect operating system - tried Linux and Windows detection methods")
-}
-
-// gatherWindowsInfo gathers system information from a Windows host
-func gatherWindowsInfo(sshClient *ssh.Client) (osRelease string, uname string, err error) {
-	// Use systeminfo for detailed Windows information
-	systemInfo, err := sshClient.Exec("systeminfo")
-	if err != nil {
-		// Fallback to simpler commands
-		verOutput, verErr := sshClient.Exec("ver")
-		hostnameOutput, hostErr := sshClient.Exec("hostname")
-
-		if verErr != nil || hostErr != nil {
-			return "", "", fmt.Errorf("failed to gather Windows system information: %w", err)
+	// Fallback to simpler commands when systeminfo is unavailable
+	verOutput, verErr := exec("ver")
+	if verErr == nil && strings.TrimSpace(string(verOutput)) != "" {
+		hostnameOutput, hostErr := exec("hostname")
+		if hostErr != nil {
+			return "", "", fmt.Errorf("failed to gather Windows system information: %w", hostErr)
 		}
 
 		// Format similar to Linux for consistency
@@ -67,12 +54,28 @@ func gatherWindowsInfo(sshClient *ssh.Client) (osRelease string, uname string, e
 		return osRelease, uname, nil
 	}
 
-	// Parse systeminfo output to extract key information
-	systemInfoStr := string(systemInfo)
-	lines := strings.Split(systemInfoStr, "\n")
+	if probeErr != nil {
+		return "", "", fmt.Errorf("unable to detect operating system - tried Linux and Windows detection methods: %w", probeErr)
+	}
+	// If we couldn't detect the OS, return an error
+	return "", "", fmt.Errorf("unable to detect operating system - tried Linux and Windows detection methods")
+}
+
+// parseLinuxProbe splits the output of linuxProbeScript into the /etc/os-release content and the
+// uname output. Returns false when the marker is missing or there is no os-release content.
+func parseLinuxProbe(output string) (osRelease string, uname string, ok bool) {
+	osRelease, uname, found := strings.Cut(output, linuxProbeMarker+"\n")
+	if !found || strings.TrimSpace(osRelease) == "" {
+		return "", "", false
+	}
+	return osRelease, uname, true
+}
 
+// parseSystemInfo extracts the key information from the output of systeminfo. Returns false when
+// the output is not from systeminfo.
+func parseSystemInfo(output string) (osRelease string, uname string, ok bool) {
 	var osName, osVersion, hostname, architecture string
-	for _, line := range lines {
+	for _, line := range strings.Split(output, "\n") {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "OS Name:") {
 			osName = strings.TrimSpace(strings.TrimPrefix(line, "OS Name:"))
@@ -84,10 +87,54 @@ func gatherWindowsInfo(sshClient *ssh.Client) (osRelease string, uname string, e
 			architecture = strings.TrimSpace(strings.TrimPrefix(line, "System Type:"))
 		}
 	}
+	if osName == "" {
+		return "", "", false
+	}
 
 	// Format in a Linux-like style for consistency
 	osRelease = fmt.Sprintf("NAME=\"%s\"\nVERSION=\"%s\"\nARCHITECTURE=\"%s\"", osName, osVersion, architecture)
 	uname = fmt.Sprintf("Windows %s %s", hostname, architecture)
+	return osRelease, uname, true
+}
+
+// ParseOSRelease parses the KEY=VALUE lines of /etc/os-release into a map. Blank lines, comments
+// and lines that are not assignments are skipped. Double quoted values have their shell escapes
+// (\" \\ \$ \`) resolved and single quoted values are taken literally, as described in
+// os-release(5).
+func ParseOSRelease(content string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			continue
+		}
+		fields[key] = unquoteOSReleaseValue(value)
+	}
+	return fields
+}
 
-	return osRelease, uname, nil
+// unquoteOSReleaseValue removes the quoting of an os-release value.
+func unquoteOSReleaseValue(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+	switch quote := value[0]; {
+	case quote == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1]
+	case quote == '"' && value[len(value)-1] == '"':
+		inner := value[1 : len(value)-1]
+		var unquoted strings.Builder
+		for i := 0; i < len(inner); i++ {
+			if inner[i] == '\\' && i+1 < len(inner) && strings.IndexByte("\"\\$`", inner[i+1]) >= 0 {
+				i++
+			}
+			unquoted.WriteByte(inner[i])
+		}
+		return unquoted.String()
+	}
+	return value
 }
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+func TestParseOSRelease(t *testing.T) {
+	content := `# comment line
+NAME="Ubuntu"
+VERSION_ID="22.04"
+ID=ubuntu
+ID_LIKE=debian
+PRETTY_NAME="Ubuntu 22.04.3 LTS"
+VARIANT='Server Edition'
+ESCAPED="say \"hi\" for \$5 and \\ done"
+
+not an assignment
+EMPTY=
+`
+	expected := map[string]string{
+		"NAME":        "Ubuntu",
+		"VERSION_ID":  "22.04",
+		"ID":          "ubuntu",
+		"ID_LIKE":     "debian",
+		"PRETTY_NAME": "Ubuntu 22.04.3 LTS",
+		"VARIANT":     "Server Edition",
+		"ESCAPED":     `say "hi" for $5 and \ done`,
+		"EMPTY":       "",
+	}
+	if got := ParseOSRelease(content); !maps.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestParseOSRelease_Empty(t *testing.T) {
+	if got := ParseOSRelease(""); len(got) != 0 {
+		t.Errorf("expected no fields, got %v", got)
+	}
+}
+
+// fakeExec returns an exec function answering commands from outputs, failing the others, and
+// records the commands run.
+func fakeExec(outputs map[string]string, ran *[]string) func(cmd string) ([]byte, error) {
+	return func(cmd string) ([]byte, error) {
+		*ran = append(*ran, cmd)
+		output, ok := outputs[cmd]
+		if !ok {
+			return nil, errors.New("Process exited with status 1")
+		}
+		return []byte(output), nil
+	}
+}
+
+func TestGatherOSInfo_LinuxSingleSession(t *testing.T) {
+	var ran []string
+	exec := fakeExec(map[string]string{
+		linuxProbeScript: "NAME=\"Ubuntu\"\nID=ubuntu\n" + linuxProbeMarker + "\nLinux web1 6.8.0 x86_64 GNU/Linux\n",
+	}, &ran)
+
+	osRelease, uname, err := gatherOSInfo(exec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if osRelease != "NAME=\"Ubuntu\"\nID=ubuntu\n" {
+		t.Errorf("unexpected os-release: %q", osRelease)
+	}
+	if uname != "Linux web1 6.8.0 x86_64 GNU/Linux\n" {
+		t.Errorf("unexpected uname: %q", uname)
+	}
+	if len(ran) != 1 {
+		t.Errorf("expected a single session, ran %v", ran)
+	}
+}
+
+func TestGatherOSInfo_Windows(t *testing.T) {
+	var ran []string
+	exec := fakeExec(map[string]string{
+		"systeminfo": "Host Name:                 WIN1\r\nOS Name:                   Microsoft Windows Server 2022 Standard\r\nOS Version:                10.0.20348 N/A Build 20348\r\nSystem Type:               x64-based PC\r\n",
+	}, &ran)
+
+	osRelease, uname, err := gatherOSInfo(exec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "NAME=\"Microsoft Windows Server 2022 Standard\"\nVERSION=\"10.0.20348 N/A Build 20348\"\nARCHITECTURE=\"x64-based PC\""
+	if osRelease != expected {
+		t.Errorf("unexpected os-release: %q", osRelease)
+	}
+	if uname != "Windows WIN1 x64-based PC" {
+		t.Errorf("unexpected uname: %q", uname)
+	}
+	if len(ran) != 2 {
+		t.Errorf("expected two sessions, ran %v", ran)
+	}
+}
+
+func TestGatherOSInfo_WindowsWithoutSystemInfo(t *testing.T) {
+	var ran []string
+	exec := fakeExec(map[string]string{
+		"ver":      "\r\nMicrosoft Windows [Version 10.0.20348.2340]\r\n",
+		"hostname": "WIN1\r\n",
+	}, &ran)
+
+	osRelease, uname, err := gatherOSInfo(exec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if osRelease != "NAME=\"Microsoft Windows\"\nVERSION=\"Microsoft Windows [Version 10.0.20348.2340]\"" {
+		t.Errorf("unexpected os-release: %q", osRelease)
+	}
+	if uname != "Windows WIN1" {
+		t.Errorf("unexpected uname: %q", uname)
+	}
+}
+
+func TestGatherOSInfo_Undetected(t *testing.T) {
+	var ran []string
+	exec := fakeExec(map[string]string{
+		// no /etc/os-release, as on macOS
+		linuxProbeScript: linuxProbeMarker + "\nDarwin mac1 23.4.0\n",
+	}, &ran)
+
+	if _, _, err := gatherOSInfo(exec); err == nil {
+		t.Error("expected an error when the OS cannot be detected")
+	}
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+// ShellQuote quotes a string so it is passed as a single argument to a POSIX shell
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// powerShellQuoteReplacer doubles every character PowerShell treats as a single quote, which
+// includes the typographic quotes U+2018 to U+201B as well as the ASCII apostrophe
+var powerShellQuoteReplacer = strings.NewReplacer(
+	"'", "''",
+	"\u2018", "\u2018\u2018",
+	"\u2019", "\u2019\u2019",
+	"\u201A", "\u201A\u201A",
+	"\u201B", "\u201B\u201B",
+)
+
+// PowerShellQuote quotes a string as a literal PowerShell string
+func PowerShellQuote(s string) string {
+	return "'" + powerShellQuoteReplacer.Replace(s) + "'"
+}
+
+// IsWindows returns true when the cached OS information identifies a Windows host
+func IsWindows(host ssh.ClientInfo) bool {
+	return strings.HasPrefix(host.OS.Uname, "Windows")
+}
+
+// PowerShellCommand wraps a PowerShell script so it can be executed from either cmd.exe or PowerShell
+// The script is passed base64 encoded, so no character in it is interpreted by the calling shell
+func PowerShellCommand(script string) string {
+	return "powershell -NoProfile -NonInteractive -EncodedCommand " + encodePowerShell(script)
+}
+
+// encodePowerShell encodes a script as the base64 of its UTF-16LE text, as -EncodedCommand expects
+func encodePowerShell(script string) string {
+	units := utf16.Encode([]rune(script))
+	encoded := make([]byte, 0, len(units)*2)
+	for _, unit := range units {
+		encoded = append(encoded, byte(unit), byte(unit>>8))
+	}
+	return base64.StdEncoding.EncodeToString(encoded)
+}
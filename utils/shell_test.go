@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/blakerouse/ssh-mcp/ssh"
+)
+
+func TestShellQuote(t *testing.T) {
+	testCases := map[string]string{
+		"/var/log":    `'/var/log'`,
+		"with space":  `'with space'`,
+		"it's":        `'it'\''s'`,
+		"$(rm -rf /)": `'$(rm -rf /)'`,
+		"":            `''`,
+	}
+	for input, expected := range testCases {
+		if got := ShellQuote(input); got != expected {
+			t.Errorf("ShellQuote(%q): expected %s, got %s", input, expected, got)
+		}
+	}
+}
+
+func TestPowerShellQuote(t *testing.T) {
+	if got := PowerShellQuote(`C:\it's here`); got != `'C:\it''s here'` {
+		t.Errorf("unexpected PowerShell quoting: %s", got)
+	}
+
+	// PowerShell also ends a literal string at the typographic single quotes
+	for _, quote := range []string{"\u2018", "\u2019", "\u201A", "\u201B"} {
+		t.Run(fmt.Sprintf("%U", []rune(quote)[0]), func(t *testing.T) {
+			input := "x" + quote + "; whoami; " + quote
+			expected := "'x" + quote + quote + "; whoami; " + quote + quote + "'"
+			if got := PowerShellQuote(input); got != expected {
+				t.Errorf("expected %s, got %s", expected, got)
+			}
+		})
+	}
+}
+
+func TestPowerShellCommand(t *testing.T) {
+	script := "Get-Item " + PowerShellQuote(`C:\x" & whoami & "`)
+	command := PowerShellCommand(script)
+	if strings.ContainsAny(command, `"&'`) {
+		t.Errorf("expected nothing for cmd.exe to interpret, got %s", command)
+	}
+	encoded, ok := strings.CutPrefix(command, "powershell -NoProfile -NonInteractive -EncodedCommand ")
+	if !ok {
+		t.Fatalf("unexpected command: %s", command)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("failed to decode command: %v", err)
+	}
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = uint16(raw[2*i]) | uint16(raw[2*i+1])<<8
+	}
+	if got := string(utf16.Decode(units)); got != script {
+		t.Errorf("expected script %s, got %s", script, got)
+	}
+}
+
+func TestIsWindows(t *testing.T) {
+	windows := ssh.ClientInfo{OS: ssh.OSInfo{Uname: "Windows WIN01 x64-based PC"}}
+	if !IsWindows(windows) {
+		t.Error("expected Windows host to be detected")
+	}
+	linux := ssh.ClientInfo{OS: ssh.OSInfo{Uname: "Linux web01 5.15.0"}}
+	if IsWindows(linux) {
+		t.Error("expected Linux host to not be detected as Windows")
+	}
+}